@@ -0,0 +1,196 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// AggFunc is an aggregation function applied to a column by Aggregate
+type AggFunc int
+
+const (
+	// AggMin is the smallest value of a column
+	AggMin AggFunc = iota + 1
+
+	// AggMax is the largest value of a column
+	AggMax
+
+	// AggSum is the sum of a numeric column, as an int64 for
+	// integer columns and a float64 for Double columns
+	AggSum
+
+	// AggAvg is the average of a numeric column, as a float64
+	AggAvg
+
+	// AggCount is the number of rows where the column is not null, as an int64
+	AggCount
+)
+
+// String satisfies the Stringer interface
+func (f AggFunc) String() string {
+	switch f {
+	case AggMin:
+		return "MIN"
+	case AggMax:
+		return "MAX"
+	case AggSum:
+		return "SUM"
+	case AggAvg:
+		return "AVG"
+	case AggCount:
+		return "COUNT"
+	}
+	return fmt.Sprintf("AggFunc(%d)", int(f))
+}
+
+// aggregateScanLimit is the page size used when aggregating by scanning
+const aggregateScanLimit = 200
+
+// CheckAggregate verifies that the aggregation can be applied to the column:
+// AggSum and AggAvg need a numeric column, AggMin and AggMax a column whose
+// values are ordered, and AggCount works with any column.
+func CheckAggregate(ed *EntityDefinition, aggFunc AggFunc, column string) error {
+	cd := ed.FindColumnDefinition(column)
+	if cd == nil {
+		return errors.Errorf("column %q not found in entity %q", column, ed.Name)
+	}
+	switch aggFunc {
+	case AggSum, AggAvg:
+		switch cd.Type {
+		case Int32, Int64, Double:
+			return nil
+		}
+	case AggMin, AggMax:
+		switch cd.Type {
+		case Int32, Int64, Double, String, Timestamp:
+			return nil
+		}
+	case AggCount:
+		return nil
+	default:
+		return errors.Errorf("invalid aggregation function %v", aggFunc)
+	}
+	return errors.Errorf("cannot apply %v to column %q of type %v", aggFunc, column, cd.Type)
+}
+
+// Aggregator computes an aggregation client-side, one value at a time. It is
+// used by connectors whose backend can't aggregate.
+type Aggregator struct {
+	aggFunc AggFunc
+	typ     Type
+	count   int64
+	sumInt  int64
+	sumF    float64
+	best    FieldValue
+}
+
+// NewAggregator creates an Aggregator for values of the given column type
+func NewAggregator(aggFunc AggFunc, typ Type) *Aggregator {
+	return &Aggregator{aggFunc: aggFunc, typ: typ}
+}
+
+// Add adds a value to the aggregation. Null values are ignored; nullable
+// values are dereferenced.
+func (a *Aggregator) Add(v FieldValue) {
+	v = DerefFieldValue(v)
+	if v == nil {
+		return
+	}
+	a.count++
+	switch a.aggFunc {
+	case AggSum, AggAvg:
+		switch v := v.(type) {
+		case int32:
+			a.sumInt += int64(v)
+			a.sumF += float64(v)
+		case int64:
+			a.sumInt += v
+			a.sumF += float64(v)
+		case float64:
+			a.sumF += v
+		}
+	case AggMin, AggMax:
+		if a.best == nil {
+			a.best = v
+			return
+		}
+		if (a.aggFunc == AggMin && FieldValueLess(v, a.best)) || (a.aggFunc == AggMax && FieldValueLess(a.best, v)) {
+			a.best = v
+		}
+	}
+}
+
+// Result returns the aggregated value. AggMin, AggMax and AggAvg return
+// ErrNotFound when no (non-null) values were added.
+func (a *Aggregator) Result() (FieldValue, error) {
+	switch a.aggFunc {
+	case AggCount:
+		return a.count, nil
+	case AggSum:
+		if a.typ == Double {
+			return a.sumF, nil
+		}
+		return a.sumInt, nil
+	}
+	if a.count == 0 {
+		return nil, &ErrNotFound{}
+	}
+	if a.aggFunc == AggAvg {
+		return a.sumF / float64(a.count), nil
+	}
+	return a.best, nil
+}
+
+// AggregateByScanning aggregates a column client-side, reading the rows that
+// match the conditions with Range, or all of the rows with Scan when there are
+// no conditions. This is the fallback for connectors whose backend can't
+// aggregate; it reads every matching row.
+func AggregateByScanning(ctx context.Context, conn Connector, ei *EntityInfo, aggFunc AggFunc, column string, columnConditions map[string][]*Condition) (FieldValue, error) {
+	if err := CheckAggregate(ei.Def, aggFunc, column); err != nil {
+		return nil, err
+	}
+	agg := NewAggregator(aggFunc, ei.Def.FindColumnDefinition(column).Type)
+	fields := []string{column}
+	token := ""
+	for {
+		var rows []map[string]FieldValue
+		var err error
+		if len(columnConditions) == 0 {
+			rows, token, err = conn.Scan(ctx, ei, fields, token, aggregateScanLimit)
+		} else {
+			rows, token, err = conn.Range(ctx, ei, columnConditions, fields, token, aggregateScanLimit)
+		}
+		if err != nil && !ErrorIsNotFound(err) {
+			return nil, errors.Wrap(err, "failed to read rows to aggregate")
+		}
+		for _, row := range rows {
+			agg.Add(row[column])
+		}
+		if err != nil || token == "" {
+			break
+		}
+	}
+	return agg.Result()
+}
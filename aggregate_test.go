@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggFunc_String(t *testing.T) {
+	assert.Equal(t, "MIN", AggMin.String())
+	assert.Equal(t, "COUNT", AggCount.String())
+	assert.Equal(t, "AggFunc(0)", AggFunc(0).String())
+}
+
+func TestCheckAggregate(t *testing.T) {
+	ed := &EntityDefinition{
+		Name: "t",
+		Columns: []*ColumnDefinition{
+			{Name: "i32", Type: Int32},
+			{Name: "i64", Type: Int64},
+			{Name: "d", Type: Double},
+			{Name: "s", Type: String},
+			{Name: "ts", Type: Timestamp},
+			{Name: "b", Type: Bool},
+			{Name: "u", Type: TUUID},
+			{Name: "bl", Type: Blob},
+		},
+	}
+	for _, tc := range []struct {
+		aggFunc AggFunc
+		ok      []string
+		notOk   []string
+	}{
+		{AggSum, []string{"i32", "i64", "d"}, []string{"s", "ts", "b", "u", "bl"}},
+		{AggAvg, []string{"i32", "i64", "d"}, []string{"s", "ts", "b", "u", "bl"}},
+		{AggMin, []string{"i32", "i64", "d", "s", "ts"}, []string{"b", "u", "bl"}},
+		{AggMax, []string{"i32", "i64", "d", "s", "ts"}, []string{"b", "u", "bl"}},
+		{AggCount, []string{"i32", "i64", "d", "s", "ts", "b", "u", "bl"}, nil},
+	} {
+		for _, column := range tc.ok {
+			assert.NoError(t, CheckAggregate(ed, tc.aggFunc, column), tc.aggFunc.String()+"("+column+")")
+		}
+		for _, column := range tc.notOk {
+			assert.Error(t, CheckAggregate(ed, tc.aggFunc, column), tc.aggFunc.String()+"("+column+")")
+		}
+	}
+	assert.Error(t, CheckAggregate(ed, AggCount, "missing"))
+	assert.Error(t, CheckAggregate(ed, AggFunc(0), "i32"))
+}
+
+func TestAggregator(t *testing.T) {
+	i := int32(7)
+	for _, tc := range []struct {
+		aggFunc  AggFunc
+		typ      Type
+		values   []FieldValue
+		expected FieldValue
+	}{
+		{AggCount, Int32, []FieldValue{int32(1), nil, &i, (*int32)(nil)}, int64(2)},
+		{AggSum, Int32, []FieldValue{int32(1), nil, &i}, int64(8)},
+		{AggSum, Int64, nil, int64(0)},
+		{AggSum, Double, []FieldValue{1.5, 2.0}, 3.5},
+		{AggAvg, Int64, []FieldValue{int64(1), int64(2)}, 1.5},
+		{AggMin, Int32, []FieldValue{int32(3), &i, int32(-1)}, int32(-1)},
+		{AggMax, Int32, []FieldValue{int32(3), &i, int32(-1)}, int32(7)},
+		{AggMin, String, []FieldValue{"b", "a", "c"}, "a"},
+		{AggMax, Timestamp, []FieldValue{time.Unix(1, 0), time.Unix(3, 0), time.Unix(2, 0)}, time.Unix(3, 0)},
+	} {
+		agg := NewAggregator(tc.aggFunc, tc.typ)
+		for _, v := range tc.values {
+			agg.Add(v)
+		}
+		v, err := agg.Result()
+		assert.NoError(t, err, tc.aggFunc.String())
+		assert.Equal(t, tc.expected, v, tc.aggFunc.String())
+	}
+
+	for _, aggFunc := range []AggFunc{AggMin, AggMax, AggAvg} {
+		agg := NewAggregator(aggFunc, Int64)
+		agg.Add(nil)
+		_, err := agg.Result()
+		assert.True(t, ErrorIsNotFound(err), aggFunc.String())
+	}
+}
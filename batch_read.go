@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchRead reads the rows with the given keys from conn, with at most
+// parallelism reads in flight at a time (a parallelism below one reads one
+// key at a time). All columns are read. Results are positional: the i-th row
+// and the i-th error belong to keys[i], and a row is nil when its error is
+// not. A failed read, including ErrNotFound, doesn't stop the others, but
+// once ctx is done no more reads are started and the remaining keys get
+// ctx.Err().
+//
+// Unlike MultiRead, this works with any connector, one Read per key, which
+// suits backends that don't batch reads themselves.
+func BatchRead(ctx context.Context, conn Connector, ei *EntityInfo, keys []map[string]FieldValue, parallelism int) ([]map[string]FieldValue, []error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make([]map[string]FieldValue, len(keys))
+	errs := make([]error, len(keys))
+
+	cancelFrom := func(i int) {
+		for ; i < len(keys); i++ {
+			errs[i] = ctx.Err()
+		}
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+loop:
+	for i, key := range keys {
+		if ctx.Err() != nil {
+			cancelFrom(i)
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			cancelFrom(i)
+			break loop
+		}
+		wg.Add(1)
+		go func(i int, key map[string]FieldValue) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = conn.Read(ctx, ei, key, All())
+		}(i, key)
+	}
+	wg.Wait()
+	return results, errs
+}
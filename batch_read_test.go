@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var batchReadEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{Scope: "scope", NamePrefix: "prefix", EntityName: "users"},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Key:  &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+		},
+	},
+}
+
+func batchReadConnector(t testing.TB, n int) dosa.Connector {
+	conn := memory.NewConnector()
+	for i := 0; i < n; i++ {
+		assert.NoError(t, conn.Upsert(context.TODO(), batchReadEi, map[string]dosa.FieldValue{
+			"id": int64(i), "name": fmt.Sprintf("user%d", i),
+		}))
+	}
+	return conn
+}
+
+func batchReadKeys(n int) []map[string]dosa.FieldValue {
+	keys := make([]map[string]dosa.FieldValue, n)
+	for i := range keys {
+		keys[i] = map[string]dosa.FieldValue{"id": int64(i)}
+	}
+	return keys
+}
+
+func TestBatchRead(t *testing.T) {
+	conn := batchReadConnector(t, 10)
+	keys := append(batchReadKeys(10), map[string]dosa.FieldValue{"id": int64(42)})
+
+	for _, parallelism := range []int{0, 1, 3, 20} {
+		results, errs := dosa.BatchRead(context.TODO(), conn, batchReadEi, keys, parallelism)
+		assert.Len(t, results, 11)
+		assert.Len(t, errs, 11)
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, fmt.Sprintf("user%d", i), results[i]["name"])
+		}
+		assert.True(t, dosa.ErrorIsNotFound(errs[10]))
+		assert.Nil(t, results[10])
+	}
+
+	results, errs := dosa.BatchRead(context.TODO(), conn, batchReadEi, nil, 2)
+	assert.Empty(t, results)
+	assert.Empty(t, errs)
+}
+
+// cancelingConnector cancels the context once a number of reads have started
+type cancelingConnector struct {
+	dosa.Connector
+	cancel func()
+	after  int32
+	reads  int32
+}
+
+func (c *cancelingConnector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	if atomic.AddInt32(&c.reads, 1) == c.after {
+		c.cancel()
+	}
+	return c.Connector.Read(context.TODO(), ei, keys, minimumFields)
+}
+
+func TestBatchRead_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &cancelingConnector{Connector: batchReadConnector(t, 10), cancel: cancel, after: 3}
+
+	results, errs := dosa.BatchRead(ctx, conn, batchReadEi, batchReadKeys(10), 1)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&conn.reads))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, errs[i])
+		assert.NotNil(t, results[i])
+	}
+	for i := 3; i < 10; i++ {
+		assert.Equal(t, context.Canceled, errs[i])
+		assert.Nil(t, results[i])
+	}
+}
+
+func benchmarkBatchRead(b *testing.B, parallelism int) {
+	conn := batchReadConnector(b, 100)
+	keys := batchReadKeys(100)
+	b.ResetTimer()
+	for x := 0; x < b.N; x++ {
+		_, errs := dosa.BatchRead(context.TODO(), conn, batchReadEi, keys, parallelism)
+		for _, err := range errs {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkBatchRead_Sequential(b *testing.B) { benchmarkBatchRead(b, 1) }
+
+func BenchmarkBatchRead_Parallel(b *testing.B) { benchmarkBatchRead(b, 8) }
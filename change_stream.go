@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChangeOp is the kind of mutation reported by a ChangeEvent
+type ChangeOp int
+
+const (
+	// ChangeUpsert is reported when a row is created or updated
+	ChangeUpsert ChangeOp = iota + 1
+
+	// ChangeRemove is reported when a row is removed
+	ChangeRemove
+)
+
+// String satisfies the Stringer interface
+func (op ChangeOp) String() string {
+	switch op {
+	case ChangeUpsert:
+		return "UPSERT"
+	case ChangeRemove:
+		return "REMOVE"
+	}
+	return fmt.Sprintf("ChangeOp(%d)", int(op))
+}
+
+// Checkpoint is an opaque position in a change stream. Pass the checkpoint of
+// the last event a reader handled to StreamChanges to resume after it; the
+// empty checkpoint starts from the oldest change the connector still has.
+type Checkpoint string
+
+// ChangeEvent describes a single mutation of an entity. OldValues are the
+// values of the row before the change, or nil if the connector doesn't know
+// them or the row didn't exist; NewValues are nil for a ChangeRemove.
+type ChangeEvent struct {
+	Op         ChangeOp
+	EntityName string
+	OldValues  map[string]FieldValue
+	NewValues  map[string]FieldValue
+	Checkpoint Checkpoint
+}
+
+// ChangeStreamable is implemented by connectors that can stream the changes
+// made to an entity, e.g. from Cassandra triggers or DynamoDB Streams.
+// Callers type-assert a Connector to find out whether it's supported.
+type ChangeStreamable interface {
+	// StreamChanges returns a channel with the changes made to the entity
+	// after the checkpoint, in the order they were made. The channel is
+	// closed when the context is done or the connector shuts down.
+	StreamChanges(ctx context.Context, ei *EntityInfo, from Checkpoint) (<-chan ChangeEvent, error)
+}
@@ -51,6 +51,9 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -134,6 +137,164 @@ func ErrorIsAlreadyExists(err error) bool {
 	return ok
 }
 
+// ErrConflict is an error returned when CompareAndSwap finds that the current
+// values of a row don't match the expected ones
+type ErrConflict struct{}
+
+func (*ErrConflict) Error() string {
+	return "conflict"
+}
+
+// ErrorIsConflict checks if the error is caused by "ErrConflict"
+func ErrorIsConflict(err error) bool {
+	_, ok := errors.Cause(err).(*ErrConflict)
+	return ok
+}
+
+// ErrSchemaMismatch is returned by WarmUp when the live schema of one or
+// more entities differs from their registered definitions. Differences maps
+// each mismatched entity name to what differs.
+type ErrSchemaMismatch struct {
+	Differences map[string][]string
+}
+
+func (e *ErrSchemaMismatch) Error() string {
+	names := make([]string, 0, len(e.Differences))
+	for name := range e.Differences {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %s", name, strings.Join(e.Differences[name], ", "))
+	}
+	return "schema mismatch: " + strings.Join(parts, "; ")
+}
+
+// ErrorIsSchemaMismatch checks if the error is caused by "ErrSchemaMismatch"
+func ErrorIsSchemaMismatch(err error) bool {
+	_, ok := errors.Cause(err).(*ErrSchemaMismatch)
+	return ok
+}
+
+// ErrScanOnly is returned by the client methods that need a primary key when
+// they are called with an entity declared with primaryKey=(), which can only
+// be upserted and scanned
+type ErrScanOnly struct {
+	Entity string
+}
+
+func (e *ErrScanOnly) Error() string {
+	return fmt.Sprintf("entity %s has no primary key, it can only be upserted and scanned", e.Entity)
+}
+
+// ErrorIsScanOnly checks if the error is caused by "ErrScanOnly"
+func ErrorIsScanOnly(err error) bool {
+	_, ok := errors.Cause(err).(*ErrScanOnly)
+	return ok
+}
+
+// checkKeyed returns an ErrScanOnly if the entity has no primary key
+func checkKeyed(re *RegisteredEntity) error {
+	if re.table.ScanOnly() {
+		return &ErrScanOnly{Entity: re.table.StructName}
+	}
+	return nil
+}
+
+// ErrInvalidOperation is returned when an operation can't be applied to a
+// column, e.g. an atomic add to a column that isn't an Int64
+type ErrInvalidOperation struct {
+	Message string
+}
+
+func (e *ErrInvalidOperation) Error() string {
+	return "invalid operation: " + e.Message
+}
+
+// ErrorIsInvalidOperation checks if the error is caused by "ErrInvalidOperation"
+func ErrorIsInvalidOperation(err error) bool {
+	_, ok := errors.Cause(err).(*ErrInvalidOperation)
+	return ok
+}
+
+// ErrNotSupported is returned by connectors for operations their backend
+// can't do; the client falls back to other operations where it can
+type ErrNotSupported struct {
+	Op string
+}
+
+func (e *ErrNotSupported) Error() string {
+	return e.Op + " is not supported by the connector"
+}
+
+// ErrorIsNotSupported checks if the error is caused by "ErrNotSupported"
+func ErrorIsNotSupported(err error) bool {
+	_, ok := errors.Cause(err).(*ErrNotSupported)
+	return ok
+}
+
+// CheckAtomicAdd returns an ErrInvalidOperation unless column is a non-key
+// Int64 column of the entity, which is what AtomicAdd requires
+func CheckAtomicAdd(ed *EntityDefinition, column string) error {
+	cd := ed.FindColumnDefinition(column)
+	if cd == nil {
+		return errors.Errorf("column %q not found in entity %q", column, ed.Name)
+	}
+	if cd.Type != Int64 {
+		return &ErrInvalidOperation{Message: fmt.Sprintf("cannot add to column %q of type %v, only Int64 columns are counters", column, cd.Type)}
+	}
+	if _, ok := ed.KeySet()[column]; ok {
+		return &ErrInvalidOperation{Message: fmt.Sprintf("cannot add to key column %q", column)}
+	}
+	return nil
+}
+
+// BatchError aggregates the errors of a batch operation. Errors is positionally
+// aligned with the entities of the batch; the entry is nil if the operation on
+// that entity succeeded.
+type BatchError struct {
+	Errors []error
+}
+
+// NewBatchError returns a *BatchError for the per-entity errors of a batch
+// operation, or nil if all of them succeeded
+func NewBatchError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Errors: errs}
+		}
+	}
+	return nil
+}
+
+func (e *BatchError) Error() string {
+	failures := e.Failures()
+	for i, err := range e.Errors {
+		if err != nil {
+			return fmt.Sprintf("%d of %d operations failed, first error at %d: %v", len(failures), len(e.Errors), i, err)
+		}
+	}
+	return "batch succeeded"
+}
+
+// Failures returns the errors of the failed operations by their position in the batch
+func (e *BatchError) Failures() map[int]error {
+	failures := make(map[int]error)
+	for i, err := range e.Errors {
+		if err != nil {
+			failures[i] = err
+		}
+	}
+	return failures
+}
+
+// ErrorIsBatchError checks if the error is caused by "BatchError"
+func ErrorIsBatchError(err error) bool {
+	_, ok := errors.Cause(err).(*BatchError)
+	return ok
+}
+
 // Client defines the methods to operate with DOSA entities
 type Client interface {
 	// GetRegistrar returns the registrar
@@ -142,12 +303,32 @@ type Client interface {
 	// Initialize must be called before any data operation
 	Initialize(ctx context.Context) error
 
+	// WarmUp compares the live schema of every registered entity, as returned
+	// by the connector's DescribeTable, with its registered definition, so that
+	// schema drift is caught before serving traffic. All the differences are
+	// reported together in an ErrSchemaMismatch.
+	WarmUp(ctx context.Context) error
+
+	// WarmConnections fills the connection pool of the connector by making
+	// count concurrent calls to its ScopeExists, a round trip that reads no
+	// rows. The first error is returned, in which case the connector should
+	// be considered unhealthy. See also WithWarmConnectionsOnStart.
+	WarmConnections(ctx context.Context, count int) error
+
 	// Create creates an entity; it fails if the entity already exists.
 	// You must fill in all of the fields of the DomainObject before
 	// calling this method, or they will be inserted with the zero value
 	// This is a relatively expensive operation. Use Upsert whenever possible.
 	CreateIfNotExists(ctx context.Context, objectToCreate DomainObject) error
 
+	// ReadOrCreate creates an entity, or reads it if it already exists. existed
+	// tells whether the row was already there, in which case all the fields of
+	// the DomainObject are replaced by the stored values.
+	// This is not atomic: the row could be removed between the create and the
+	// read, which then fails with ErrNotFound. It does remove the need for a
+	// read, check for ErrNotFound, then create sequence in application code.
+	ReadOrCreate(ctx context.Context, objectToReadOrCreate DomainObject) (existed bool, err error)
+
 	// Read fetches a row by primary key. A list of fields to read can be
 	// specified. Use All() or nil for all fields.
 	// Before calling this method, fill in the DomainObject with ALL
@@ -155,6 +336,19 @@ type Client interface {
 	// as a result of the read
 	Read(ctx context.Context, fieldsToRead []string, objectToRead DomainObject) error
 
+	// GetWithFieldMask fetches a row by primary key, reading only the given
+	// fields. The primary key fields are always read, whether or not they
+	// are part of the mask; every other field is reset to its zero value.
+	// It fails if one of the fields does not exist on the entity.
+	GetWithFieldMask(ctx context.Context, entity DomainObject, fields []string) error
+
+	// GetOrSet reads the entity by primary key. If there is no such row,
+	// setter is called with the entity to fill in its non-key fields and the
+	// entity is created with CreateIfNotExists; created is true in that case.
+	// If another writer creates the row in between, the entity is read again
+	// and created is false. Errors returned by setter are returned as is.
+	GetOrSet(ctx context.Context, entity DomainObject, setter func(DomainObject) error) (created bool, err error)
+
 	// MultiRead fetches several rows by primary key. A list of fields can be
 	// specified. Use All() or nil for all fields.
 	// The domainObject will be filled by corresponding values if the object is fetched successfully.
@@ -166,6 +360,26 @@ type Client interface {
 	// use Range instead of MultiRead.
 	MultiRead(context.Context, []string, ...DomainObject) (MultiResult, error)
 
+	// GetMany reads several rows by primary key, with all of their fields.
+	// Entities of different types may be mixed; they are read with
+	// MultiRead calls to the connector of up to GetManyBatchSize keys,
+	// up to the client's maximum parallelism at a time. The entities and
+	// errors returned are positionally aligned with the given ones: a found
+	// entity is filled in and returned, while a missing one is nil with an
+	// ErrNotFound. The last result is an error that failed a whole
+	// connector call, such as a connection failure.
+	GetMany(ctx context.Context, pks []DomainObject) ([]DomainObject, []error, error)
+
+	// GetFirst returns the first row of the partition of the given entity, of
+	// which only the partition key fields are used, in the order of the
+	// clustering keys, with all of its fields. It returns ErrNotFound if the
+	// partition has no rows.
+	GetFirst(ctx context.Context, partitionKey DomainObject) (DomainObject, error)
+
+	// GetLast is like GetFirst, but returns the last row in the order of the
+	// clustering keys, e.g. the latest event of a partition sorted by time.
+	GetLast(ctx context.Context, partitionKey DomainObject) (DomainObject, error)
+
 	// Upsert creates or update a row. A list of fields to update can be
 	// specified. Use All() or nil for all fields.
 	// Before calling this method, fill in the DomainObject with ALL
@@ -173,14 +387,58 @@ type Client interface {
 	// to update in fieldsToUpdate (or all the fields if you use dosa.All())
 	Upsert(ctx context.Context, fieldsToUpdate []string, objectToUpdate DomainObject) error
 
+	// MultiUpsert upserts all of the fields of each entity, running up to
+	// the client's maximum parallelism (see WithMaxParallelism) of Upserts
+	// at a time. The returned errors are aligned with the entities; an
+	// element is nil if that entity was written. Once the context is
+	// canceled no more writes are started, the entities that were not
+	// written get the context's error, and MultiUpsert returns after the
+	// writes in flight complete.
+	MultiUpsert(ctx context.Context, entities []DomainObject) []error
+
+	// UpsertWithConditions writes all of the fields of an existing row, but
+	// only if the current row passes every condition. Each condition's Name
+	// is a field name of the entity, and any field can be used, not just the
+	// primary key. ErrConflict is returned when a condition fails and
+	// ErrNotFound when the row doesn't exist. The check is more expensive
+	// than a plain Upsert, so only use it when the write really depends on
+	// the current row, e.g. for state transitions.
+	UpsertWithConditions(ctx context.Context, objectToUpdate DomainObject, conditions []*ColumnCondition) error
+
+	// IncrementCounter atomically adds delta to an int64 (or *int64) field of the
+	// row with the entity's primary key and returns the new value, which is also
+	// set in the entity. A missing row is created and a null field counts as 0.
+	// ErrInvalidOperation is returned for fields of any other type, and for key
+	// fields.
+	IncrementCounter(ctx context.Context, entity DomainObject, fieldName string, delta int64) (int64, error)
+
+	// Replace writes all of the fields of the entity as a new row, replacing
+	// any existing row with the same primary key. Unlike Upsert, columns that
+	// the entity doesn't set are not kept from the existing row. Replace is
+	// more expensive than Upsert on backends that can't replace a row
+	// atomically, where it is a Remove followed by an Upsert.
+	Replace(ctx context.Context, objectToReplace DomainObject) error
+
 	// Remove removes a row by primary key. The passed-in entity should contain
 	// the primary key field values, all other fields are ignored.
 	Remove(ctx context.Context, objectToRemove DomainObject) error
 
+	// BatchRemove removes several rows by primary key. The returned errors are
+	// positionally aligned with the entities, nil where the remove succeeded;
+	// use NewBatchError to turn them into a single error.
+	// Entities of different types may be mixed, there is one MultiRemove call
+	// to the connector per entity type.
+	BatchRemove(ctx context.Context, entities []DomainObject) []error
+
 	// RemoveRange removes all of the rows that fall within the range specified by the
 	// given RemoveRangeOp.
 	RemoveRange(ctx context.Context, removeRangeOp *RemoveRangeOp) error
 
+	// RemoveAll removes every row of the partition of the given entity, of
+	// which only the partition key fields are used. Connectors with a native
+	// partition delete use it, others remove the rows in chunks.
+	RemoveAll(ctx context.Context, partitionKey DomainObject) error
+
 	// Range fetches entities within a range
 	// Before calling range, create a RangeOp and fill in the table
 	// along with the partition key information. You will get back
@@ -189,7 +447,8 @@ type Client interface {
 	//
 	// Range only fetches a portion of the range at a time (the size of that portion is defined
 	// by the Limit parameter of the RangeOp). A continuation token is returned so subsequent portions
-	// of the range can be fetched with additional calls to the range function.
+	// of the range can be fetched with additional calls to the range function. Without an Offset,
+	// the page token set on the context with WithPageToken is used, if any.
 	Range(ctx context.Context, rangeOp *RangeOp) ([]DomainObject, string, error)
 
 	// WalkRange starts at the offset specified by the RangeOp and walks the entire
@@ -197,17 +456,63 @@ type Client interface {
 	// range requests, fetching values until there are no more left in the range.
 	//
 	// For each value fetched, the provided onNext function is called with the value as it's argument.
+	// Return ErrStop from onNext to stop walking without an error.
 	WalkRange(ctx context.Context, r *RangeOp, onNext func(value DomainObject) error) error
 
+	// Aggregate computes aggFunc over a field of the entities within the range
+	// specified by the RangeOp; a RangeOp without conditions aggregates the whole
+	// table. AggSum and AggAvg can only be applied to numeric fields and AggMin
+	// and AggMax to fields whose values are ordered. See AggFunc for the types
+	// of the returned values.
+	Aggregate(ctx context.Context, aggFunc AggFunc, fieldName string, rangeOp *RangeOp) (FieldValue, error)
+
+	// ExplainQuery returns the plan of the connector for a Range over the
+	// entity and conditions of the RangeOp, to investigate slow queries.
+	// Limit, Offset and Fields are ignored.
+	ExplainQuery(ctx context.Context, rangeOp *RangeOp) (*QueryPlan, error)
+
+	// CountRange returns the number of entities within the range specified
+	// by the RangeOp. Limit, Offset and Fields are ignored. Connectors count
+	// natively where the backend can; when the connector returns
+	// ErrNotSupported the client reads every entity in the range to count
+	// them, which costs O(n) in the size of the range.
+	CountRange(ctx context.Context, rangeOp *RangeOp) (int64, error)
+
 	// ScanEverything fetches all entities of a type
 	// Before calling ScanEverything, create a scanOp to specify the
 	// table to scan. The return values are an array of objects, that
 	// you can type-assert to the appropriate dosa.Entity, a string
 	// that contains the continuation token, and any error.
 	// To scan the next set of rows, modify the scanOp to provide
-	// the string returned as an Offset()
+	// the string returned as an Offset(), or set it on the context
+	// with WithPageToken
 	ScanEverything(ctx context.Context, scanOp *ScanOp) ([]DomainObject, string, error)
 
+	// ScanWithCallback pages through all entities of the type of entity,
+	// pageSize rows at a time, and calls fn with each of them. Only one
+	// page of entities is held in memory at a time. Return ErrStop from fn
+	// to stop scanning without an error; any other error stops the scan and
+	// is returned.
+	ScanWithCallback(ctx context.Context, entity DomainObject, pageSize int, fn func(DomainObject) error) error
+
+	// CrossPartitionScan reads all the entities of the type of entity in
+	// the given partitions. Each partition key maps the names of all the
+	// partition key fields to their values. The partitions are ranged over
+	// concurrently, up to the client's maximum parallelism (see
+	// WithMaxParallelism), pageSize rows at a time. The results are merged
+	// in clustering key order; entities with equal clustering keys are kept
+	// in the order of their partitions. The first error stops the scan.
+	CrossPartitionScan(ctx context.Context, entity DomainObject, partitionKeys []map[string]FieldValue, pageSize int) ([]DomainObject, error)
+
+	// ScanBySecondaryIndex reads a page of up to pageSize entities of the
+	// type of entity from the named index, starting at token or else at the
+	// one set with WithPageToken. The conditions use field names and may only
+	// refer to the key fields of the index; like the conditions of a Range,
+	// they must set all its partition keys.
+	// The entities are returned in the clustering order of the index, along
+	// with the token of the next page.
+	ScanBySecondaryIndex(ctx context.Context, entity DomainObject, indexName string, conditions []*ColumnCondition, pageSize int, token string) ([]DomainObject, string, error)
+
 	// Shutdown gracefully shuts down the client, cleaning up any resources it may have
 	// allocated during its usage. Shutdown should be called whenever the client
 	// is no longer needed. After calling shutdown there should be no further usage
@@ -233,12 +538,20 @@ type AdminClient interface {
 	Excludes(excludes []string) AdminClient
 	// Scope sets the admin client scope
 	Scope(scope string) AdminClient
+	// Entity limits the schema operations to the named entity
+	Entity(name string) AdminClient
+	// SchemaFiles sets YAML files to read entities from instead of searching directories
+	SchemaFiles(files []string) AdminClient
+	// Definitions sets the entity definitions to use instead of searching directories
+	Definitions(defs []*EntityDefinition) AdminClient
 	// CanUpsertSchema checks the compatibility of to-be-upserted schemas
 	CanUpsertSchema(ctx context.Context, namePrefix string) (*SchemaStatus, error)
 	// CheckSchemaStatus checks the status of schema application
 	CheckSchemaStatus(ctx context.Context, namePrefix string, version int32) (*SchemaStatus, error)
 	// UpsertSchema upserts the schemas
 	UpsertSchema(ctx context.Context, namePrefix string) (*SchemaStatus, error)
+	// DropTables drops the tables of the entities, removing all of their data
+	DropTables(ctx context.Context, namePrefix string) error
 	// GetSchema finds entity definitions
 	GetSchema() ([]*EntityDefinition, error)
 	// CreateScope creates a new scope
@@ -254,19 +567,98 @@ type AdminClient interface {
 	Shutdown() error
 }
 
+// DefaultMaxParallelism is the number of concurrent connector calls a client
+// makes for a single multi-entity call, unless WithMaxParallelism is used
+const DefaultMaxParallelism = 10
+
 type client struct {
-	initialized bool
-	registrar   Registrar
-	connector   Connector
+	initialized    bool
+	registrar      Registrar
+	connector      Connector
+	maxParallelism int
+	logger         Logger
+	readRetries    int
+	readBackoff    time.Duration
+	timeout        time.Duration
+	warmConns      int
+}
+
+// ClientOption configures a client created by NewClient
+type ClientOption func(*client)
+
+// WithMaxParallelism sets the number of concurrent connector calls the client
+// makes for a single multi-entity call such as MultiUpsert. Values less than
+// 1 are ignored.
+func WithMaxParallelism(n int) ClientOption {
+	return func(c *client) {
+		if n > 0 {
+			c.maxParallelism = n
+		}
+	}
+}
+
+// WithLogger routes the client's diagnostic output, such as the entities
+// validated by WarmUp, to the given logger. By default nothing is logged.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithReadRepairRetries makes Read retry up to maxRetries times when the
+// connector returns ErrNotFound, for storage where a row written through one
+// replica may not be visible on another yet. The first retry waits backoff,
+// and each following one waits twice as long as the one before. Other errors
+// are returned right away; retrying them is up to the connector.
+func WithReadRepairRetries(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *client) {
+		if maxRetries > 0 {
+			c.readRetries = maxRetries
+			c.readBackoff = backoff
+		}
+	}
+}
+
+// WithTimeout gives every connector call made by the client a timeout of d,
+// as a safety net for callers that forget to set one. Calls whose context
+// already has a deadline keep it. By default there is no timeout.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithWarmConnectionsOnStart makes Initialize call WarmConnections with count
+// before it checks the schema, so that the connector's connections are
+// established before the first request. Initialize fails if a ping does.
+func WithWarmConnectionsOnStart(count int) ClientOption {
+	return func(c *client) {
+		if count > 0 {
+			c.warmConns = count
+		}
+	}
 }
 
 // NewClient returns a new DOSA client for the registrar and connector provided.
 // This is currently only a partial implementation to demonstrate basic CRUD functionality.
-func NewClient(reg Registrar, conn Connector) Client {
-	return &client{
-		registrar: reg,
-		connector: conn,
+func NewClient(reg Registrar, conn Connector, opts ...ClientOption) Client {
+	c := &client{
+		registrar:      reg,
+		connector:      conn,
+		maxParallelism: DefaultMaxParallelism,
+		logger:         nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout > 0 {
+		c.connector = &timeoutConnector{Connector: conn, timeout: c.timeout}
 	}
+	return c
 }
 
 // GetRegistrar returns the registrar that is registered in the client
@@ -286,6 +678,12 @@ func (c *client) Initialize(ctx context.Context) error {
 		return errors.Errorf("No registered entities found")
 	}
 
+	if c.warmConns > 0 {
+		if err := c.WarmConnections(ctx, c.warmConns); err != nil {
+			return err
+		}
+	}
+
 	eds := []*EntityDefinition{}
 	for _, re := range registered {
 		eds = append(eds, re.EntityDefinition())
@@ -305,11 +703,79 @@ func (c *client) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// WarmUp validates the live schemas of all registered entities
+func (c *client) WarmUp(ctx context.Context) error {
+	registered := c.registrar.FindAll()
+	if len(registered) == 0 {
+		return errors.Errorf("No registered entities found")
+	}
+
+	mismatch := &ErrSchemaMismatch{Differences: map[string][]string{}}
+	names := make([]string, 0, len(registered))
+	for _, re := range registered {
+		ei := re.EntityInfo()
+		live, err := c.connector.DescribeTable(ctx, ei)
+		if err != nil {
+			return errors.Wrapf(err, "DescribeTable failed for %q", ei.Def.Name)
+		}
+		if diffs := ei.Def.Differences(live); len(diffs) > 0 {
+			mismatch.Differences[ei.Def.Name] = diffs
+			continue
+		}
+		names = append(names, ei.Def.Name)
+	}
+	if len(mismatch.Differences) > 0 {
+		return mismatch
+	}
+	sort.Strings(names)
+	c.logger.Infof("dosa: validated the live schemas of %s", strings.Join(names, ", "))
+	return nil
+}
+
+// WarmConnections pings the connector count times at once, with ScopeExists
+func (c *client) WarmConnections(ctx context.Context, count int) error {
+	if count <= 0 {
+		return errors.Errorf("WarmConnections: invalid count %d", count)
+	}
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.connector.ScopeExists(ctx, c.registrar.Scope())
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return errors.Wrap(err, "WarmConnections")
+		}
+	}
+	return nil
+}
+
 // CreateIfNotExists creates a row, but only if it does not exist. The entity
 // provided must contain values for all components of its primary key for the
 // operation to succeed.
 func (c *client) CreateIfNotExists(ctx context.Context, entity DomainObject) error {
-	return c.createOrUpsert(ctx, nil, entity, c.connector.CreateIfNotExists)
+	return c.createOrUpsert(ctx, nil, entity, true, c.connector.CreateIfNotExists)
+}
+
+// ReadOrCreate creates an entity with CreateIfNotExists and falls back to reading
+// it when it already exists.
+func (c *client) ReadOrCreate(ctx context.Context, entity DomainObject) (bool, error) {
+	err := c.CreateIfNotExists(ctx, entity)
+	if err == nil {
+		return false, nil
+	}
+	if !ErrorIsAlreadyExists(err) {
+		return false, err
+	}
+	if err := c.Read(ctx, All(), entity); err != nil {
+		return false, errors.Wrap(err, "ReadOrCreate")
+	}
+	return true, nil
 }
 
 // Read fetches an entity by primary key, The entity provided must contain
@@ -326,6 +792,9 @@ func (c *client) Read(ctx context.Context, fieldsToRead []string, entity DomainO
 	if err != nil {
 		return err
 	}
+	if err := checkKeyed(re); err != nil {
+		return err
+	}
 
 	// translate entity field values to a map of primary key name/values pairs
 	// required to perform a read
@@ -337,7 +806,7 @@ func (c *client) Read(ctx context.Context, fieldsToRead []string, entity DomainO
 		return err
 	}
 
-	results, err := c.connector.Read(ctx, re.EntityInfo(), fieldValues, columnsToRead)
+	results, err := c.readWithRetries(ctx, re.EntityInfo(), fieldValues, columnsToRead)
 	if err != nil {
 		return err
 	}
@@ -348,6 +817,77 @@ func (c *client) Read(ctx context.Context, fieldsToRead []string, entity DomainO
 	return nil
 }
 
+// GetWithFieldMask fetches an entity by primary key, populating only the
+// fields in the mask and the primary key; the other fields are zeroed.
+func (c *client) GetWithFieldMask(ctx context.Context, entity DomainObject, fields []string) error {
+	if !c.initialized {
+		return &ErrNotInitialized{}
+	}
+
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return err
+	}
+	if err := checkKeyed(re); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("GetWithFieldMask: empty field mask")
+	}
+
+	// validate the mask before adding the key columns it lacks, since
+	// they are needed to route the read
+	columnsToRead, err := re.ColumnNames(fields)
+	if err != nil {
+		return err
+	}
+	keyColumns := re.table.Key.PrimaryKeySet()
+	for _, name := range columnsToRead {
+		delete(keyColumns, name)
+	}
+	for _, col := range re.table.Key.PartitionKeys {
+		if _, ok := keyColumns[col]; ok {
+			columnsToRead = append(columnsToRead, col)
+		}
+	}
+	for _, ck := range re.table.Key.ClusteringKeys {
+		if _, ok := keyColumns[ck.Name]; ok {
+			columnsToRead = append(columnsToRead, ck.Name)
+		}
+	}
+
+	keys := re.KeyFieldValues(entity)
+	results, err := c.readWithRetries(ctx, re.EntityInfo(), keys, columnsToRead)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(entity).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	re.SetFieldValues(entity, results, columnsToRead)
+	re.SetFieldValues(entity, keys, nil)
+
+	return nil
+}
+
+// readWithRetries reads from the connector, retrying on ErrNotFound as
+// configured by WithReadRepairRetries
+func (c *client) readWithRetries(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue, columnsToRead []string) (map[string]FieldValue, error) {
+	backoff := c.readBackoff
+	for retry := 0; ; retry++ {
+		results, err := c.connector.Read(ctx, ei, keys, columnsToRead)
+		if !ErrorIsNotFound(err) || retry == c.readRetries {
+			return results, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
 // MultiRead fetches several entities by primary key, The entities provided
 // must contain values for all components of its primary key for the operation
 // to succeed. If `fieldsToRead` is provided, only a subset of fields will be
@@ -369,6 +909,9 @@ func (c *client) MultiRead(ctx context.Context, fieldsToRead []string, entities
 		if err != nil {
 			return nil, err
 		}
+		if err := checkKeyed(ere); err != nil {
+			return nil, err
+		}
 
 		if re == nil {
 			re = ere
@@ -405,6 +948,89 @@ func (c *client) MultiRead(ctx context.Context, fieldsToRead []string, entities
 	return multiResult, nil
 }
 
+// GetManyBatchSize is the largest number of keys GetMany reads with one
+// connector MultiRead call
+const GetManyBatchSize = 100
+
+// GetMany reads the entities with MultiRead calls, up to maxParallelism at a time
+func (c *client) GetMany(ctx context.Context, pks []DomainObject) ([]DomainObject, []error, error) {
+	if !c.initialized {
+		return nil, nil, &ErrNotInitialized{}
+	}
+
+	found := make([]DomainObject, len(pks))
+	errs := make([]error, len(pks))
+
+	// group the entities by type and then into batches, remembering their
+	// position in pks
+	type batch struct {
+		re        *RegisteredEntity
+		positions []int
+		keys      []map[string]FieldValue
+	}
+	var batches []*batch
+	open := map[*RegisteredEntity]*batch{}
+	for i, entity := range pks {
+		re, err := c.registrar.Find(entity)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := checkKeyed(re); err != nil {
+			errs[i] = err
+			continue
+		}
+		b, ok := open[re]
+		if !ok || len(b.positions) == GetManyBatchSize {
+			b = &batch{re: re}
+			open[re] = b
+			batches = append(batches, b)
+		}
+		b.positions = append(b.positions, i)
+		b.keys = append(b.keys, re.KeyFieldValues(entity))
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.maxParallelism)
+	batchErrs := make([]error, len(batches))
+	for i, b := range batches {
+		if err := acquire(ctx, sem); err != nil {
+			batchErrs[i] = err
+			break
+		}
+		wg.Add(1)
+		go func(i int, b *batch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results, err := c.connector.MultiRead(ctx, b.re.EntityInfo(), b.keys, nil)
+			if err != nil {
+				batchErrs[i] = errors.Wrap(err, "GetMany")
+				return
+			}
+			// each batch has its own positions, so no lock is needed
+			for j, pos := range b.positions {
+				switch {
+				case j >= len(results) || results[j] == nil:
+					errs[pos] = errors.Errorf("GetMany: no result for entity %d", pos)
+				case results[j].Error != nil:
+					errs[pos] = results[j].Error
+				default:
+					b.re.SetFieldValues(pks[pos], results[j].Values, nil)
+					found[pos] = pks[pos]
+				}
+			}
+		}(i, b)
+	}
+	wg.Wait()
+
+	for _, err := range batchErrs {
+		if err != nil {
+			return found, errs, err
+		}
+	}
+	return found, errs, nil
+}
+
 type createOrUpsertType func(context.Context, *EntityInfo, map[string]FieldValue) error
 
 // Upsert updates some values of an entity, or creates it if it doesn't exist.
@@ -412,10 +1038,153 @@ type createOrUpsertType func(context.Context, *EntityInfo, map[string]FieldValue
 // key for the operation to succeed. If `fieldsToUpdate` is provided, only a
 // subset of fields will be updated.
 func (c *client) Upsert(ctx context.Context, fieldsToUpdate []string, entity DomainObject) error {
-	return c.createOrUpsert(ctx, fieldsToUpdate, entity, c.connector.Upsert)
+	return c.createOrUpsert(ctx, fieldsToUpdate, entity, false, c.connector.Upsert)
+}
+
+// GetOrSet reads the entity, or creates it with the values filled in by setter if it doesn't exist
+func (c *client) GetOrSet(ctx context.Context, entity DomainObject, setter func(DomainObject) error) (bool, error) {
+	err := c.Read(ctx, nil, entity)
+	if err == nil {
+		return false, nil
+	}
+	if !ErrorIsNotFound(err) {
+		return false, errors.Wrap(err, "GetOrSet")
+	}
+
+	if err := setter(entity); err != nil {
+		return false, err
+	}
+	err = c.CreateIfNotExists(ctx, entity)
+	if err == nil {
+		return true, nil
+	}
+	if !ErrorIsAlreadyExists(err) {
+		return false, errors.Wrap(err, "GetOrSet")
+	}
+
+	// lost the race to another writer, so their row is the one to return
+	if err := c.Read(ctx, nil, entity); err != nil {
+		return false, errors.Wrap(err, "GetOrSet")
+	}
+	return false, nil
+}
+
+// MultiUpsert fans out an Upsert of all fields for each entity, up to maxParallelism at a time
+func (c *client) MultiUpsert(ctx context.Context, entities []DomainObject) []error {
+	errs := make([]error, len(entities))
+	if !c.initialized {
+		for i := range errs {
+			errs[i] = &ErrNotInitialized{}
+		}
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.maxParallelism)
+	for i, entity := range entities {
+		if err := acquire(ctx, sem); err != nil {
+			for j := i; j < len(entities); j++ {
+				errs[j] = err
+			}
+			break
+		}
+		wg.Add(1)
+		go func(i int, entity DomainObject) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = c.Upsert(ctx, nil, entity)
+		}(i, entity)
+	}
+	wg.Wait()
+	return errs
+}
+
+// acquire takes a slot in sem, or returns the context's error if it is canceled first
+func acquire(ctx context.Context, sem chan struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UpsertWithConditions uses the connector's UpsertWithConditions to write the entity
+// if the current row passes the conditions
+func (c *client) UpsertWithConditions(ctx context.Context, entity DomainObject, conditions []*ColumnCondition) error {
+	if !c.initialized {
+		return &ErrNotInitialized{}
+	}
+
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return errors.Wrap(err, "UpsertWithConditions")
+	}
+	if err := checkKeyed(re); err != nil {
+		return errors.Wrap(err, "UpsertWithConditions")
+	}
+	fieldConditions := make(map[string][]*Condition, len(conditions))
+	for _, cond := range conditions {
+		if cond == nil || cond.Condition == nil {
+			return errors.New("UpsertWithConditions: nil condition")
+		}
+		fieldConditions[cond.Name] = append(fieldConditions[cond.Name], cond.Condition)
+	}
+	columnConditions, err := ConvertConditions(fieldConditions, re.table)
+	if err != nil {
+		return errors.Wrap(err, "UpsertWithConditions")
+	}
+
+	return c.createOrUpsert(ctx, nil, entity, true, func(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error {
+		return c.connector.UpsertWithConditions(ctx, ei, values, columnConditions)
+	})
+}
+
+// IncrementCounter uses the connector's AtomicAdd to add to a counter field
+func (c *client) IncrementCounter(ctx context.Context, entity DomainObject, fieldName string, delta int64) (int64, error) {
+	if !c.initialized {
+		return 0, &ErrNotInitialized{}
+	}
+
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return 0, errors.Wrap(err, "IncrementCounter")
+	}
+	if err := checkKeyed(re); err != nil {
+		return 0, errors.Wrap(err, "IncrementCounter")
+	}
+	columns, err := re.ColumnNames([]string{fieldName})
+	if err != nil {
+		return 0, errors.Wrap(err, "IncrementCounter")
+	}
+	column := columns[0]
+	if err := CheckAtomicAdd(&re.table.EntityDefinition, column); err != nil {
+		return 0, errors.Wrap(err, "IncrementCounter")
+	}
+
+	value, err := c.connector.AtomicAdd(ctx, re.EntityInfo(), re.KeyFieldValues(entity), column, delta)
+	if err != nil {
+		return 0, errors.Wrap(err, "IncrementCounter")
+	}
+	var fv FieldValue = value
+	if re.table.FindColumnDefinition(column).IsPointer {
+		fv = &value
+	}
+	re.SetFieldValues(entity, map[string]FieldValue{column: fv}, columns)
+	return value, nil
 }
 
-func (c *client) createOrUpsert(ctx context.Context, fieldsToUpdate []string, entity DomainObject, fn createOrUpsertType) error {
+// Replace replaces the row with the entity's primary key with the entity
+func (c *client) Replace(ctx context.Context, entity DomainObject) error {
+	return c.createOrUpsert(ctx, nil, entity, true, c.connector.Replace)
+}
+
+// createOrUpsert writes the entity with fn; with requireKey, entities without
+// a primary key are rejected
+func (c *client) createOrUpsert(ctx context.Context, fieldsToUpdate []string, entity DomainObject, requireKey bool, fn createOrUpsertType) error {
 	if !c.initialized {
 		return &ErrNotInitialized{}
 	}
@@ -425,6 +1194,11 @@ func (c *client) createOrUpsert(ctx context.Context, fieldsToUpdate []string, en
 	if err != nil {
 		return err
 	}
+	if requireKey {
+		if err := checkKeyed(re); err != nil {
+			return err
+		}
+	}
 
 	// translate entity field values to a map of primary key name/values pairs
 	keyFieldValues := re.KeyFieldValues(entity)
@@ -468,6 +1242,9 @@ func (c *client) Remove(ctx context.Context, entity DomainObject) error {
 	if err != nil {
 		return err
 	}
+	if err := checkKeyed(re); err != nil {
+		return err
+	}
 
 	// translate entity field values to a map of primary key name/values pairs
 	keyFieldValues := re.KeyFieldValues(entity)
@@ -476,6 +1253,61 @@ func (c *client) Remove(ctx context.Context, entity DomainObject) error {
 	return err
 }
 
+// BatchRemove deletes several entities by primary key. Each entity provided must
+// contain values for all components of its primary key.
+func (c *client) BatchRemove(ctx context.Context, entities []DomainObject) []error {
+	errs := make([]error, len(entities))
+	if !c.initialized {
+		for i := range errs {
+			errs[i] = &ErrNotInitialized{}
+		}
+		return errs
+	}
+
+	// group the entities by type, remembering their position in the batch
+	type batch struct {
+		re        *RegisteredEntity
+		positions []int
+		keys      []map[string]FieldValue
+	}
+	var batches []*batch
+	byEntity := map[*RegisteredEntity]*batch{}
+	for i, entity := range entities {
+		re, err := c.registrar.Find(entity)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := checkKeyed(re); err != nil {
+			errs[i] = err
+			continue
+		}
+		b, ok := byEntity[re]
+		if !ok {
+			b = &batch{re: re}
+			byEntity[re] = b
+			batches = append(batches, b)
+		}
+		b.positions = append(b.positions, i)
+		b.keys = append(b.keys, re.KeyFieldValues(entity))
+	}
+
+	for _, b := range batches {
+		results, err := c.connector.MultiRemove(ctx, b.re.EntityInfo(), b.keys)
+		for j, pos := range b.positions {
+			switch {
+			case err != nil:
+				errs[pos] = err
+			case j >= len(results):
+				errs[pos] = errors.Errorf("BatchRemove: no result for entity %d", pos)
+			default:
+				errs[pos] = results[j]
+			}
+		}
+	}
+	return errs
+}
+
 // RemoveRange removes all of the rows that fall within the range specified by the
 // given RemoveRangeOp.
 func (c *client) RemoveRange(ctx context.Context, r *RemoveRangeOp) error {
@@ -488,6 +1320,9 @@ func (c *client) RemoveRange(ctx context.Context, r *RemoveRangeOp) error {
 	if err != nil {
 		return errors.Wrap(err, "RemoveRange")
 	}
+	if err := checkKeyed(re); err != nil {
+		return errors.Wrap(err, "RemoveRange")
+	}
 
 	// now convert the client range columns to server side column conditions structure
 	columnConditions, err := ConvertConditions(r.conditions, re.table)
@@ -498,6 +1333,30 @@ func (c *client) RemoveRange(ctx context.Context, r *RemoveRangeOp) error {
 	return errors.Wrap(c.connector.RemoveRange(ctx, re.EntityInfo(), columnConditions), "RemoveRange")
 }
 
+// RemoveAll removes every row of the partition of the given entity.
+func (c *client) RemoveAll(ctx context.Context, partitionKey DomainObject) error {
+	if !c.initialized {
+		return &ErrNotInitialized{}
+	}
+
+	re, err := c.registrar.Find(partitionKey)
+	if err != nil {
+		return errors.Wrap(err, "RemoveAll")
+	}
+	if err := checkKeyed(re); err != nil {
+		return errors.Wrap(err, "RemoveAll")
+	}
+
+	// keep only the partition key of the primary key values
+	keys := re.KeyFieldValues(partitionKey)
+	pk := make(map[string]FieldValue, len(re.table.Key.PartitionKeys))
+	for _, name := range re.table.Key.PartitionKeys {
+		pk[name] = keys[name]
+	}
+
+	return errors.Wrap(c.connector.DeletePartition(ctx, re.EntityInfo(), pk), "RemoveAll")
+}
+
 // Range uses the connector to fetch DOSA entities for a given range.
 func (c *client) Range(ctx context.Context, r *RangeOp) ([]DomainObject, string, error) {
 	if !c.initialized {
@@ -508,6 +1367,9 @@ func (c *client) Range(ctx context.Context, r *RangeOp) ([]DomainObject, string,
 	if err != nil {
 		return nil, "", errors.Wrap(err, "Range")
 	}
+	if err := checkKeyed(re); err != nil {
+		return nil, "", errors.Wrap(err, "Range")
+	}
 
 	// now convert the client range columns to server side column conditions structure
 	columnConditions, err := ConvertConditions(r.conditions, re.table)
@@ -521,8 +1383,20 @@ func (c *client) Range(ctx context.Context, r *RangeOp) ([]DomainObject, string,
 		return nil, "", errors.Wrap(err, "Range")
 	}
 
+	if len(r.sortColumns) > 0 {
+		sortColumns := make([]ColumnOrder, len(r.sortColumns))
+		for i, col := range r.sortColumns {
+			name, ok := re.table.FieldToCol[col.Column]
+			if !ok {
+				return nil, "", errors.Errorf("Range: cannot find column %q in struct %q", col.Column, re.table.StructName)
+			}
+			sortColumns[i] = ColumnOrder{Column: name, Direction: col.Direction}
+		}
+		ctx = WithSortColumns(ctx, sortColumns)
+	}
+
 	// call the server side method
-	values, token, err := c.connector.Range(ctx, re.EntityInfo(), columnConditions, fieldsToRead, r.token, r.limit)
+	values, token, err := c.connector.Range(ctx, re.EntityInfo(), columnConditions, fieldsToRead, pageToken(ctx, r.token), r.limit)
 	if err != nil {
 		return nil, "", errors.Wrap(err, "Range")
 	}
@@ -531,6 +1405,60 @@ func (c *client) Range(ctx context.Context, r *RangeOp) ([]DomainObject, string,
 	return objectArray, token, nil
 }
 
+// GetFirst returns the first row of a partition with a Range of one row
+func (c *client) GetFirst(ctx context.Context, partitionKey DomainObject) (DomainObject, error) {
+	result, err := c.partitionEdge(ctx, partitionKey, false)
+	return result, errors.Wrap(err, "GetFirst")
+}
+
+// GetLast returns the last row of a partition with a Range of one row, sorted
+// in the reverse order of the clustering keys
+func (c *client) GetLast(ctx context.Context, partitionKey DomainObject) (DomainObject, error) {
+	result, err := c.partitionEdge(ctx, partitionKey, true)
+	return result, errors.Wrap(err, "GetLast")
+}
+
+// partitionEdge reads the first row of a partition, or with last, the first
+// row in the reverse order
+func (c *client) partitionEdge(ctx context.Context, partitionKey DomainObject, last bool) (DomainObject, error) {
+	if !c.initialized {
+		return nil, &ErrNotInitialized{}
+	}
+	re, err := c.registrar.Find(partitionKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyed(re); err != nil {
+		return nil, err
+	}
+
+	keys := re.KeyFieldValues(partitionKey)
+	r := NewRangeOp(partitionKey).Limit(1)
+	for _, name := range re.table.Key.PartitionKeys {
+		r = r.Eq(re.table.ColToField[name], keys[name])
+	}
+	if last {
+		sortColumns := make([]ColumnOrder, len(re.table.Key.ClusteringKeys))
+		for i, ck := range re.table.Key.ClusteringKeys {
+			direction := Descending
+			if ck.Descending {
+				direction = Ascending
+			}
+			sortColumns[i] = ColumnOrder{Column: re.table.ColToField[ck.Name], Direction: direction}
+		}
+		r = r.WithSortColumns(sortColumns)
+	}
+
+	results, _, err := c.Range(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, &ErrNotFound{}
+	}
+	return results[0], nil
+}
+
 func (c *client) WalkRange(ctx context.Context, r *RangeOp, onNext func(value DomainObject) error) error {
 	for {
 		results, nextToken, err := c.Range(ctx, r)
@@ -541,6 +1469,9 @@ func (c *client) WalkRange(ctx context.Context, r *RangeOp, onNext func(value Do
 
 		for _, result := range results {
 			if cerr := onNext(result); cerr != nil {
+				if ErrorIsStop(cerr) {
+					return nil
+				}
 				return cerr
 			}
 		}
@@ -552,6 +1483,103 @@ func (c *client) WalkRange(ctx context.Context, r *RangeOp, onNext func(value Do
 	}
 }
 
+// Aggregate uses the connector to aggregate a field over a range of entities
+func (c *client) Aggregate(ctx context.Context, aggFunc AggFunc, fieldName string, r *RangeOp) (FieldValue, error) {
+	if !c.initialized {
+		return nil, &ErrNotInitialized{}
+	}
+	// look up the entity in the registry
+	re, err := c.registrar.Find(r.object)
+	if err != nil {
+		return nil, errors.Wrap(err, "Aggregate")
+	}
+
+	columns, err := re.ColumnNames([]string{fieldName})
+	if err != nil {
+		return nil, errors.Wrap(err, "Aggregate")
+	}
+	if err := CheckAggregate(&re.table.EntityDefinition, aggFunc, columns[0]); err != nil {
+		return nil, errors.Wrap(err, "Aggregate")
+	}
+
+	columnConditions, err := ConvertConditions(r.conditions, re.table)
+	if err != nil {
+		return nil, errors.Wrap(err, "Aggregate")
+	}
+
+	value, err := c.connector.Aggregate(ctx, re.EntityInfo(), aggFunc, columns[0], columnConditions)
+	return value, errors.Wrap(err, "Aggregate")
+}
+
+// ExplainQuery asks the connector for its plan of a Range
+func (c *client) ExplainQuery(ctx context.Context, r *RangeOp) (*QueryPlan, error) {
+	if !c.initialized {
+		return nil, &ErrNotInitialized{}
+	}
+	// look up the entity in the registry
+	re, err := c.registrar.Find(r.object)
+	if err != nil {
+		return nil, errors.Wrap(err, "ExplainQuery")
+	}
+
+	columnConditions, err := ConvertConditions(r.conditions, re.table)
+	if err != nil {
+		return nil, errors.Wrap(err, "ExplainQuery")
+	}
+
+	plan, err := c.connector.ExplainQuery(ctx, re.EntityInfo(), columnConditions)
+	return plan, errors.Wrap(err, "ExplainQuery")
+}
+
+// CountRange asks the connector to count a range, or counts the rows of the
+// range itself when the connector can't
+func (c *client) CountRange(ctx context.Context, r *RangeOp) (int64, error) {
+	if !c.initialized {
+		return 0, &ErrNotInitialized{}
+	}
+	// look up the entity in the registry
+	re, err := c.registrar.Find(r.object)
+	if err != nil {
+		return 0, errors.Wrap(err, "CountRange")
+	}
+	if err := checkKeyed(re); err != nil {
+		return 0, err
+	}
+
+	columnConditions, err := ConvertConditions(r.conditions, re.table)
+	if err != nil {
+		return 0, errors.Wrap(err, "CountRange")
+	}
+
+	count, err := c.connector.CountRange(ctx, re.EntityInfo(), columnConditions)
+	if !ErrorIsNotSupported(err) {
+		return count, errors.Wrap(err, "CountRange")
+	}
+
+	// count the rows of each page; only the key columns are read
+	keys := re.table.Key.PrimaryKeySet()
+	fields := make([]string, 0, len(keys))
+	for column := range keys {
+		fields = append(fields, column)
+	}
+	count = 0
+	token := ""
+	for {
+		var rows []map[string]FieldValue
+		rows, token, err = c.connector.Range(ctx, re.EntityInfo(), columnConditions, fields, token, AdaptiveRangeLimit)
+		if err != nil {
+			if ErrorIsNotFound(err) {
+				return count, nil
+			}
+			return 0, errors.Wrap(err, "CountRange")
+		}
+		count += int64(len(rows))
+		if token == "" {
+			return count, nil
+		}
+	}
+}
+
 func objectsFromValueArray(object DomainObject, values []map[string]FieldValue, re *RegisteredEntity, columnsToRead []string) []DomainObject {
 	goType := reflect.TypeOf(object).Elem() // get the reflect.Type of the client entity
 	doType := reflect.TypeOf((*DomainObject)(nil)).Elem()
@@ -583,7 +1611,7 @@ func (c *client) ScanEverything(ctx context.Context, sop *ScanOp) ([]DomainObjec
 	}
 
 	// call the server side method
-	values, token, err := c.connector.Scan(ctx, re.EntityInfo(), fieldsToRead, sop.token, sop.limit)
+	values, token, err := c.connector.Scan(ctx, re.EntityInfo(), fieldsToRead, pageToken(ctx, sop.token), sop.limit)
 	if err != nil {
 		return nil, "", err
 	}
@@ -591,15 +1619,208 @@ func (c *client) ScanEverything(ctx context.Context, sop *ScanOp) ([]DomainObjec
 	return objectArray, token, nil
 }
 
+// ScanWithCallback uses the connector to scan all DOSA entities of the given type, one page at a time.
+func (c *client) ScanWithCallback(ctx context.Context, entity DomainObject, pageSize int, fn func(DomainObject) error) error {
+	if !c.initialized {
+		return &ErrNotInitialized{}
+	}
+	if pageSize <= 0 {
+		return errors.Errorf("failed to ScanWithCallback: invalid page size %d", pageSize)
+	}
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return errors.Wrap(err, "failed to ScanWithCallback")
+	}
+
+	ei := re.EntityInfo()
+	token := ""
+	for {
+		values, next, err := c.connector.Scan(ctx, ei, nil, token, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, object := range objectsFromValueArray(entity, values, re, nil) {
+			if err := fn(object); err != nil {
+				if ErrorIsStop(err) {
+					return nil
+				}
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
+
+// ScanBySecondaryIndex checks the conditions against the key of the index, then
+// uses the connector's Range with the index named on the context
+func (c *client) ScanBySecondaryIndex(ctx context.Context, entity DomainObject, indexName string, conditions []*ColumnCondition, pageSize int, token string) ([]DomainObject, string, error) {
+	if !c.initialized {
+		return nil, "", &ErrNotInitialized{}
+	}
+	if pageSize <= 0 {
+		return nil, "", errors.Errorf("ScanBySecondaryIndex: invalid page size %d", pageSize)
+	}
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "ScanBySecondaryIndex")
+	}
+	if err := checkKeyed(re); err != nil {
+		return nil, "", errors.Wrap(err, "ScanBySecondaryIndex")
+	}
+	index, ok := re.table.Indexes[indexName]
+	if !ok {
+		return nil, "", errors.Errorf("ScanBySecondaryIndex: %q is not an index of %q", indexName, re.table.StructName)
+	}
+	keyColumns := index.Key.PrimaryKeySet()
+
+	fieldConditions := make(map[string][]*Condition, len(conditions))
+	for _, cond := range conditions {
+		if cond == nil || cond.Condition == nil {
+			return nil, "", errors.New("ScanBySecondaryIndex: nil condition")
+		}
+		fieldConditions[cond.Name] = append(fieldConditions[cond.Name], cond.Condition)
+	}
+	columnConditions, err := ConvertConditions(fieldConditions, re.table)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "ScanBySecondaryIndex")
+	}
+	for column := range columnConditions {
+		if _, ok := keyColumns[column]; !ok {
+			return nil, "", errors.Errorf("ScanBySecondaryIndex: column %q is not a key of index %q", column, indexName)
+		}
+	}
+
+	values, next, err := c.connector.Range(WithIndexName(ctx, indexName), re.EntityInfo(), columnConditions, All(), pageToken(ctx, token), pageSize)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "ScanBySecondaryIndex")
+	}
+	return objectsFromValueArray(entity, values, re, nil), next, nil
+}
+
+// CrossPartitionScan fans out a Range over each partition, up to maxParallelism at a time
+func (c *client) CrossPartitionScan(ctx context.Context, entity DomainObject, partitionKeys []map[string]FieldValue, pageSize int) ([]DomainObject, error) {
+	if !c.initialized {
+		return nil, &ErrNotInitialized{}
+	}
+	if pageSize <= 0 {
+		return nil, errors.Errorf("failed to CrossPartitionScan: invalid page size %d", pageSize)
+	}
+	re, err := c.registrar.Find(entity)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to CrossPartitionScan")
+	}
+	if err := checkKeyed(re); err != nil {
+		return nil, errors.Wrap(err, "failed to CrossPartitionScan")
+	}
+
+	// check all the partition keys before ranging over any of them
+	conditions := make([]map[string][]*Condition, len(partitionKeys))
+	for i, partitionKey := range partitionKeys {
+		if conditions[i], err = partitionConditions(re, partitionKey); err != nil {
+			return nil, errors.Wrapf(err, "failed to CrossPartitionScan: partition key %d", i)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	partitions := make([][]map[string]FieldValue, len(partitionKeys))
+	errs := make([]error, len(partitionKeys))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.maxParallelism)
+	for i := range conditions {
+		if err := acquire(ctx, sem); err != nil {
+			errs[i] = err
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			partitions[i], errs[i] = c.rangePartition(ctx, re, conditions[i], pageSize)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil && errors.Cause(err) != context.Canceled {
+			return nil, errors.Wrapf(err, "failed to CrossPartitionScan: partition key %d", i)
+		}
+	}
+	// only canceled partitions are left, so the caller's context was canceled
+	for _, err := range errs {
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to CrossPartitionScan")
+		}
+	}
+
+	var rows []map[string]FieldValue
+	for _, partition := range partitions {
+		rows = append(rows, partition...)
+	}
+	// each partition is in clustering key order already, so the stable sort merges them
+	SortRows(re.table.Key, rows)
+	return objectsFromValueArray(entity, rows, re, nil), nil
+}
+
+// partitionConditions converts a partition key, from field names to values,
+// to the column conditions of a Range over that partition
+func partitionConditions(re *RegisteredEntity, partitionKey map[string]FieldValue) (map[string][]*Condition, error) {
+	fieldConditions := make(map[string][]*Condition, len(partitionKey))
+	for field, value := range partitionKey {
+		fieldConditions[field] = []*Condition{{Op: Eq, Value: value}}
+	}
+	columnConditions, err := ConvertConditions(fieldConditions, re.table)
+	if err != nil {
+		return nil, err
+	}
+	for _, pk := range re.table.Key.PartitionKeys {
+		if _, ok := columnConditions[pk]; !ok {
+			return nil, errors.Errorf("missing partition key field %q", re.table.ColToField[pk])
+		}
+	}
+	if len(columnConditions) != len(re.table.Key.PartitionKeys) {
+		return nil, errors.New("only partition key fields may be given")
+	}
+	return columnConditions, nil
+}
+
+// rangePartition returns all the rows of a partition, pageSize rows at a time
+func (c *client) rangePartition(ctx context.Context, re *RegisteredEntity, columnConditions map[string][]*Condition, pageSize int) ([]map[string]FieldValue, error) {
+	var rows []map[string]FieldValue
+	token := ""
+	for {
+		page, next, err := c.connector.Range(ctx, re.EntityInfo(), columnConditions, nil, token, pageSize)
+		if err != nil {
+			if ErrorIsNotFound(err) {
+				return rows, nil
+			}
+			return nil, err
+		}
+		rows = append(rows, page...)
+		if next == "" {
+			return rows, nil
+		}
+		token = next
+	}
+}
+
 func (c *client) Shutdown() error {
 	return c.connector.Shutdown()
 }
 
 type adminClient struct {
-	scope     string
-	dirs      []string
-	excludes  []string
-	connector Connector
+	scope       string
+	dirs        []string
+	excludes    []string
+	entity      string
+	schemaFiles []string
+	definitions []*EntityDefinition
+	connector   Connector
 }
 
 // NewAdminClient returns a new DOSA admin client for the connector provided.
@@ -632,6 +1853,30 @@ func (c *adminClient) Scope(scope string) AdminClient {
 	return c
 }
 
+// Entity limits schema operations to the entities with the given struct or
+// entity name, compared case-insensitively; usually there is just one.
+// Defaults to "", meaning all the entities that are found.
+func (c *adminClient) Entity(name string) AdminClient {
+	c.entity = name
+	return c
+}
+
+// SchemaFiles makes schema operations read the entities from the given YAML
+// files (see LoadEntitiesFromYAML) instead of the Go sources in the
+// directories, for services that are not written in Go
+func (c *adminClient) SchemaFiles(files []string) AdminClient {
+	c.schemaFiles = files
+	return c
+}
+
+// Definitions makes schema operations use the given entity definitions, e.g.
+// ones read back from the YAML of dosa schema export, instead of searching the
+// directories or reading the schema files
+func (c *adminClient) Definitions(defs []*EntityDefinition) AdminClient {
+	c.definitions = defs
+	return c
+}
+
 // CanUpsertSchema first searches for entity definitions within configured
 // directories before checking the compatibility of each entity for the givena
 // the namePrefix. The client's scope and search directories should be
@@ -677,6 +1922,26 @@ func (c *adminClient) UpsertSchema(ctx context.Context, namePrefix string) (*Sch
 	return status, nil
 }
 
+// DropTables drops the table of every entity found, see GetSchema, so that
+// their data is gone. Entities are dropped one at a time and the first error
+// stops the operation.
+func (c *adminClient) DropTables(ctx context.Context, namePrefix string) error {
+	defs, err := c.GetSchema()
+	if err != nil {
+		return errors.Wrapf(err, "GetSchema failed")
+	}
+	for _, def := range defs {
+		ei := &EntityInfo{
+			Ref: &SchemaRef{Scope: c.scope, NamePrefix: namePrefix, EntityName: def.Name},
+			Def: def,
+		}
+		if err := c.connector.DropTable(ctx, ei); err != nil {
+			return errors.Wrapf(err, "DropTable failed for entity %q, scope: %s", def.Name, c.scope)
+		}
+	}
+	return nil
+}
+
 // GetSchema returns the derived entity definitions that are found within the
 // current search path of the client. GetSchema can be used to introspect the
 // state of schema before further operations are performed. For example,
@@ -686,17 +1951,13 @@ func (c *adminClient) UpsertSchema(ctx context.Context, namePrefix string) (*Sch
 //   - invalid directory (eg. path does not exist, is not a directory)
 //   - unparseable entity (eg. invalid primary key)
 //   - no entities were found
+//   - the entity set with Entity was not found
 func (c *adminClient) GetSchema() ([]*EntityDefinition, error) {
 	// prevent bogus scope names from reaching connectors
 	if err := IsValidName(c.scope); err != nil {
 		return nil, errors.Wrapf(err, "invalid scope name %q", c.scope)
 	}
-	// "warnings" mean entity was found but contained invalid annotations
-	entities, warns, err := findEntities(c.dirs, c.excludes)
-	if len(warns) > 0 {
-		return nil, NewEntityErrors(warns)
-	}
-	// I/O and AST parsing errors
+	entities, err := c.findEntities()
 	if err != nil {
 		return nil, err
 	}
@@ -705,6 +1966,19 @@ func (c *adminClient) GetSchema() ([]*EntityDefinition, error) {
 		return nil, fmt.Errorf("no entities found; did you specify the right directories for your source?")
 	}
 
+	if c.entity != "" {
+		var defs []*EntityDefinition
+		for _, e := range entities {
+			if strings.EqualFold(e.StructName, c.entity) || strings.EqualFold(e.Name, c.entity) {
+				defs = append(defs, &e.EntityDefinition)
+			}
+		}
+		if len(defs) == 0 {
+			return nil, fmt.Errorf("entity %q not found", c.entity)
+		}
+		return defs, nil
+	}
+
 	defs := make([]*EntityDefinition, len(entities))
 	for idx, e := range entities {
 		defs[idx] = &e.EntityDefinition
@@ -712,6 +1986,38 @@ func (c *adminClient) GetSchema() ([]*EntityDefinition, error) {
 	return defs, nil
 }
 
+// findEntities returns the entity definitions set with Definitions, or reads
+// the entities from the schema files if there are any, or else from the Go
+// sources in the directories
+func (c *adminClient) findEntities() ([]*Table, error) {
+	if len(c.definitions) > 0 {
+		entities := make([]*Table, len(c.definitions))
+		for i, def := range c.definitions {
+			entities[i] = &Table{EntityDefinition: *def.Clone()}
+		}
+		return entities, nil
+	}
+	if len(c.schemaFiles) > 0 {
+		var entities []*Table
+		for _, file := range c.schemaFiles {
+			tables, err := loadEntitiesFromYAMLFile(file)
+			if err != nil {
+				return nil, err
+			}
+			entities = append(entities, tables...)
+		}
+		return entities, nil
+	}
+
+	// "warnings" mean entity was found but contained invalid annotations
+	entities, warns, err := FindEntities(c.dirs, c.excludes)
+	if len(warns) > 0 {
+		return nil, NewEntityErrors(warns)
+	}
+	// I/O and AST parsing errors
+	return entities, err
+}
+
 // EntityErrors is a container for parse errors/warning.
 type EntityErrors struct {
 	warns []error
@@ -0,0 +1,362 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"sync"
+)
+
+// ClientInterceptor is middleware for a Client, like a grpc.UnaryServerInterceptor
+// is for a gRPC server. It's called with the name of the Client method, such as
+// "Upsert", and the entity the call is about, and calls next to run the rest of
+// the chain and then the method itself. An interceptor can change the entity
+// before calling next, look at the error next returns, or return an error without
+// calling next to stop the call.
+//
+// The entity is the partition key object for the methods that take one and the
+// object of the RangeOp, RemoveRangeOp or ScanOp for the methods that take those;
+// it's nil for MultiRead, GetMany, MultiUpsert and BatchRemove, which work on
+// several entities.
+type ClientInterceptor func(ctx context.Context, method string, entity DomainObject, next func() error) error
+
+// InterceptedClient is a Client that calls its interceptors around the data
+// methods of the Client it wraps. Interceptors are called in the order they
+// were added: the first one added is the outermost. GetRegistrar, Initialize,
+// WarmUp, WarmConnections and Shutdown are passed through without interceptors.
+type InterceptedClient struct {
+	Client
+
+	lock         sync.RWMutex
+	interceptors []ClientInterceptor
+}
+
+// NewInterceptedClient returns an InterceptedClient around c with the given
+// interceptors
+func NewInterceptedClient(c Client, interceptors ...ClientInterceptor) *InterceptedClient {
+	ic := &InterceptedClient{Client: c}
+	ic.Use(interceptors...)
+	return ic
+}
+
+// Use adds interceptors after the ones already added, so that they run closer
+// to the wrapped Client
+func (c *InterceptedClient) Use(interceptors ...ClientInterceptor) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+// intercept runs call through the interceptors
+func (c *InterceptedClient) intercept(ctx context.Context, method string, entity DomainObject, call func() error) error {
+	c.lock.RLock()
+	interceptors := c.interceptors
+	c.lock.RUnlock()
+	return runInterceptors(ctx, interceptors, method, entity, call)
+}
+
+func runInterceptors(ctx context.Context, interceptors []ClientInterceptor, method string, entity DomainObject, call func() error) error {
+	if len(interceptors) == 0 {
+		return call()
+	}
+	return interceptors[0](ctx, method, entity, func() error {
+		return runInterceptors(ctx, interceptors[1:], method, entity, call)
+	})
+}
+
+// CreateIfNotExists calls the interceptors around CreateIfNotExists
+func (c *InterceptedClient) CreateIfNotExists(ctx context.Context, objectToCreate DomainObject) error {
+	return c.intercept(ctx, "CreateIfNotExists", objectToCreate, func() error {
+		return c.Client.CreateIfNotExists(ctx, objectToCreate)
+	})
+}
+
+// ReadOrCreate calls the interceptors around ReadOrCreate
+func (c *InterceptedClient) ReadOrCreate(ctx context.Context, objectToReadOrCreate DomainObject) (existed bool, err error) {
+	err = c.intercept(ctx, "ReadOrCreate", objectToReadOrCreate, func() error {
+		var err error
+		existed, err = c.Client.ReadOrCreate(ctx, objectToReadOrCreate)
+		return err
+	})
+	return existed, err
+}
+
+// Read calls the interceptors around Read
+func (c *InterceptedClient) Read(ctx context.Context, fieldsToRead []string, objectToRead DomainObject) error {
+	return c.intercept(ctx, "Read", objectToRead, func() error {
+		return c.Client.Read(ctx, fieldsToRead, objectToRead)
+	})
+}
+
+// GetWithFieldMask calls the interceptors around GetWithFieldMask
+func (c *InterceptedClient) GetWithFieldMask(ctx context.Context, entity DomainObject, fields []string) error {
+	return c.intercept(ctx, "GetWithFieldMask", entity, func() error {
+		return c.Client.GetWithFieldMask(ctx, entity, fields)
+	})
+}
+
+// GetOrSet calls the interceptors around GetOrSet
+func (c *InterceptedClient) GetOrSet(ctx context.Context, entity DomainObject, setter func(DomainObject) error) (created bool, err error) {
+	err = c.intercept(ctx, "GetOrSet", entity, func() error {
+		var err error
+		created, err = c.Client.GetOrSet(ctx, entity, setter)
+		return err
+	})
+	return created, err
+}
+
+// MultiRead calls the interceptors around MultiRead
+func (c *InterceptedClient) MultiRead(ctx context.Context, fieldsToRead []string, objectsToRead ...DomainObject) (MultiResult, error) {
+	var result MultiResult
+	err := c.intercept(ctx, "MultiRead", nil, func() error {
+		var err error
+		result, err = c.Client.MultiRead(ctx, fieldsToRead, objectsToRead...)
+		return err
+	})
+	return result, err
+}
+
+// GetMany calls the interceptors around GetMany
+func (c *InterceptedClient) GetMany(ctx context.Context, pks []DomainObject) ([]DomainObject, []error, error) {
+	var results []DomainObject
+	var errs []error
+	err := c.intercept(ctx, "GetMany", nil, func() error {
+		var err error
+		results, errs, err = c.Client.GetMany(ctx, pks)
+		return err
+	})
+	return results, errs, err
+}
+
+// GetFirst calls the interceptors around GetFirst
+func (c *InterceptedClient) GetFirst(ctx context.Context, partitionKey DomainObject) (DomainObject, error) {
+	var result DomainObject
+	err := c.intercept(ctx, "GetFirst", partitionKey, func() error {
+		var err error
+		result, err = c.Client.GetFirst(ctx, partitionKey)
+		return err
+	})
+	return result, err
+}
+
+// GetLast calls the interceptors around GetLast
+func (c *InterceptedClient) GetLast(ctx context.Context, partitionKey DomainObject) (DomainObject, error) {
+	var result DomainObject
+	err := c.intercept(ctx, "GetLast", partitionKey, func() error {
+		var err error
+		result, err = c.Client.GetLast(ctx, partitionKey)
+		return err
+	})
+	return result, err
+}
+
+// Upsert calls the interceptors around Upsert
+func (c *InterceptedClient) Upsert(ctx context.Context, fieldsToUpdate []string, objectToUpdate DomainObject) error {
+	return c.intercept(ctx, "Upsert", objectToUpdate, func() error {
+		return c.Client.Upsert(ctx, fieldsToUpdate, objectToUpdate)
+	})
+}
+
+// MultiUpsert calls the interceptors around MultiUpsert. When an interceptor
+// stops the call, every entity gets its error.
+func (c *InterceptedClient) MultiUpsert(ctx context.Context, entities []DomainObject) []error {
+	var errs []error
+	err := c.intercept(ctx, "MultiUpsert", nil, func() error {
+		errs = c.Client.MultiUpsert(ctx, entities)
+		return firstError(errs)
+	})
+	return errorsFor(entities, errs, err)
+}
+
+// UpsertWithConditions calls the interceptors around UpsertWithConditions
+func (c *InterceptedClient) UpsertWithConditions(ctx context.Context, objectToUpdate DomainObject, conditions []*ColumnCondition) error {
+	return c.intercept(ctx, "UpsertWithConditions", objectToUpdate, func() error {
+		return c.Client.UpsertWithConditions(ctx, objectToUpdate, conditions)
+	})
+}
+
+// IncrementCounter calls the interceptors around IncrementCounter
+func (c *InterceptedClient) IncrementCounter(ctx context.Context, entity DomainObject, fieldName string, delta int64) (int64, error) {
+	var value int64
+	err := c.intercept(ctx, "IncrementCounter", entity, func() error {
+		var err error
+		value, err = c.Client.IncrementCounter(ctx, entity, fieldName, delta)
+		return err
+	})
+	return value, err
+}
+
+// Replace calls the interceptors around Replace
+func (c *InterceptedClient) Replace(ctx context.Context, objectToReplace DomainObject) error {
+	return c.intercept(ctx, "Replace", objectToReplace, func() error {
+		return c.Client.Replace(ctx, objectToReplace)
+	})
+}
+
+// Remove calls the interceptors around Remove
+func (c *InterceptedClient) Remove(ctx context.Context, objectToRemove DomainObject) error {
+	return c.intercept(ctx, "Remove", objectToRemove, func() error {
+		return c.Client.Remove(ctx, objectToRemove)
+	})
+}
+
+// BatchRemove calls the interceptors around BatchRemove. When an interceptor
+// stops the call, every entity gets its error.
+func (c *InterceptedClient) BatchRemove(ctx context.Context, entities []DomainObject) []error {
+	var errs []error
+	err := c.intercept(ctx, "BatchRemove", nil, func() error {
+		errs = c.Client.BatchRemove(ctx, entities)
+		return firstError(errs)
+	})
+	return errorsFor(entities, errs, err)
+}
+
+// RemoveRange calls the interceptors around RemoveRange
+func (c *InterceptedClient) RemoveRange(ctx context.Context, removeRangeOp *RemoveRangeOp) error {
+	return c.intercept(ctx, "RemoveRange", removeRangeOp.object, func() error {
+		return c.Client.RemoveRange(ctx, removeRangeOp)
+	})
+}
+
+// RemoveAll calls the interceptors around RemoveAll
+func (c *InterceptedClient) RemoveAll(ctx context.Context, partitionKey DomainObject) error {
+	return c.intercept(ctx, "RemoveAll", partitionKey, func() error {
+		return c.Client.RemoveAll(ctx, partitionKey)
+	})
+}
+
+// Range calls the interceptors around Range
+func (c *InterceptedClient) Range(ctx context.Context, rangeOp *RangeOp) ([]DomainObject, string, error) {
+	var results []DomainObject
+	var token string
+	err := c.intercept(ctx, "Range", rangeOp.object, func() error {
+		var err error
+		results, token, err = c.Client.Range(ctx, rangeOp)
+		return err
+	})
+	return results, token, err
+}
+
+// WalkRange calls the interceptors around WalkRange
+func (c *InterceptedClient) WalkRange(ctx context.Context, r *RangeOp, onNext func(value DomainObject) error) error {
+	return c.intercept(ctx, "WalkRange", r.object, func() error {
+		return c.Client.WalkRange(ctx, r, onNext)
+	})
+}
+
+// Aggregate calls the interceptors around Aggregate
+func (c *InterceptedClient) Aggregate(ctx context.Context, aggFunc AggFunc, fieldName string, rangeOp *RangeOp) (FieldValue, error) {
+	var value FieldValue
+	err := c.intercept(ctx, "Aggregate", rangeOp.object, func() error {
+		var err error
+		value, err = c.Client.Aggregate(ctx, aggFunc, fieldName, rangeOp)
+		return err
+	})
+	return value, err
+}
+
+// ExplainQuery calls the interceptors around ExplainQuery
+func (c *InterceptedClient) ExplainQuery(ctx context.Context, rangeOp *RangeOp) (*QueryPlan, error) {
+	var plan *QueryPlan
+	err := c.intercept(ctx, "ExplainQuery", rangeOp.object, func() error {
+		var err error
+		plan, err = c.Client.ExplainQuery(ctx, rangeOp)
+		return err
+	})
+	return plan, err
+}
+
+// CountRange calls the interceptors around CountRange
+func (c *InterceptedClient) CountRange(ctx context.Context, rangeOp *RangeOp) (int64, error) {
+	var count int64
+	err := c.intercept(ctx, "CountRange", rangeOp.object, func() error {
+		var err error
+		count, err = c.Client.CountRange(ctx, rangeOp)
+		return err
+	})
+	return count, err
+}
+
+// ScanEverything calls the interceptors around ScanEverything
+func (c *InterceptedClient) ScanEverything(ctx context.Context, scanOp *ScanOp) ([]DomainObject, string, error) {
+	var results []DomainObject
+	var token string
+	err := c.intercept(ctx, "ScanEverything", scanOp.object, func() error {
+		var err error
+		results, token, err = c.Client.ScanEverything(ctx, scanOp)
+		return err
+	})
+	return results, token, err
+}
+
+// ScanWithCallback calls the interceptors around ScanWithCallback
+func (c *InterceptedClient) ScanWithCallback(ctx context.Context, entity DomainObject, pageSize int, fn func(DomainObject) error) error {
+	return c.intercept(ctx, "ScanWithCallback", entity, func() error {
+		return c.Client.ScanWithCallback(ctx, entity, pageSize, fn)
+	})
+}
+
+// CrossPartitionScan calls the interceptors around CrossPartitionScan
+func (c *InterceptedClient) CrossPartitionScan(ctx context.Context, entity DomainObject, partitionKeys []map[string]FieldValue, pageSize int) ([]DomainObject, error) {
+	var results []DomainObject
+	err := c.intercept(ctx, "CrossPartitionScan", entity, func() error {
+		var err error
+		results, err = c.Client.CrossPartitionScan(ctx, entity, partitionKeys, pageSize)
+		return err
+	})
+	return results, err
+}
+
+// ScanBySecondaryIndex calls the interceptors around ScanBySecondaryIndex
+func (c *InterceptedClient) ScanBySecondaryIndex(ctx context.Context, entity DomainObject, indexName string, conditions []*ColumnCondition, pageSize int, token string) ([]DomainObject, string, error) {
+	var results []DomainObject
+	var next string
+	err := c.intercept(ctx, "ScanBySecondaryIndex", entity, func() error {
+		var err error
+		results, next, err = c.Client.ScanBySecondaryIndex(ctx, entity, indexName, conditions, pageSize, token)
+		return err
+	})
+	return results, next, err
+}
+
+// firstError returns the first non-nil error of errs, so that interceptors
+// see whether a call on several entities failed
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorsFor returns errs, the errors of the call, unless the interceptors
+// stopped it before, in which case every entity gets the error they returned
+func errorsFor(entities []DomainObject, errs []error, err error) []error {
+	if errs != nil || err == nil {
+		return errs
+	}
+	errs = make([]error, len(entities))
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	dosaRenamed "github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+// recordingInterceptor appends "name method" to calls before calling next,
+// and "name done" after
+func recordingInterceptor(name string, calls *[]string) dosaRenamed.ClientInterceptor {
+	return func(ctx context.Context, method string, entity dosaRenamed.DomainObject, next func() error) error {
+		*calls = append(*calls, name+" "+method)
+		err := next()
+		*calls = append(*calls, name+" done")
+		return err
+	}
+}
+
+func TestInterceptedClient_Order(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	var calls []string
+	c := dosaRenamed.NewInterceptedClient(dosaRenamed.NewClient(reg, memory.NewConnector()), recordingInterceptor("first", &calls))
+	c.Use(recordingInterceptor("second", &calls), recordingInterceptor("third", &calls))
+
+	// Initialize isn't intercepted
+	assert.NoError(t, c.Initialize(ctx))
+	assert.Empty(t, calls)
+
+	assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo"}))
+	assert.Equal(t, []string{"first Upsert", "second Upsert", "third Upsert", "third done", "second done", "first done"}, calls)
+
+	// the entity of a range is the object of the RangeOp
+	var entities []dosaRenamed.DomainObject
+	c.Use(func(ctx context.Context, method string, entity dosaRenamed.DomainObject, next func() error) error {
+		entities = append(entities, entity)
+		return next()
+	})
+	calls = nil
+	rop := dosaRenamed.NewRangeOp(&ClientTestEntity1{}).Eq("ID", int64(1))
+	count, err := c.CountRange(ctx, rop)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, []string{"first CountRange", "second CountRange", "third CountRange", "third done", "second done", "first done"}, calls)
+	assert.Equal(t, []dosaRenamed.DomainObject{&ClientTestEntity1{}}, entities)
+}
+
+func TestInterceptedClient_ShortCircuit(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	var calls []string
+	readOnly := func(ctx context.Context, method string, entity dosaRenamed.DomainObject, next func() error) error {
+		switch method {
+		case "Upsert", "MultiUpsert":
+			return errors.Errorf("%s is not allowed", method)
+		}
+		return next()
+	}
+	c := dosaRenamed.NewInterceptedClient(dosaRenamed.NewClient(reg, memory.NewConnector()), recordingInterceptor("outer", &calls), readOnly, recordingInterceptor("inner", &calls))
+	assert.NoError(t, c.Initialize(ctx))
+
+	// the outer interceptor sees the error, the inner one and the client are never called
+	err := c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo"})
+	assert.EqualError(t, err, "Upsert is not allowed")
+	assert.Equal(t, []string{"outer Upsert", "outer done"}, calls)
+	err = c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	// every entity of a stopped call on several gets the error
+	errs := c.MultiUpsert(ctx, []dosaRenamed.DomainObject{&ClientTestEntity1{ID: 1}, &ClientTestEntity1{ID: 2}})
+	assert.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "MultiUpsert is not allowed")
+	assert.EqualError(t, errs[1], "MultiUpsert is not allowed")
+}
+
+func TestHookedClient_Use(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewHookedClient(dosaRenamed.NewClient(reg, memory.NewConnector()))
+	assert.NoError(t, c.Initialize(ctx))
+
+	// interceptors run inside the hooks
+	var calls []string
+	c.OnBeforeUpsert(func(entity dosaRenamed.DomainObject) error {
+		calls = append(calls, "before")
+		return nil
+	})
+	c.OnAfterUpsert(func(entity dosaRenamed.DomainObject, err error) {
+		calls = append(calls, "after")
+	})
+	c.Use(recordingInterceptor("interceptor", &calls))
+	assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo"}))
+	assert.Equal(t, []string{"before", "interceptor Upsert", "interceptor done", "after"}, calls)
+
+	// methods without hooks still go through the interceptors
+	calls = nil
+	assert.NoError(t, c.Remove(ctx, &ClientTestEntity1{ID: 1}))
+	assert.Equal(t, []string{"interceptor Remove", "interceptor done"}, calls)
+}
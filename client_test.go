@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -34,7 +35,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	dosaRenamed "github.com/uber-go/dosa"
 	"github.com/uber-go/dosa/connectors/devnull"
+	"github.com/uber-go/dosa/connectors/memory"
 	"github.com/uber-go/dosa/mocks"
+	"github.com/uber-go/dosa/testentity"
 	"github.com/uber-go/dosa/testutil"
 )
 
@@ -130,6 +133,48 @@ func TestClient_Initialize(t *testing.T) {
 	assert.NoError(t, c3.Initialize(ctx))
 }
 
+type infoLogger struct {
+	dosaRenamed.Logger
+	info []string
+}
+
+func (l *infoLogger) Infof(format string, args ...interface{}) {
+	l.info = append(l.info, fmt.Sprintf(format, args...))
+}
+
+func TestClient_WarmUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	emptyReg, _ := dosaRenamed.NewRegistrar("test", "team.service")
+	reg, _ := dosaRenamed.NewRegistrar("test", "team.service", cte1)
+	assert.Error(t, dosaRenamed.NewClient(emptyReg, nullConnector).WarmUp(ctx))
+
+	// the memory connector's schema always matches
+	logger := &infoLogger{}
+	c := dosaRenamed.NewClient(reg, memory.NewConnector(), dosaRenamed.WithLogger(logger))
+	assert.NoError(t, c.WarmUp(ctx))
+	assert.Equal(t, []string{"dosa: validated the live schemas of clienttestentity1"}, logger.info)
+
+	// all the differences are reported
+	mockConn := mocks.NewMockConnector(ctrl)
+	c = dosaRenamed.NewClient(reg, mockConn)
+	live := &dosaRenamed.EntityDefinition{
+		Name:    "clienttestentity1",
+		Key:     &dosaRenamed.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosaRenamed.ColumnDefinition{{Name: "id", Type: dosaRenamed.Int64}, {Name: "name", Type: dosaRenamed.Blob}},
+	}
+	mockConn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(live, nil)
+	err := c.WarmUp(ctx)
+	assert.True(t, dosaRenamed.ErrorIsSchemaMismatch(err))
+	assert.Equal(t, "schema mismatch: clienttestentity1: column \"name\" is String, live column is Blob, "+
+		"column \"email\" is missing from the live schema, index \"username\" is missing from the live schema", err.Error())
+
+	mockConn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(nil, errors.New("no such table"))
+	err = c.WarmUp(ctx)
+	assert.False(t, dosaRenamed.ErrorIsSchemaMismatch(err))
+	assert.Contains(t, err.Error(), "no such table")
+}
+
 func TestClient_Read(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 	reg2, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1, cte2)
@@ -169,6 +214,152 @@ func TestClient_Read(t *testing.T) {
 	assert.Equal(t, cte1.Email, results["email"])
 }
 
+func TestClient_GetWithFieldMask(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+
+	// uninitialized
+	c := dosaRenamed.NewClient(reg, nullConnector)
+	assert.Error(t, c.GetWithFieldMask(ctx, &ClientTestEntity1{ID: 1}, []string{"Email"}))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	c = dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	// the primary key is always read, the fields outside the mask are zeroed
+	mockConn.EXPECT().Read(ctx, gomock.Any(), map[string]dosaRenamed.FieldValue{"id": int64(1)}, []string{"email", "id"}).
+		Return(map[string]dosaRenamed.FieldValue{"id": int64(1), "email": "foo@email.com"}, nil)
+	e := &ClientTestEntity1{ID: 1, Name: "stale", Email: "stale"}
+	assert.NoError(t, c.GetWithFieldMask(ctx, e, []string{"Email"}))
+	assert.Equal(t, &ClientTestEntity1{ID: 1, Email: "foo@email.com"}, e)
+
+	// key fields in the mask are not read twice
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), []string{"id", "name"}).
+		Return(map[string]dosaRenamed.FieldValue{"name": "foo"}, nil)
+	assert.NoError(t, c.GetWithFieldMask(ctx, e, []string{"ID", "Name"}))
+	assert.Equal(t, &ClientTestEntity1{ID: 1, Name: "foo"}, e)
+
+	// unknown fields and empty masks are rejected before reading
+	err := c.GetWithFieldMask(ctx, e, []string{"Email", "Phone"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Phone")
+	assert.Error(t, c.GetWithFieldMask(ctx, e, nil))
+
+	// read errors are returned as is
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(c.GetWithFieldMask(ctx, e, []string{"Email"})))
+}
+
+func TestClient_ReadRepairRetries(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	c := dosaRenamed.NewClient(reg, mockConn, dosaRenamed.WithReadRepairRetries(2, time.Millisecond))
+	assert.NoError(t, c.Initialize(ctx))
+
+	// found on the last retry
+	gomock.InOrder(
+		mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{}).Times(2),
+		mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(map[string]dosaRenamed.FieldValue{"name": "lagged"}, nil),
+	)
+	e := &ClientTestEntity1{ID: 7}
+	assert.NoError(t, c.Read(ctx, dosaRenamed.All(), e))
+	assert.Equal(t, "lagged", e.Name)
+
+	// still not found after the retries
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{}).Times(3)
+	assert.True(t, dosaRenamed.ErrorIsNotFound(c.Read(ctx, dosaRenamed.All(), e)))
+
+	// other errors aren't retried
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+	assert.EqualError(t, c.Read(ctx, dosaRenamed.All(), e), "unavailable")
+
+	// nor is anything without the option
+	c = dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(c.Read(ctx, dosaRenamed.All(), e)))
+}
+
+func TestClient_WithTimeout(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	c := dosaRenamed.NewClient(reg, mockConn, dosaRenamed.WithTimeout(time.Minute))
+	assert.NoError(t, c.Initialize(ctx))
+
+	var deadline time.Time
+	var hasDeadline bool
+	captureDeadline := func(ctx context.Context, _ *dosaRenamed.EntityInfo, _ map[string]dosaRenamed.FieldValue, _ []string) {
+		deadline, hasDeadline = ctx.Deadline()
+	}
+	mockConn.EXPECT().Read(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(captureDeadline).
+		Return(map[string]dosaRenamed.FieldValue{"name": "foo"}, nil).Times(2)
+
+	// calls without a deadline get the client's timeout
+	start := time.Now()
+	assert.NoError(t, c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 7}))
+	assert.True(t, hasDeadline)
+	assert.False(t, deadline.Before(start.Add(time.Minute)))
+	assert.True(t, deadline.Before(time.Now().Add(time.Minute+time.Second)))
+
+	// and calls with one keep it
+	short, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	want, _ := short.Deadline()
+	assert.NoError(t, c.Read(short, dosaRenamed.All(), &ClientTestEntity1{ID: 7}))
+	assert.Equal(t, want, deadline)
+
+	// there is no timeout without the option
+	c = dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+	mockConn.EXPECT().Read(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(captureDeadline).
+		Return(map[string]dosaRenamed.FieldValue{"name": "foo"}, nil)
+	assert.NoError(t, c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 7}))
+	assert.False(t, hasDeadline)
+}
+
+func TestClient_WarmConnections(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	c := dosaRenamed.NewClient(reg, mockConn)
+
+	mockConn.EXPECT().ScopeExists(gomock.Any(), scope).Return(true, nil).Times(3)
+	assert.NoError(t, c.WarmConnections(ctx, 3))
+	assert.EqualError(t, c.WarmConnections(ctx, 0), "WarmConnections: invalid count 0")
+
+	mockConn.EXPECT().ScopeExists(gomock.Any(), scope).Return(true, nil).Times(2)
+	mockConn.EXPECT().ScopeExists(gomock.Any(), scope).Return(false, errors.New("connection refused"))
+	assert.EqualError(t, c.WarmConnections(ctx, 3), "WarmConnections: connection refused")
+}
+
+func TestClient_WithWarmConnectionsOnStart(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+
+	// a failed ping leaves the client uninitialized
+	c := dosaRenamed.NewClient(reg, mockConn, dosaRenamed.WithWarmConnectionsOnStart(2))
+	mockConn.EXPECT().ScopeExists(gomock.Any(), scope).Return(false, errors.New("connection refused")).Times(2)
+	assert.Error(t, c.Initialize(ctx))
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1})))
+
+	gomock.InOrder(
+		mockConn.EXPECT().ScopeExists(gomock.Any(), scope).Return(true, nil).Times(2),
+		mockConn.EXPECT().CheckSchema(gomock.Any(), scope, namePrefix, gomock.Any()).Return(int32(1), nil),
+	)
+	assert.NoError(t, c.Initialize(ctx))
+}
+
 func TestClient_Read_pointer_result(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 	reg2, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1, cte2)
@@ -341,6 +532,321 @@ func TestClient_Upsert(t *testing.T) {
 	assert.Equal(t, cte1.Email, updatedEmail)
 }
 
+func TestClient_GetOrSet(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	assert.NoError(t, c.Initialize(ctx))
+
+	calls := 0
+	setter := func(o dosaRenamed.DomainObject) error {
+		calls++
+		o.(*ClientTestEntity1).Name = "computed"
+		return nil
+	}
+
+	// the row is created from the setter's values
+	e := &ClientTestEntity1{ID: 1}
+	created, err := c.GetOrSet(ctx, e, setter)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "computed", e.Name)
+
+	// and then read back, without calling the setter
+	e = &ClientTestEntity1{ID: 1}
+	created, err = c.GetOrSet(ctx, e, setter)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "computed", e.Name)
+	assert.Equal(t, 1, calls)
+
+	_, err = c.GetOrSet(ctx, &ClientTestEntity1{ID: 2}, func(dosaRenamed.DomainObject) error {
+		return errors.New("cannot compute")
+	})
+	assert.EqualError(t, err, "cannot compute")
+	_, err = c.GetOrSet(ctx, cte2, setter)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity2")
+}
+
+func TestClient_GetOrSetRace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil)
+	c := dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	// another writer creates the row between the read and the create
+	gomock.InOrder(
+		mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{}),
+		mockConn.EXPECT().CreateIfNotExists(ctx, gomock.Any(), gomock.Any()).Return(&dosaRenamed.ErrAlreadyExists{}),
+		mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(map[string]dosaRenamed.FieldValue{"id": int64(1), "name": "theirs"}, nil),
+	)
+	e := &ClientTestEntity1{ID: 1}
+	created, err := c.GetOrSet(ctx, e, func(o dosaRenamed.DomainObject) error {
+		o.(*ClientTestEntity1).Name = "ours"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "theirs", e.Name)
+
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("read failed"))
+	_, err = c.GetOrSet(ctx, e, func(dosaRenamed.DomainObject) error { return nil })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "read failed")
+}
+
+// slowUpsertConnector tracks how many Upserts run at the same time
+type slowUpsertConnector struct {
+	dosaRenamed.Connector
+	sync.Mutex
+	running, maxRunning, calls int
+	release                    chan struct{}
+}
+
+func (c *slowUpsertConnector) Upsert(ctx context.Context, ei *dosaRenamed.EntityInfo, values map[string]dosaRenamed.FieldValue) error {
+	c.Lock()
+	c.calls++
+	c.running++
+	if c.running > c.maxRunning {
+		c.maxRunning = c.running
+	}
+	c.Unlock()
+	if c.release != nil {
+		<-c.release
+	} else {
+		time.Sleep(time.Millisecond)
+	}
+	c.Lock()
+	c.running--
+	c.Unlock()
+	return c.Connector.Upsert(ctx, ei, values)
+}
+
+func TestClient_MultiUpsert(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	conn := &slowUpsertConnector{Connector: memory.NewConnector()}
+	c := dosaRenamed.NewClient(reg, conn, dosaRenamed.WithMaxParallelism(3))
+
+	errs := c.MultiUpsert(ctx, []dosaRenamed.DomainObject{cte1})
+	assert.Len(t, errs, 1)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(errs[0]))
+	assert.NoError(t, c.Initialize(ctx))
+
+	var entities []dosaRenamed.DomainObject
+	for i := 0; i < 20; i++ {
+		entities = append(entities, &ClientTestEntity1{ID: int64(i), Name: fmt.Sprint("name", i)})
+	}
+	// errors are aligned with the entities
+	entities[5] = cte2
+	errs = c.MultiUpsert(ctx, entities)
+	assert.Len(t, errs, 20)
+	for i, err := range errs {
+		if i == 5 {
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "ClientTestEntity2")
+			continue
+		}
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 19, conn.calls)
+	assert.True(t, conn.maxRunning <= 3, "too many concurrent upserts")
+	assert.True(t, conn.maxRunning > 1, "upserts did not run concurrently")
+
+	read := &ClientTestEntity1{ID: 12}
+	assert.NoError(t, c.Read(ctx, nil, read))
+	assert.Equal(t, "name12", read.Name)
+
+	assert.Empty(t, c.MultiUpsert(ctx, nil))
+}
+
+func TestClient_MultiUpsertCanceled(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	conn := &slowUpsertConnector{Connector: memory.NewConnector(), release: make(chan struct{})}
+	c := dosaRenamed.NewClient(reg, conn, dosaRenamed.WithMaxParallelism(2))
+	assert.NoError(t, c.Initialize(ctx))
+
+	var entities []dosaRenamed.DomainObject
+	for i := 0; i < 5; i++ {
+		entities = append(entities, &ClientTestEntity1{ID: int64(i)})
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	done := make(chan []error)
+	go func() { done <- c.MultiUpsert(cctx, entities) }()
+
+	// cancel while two writes are in flight, then let them finish
+	for {
+		conn.Lock()
+		running := conn.running
+		conn.Unlock()
+		if running == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+		assert.Fail(t, "MultiUpsert returned before the writes in flight completed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(conn.release)
+
+	errs := <-done
+	assert.Equal(t, 2, conn.calls)
+	// the writes in flight complete, the others are not started
+	for i, err := range errs {
+		if i < 2 {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.Equal(t, context.Canceled, err)
+	}
+}
+
+func TestClient_UpsertWithConditions(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar("test", "team.service", &testentity.TestEntity{})
+	entity := &testentity.TestEntity{UUIDKey: dosaRenamed.NewUUID(), StrKey: "key", Int64Key: 1, StrV: "new", Int32V: 1}
+	pending := []*dosaRenamed.ColumnCondition{
+		{Name: "StrV", Condition: &dosaRenamed.Condition{Op: dosaRenamed.Eq, Value: "new"}},
+	}
+
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	err := c.UpsertWithConditions(ctx, entity, pending)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.NoError(t, c.Initialize(ctx))
+
+	err = c.UpsertWithConditions(ctx, entity, pending)
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+	assert.NoError(t, c.Upsert(ctx, nil, entity))
+
+	// new -> done, only while the row is still new
+	entity.StrV = "done"
+	entity.Int32V = 2
+	assert.NoError(t, c.UpsertWithConditions(ctx, entity, pending))
+	err = c.UpsertWithConditions(ctx, entity, pending)
+	assert.True(t, dosaRenamed.ErrorIsConflict(err))
+
+	read := &testentity.TestEntity{UUIDKey: entity.UUIDKey, StrKey: "key", Int64Key: 1}
+	assert.NoError(t, c.Read(ctx, nil, read))
+	assert.Equal(t, "done", read.StrV)
+	assert.Equal(t, int32(2), read.Int32V)
+
+	err = c.UpsertWithConditions(ctx, entity, []*dosaRenamed.ColumnCondition{
+		{Name: "Nope", Condition: &dosaRenamed.Condition{Op: dosaRenamed.Eq, Value: "x"}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Nope")
+	err = c.UpsertWithConditions(ctx, entity, []*dosaRenamed.ColumnCondition{{Name: "StrV"}})
+	assert.Error(t, err)
+}
+
+func TestClient_IncrementCounter(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar("test", "team.service", &testentity.TestEntity{})
+	entity := &testentity.TestEntity{UUIDKey: dosaRenamed.NewUUID(), StrKey: "key", Int64Key: 1}
+
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	_, err := c.IncrementCounter(ctx, entity, "Int64V", 1)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.NoError(t, c.Initialize(ctx))
+
+	// the row is created by the first increment
+	value, err := c.IncrementCounter(ctx, entity, "Int64V", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+	value, err = c.IncrementCounter(ctx, entity, "Int64V", -2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+	assert.Equal(t, int64(3), entity.Int64V)
+
+	// nullable counters start out null
+	value, err = c.IncrementCounter(ctx, entity, "Int64VP", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), value)
+	assert.Equal(t, int64(7), *entity.Int64VP)
+
+	read := &testentity.TestEntity{UUIDKey: entity.UUIDKey, StrKey: "key", Int64Key: 1}
+	assert.NoError(t, c.Read(ctx, nil, read))
+	assert.Equal(t, int64(3), read.Int64V)
+	assert.Equal(t, int64(7), *read.Int64VP)
+
+	// only non-key int64 fields are counters
+	_, err = c.IncrementCounter(ctx, entity, "Int32V", 1)
+	assert.True(t, dosaRenamed.ErrorIsInvalidOperation(err))
+	assert.Contains(t, err.Error(), "only Int64 columns are counters")
+	_, err = c.IncrementCounter(ctx, entity, "Int64Key", 1)
+	assert.True(t, dosaRenamed.ErrorIsInvalidOperation(err))
+	_, err = c.IncrementCounter(ctx, entity, "Nope", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Nope")
+	_, err = c.IncrementCounter(ctx, cte1, "ID", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity1")
+
+	// connector errors
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().AtomicAdd(ctx, gomock.Any(), gomock.Any(), "an_int64_value", int64(1)).Return(int64(0), errors.New("oops"))
+	c2 := dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c2.Initialize(ctx))
+	_, err = c2.IncrementCounter(ctx, entity, "Int64V", 1)
+	assert.EqualError(t, err, "IncrementCounter: oops")
+}
+
+func TestClient_Replace(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar("test", "team.service", cte1)
+	entity := &ClientTestEntity1{ID: int64(1), Name: "foo"}
+
+	// uninitialized
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(c1.Replace(ctx, entity)))
+
+	// unregistered object error
+	c1.Initialize(ctx)
+	assert.Error(t, c1.Replace(ctx, cte2))
+
+	// all of the fields are written, including the empty ones
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Replace(ctx, gomock.Any(), map[string]dosaRenamed.FieldValue{
+		"id":    int64(1),
+		"name":  "foo",
+		"email": "",
+	}).Return(nil)
+	c2 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c2.Initialize(ctx))
+	assert.NoError(t, c2.Replace(ctx, entity))
+}
+
+// TestClient_ReplaceClearsColumns checks the difference between Replace and Upsert
+// against the memory connector
+func TestClient_ReplaceClearsColumns(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar("test", "team.service", &ClientTestEntity1{})
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	assert.NoError(t, c.Initialize(ctx))
+
+	assert.NoError(t, c.Upsert(ctx, nil, &ClientTestEntity1{ID: 1, Name: "foo", Email: "foo@email.com"}))
+
+	// Upsert of only some fields keeps the others
+	assert.NoError(t, c.Upsert(ctx, []string{"Name"}, &ClientTestEntity1{ID: 1, Name: "bar"}))
+	read := &ClientTestEntity1{ID: 1}
+	assert.NoError(t, c.Read(ctx, nil, read))
+	assert.Equal(t, "foo@email.com", read.Email)
+
+	// Replace writes the whole entity
+	assert.NoError(t, c.Replace(ctx, &ClientTestEntity1{ID: 1, Name: "baz"}))
+	read = &ClientTestEntity1{ID: 1}
+	assert.NoError(t, c.Read(ctx, nil, read))
+	assert.Equal(t, &ClientTestEntity1{ID: 1, Name: "baz"}, read)
+}
+
 func TestClient_Upsert_DynTTL(t *testing.T) {
 	cte3 := &ClientTestEntity1{}
 	reg1, _ := dosaRenamed.NewRegistrar("test", "team.service", cte3)
@@ -411,6 +917,49 @@ func TestClient_CreateIfNotExists(t *testing.T) {
 	assert.Equal(t, cte1.Email, updatedEmail)
 }
 
+func TestClient_ReadOrCreate(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar("test", "team.service", cte1)
+
+	// uninitialized
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	_, err := c1.ReadOrCreate(ctx, cte1)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	c2 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c2.Initialize(ctx))
+
+	// the row didn't exist
+	mockConn.EXPECT().CreateIfNotExists(ctx, gomock.Any(), gomock.Any()).Return(nil)
+	existed, err := c2.ReadOrCreate(ctx, &ClientTestEntity1{ID: int64(1)})
+	assert.NoError(t, err)
+	assert.False(t, existed)
+
+	// the row existed, and is read
+	mockConn.EXPECT().CreateIfNotExists(ctx, gomock.Any(), gomock.Any()).Return(&dosaRenamed.ErrAlreadyExists{})
+	mockConn.EXPECT().Read(ctx, gomock.Any(), map[string]dosaRenamed.FieldValue{"id": dosaRenamed.FieldValue(int64(2))}, gomock.Any()).
+		Return(map[string]dosaRenamed.FieldValue{"id": int64(2), "name": "existing"}, nil)
+	e := &ClientTestEntity1{ID: int64(2), Name: "default"}
+	existed, err = c2.ReadOrCreate(ctx, e)
+	assert.NoError(t, err)
+	assert.True(t, existed)
+	assert.Equal(t, "existing", e.Name)
+
+	// the row was removed before it could be read
+	mockConn.EXPECT().CreateIfNotExists(ctx, gomock.Any(), gomock.Any()).Return(&dosaRenamed.ErrAlreadyExists{})
+	mockConn.EXPECT().Read(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{})
+	_, err = c2.ReadOrCreate(ctx, &ClientTestEntity1{ID: int64(3)})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	// create fails
+	mockConn.EXPECT().CreateIfNotExists(ctx, gomock.Any(), gomock.Any()).Return(errors.New("create failed"))
+	_, err = c2.ReadOrCreate(ctx, &ClientTestEntity1{ID: int64(4)})
+	assert.EqualError(t, err, "create failed")
+}
+
 func TestClient_CreateIfNotExists_DynTTL(t *testing.T) {
 	cte3 := &ClientTestEntity1{}
 	reg1, _ := dosaRenamed.NewRegistrar("test", "team.service", cte3)
@@ -472,6 +1021,68 @@ func TestClient_Upsert_Errors(t *testing.T) {
 	assert.Contains(t, err.Error(), "badcol")
 }
 
+func TestClient_GetFirstAndLast(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, &testentity.TestEntity{})
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	partition := &testentity.TestEntity{UUIDKey: dosaRenamed.NewUUID()}
+	_, err := c.GetFirst(ctx, partition)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.NoError(t, c.Initialize(ctx))
+
+	_, err = c.GetFirst(ctx, partition)
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+	_, err = c.GetLast(ctx, partition)
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	other := dosaRenamed.NewUUID()
+	for _, key := range []dosaRenamed.UUID{partition.UUIDKey, other} {
+		for _, str := range []string{"a", "b"} {
+			for i := int64(1); i <= 2; i++ {
+				assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &testentity.TestEntity{UUIDKey: key, StrKey: str, Int64Key: i, StrV: string(key)}))
+			}
+		}
+	}
+
+	// StrKey is ascending and Int64Key descending
+	first, err := c.GetFirst(ctx, partition)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", first.(*testentity.TestEntity).StrKey)
+	assert.Equal(t, int64(2), first.(*testentity.TestEntity).Int64Key)
+	assert.Equal(t, string(partition.UUIDKey), first.(*testentity.TestEntity).StrV)
+
+	last, err := c.GetLast(ctx, partition)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", last.(*testentity.TestEntity).StrKey)
+	assert.Equal(t, int64(1), last.(*testentity.TestEntity).Int64Key)
+	assert.Equal(t, string(partition.UUIDKey), last.(*testentity.TestEntity).StrV)
+
+	_, err = c.GetLast(ctx, cte1)
+	assert.Contains(t, err.Error(), "ClientTestEntity1")
+}
+
+func TestClient_RemoveAll(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte2)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(dosaRenamed.NewClient(reg, nullConnector).RemoveAll(ctx, cte2)))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	c := dosaRenamed.NewClient(reg, mockConn)
+	assert.NoError(t, c.Initialize(ctx))
+
+	// only the partition key is sent, not the clustering key
+	mockConn.EXPECT().DeletePartition(ctx, gomock.Any(), map[string]dosaRenamed.FieldValue{"uuid": cte2.UUID}).Return(nil)
+	assert.NoError(t, c.RemoveAll(ctx, cte2))
+
+	mockConn.EXPECT().DeletePartition(ctx, gomock.Any(), gomock.Any()).Return(errors.New("unavailable"))
+	assert.EqualError(t, c.RemoveAll(ctx, cte2), "RemoveAll: unavailable")
+
+	err := c.RemoveAll(ctx, cte1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity1")
+}
+
 func TestClient_RemoveRange(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 
@@ -508,6 +1119,138 @@ func TestClient_RemoveRange(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestClient_Aggregate(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	rop := dosaRenamed.NewRangeOp(cte1)
+	_, err := c1.Aggregate(ctx, dosaRenamed.AggSum, "ID", rop)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	c1.Initialize(ctx)
+
+	// bad entity
+	_, err = c1.Aggregate(ctx, dosaRenamed.AggSum, "ID", dosaRenamed.NewRangeOp(cte2))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity2")
+
+	// bad field
+	_, err = c1.Aggregate(ctx, dosaRenamed.AggSum, "borkborkbork", rop)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "borkborkbork")
+
+	// numeric aggregation of a string field
+	_, err = c1.Aggregate(ctx, dosaRenamed.AggAvg, "Email", rop)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot apply AVG")
+
+	// bad column in range
+	_, err = c1.Aggregate(ctx, dosaRenamed.AggSum, "ID", dosaRenamed.NewRangeOp(cte1).Eq("borkborkbork", int64(1)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "borkborkbork")
+
+	// success case
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Aggregate(ctx, gomock.Any(), dosaRenamed.AggMax, "email", map[string][]*dosaRenamed.Condition{
+		"name": {{Op: dosaRenamed.Eq, Value: "foo"}},
+	}).Return(dosaRenamed.FieldValue("foo@email.com"), nil)
+	c2 := dosaRenamed.NewClient(reg1, mockConn)
+	c2.Initialize(ctx)
+	v, err := c2.Aggregate(ctx, dosaRenamed.AggMax, "Email", dosaRenamed.NewRangeOp(cte1).Eq("Name", "foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, dosaRenamed.FieldValue("foo@email.com"), v)
+}
+
+func TestClient_ExplainQuery(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	_, err := c1.ExplainQuery(ctx, dosaRenamed.NewRangeOp(cte1))
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	c1.Initialize(ctx)
+
+	// bad entity
+	_, err = c1.ExplainQuery(ctx, dosaRenamed.NewRangeOp(cte2))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity2")
+
+	// bad column in range
+	_, err = c1.ExplainQuery(ctx, dosaRenamed.NewRangeOp(cte1).Eq("borkborkbork", int64(1)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "borkborkbork")
+
+	// the index on Name is used, and the rows are counted by the memory connector
+	c2 := dosaRenamed.NewClient(reg1, memory.NewConnector())
+	assert.NoError(t, c2.Initialize(ctx))
+	assert.NoError(t, c2.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo", Email: "foo@email.com"}))
+	assert.NoError(t, c2.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 2, Name: "foo", Email: "bar@email.com"}))
+	plan, err := c2.ExplainQuery(ctx, dosaRenamed.NewRangeOp(cte1).Eq("Name", "foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, &dosaRenamed.QueryPlan{Index: "username", EstimatedRows: 2}, plan)
+}
+
+func TestClient_CountRange(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	_, err := c1.CountRange(ctx, dosaRenamed.NewRangeOp(cte1))
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	c1.Initialize(ctx)
+
+	// bad entity
+	_, err = c1.CountRange(ctx, dosaRenamed.NewRangeOp(cte2))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity2")
+
+	// bad column in range
+	_, err = c1.CountRange(ctx, dosaRenamed.NewRangeOp(cte1).Eq("borkborkbork", int64(1)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "borkborkbork")
+
+	// the memory connector counts natively
+	c2 := dosaRenamed.NewClient(reg1, memory.NewConnector())
+	assert.NoError(t, c2.Initialize(ctx))
+	assert.NoError(t, c2.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo", Email: "foo@email.com"}))
+	assert.NoError(t, c2.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 2, Name: "foo", Email: "bar@email.com"}))
+	count, err := c2.CountRange(ctx, dosaRenamed.NewRangeOp(cte1).Eq("Name", "foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// connectors that can't count fall back to reading the range, page by page
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().CountRange(ctx, gomock.Any(), gomock.Any()).
+		Return(int64(0), &dosaRenamed.ErrNotSupported{Op: "CountRange"}).Times(3)
+	page := []map[string]dosaRenamed.FieldValue{{"id": int64(1)}, {"id": int64(2)}}
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), []string{"id"}, "", gomock.Any()).
+		Return(page, "next", nil)
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), []string{"id"}, "next", gomock.Any()).
+		Return(page[:1], "", nil)
+	c3 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c3.Initialize(ctx))
+	count, err = c3.CountRange(ctx, dosaRenamed.NewRangeOp(cte1).Eq("ID", int64(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// an empty range counts zero, other errors are returned
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, "", &dosaRenamed.ErrNotFound{})
+	count, err = c3.CountRange(ctx, dosaRenamed.NewRangeOp(cte1).Eq("ID", int64(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	mockConn.EXPECT().Range(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, "", errors.New("range failed"))
+	_, err = c3.CountRange(ctx, dosaRenamed.NewRangeOp(cte1).Eq("ID", int64(1)))
+	assert.EqualError(t, err, "CountRange: range failed")
+}
+
 func TestClient_Range(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 	fieldsToRead := []string{"ID", "Email"}
@@ -567,6 +1310,33 @@ func TestClient_Range(t *testing.T) {
 	}
 	assert.Equal(t, "continuation-token", token)
 
+	// the page token in the context is used without an explicit offset
+	tokenCtx := dosaRenamed.WithPageToken(ctx, "context-token")
+	mockConn.EXPECT().Range(tokenCtx, gomock.Any(), gomock.Any(), gomock.Any(), "context-token", gomock.Any()).
+		Return([]map[string]dosaRenamed.FieldValue{resultRow}, "", nil)
+	_, _, err = c2.Range(tokenCtx, dosaRenamed.NewRangeOp(cte1))
+	assert.NoError(t, err)
+	mockConn.EXPECT().Range(tokenCtx, gomock.Any(), gomock.Any(), gomock.Any(), "explicit-token", gomock.Any()).
+		Return([]map[string]dosaRenamed.FieldValue{resultRow}, "", nil)
+	_, _, err = c2.Range(tokenCtx, dosaRenamed.NewRangeOp(cte1).Offset("explicit-token"))
+	assert.NoError(t, err)
+
+	// the sort columns reach the connector by column name
+	mockConn.EXPECT().Range(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, _ *dosaRenamed.EntityInfo, _ map[string][]*dosaRenamed.Condition, _ []string, _ string, _ int) {
+			cols, ok := dosaRenamed.SortColumnsFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, []dosaRenamed.ColumnOrder{{Column: "id", Direction: dosaRenamed.Descending}}, cols)
+		}).Return([]map[string]dosaRenamed.FieldValue{resultRow}, "", nil)
+	_, _, err = c2.Range(ctx, dosaRenamed.NewRangeOp(cte1).WithSortColumns([]dosaRenamed.ColumnOrder{
+		{Column: "ID", Direction: dosaRenamed.Descending},
+	}))
+	assert.NoError(t, err)
+	_, _, err = c2.Range(ctx, dosaRenamed.NewRangeOp(cte1).WithSortColumns([]dosaRenamed.ColumnOrder{
+		{Column: "borkborkbork", Direction: dosaRenamed.Descending},
+	}))
+	assert.Contains(t, err.Error(), "borkborkbork")
+
 	// no resulting rows, just use the devnull connector
 	rop = dosaRenamed.NewRangeOp(cte1)
 	_, _, err = c1.Range(ctx, rop)
@@ -637,6 +1407,246 @@ func TestClient_WalkRange(t *testing.T) {
 	assert.EqualError(t, err, "woops!")
 }
 
+func TestClient_WalkRangeStop(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	assert.NoError(t, c.Initialize(ctx))
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, c.Upsert(ctx, nil, &ClientTestEntity1{ID: int64(i), Name: "foo"}))
+	}
+
+	// the walk crosses pages until the callback stops it
+	count := 0
+	rop := dosaRenamed.NewRangeOp(cte1).Eq("Name", "foo").Limit(2)
+	err := c.WalkRange(ctx, rop, func(dosaRenamed.DomainObject) error {
+		count++
+		if count == 3 {
+			return dosaRenamed.ErrStop
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestClient_ScanWithCallback(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	noop := func(dosaRenamed.DomainObject) error { return nil }
+	err := c.ScanWithCallback(ctx, cte1, 10, noop)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.NoError(t, c.Initialize(ctx))
+
+	for i := 1; i <= 25; i++ {
+		assert.NoError(t, c.Upsert(ctx, nil, &ClientTestEntity1{ID: int64(i), Name: "foo"}))
+	}
+
+	// every row is seen once, across three pages
+	seen := map[int64]bool{}
+	assert.NoError(t, c.ScanWithCallback(ctx, cte1, 10, func(o dosaRenamed.DomainObject) error {
+		e := o.(*ClientTestEntity1)
+		assert.False(t, seen[e.ID])
+		assert.Equal(t, "foo", e.Name)
+		seen[e.ID] = true
+		return nil
+	}))
+	assert.Len(t, seen, 25)
+
+	// ErrStop stops without an error, other errors are returned
+	count := 0
+	assert.NoError(t, c.ScanWithCallback(ctx, cte1, 10, func(dosaRenamed.DomainObject) error {
+		count++
+		if count == 12 {
+			return errors.Wrap(dosaRenamed.ErrStop, "done")
+		}
+		return nil
+	}))
+	assert.Equal(t, 12, count)
+	err = c.ScanWithCallback(ctx, cte1, 10, func(dosaRenamed.DomainObject) error {
+		return errors.New("callback failed")
+	})
+	assert.EqualError(t, err, "callback failed")
+
+	err = c.ScanWithCallback(ctx, cte1, 0, noop)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid page size")
+	err = c.ScanWithCallback(ctx, cte2, 10, noop)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClientTestEntity2")
+}
+
+func TestClient_CrossPartitionScan(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar("test", "team.service", &testentity.TestEntity{})
+	c := dosaRenamed.NewClient(reg, memory.NewConnector(), dosaRenamed.WithMaxParallelism(2))
+	p1, p2, p3 := dosaRenamed.NewUUID(), dosaRenamed.NewUUID(), dosaRenamed.NewUUID()
+	partitions := []map[string]dosaRenamed.FieldValue{{"UUIDKey": p1}, {"UUIDKey": p2}, {"UUIDKey": p3}}
+
+	_, err := c.CrossPartitionScan(ctx, &testentity.TestEntity{}, partitions, 1)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.NoError(t, c.Initialize(ctx))
+
+	for _, e := range []*testentity.TestEntity{
+		{UUIDKey: p1, StrKey: "a", Int64Key: 1},
+		{UUIDKey: p1, StrKey: "c", Int64Key: 1},
+		{UUIDKey: p1, StrKey: "e", Int64Key: 1, StrV: "first"},
+		{UUIDKey: p2, StrKey: "b", Int64Key: 2},
+		{UUIDKey: p2, StrKey: "b", Int64Key: 1},
+		{UUIDKey: p2, StrKey: "e", Int64Key: 1, StrV: "second"},
+		{UUIDKey: dosaRenamed.NewUUID(), StrKey: "a", Int64Key: 1},
+	} {
+		// the memory connector's page tokens can't hold nil pointer fields
+		assert.NoError(t, c.Upsert(ctx, []string{"StrV"}, e))
+	}
+
+	results, err := c.CrossPartitionScan(ctx, &testentity.TestEntity{}, partitions, 1)
+	assert.NoError(t, err)
+	var keys []string
+	for _, result := range results {
+		e := result.(*testentity.TestEntity)
+		keys = append(keys, fmt.Sprint(e.StrKey, e.Int64Key, e.StrV))
+	}
+	// interleaved by StrKey ascending and Int64Key descending, then by partition
+	assert.Equal(t, []string{"a1", "b2", "b1", "c1", "e1first", "e1second"}, keys)
+
+	results, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, nil, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, partitions, 0)
+	assert.Contains(t, err.Error(), "invalid page size 0")
+	_, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, []map[string]dosaRenamed.FieldValue{{}}, 1)
+	assert.Contains(t, err.Error(), `missing partition key field "UUIDKey"`)
+	_, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, []map[string]dosaRenamed.FieldValue{{"UUIDKey": p1, "StrKey": "a"}}, 1)
+	assert.Contains(t, err.Error(), "only partition key fields")
+	_, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, []map[string]dosaRenamed.FieldValue{{"Nope": p1}}, 1)
+	assert.Error(t, err)
+	_, err = c.CrossPartitionScan(ctx, cte1, partitions, 1)
+	assert.Error(t, err)
+
+	// the first failing partition fails the scan
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().Range(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "", 1).
+		Return(nil, "", errors.New("range failed"))
+	c = dosaRenamed.NewClient(reg, mockConn, dosaRenamed.WithMaxParallelism(1))
+	assert.NoError(t, c.Initialize(ctx))
+	_, err = c.CrossPartitionScan(ctx, &testentity.TestEntity{}, partitions[:1], 1)
+	assert.EqualError(t, err, "failed to CrossPartitionScan: partition key 0: range failed")
+}
+
+func TestClient_ScanBySecondaryIndex(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c1 := dosaRenamed.NewClient(reg1, memory.NewConnector())
+	fooName := []*dosaRenamed.ColumnCondition{
+		{Name: "Name", Condition: &dosaRenamed.Condition{Op: dosaRenamed.Eq, Value: "foo"}},
+	}
+	_, _, err := c1.ScanBySecondaryIndex(ctx, cte1, "username", fooName, 2, "")
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	assert.NoError(t, c1.Initialize(ctx))
+	for _, e := range []*ClientTestEntity1{{ID: 3, Name: "foo"}, {ID: 1, Name: "foo"}, {ID: 4, Name: "bar"}, {ID: 2, Name: "foo"}} {
+		assert.NoError(t, c1.Upsert(ctx, dosaRenamed.All(), e))
+	}
+
+	// the rows come in the clustering order of the index, a page at a time
+	objs, token, err := c1.ScanBySecondaryIndex(ctx, cte1, "username", fooName, 2, "")
+	assert.NoError(t, err)
+	assert.Len(t, objs, 2)
+	assert.Equal(t, int64(1), objs[0].(*ClientTestEntity1).ID)
+	assert.Equal(t, int64(2), objs[1].(*ClientTestEntity1).ID)
+	assert.NotEmpty(t, token)
+	objs, token, err = c1.ScanBySecondaryIndex(ctx, cte1, "username", fooName, 2, token)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+	assert.Equal(t, int64(3), objs[0].(*ClientTestEntity1).ID)
+	assert.Empty(t, token)
+
+	_, _, err = c1.ScanBySecondaryIndex(ctx, cte1, "username", fooName, 0, "")
+	assert.EqualError(t, err, "ScanBySecondaryIndex: invalid page size 0")
+	_, _, err = c1.ScanBySecondaryIndex(ctx, cte1, "byemail", fooName, 2, "")
+	assert.EqualError(t, err, `ScanBySecondaryIndex: "byemail" is not an index of "ClientTestEntity1"`)
+	_, _, err = c1.ScanBySecondaryIndex(ctx, cte1, "username", []*dosaRenamed.ColumnCondition{
+		{Name: "Email", Condition: &dosaRenamed.Condition{Op: dosaRenamed.Eq, Value: "foo@uber.com"}},
+	}, 2, "")
+	assert.EqualError(t, err, `ScanBySecondaryIndex: column "email" is not a key of index "username"`)
+	_, _, err = c1.ScanBySecondaryIndex(ctx, cte1, "username", []*dosaRenamed.ColumnCondition{nil}, 2, "")
+	assert.EqualError(t, err, "ScanBySecondaryIndex: nil condition")
+
+	// the partition key of the index must be set
+	_, _, err = c1.ScanBySecondaryIndex(ctx, cte1, "username", nil, 2, "")
+	assert.Error(t, err)
+}
+
+type ClientTestScanOnly struct {
+	dosaRenamed.Entity `dosa:"primaryKey=()"`
+	Message            string
+}
+
+func TestClient_ScanOnlyEntity(t *testing.T) {
+	reg, err := dosaRenamed.NewRegistrar(scope, namePrefix, &ClientTestScanOnly{})
+	assert.NoError(t, err)
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	assert.NoError(t, c.Initialize(ctx))
+
+	// every upsert adds a row, and the rows can be scanned
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &ClientTestScanOnly{Message: "hello"}))
+	}
+	assert.Equal(t, []error{nil, nil}, c.MultiUpsert(ctx, []dosaRenamed.DomainObject{
+		&ClientTestScanOnly{Message: "a"}, &ClientTestScanOnly{Message: "b"},
+	}))
+	objs, _, err := c.ScanEverything(ctx, dosaRenamed.NewScanOp(&ClientTestScanOnly{}).Limit(10))
+	assert.NoError(t, err)
+	assert.Len(t, objs, 5)
+
+	// everything that needs a primary key is rejected
+	e := &ClientTestScanOnly{Message: "hello"}
+	cond := []*dosaRenamed.ColumnCondition{{Name: "Message", Condition: &dosaRenamed.Condition{Op: dosaRenamed.Eq, Value: "hello"}}}
+	var errs []error
+	errs = append(errs, c.Read(ctx, dosaRenamed.All(), e))
+	errs = append(errs, c.GetWithFieldMask(ctx, e, []string{"Message"}))
+	_, err = c.MultiRead(ctx, dosaRenamed.All(), e)
+	errs = append(errs, err)
+	_, getManyErrs, _ := c.GetMany(ctx, []dosaRenamed.DomainObject{e})
+	errs = append(errs, getManyErrs[0])
+	_, err = c.GetOrSet(ctx, e, func(dosaRenamed.DomainObject) error { return nil })
+	errs = append(errs, err)
+	_, err = c.ReadOrCreate(ctx, e)
+	errs = append(errs, err)
+	errs = append(errs, c.CreateIfNotExists(ctx, e))
+	errs = append(errs, c.Replace(ctx, e))
+	errs = append(errs, c.UpsertWithConditions(ctx, e, cond))
+	_, err = c.IncrementCounter(ctx, e, "Message", 1)
+	errs = append(errs, err)
+	_, err = c.CountRange(ctx, dosaRenamed.NewRangeOp(e))
+	errs = append(errs, err)
+	errs = append(errs, c.Remove(ctx, e))
+	errs = append(errs, c.BatchRemove(ctx, []dosaRenamed.DomainObject{e})[0])
+	errs = append(errs, c.RemoveRange(ctx, dosaRenamed.NewRemoveRangeOp(e)))
+	errs = append(errs, c.RemoveAll(ctx, e))
+	_, _, err = c.Range(ctx, dosaRenamed.NewRangeOp(e))
+	errs = append(errs, err)
+	_, err = c.GetFirst(ctx, e)
+	errs = append(errs, err)
+	_, err = c.GetLast(ctx, e)
+	errs = append(errs, err)
+	_, _, err = c.ScanBySecondaryIndex(ctx, e, "none", cond, 10, "")
+	errs = append(errs, err)
+	_, err = c.CrossPartitionScan(ctx, e, []map[string]dosaRenamed.FieldValue{{}}, 10)
+	errs = append(errs, err)
+	for i, err := range errs {
+		assert.True(t, dosaRenamed.ErrorIsScanOnly(err), fmt.Sprint("call ", i, ": ", err))
+	}
+	assert.Contains(t, errs[0].Error(), "entity ClientTestScanOnly has no primary key, it can only be upserted and scanned")
+
+	// none of them removed a row
+	objs, _, err = c.ScanEverything(ctx, dosaRenamed.NewScanOp(&ClientTestScanOnly{}).Limit(10))
+	assert.NoError(t, err)
+	assert.Len(t, objs, 5)
+}
+
 func TestClient_ScanEverything(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 	fieldsToRead := []string{"ID", "Email"}
@@ -689,6 +1699,13 @@ func TestClient_ScanEverything(t *testing.T) {
 	}
 	assert.Equal(t, "continuation-token", token)
 
+	// the page token in the context is used without an explicit offset
+	tokenCtx := dosaRenamed.WithPageToken(ctx, "context-token")
+	mockConn.EXPECT().Scan(tokenCtx, gomock.Any(), gomock.Any(), "context-token", gomock.Any()).
+		Return([]map[string]dosaRenamed.FieldValue{resultRow}, "", nil)
+	_, _, err = c2.ScanEverything(tokenCtx, dosaRenamed.NewScanOp(cte1))
+	assert.NoError(t, err)
+
 	// no resulting rows, just use the devnull connector
 	sop = dosaRenamed.NewScanOp(cte1)
 	_, _, err = c1.ScanEverything(ctx, sop)
@@ -722,6 +1739,119 @@ func TestClient_Remove(t *testing.T) {
 
 }
 
+func TestClient_GetMany(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+
+	// uninitialized
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	_, _, err := c1.GetMany(ctx, []dosaRenamed.DomainObject{&ClientTestEntity1{ID: 1}})
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+
+	// the rows with even ids exist, and more keys are read than fit in a batch
+	c2 := dosaRenamed.NewClient(reg1, memory.NewConnector(), dosaRenamed.WithMaxParallelism(2))
+	assert.NoError(t, c2.Initialize(ctx))
+	var pks []dosaRenamed.DomainObject
+	for i := 0; i < 2*dosaRenamed.GetManyBatchSize+10; i++ {
+		if i%2 == 0 {
+			assert.NoError(t, c2.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: int64(i), Name: fmt.Sprintf("name%d", i)}))
+		}
+		pks = append(pks, &ClientTestEntity1{ID: int64(i)})
+	}
+	pks = append(pks, cte2)
+	found, errs, err := c2.GetMany(ctx, pks)
+	assert.NoError(t, err)
+	assert.Len(t, found, len(pks))
+	assert.Len(t, errs, len(pks))
+	for i := 0; i < len(pks)-1; i++ {
+		if i%2 == 0 {
+			assert.NoError(t, errs[i])
+			assert.True(t, found[i] == pks[i], "the given entity is filled in")
+			assert.Equal(t, fmt.Sprintf("name%d", i), found[i].(*ClientTestEntity1).Name)
+		} else {
+			assert.True(t, dosaRenamed.ErrorIsNotFound(errs[i]))
+			assert.Nil(t, found[i])
+		}
+	}
+	assert.Contains(t, errs[len(pks)-1].Error(), "ClientTestEntity2")
+	assert.Nil(t, found[len(pks)-1])
+
+	// a failed connector call is the overall error
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().MultiRead(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+	c3 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c3.Initialize(ctx))
+	_, _, err = c3.GetMany(ctx, []dosaRenamed.DomainObject{&ClientTestEntity1{ID: 1}})
+	assert.EqualError(t, err, "GetMany: connection refused")
+}
+
+func TestClient_BatchRemove(t *testing.T) {
+	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	reg2, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1, cte2)
+	e1 := &ClientTestEntity1{ID: int64(1)}
+	e2 := &ClientTestEntity1{ID: int64(2)}
+
+	// uninitialized
+	c1 := dosaRenamed.NewClient(reg1, nullConnector)
+	errs := c1.BatchRemove(ctx, []dosaRenamed.DomainObject{e1, e2})
+	assert.Len(t, errs, 2)
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(errs[0]))
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(errs[1]))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// unregistered entities fail, the others are removed
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().MultiRemove(ctx, gomock.Any(), []map[string]dosaRenamed.FieldValue{
+		{"id": dosaRenamed.FieldValue(int64(1))},
+		{"id": dosaRenamed.FieldValue(int64(2))},
+	}).Return([]error{nil, &dosaRenamed.ErrNotFound{}}, nil)
+	c2 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c2.Initialize(ctx))
+	errs = c2.BatchRemove(ctx, []dosaRenamed.DomainObject{e1, cte2, e2})
+	assert.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.Contains(t, errs[1].Error(), "ClientTestEntity2")
+	assert.True(t, dosaRenamed.ErrorIsNotFound(errs[2]))
+
+	err := dosaRenamed.NewBatchError(errs)
+	assert.True(t, dosaRenamed.ErrorIsBatchError(err))
+	assert.Contains(t, err.Error(), "2 of 3 operations failed, first error at 1")
+	assert.Len(t, err.(*dosaRenamed.BatchError).Failures(), 2)
+	assert.NoError(t, dosaRenamed.NewBatchError([]error{nil, nil}))
+
+	// entities of different types are removed with one call per type
+	mockConn = mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().MultiRemove(ctx, gomock.Any(), []map[string]dosaRenamed.FieldValue{
+		{"id": dosaRenamed.FieldValue(int64(1))},
+		{"id": dosaRenamed.FieldValue(int64(2))},
+	}).Return([]error{nil, nil}, nil)
+	mockConn.EXPECT().MultiRemove(ctx, gomock.Any(), []map[string]dosaRenamed.FieldValue{
+		{"uuid": dosaRenamed.FieldValue(cte2.UUID), "color": dosaRenamed.FieldValue(cte2.Color)},
+	}).Return(nil, errors.New("connector error"))
+	c3 := dosaRenamed.NewClient(reg2, mockConn)
+	assert.NoError(t, c3.Initialize(ctx))
+	errs = c3.BatchRemove(ctx, []dosaRenamed.DomainObject{e1, cte2, e2})
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "connector error")
+	assert.NoError(t, errs[2])
+
+	// a connector that returns fewer results than keys doesn't report success
+	mockConn = mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+	mockConn.EXPECT().MultiRemove(ctx, gomock.Any(), gomock.Any()).Return([]error{nil}, nil)
+	c4 := dosaRenamed.NewClient(reg1, mockConn)
+	assert.NoError(t, c4.Initialize(ctx))
+	errs = c4.BatchRemove(ctx, []dosaRenamed.DomainObject{e1, e2})
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "BatchRemove: no result for entity 1")
+}
+
 func TestClient_MultiRead(t *testing.T) {
 	reg1, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
 	reg2, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1, cte2)
@@ -997,6 +2127,83 @@ type TestEntityB struct {
 	}
 }
 
+func TestAdminClient_DropTables(t *testing.T) {
+	// write some entities to disk
+	tmpdir := ".testdroptables"
+	os.RemoveAll(tmpdir)
+	defer os.RemoveAll(tmpdir)
+	content := `
+package main
+
+import "github.com/uber-go/dosa"
+
+type TestEntityA struct {
+	dosa.Entity ` + "`dosa:\"primaryKey=(ID)\"`" + `
+	ID int32
+}
+type TestEntityB struct {
+	dosa.Entity ` + "`dosa:\"name=entity_b, primaryKey=(ID)\"`" + `
+	ID int32
+}
+`
+	assert.NoError(t, os.MkdirAll(tmpdir, 0770))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmpdir, "f1.go"), []byte(content), 0700))
+
+	conn := memory.NewConnector()
+	defs, err := dosaRenamed.NewAdminClient(conn).Directories([]string{tmpdir}).GetSchema()
+	assert.NoError(t, err)
+	assert.Len(t, defs, 2)
+	key := map[string]dosaRenamed.FieldValue{"id": int32(1)}
+	eis := make(map[string]*dosaRenamed.EntityInfo)
+	for _, def := range defs {
+		ei := &dosaRenamed.EntityInfo{
+			Ref: &dosaRenamed.SchemaRef{Scope: scope, NamePrefix: namePrefix, EntityName: def.Name},
+			Def: def,
+		}
+		assert.NoError(t, conn.Upsert(ctx, ei, key))
+		eis[def.Name] = ei
+	}
+
+	// unknown entity
+	err = dosaRenamed.NewAdminClient(conn).
+		Directories([]string{tmpdir}).
+		Scope(scope).
+		Entity("TestEntityC").
+		DropTables(ctx, namePrefix)
+	assert.Contains(t, err.Error(), `entity "TestEntityC" not found`)
+
+	// only the named entity is dropped; the name can be the struct name
+	assert.NoError(t, dosaRenamed.NewAdminClient(conn).
+		Directories([]string{tmpdir}).
+		Scope(scope).
+		Entity("TestEntityA").
+		DropTables(ctx, namePrefix))
+	_, err = conn.Read(ctx, eis["testentitya"], key, dosaRenamed.All())
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+	_, err = conn.Read(ctx, eis["entity_b"], key, dosaRenamed.All())
+	assert.NoError(t, err)
+
+	// or the entity name
+	assert.NoError(t, dosaRenamed.NewAdminClient(conn).
+		Directories([]string{tmpdir}).
+		Scope(scope).
+		Entity("entity_b").
+		DropTables(ctx, namePrefix))
+	_, err = conn.Read(ctx, eis["entity_b"], key, dosaRenamed.All())
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	// connector error
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	mockConn.EXPECT().DropTable(ctx, gomock.Any()).Return(errors.New("connector error"))
+	err = dosaRenamed.NewAdminClient(mockConn).
+		Directories([]string{tmpdir}).
+		Scope(scope).
+		DropTables(ctx, namePrefix)
+	assert.Contains(t, err.Error(), "connector error")
+}
+
 func TestAdminClient_GetSchema(t *testing.T) {
 	// write some entities to disk
 	tmpdir := ".testgetschema"
@@ -1079,6 +2286,60 @@ type TestEntityC struct {
 	}
 }
 
+func TestAdminClient_SchemaFiles(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "schemafiles")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	path := filepath.Join(tmpdir, "entities.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+- name: users
+  columns:
+  - {name: id, type: UUID, key: partition}
+- name: events
+  columns:
+  - {name: id, type: Int64, key: partition}
+`), 0600))
+
+	// the directories aren't searched when there are schema files
+	defs, err := dosaRenamed.NewAdminClient(nullConnector).
+		Directories([]string{"/foo/bar/baz"}).
+		SchemaFiles([]string{path}).
+		Entity("events").
+		GetSchema()
+	assert.NoError(t, err)
+	if assert.Len(t, defs, 1) {
+		assert.Equal(t, "events", defs[0].Name)
+	}
+
+	_, err = dosaRenamed.NewAdminClient(nullConnector).SchemaFiles([]string{filepath.Join(tmpdir, "missing.yaml")}).GetSchema()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot open schema file")
+}
+
+func TestAdminClient_Definitions(t *testing.T) {
+	def := &dosaRenamed.EntityDefinition{
+		Name:    "users",
+		Key:     &dosaRenamed.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosaRenamed.ColumnDefinition{{Name: "id", Type: dosaRenamed.TUUID}},
+	}
+
+	// neither the directories nor the schema files are read
+	defs, err := dosaRenamed.NewAdminClient(nullConnector).
+		Directories([]string{"/foo/bar/baz"}).
+		SchemaFiles([]string{"/foo/bar/baz.yaml"}).
+		Definitions([]*dosaRenamed.EntityDefinition{def}).
+		GetSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, []*dosaRenamed.EntityDefinition{def}, defs)
+	assert.False(t, defs[0] == def, "the definitions are copied")
+
+	_, err = dosaRenamed.NewAdminClient(nullConnector).
+		Definitions([]*dosaRenamed.EntityDefinition{def}).
+		Entity("other").
+		GetSchema()
+	assert.EqualError(t, err, `entity "other" not found`)
+}
+
 func TestErrorIsNotFound(t *testing.T) {
 	assert.False(t, dosaRenamed.ErrorIsNotFound(errors.New("not a IsNotFound error")))
 	assert.False(t, dosaRenamed.ErrorIsNotFound(&dosaRenamed.ErrNotInitialized{}))
@@ -1087,6 +2348,14 @@ func TestErrorIsNotFound(t *testing.T) {
 	assert.Equal(t, (&dosaRenamed.ErrNotFound{}).Error(), "not found")
 }
 
+func TestErrorIsStop(t *testing.T) {
+	assert.False(t, dosaRenamed.ErrorIsStop(errors.New("scan stopped by caller")))
+	assert.False(t, dosaRenamed.ErrorIsStop(nil))
+	assert.True(t, dosaRenamed.ErrorIsStop(dosaRenamed.ErrStop))
+	assert.True(t, dosaRenamed.ErrorIsStop(errors.Wrap(dosaRenamed.ErrStop, "wrapped")))
+	assert.Equal(t, "scan stopped by caller", dosaRenamed.ErrStop.Error())
+}
+
 func TestErrNotInitialized_Error(t *testing.T) {
 	assert.False(t, dosaRenamed.ErrorIsNotInitialized(errors.New("not a IsNotInitializedError")))
 	assert.False(t, dosaRenamed.ErrorIsNotInitialized(&dosaRenamed.ErrNotFound{}))
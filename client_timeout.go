@@ -0,0 +1,228 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"time"
+)
+
+// timeoutConnector gives every call to the wrapped connector a timeout,
+// unless the caller's context already has a deadline; see WithTimeout
+type timeoutConnector struct {
+	Connector
+	timeout time.Duration
+}
+
+// withTimeout returns ctx with the connector's timeout, or ctx itself if it
+// already has a deadline
+func (c *timeoutConnector) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *timeoutConnector) CreateIfNotExists(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CreateIfNotExists(ctx, ei, values)
+}
+
+func (c *timeoutConnector) Read(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue, minimumFields []string) (map[string]FieldValue, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Read(ctx, ei, keys, minimumFields)
+}
+
+func (c *timeoutConnector) MultiRead(ctx context.Context, ei *EntityInfo, keys []map[string]FieldValue, minimumFields []string) ([]*FieldValuesOrError, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.MultiRead(ctx, ei, keys, minimumFields)
+}
+
+func (c *timeoutConnector) Upsert(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Upsert(ctx, ei, values)
+}
+
+func (c *timeoutConnector) CompareAndSwap(ctx context.Context, ei *EntityInfo, conditions map[string]FieldValue, newValues map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+func (c *timeoutConnector) UpsertWithConditions(ctx context.Context, ei *EntityInfo, values map[string]FieldValue, conditions map[string][]*Condition) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+func (c *timeoutConnector) UpsertAndRead(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) (map[string]FieldValue, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.UpsertAndRead(ctx, ei, values)
+}
+
+func (c *timeoutConnector) Replace(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Replace(ctx, ei, values)
+}
+
+func (c *timeoutConnector) AtomicAdd(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue, column string, delta int64) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+func (c *timeoutConnector) Aggregate(ctx context.Context, ei *EntityInfo, aggFunc AggFunc, column string, columnConditions map[string][]*Condition) (FieldValue, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+}
+
+func (c *timeoutConnector) MultiUpsert(ctx context.Context, ei *EntityInfo, multiValues []map[string]FieldValue) ([]error, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.MultiUpsert(ctx, ei, multiValues)
+}
+
+func (c *timeoutConnector) Remove(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Remove(ctx, ei, keys)
+}
+
+func (c *timeoutConnector) RemoveRange(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.RemoveRange(ctx, ei, columnConditions)
+}
+
+func (c *timeoutConnector) DeletePartition(ctx context.Context, ei *EntityInfo, pk map[string]FieldValue) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.DeletePartition(ctx, ei, pk)
+}
+
+func (c *timeoutConnector) MultiRemove(ctx context.Context, ei *EntityInfo, multiKeys []map[string]FieldValue) ([]error, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.MultiRemove(ctx, ei, multiKeys)
+}
+
+func (c *timeoutConnector) Range(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition, minimumFields []string, token string, limit int) ([]map[string]FieldValue, string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+}
+
+func (c *timeoutConnector) Scan(ctx context.Context, ei *EntityInfo, minimumFields []string, token string, limit int) ([]map[string]FieldValue, string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.Scan(ctx, ei, minimumFields, token, limit)
+}
+
+func (c *timeoutConnector) CopyTable(ctx context.Context, src, dst *EntityInfo) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CopyTable(ctx, src, dst)
+}
+
+func (c *timeoutConnector) ExplainQuery(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) (*QueryPlan, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.ExplainQuery(ctx, ei, columnConditions)
+}
+
+func (c *timeoutConnector) CountRange(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CountRange(ctx, ei, columnConditions)
+}
+
+func (c *timeoutConnector) CheckSchema(ctx context.Context, scope string, namePrefix string, eds []*EntityDefinition) (int32, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CheckSchema(ctx, scope, namePrefix, eds)
+}
+
+func (c *timeoutConnector) CanUpsertSchema(ctx context.Context, scope string, namePrefix string, eds []*EntityDefinition) (int32, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CanUpsertSchema(ctx, scope, namePrefix, eds)
+}
+
+func (c *timeoutConnector) UpsertSchema(ctx context.Context, scope string, namePrefix string, eds []*EntityDefinition) (*SchemaStatus, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.UpsertSchema(ctx, scope, namePrefix, eds)
+}
+
+func (c *timeoutConnector) CheckSchemaStatus(ctx context.Context, scope string, namePrefix string, version int32) (*SchemaStatus, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CheckSchemaStatus(ctx, scope, namePrefix, version)
+}
+
+func (c *timeoutConnector) GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*EntityDefinition, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.GetEntitySchema(ctx, scope, namePrefix, entityName, version)
+}
+
+func (c *timeoutConnector) DescribeTable(ctx context.Context, ei *EntityInfo) (*EntityDefinition, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.DescribeTable(ctx, ei)
+}
+
+func (c *timeoutConnector) DropTable(ctx context.Context, ei *EntityInfo) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.DropTable(ctx, ei)
+}
+
+func (c *timeoutConnector) CreateScope(ctx context.Context, md *ScopeMetadata) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.CreateScope(ctx, md)
+}
+
+func (c *timeoutConnector) TruncateScope(ctx context.Context, scope string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.TruncateScope(ctx, scope)
+}
+
+func (c *timeoutConnector) DropScope(ctx context.Context, scope string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.DropScope(ctx, scope)
+}
+
+func (c *timeoutConnector) ScopeExists(ctx context.Context, scope string) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.Connector.ScopeExists(ctx, scope)
+}
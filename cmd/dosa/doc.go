@@ -74,10 +74,21 @@ Upsert schema with prefix "oss.user" to the "infra_dev" scope:
 
 	$ dosa schema upsert -s infra_dev -np oss.user
 
+Export the schema to YAML files in the "schemas" directory, and upsert it from them:
+
+	$ dosa schema export -o schemas
+	$ dosa schema import -s infra_dev -np oss.user schemas
+
 
 Code Generation:
 
-TODO
+Generate a DOSA entity from the "users" table of an existing MySQL database:
+
+	$ dosa generate from-sql --dsn "user:pass@/db" --table users -o users.go
+
+Map an additional SQL type to a Go type:
+
+	$ dosa generate from-sql --driver postgres --dsn "..." --table users --type citext=string
 
 	$ dosa gen ...
 
@@ -0,0 +1,677 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	// the database/sql drivers of the databases in sqlIntrospectionQueries
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// defaultSQLTypeMap maps (lowercased) SQL column types to the Go type of the generated field.
+// It covers the type names reported by MySQL and PostgreSQL in information_schema.
+var defaultSQLTypeMap = map[string]string{
+	"char":                        "string",
+	"varchar":                     "string",
+	"character":                   "string",
+	"character varying":           "string",
+	"text":                        "string",
+	"tinytext":                    "string",
+	"mediumtext":                  "string",
+	"longtext":                    "string",
+	"enum":                        "string",
+	"json":                        "string",
+	"tinyint":                     "int32",
+	"smallint":                    "int32",
+	"mediumint":                   "int32",
+	"int":                         "int32",
+	"integer":                     "int32",
+	"bigint":                      "int64",
+	"float":                       "float64",
+	"double":                      "float64",
+	"double precision":            "float64",
+	"real":                        "float64",
+	"decimal":                     "float64",
+	"numeric":                     "float64",
+	"bool":                        "bool",
+	"boolean":                     "bool",
+	"binary":                      "[]byte",
+	"varbinary":                   "[]byte",
+	"blob":                        "[]byte",
+	"tinyblob":                    "[]byte",
+	"mediumblob":                  "[]byte",
+	"longblob":                    "[]byte",
+	"bytea":                       "[]byte",
+	"date":                        "time.Time",
+	"datetime":                    "time.Time",
+	"timestamp":                   "time.Time",
+	"timestamp without time zone": "time.Time",
+	"timestamp with time zone":    "time.Time",
+	"uuid":                        "dosa.UUID",
+}
+
+// sqlGoTypes are the Go types that a SQL type can be mapped to
+var sqlGoTypes = map[string]bool{
+	"string":    true,
+	"int32":     true,
+	"int64":     true,
+	"float64":   true,
+	"bool":      true,
+	"[]byte":    true,
+	"time.Time": true,
+	"dosa.UUID": true,
+}
+
+// sqlIntrospectionQueries holds, per database/sql driver name (mysql for
+// go-sql-driver/mysql and postgres for lib/pq), the queries that list the columns
+// and the primary key columns of a table in the current database
+var sqlIntrospectionQueries = map[string]struct {
+	columns    string
+	primaryKey string
+}{
+	"mysql": {
+		columns: "SELECT column_name, data_type, is_nullable FROM information_schema.columns " +
+			"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position",
+		primaryKey: "SELECT column_name FROM information_schema.key_column_usage " +
+			"WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY' ORDER BY ordinal_position",
+	},
+	"postgres": {
+		columns: "SELECT column_name, data_type, is_nullable FROM information_schema.columns " +
+			"WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position",
+		primaryKey: "SELECT kcu.column_name FROM information_schema.table_constraints tc " +
+			"JOIN information_schema.key_column_usage kcu " +
+			"ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema " +
+			"WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = current_schema() AND tc.table_name = $1 " +
+			"ORDER BY kcu.ordinal_position",
+	},
+}
+
+// sqlColumn is the metadata of a single column read from the database
+type sqlColumn struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	PrimaryKey bool
+}
+
+// sqlSchemaReader reads the column metadata of a table
+type sqlSchemaReader interface {
+	Columns(ctx context.Context, table string) ([]sqlColumn, error)
+	Close() error
+}
+
+type sqlSchemaReaderProvider func(driver, dsn string) (sqlSchemaReader, error)
+
+// dbSchemaReader reads column metadata through database/sql introspection queries
+type dbSchemaReader struct {
+	db     *sql.DB
+	driver string
+}
+
+func provideSQLSchemaReader(driver, dsn string) (sqlSchemaReader, error) {
+	if _, ok := sqlIntrospectionQueries[driver]; !ok {
+		return nil, errors.Errorf("unsupported driver %q", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open database")
+	}
+	return &dbSchemaReader{db: db, driver: driver}, nil
+}
+
+// Columns returns the columns of the table in their ordinal position
+func (r *dbSchemaReader) Columns(ctx context.Context, table string) ([]sqlColumn, error) {
+	queries := sqlIntrospectionQueries[r.driver]
+
+	pk := map[string]bool{}
+	rows, err := r.db.QueryContext(ctx, queries.primaryKey, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read primary key")
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "could not read primary key")
+		}
+		pk[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read primary key")
+	}
+
+	rows, err = r.db.QueryContext(ctx, queries.columns, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read columns")
+	}
+	defer func() { _ = rows.Close() }()
+	var columns []sqlColumn
+	for rows.Next() {
+		var col sqlColumn
+		var nullable string
+		if err := rows.Scan(&col.Name, &col.Type, &nullable); err != nil {
+			return nil, errors.Wrap(err, "could not read columns")
+		}
+		col.Nullable = strings.EqualFold(nullable, "YES")
+		col.PrimaryKey = pk[col.Name]
+		columns = append(columns, col)
+	}
+	return columns, errors.Wrap(rows.Err(), "could not read columns")
+}
+
+// Close closes the database handle
+func (r *dbSchemaReader) Close() error {
+	return r.db.Close()
+}
+
+// GenerateOptions contains configuration for generate command flags.
+type GenerateOptions struct{}
+
+// GenerateFromSQL contains data for executing the generate from-sql command.
+type GenerateFromSQL struct {
+	*GenerateOptions
+	Driver        string   `long:"driver" default:"mysql" description:"The database/sql driver, mysql or postgres."`
+	DSN           string   `long:"dsn" description:"The data source name used to connect to the database." required:"true"`
+	Table         string   `long:"table" description:"The table to generate an entity for." required:"true"`
+	Package       string   `long:"package" default:"entity" description:"The package name of the generated file."`
+	StructName    string   `long:"struct" description:"The name of the generated struct. Defaults to the table name in CamelCase."`
+	Output        string   `short:"o" long:"output" description:"The file to write to. Defaults to stdout."`
+	TypeMap       []string `short:"t" long:"type" description:"Map an SQL type to a Go type, e.g. VARCHAR=string. May be repeated."`
+	provideReader sqlSchemaReaderProvider
+}
+
+func newGenerateFromSQL(provideReader sqlSchemaReaderProvider) *GenerateFromSQL {
+	return &GenerateFromSQL{
+		provideReader: provideReader,
+	}
+}
+
+// Execute executes a generate from-sql command
+func (c *GenerateFromSQL) Execute(args []string) error {
+	typeMap, err := parseSQLTypeMap(c.TypeMap)
+	if err != nil {
+		return err
+	}
+
+	reader, err := c.provideReader(c.Driver, c.DSN)
+	if err != nil {
+		return errors.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = reader.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout.Duration())
+	defer cancel()
+
+	columns, err := reader.Columns(ctx, c.Table)
+	if err != nil {
+		return errors.Wrapf(err, "could not read schema of table %q", c.Table)
+	}
+
+	src, err := generateEntityFromSQL(c.Package, c.StructName, c.Table, columns, typeMap)
+	if err != nil {
+		return err
+	}
+
+	if c.Output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return errors.Wrapf(ioutil.WriteFile(c.Output, src, 0644), "could not write %q", c.Output)
+}
+
+// parseSQLTypeMap returns the default type map with the SQLTYPE=gotype overrides applied
+func parseSQLTypeMap(overrides []string) (map[string]string, error) {
+	typeMap := make(map[string]string, len(defaultSQLTypeMap))
+	for k, v := range defaultSQLTypeMap {
+		typeMap[k] = v
+	}
+	for _, o := range overrides {
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid type mapping %q, expected SQLTYPE=gotype", o)
+		}
+		goType := strings.TrimSpace(parts[1])
+		if !sqlGoTypes[goType] {
+			return nil, errors.Errorf("invalid type mapping %q, unsupported Go type %q", o, goType)
+		}
+		typeMap[normalizeSQLType(parts[0])] = goType
+	}
+	return typeMap, nil
+}
+
+// normalizeSQLType lowercases a SQL type and removes its length or precision, e.g. VARCHAR(255) is varchar
+func normalizeSQLType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.Index(t, "("); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	return t
+}
+
+type generatedField struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+type generatedEntity struct {
+	Package    string
+	Table      string
+	StructName string
+	EntityTag  string
+	Fields     []generatedField
+	ImportTime bool
+}
+
+var generatedEntityTemplate = template.Must(template.New("entity").Parse(`// Code generated by "dosa generate from-sql" from table {{.Table}}.
+
+package {{.Package}}
+
+import (
+{{- if .ImportTime}}
+	"time"
+{{end}}
+	"github.com/uber-go/dosa"
+)
+
+// {{.StructName}} is a DOSA entity for the {{.Table}} table
+type {{.StructName}} struct {
+	dosa.Entity ` + "`{{.EntityTag}}`" + `
+{{- range .Fields}}
+	{{.Name}} {{.Type}}{{if .Tag}} ` + "`{{.Tag}}`" + `{{end}}
+{{- end}}
+}
+`))
+
+// generateEntityFromSQL returns the formatted source of a DOSA entity struct for the columns
+// of a table. The primary key columns of the table become the partition key of the entity.
+func generateEntityFromSQL(pkg, structName, table string, columns []sqlColumn, typeMap map[string]string) ([]byte, error) {
+	entityName, err := dosa.NormalizeName(table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "table %q cannot be used as an entity name", table)
+	}
+	if structName == "" {
+		structName = sqlNameToGo(table)
+	}
+	e := generatedEntity{
+		Package:    pkg,
+		Table:      table,
+		StructName: structName,
+	}
+
+	var keys []string
+	seen := map[string]bool{}
+	for _, col := range columns {
+		name, err := dosa.NormalizeName(col.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q cannot be used as a DOSA column", col.Name)
+		}
+		goType, ok := typeMap[normalizeSQLType(col.Type)]
+		if !ok {
+			return nil, errors.Errorf("column %q has type %q with no Go type mapping, use --type to add one", col.Name, col.Type)
+		}
+		if col.PrimaryKey {
+			keys = append(keys, name)
+		} else if col.Nullable && goType != "[]byte" {
+			// nullable columns become pointers, the key columns can't be null
+			goType = "*" + goType
+		}
+		if strings.Contains(goType, "time.Time") {
+			e.ImportTime = true
+		}
+
+		field := generatedField{Name: sqlNameToGo(col.Name), Type: goType}
+		if seen[field.Name] || field.Name == "Entity" {
+			return nil, errors.Errorf("column %q maps to duplicate field name %q", col.Name, field.Name)
+		}
+		seen[field.Name] = true
+		if strings.ToLower(field.Name) != name {
+			field.Tag = fmt.Sprintf(`dosa:"name=%s"`, name)
+		}
+		e.Fields = append(e.Fields, field)
+	}
+	if len(keys) == 0 {
+		return nil, errors.Errorf("table %q has no primary key", table)
+	}
+
+	pk := strings.Join(keys, ", ")
+	if len(keys) > 1 {
+		pk = "(" + pk + ")"
+	}
+	e.EntityTag = fmt.Sprintf(`dosa:"name=%s, primaryKey=(%s)"`, entityName, pk)
+
+	var buf bytes.Buffer
+	if err := generatedEntityTemplate.Execute(&buf, e); err != nil {
+		return nil, errors.Wrap(err, "could not generate entity")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not format generated entity")
+	}
+	return src, nil
+}
+
+// commonInitialisms are the words that golint expects to be all upper case
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URL": true, "UUID": true,
+}
+
+// sqlNameToGo converts a SQL name such as user_id into an exported Go name such as UserID
+func sqlNameToGo(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b bytes.Buffer
+	for _, w := range words {
+		if commonInitialisms[strings.ToUpper(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "Col" + s
+	}
+	return s
+}
+
+// GenerateEntity contains data for executing the generate entity command.
+type GenerateEntity struct {
+	*GenerateOptions
+	Name          string   `long:"name" description:"The name of the generated struct, e.g. UserProfile." required:"true"`
+	PartitionKeys []string `long:"partitionKey" description:"A partition key field as Name:Type, e.g. UserID:UUID. May be repeated or comma separated." required:"true"`
+	ClusterKeys   []string `long:"clusterKey" description:"A clustering key field as Name:Type or Name:Type:desc, e.g. CreatedAt:Timestamp. May be repeated or comma separated."`
+	Fields        string   `long:"fields" description:"The other fields as comma separated Name:Type pairs, e.g. Name:string,Score:int64. Prefix a type with * for a nullable field."`
+	Output        string   `short:"o" long:"output" default:"." description:"The directory to write the file to; the package is named after it."`
+}
+
+// Execute executes a generate entity command. The file is named after the
+// struct, e.g. user_profile.go, and an existing file is never overwritten.
+func (c *GenerateEntity) Execute(args []string) error {
+	pkg, err := packageNameFromDir(c.Output)
+	if err != nil {
+		return err
+	}
+	src, err := generateEntity(pkg, c.Name, splitFieldSpecs(c.PartitionKeys), splitFieldSpecs(c.ClusterKeys), splitFieldSpecs([]string{c.Fields}))
+	if err != nil {
+		return err
+	}
+	if err := validateGeneratedEntity(src); err != nil {
+		return err
+	}
+
+	path := filepath.Join(c.Output, goFileName(c.Name))
+	if _, err := os.Stat(path); err == nil {
+		return errors.Errorf("%q already exists", path)
+	}
+	if err := os.MkdirAll(c.Output, 0755); err != nil {
+		return errors.Wrapf(err, "could not create output directory %q", c.Output)
+	}
+	if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		return errors.Wrapf(err, "could not write %q", path)
+	}
+	fmt.Printf("wrote %s\n", path)
+	return nil
+}
+
+// entityFieldTypes maps the type names accepted by generate entity, both DOSA
+// type names and Go types, to the Go type of the generated field
+var entityFieldTypes = map[string]string{
+	"uuid":      "dosa.UUID",
+	"tuuid":     "dosa.UUID",
+	"dosa.uuid": "dosa.UUID",
+	"string":    "string",
+	"int32":     "int32",
+	"int64":     "int64",
+	"double":    "float64",
+	"float64":   "float64",
+	"blob":      "[]byte",
+	"[]byte":    "[]byte",
+	"timestamp": "time.Time",
+	"time.time": "time.Time",
+	"bool":      "bool",
+}
+
+var entityTemplate = template.Must(template.New("entity").Parse(`package {{.Package}}
+
+import (
+{{- if .ImportTime}}
+	"time"
+{{end}}
+	"github.com/uber-go/dosa"
+)
+
+// {{.StructName}} is a DOSA entity. See https://github.com/uber-go/dosa/wiki
+// for the annotations DOSA supports, such as indexes, renamed columns and TTLs.
+type {{.StructName}} struct {
+	dosa.Entity ` + "`{{.EntityTag}}`" + `
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+`))
+
+// splitFieldSpecs splits comma separated field specs and drops empty ones
+func splitFieldSpecs(specs []string) []string {
+	var fields []string
+	for _, s := range specs {
+		for _, f := range strings.Split(s, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// parseEntityField parses a Name:Type field spec; key fields may also have a
+// third ASC or DESC part, and can't be nullable
+func parseEntityField(spec string, key bool) (generatedField, string, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && !key) {
+		return generatedField{}, "", errors.Errorf("invalid field %q, expected Name:Type", spec)
+	}
+	name, typ := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if !isExportedIdent(name) {
+		return generatedField{}, "", errors.Errorf("invalid field %q, %q is not an exported Go name", spec, name)
+	}
+	nullable := strings.HasPrefix(typ, "*")
+	if nullable && key {
+		return generatedField{}, "", errors.Errorf("invalid field %q, key fields can't be nullable", spec)
+	}
+	goType, ok := entityFieldTypes[strings.ToLower(strings.TrimPrefix(typ, "*"))]
+	if !ok {
+		return generatedField{}, "", errors.Errorf("invalid field %q, unknown type %q", spec, typ)
+	}
+	if nullable && goType != "[]byte" {
+		goType = "*" + goType
+	}
+	var order string
+	if len(parts) == 3 {
+		order = strings.ToUpper(strings.TrimSpace(parts[2]))
+		if order != "ASC" && order != "DESC" {
+			return generatedField{}, "", errors.Errorf("invalid field %q, the order must be asc or desc", spec)
+		}
+	}
+	return generatedField{Name: name, Type: goType}, order, nil
+}
+
+// generateEntity returns the formatted source of a DOSA entity struct with the
+// given key and value fields
+func generateEntity(pkg, structName string, partitionKeys, clusterKeys, fields []string) ([]byte, error) {
+	if !isExportedIdent(structName) {
+		return nil, errors.Errorf("%q is not an exported Go name", structName)
+	}
+	if len(partitionKeys) == 0 {
+		return nil, errors.New("at least one partition key is required")
+	}
+	e := generatedEntity{Package: pkg, StructName: structName}
+	seen := map[string]bool{"Entity": true}
+	add := func(spec string, key bool) (generatedField, string, error) {
+		field, order, err := parseEntityField(spec, key)
+		if err != nil {
+			return field, order, err
+		}
+		if seen[field.Name] {
+			return field, order, errors.Errorf("duplicate field name %q", field.Name)
+		}
+		seen[field.Name] = true
+		if strings.Contains(field.Type, "time.Time") {
+			e.ImportTime = true
+		}
+		e.Fields = append(e.Fields, field)
+		return field, order, nil
+	}
+
+	var pks, cks []string
+	for _, spec := range partitionKeys {
+		field, order, err := add(spec, true)
+		if err != nil {
+			return nil, err
+		}
+		if order != "" {
+			return nil, errors.Errorf("invalid field %q, partition keys have no order", spec)
+		}
+		pks = append(pks, field.Name)
+	}
+	for _, spec := range clusterKeys {
+		field, order, err := add(spec, true)
+		if err != nil {
+			return nil, err
+		}
+		if order == "" {
+			order = "ASC"
+		}
+		cks = append(cks, field.Name+" "+order)
+	}
+	for _, spec := range fields {
+		if _, _, err := add(spec, false); err != nil {
+			return nil, err
+		}
+	}
+
+	pk := strings.Join(pks, ", ")
+	if len(pks) > 1 {
+		pk = "(" + pk + ")"
+	}
+	e.EntityTag = fmt.Sprintf(`dosa:"primaryKey=(%s)"`, strings.Join(append([]string{pk}, cks...), ", "))
+
+	var buf bytes.Buffer
+	if err := entityTemplate.Execute(&buf, e); err != nil {
+		return nil, errors.Wrap(err, "could not generate entity")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not format generated entity")
+	}
+	return src, nil
+}
+
+// validateGeneratedEntity parses the generated source the way schema commands
+// do, so that an invalid entity is reported before anything is written
+func validateGeneratedEntity(src []byte) error {
+	dir, err := ioutil.TempDir("", "dosa-entity")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := ioutil.WriteFile(filepath.Join(dir, "entity.go"), src, 0644); err != nil {
+		return err
+	}
+	entities, warns, err := dosa.FindEntities([]string{dir}, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not parse generated entity")
+	}
+	if len(warns) > 0 {
+		return errors.Wrap(dosa.NewEntityErrors(warns), "invalid entity")
+	}
+	if len(entities) != 1 {
+		return errors.Errorf("expected one entity in the generated source, found %d", len(entities))
+	}
+	return nil
+}
+
+// packageNameFromDir returns a package name for files in dir: its base name,
+// lowercased, without the characters Go doesn't allow
+func packageNameFromDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid output directory %q", dir)
+	}
+	var b bytes.Buffer
+	for _, r := range strings.ToLower(filepath.Base(abs)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		return "", errors.Errorf("cannot name a package after the output directory %q", dir)
+	}
+	return name, nil
+}
+
+// goFileName returns the snake case file name for a struct, e.g.
+// user_profile.go for UserProfile and http_log.go for HTTPLog
+func goFileName(structName string) string {
+	runes := []rune(structName)
+	var b bytes.Buffer
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String() + ".go"
+}
+
+// isExportedIdent returns true if name is an exported Go identifier
+func isExportedIdent(name string) bool {
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
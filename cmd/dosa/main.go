@@ -57,7 +57,8 @@ type BuildInfo struct {
 
 // String satisfies Stringer interface
 func (b BuildInfo) String() string {
-	return fmt.Sprintf("Version:\t%s\nGit Commit:\t%s\nUTC Build Time:\t%s", version, githash, timestamp)
+	return fmt.Sprintf("Version:\t%s\nLibrary Version:\t%s\nSchema Format:\t%d\nGit Commit:\t%s\nUTC Build Time:\t%s",
+		version, dosa.Version, dosa.SchemaFormatVersion, githash, timestamp)
 }
 
 // Execute is ran for the version subcommand
@@ -103,13 +104,20 @@ dosa manages your schema both in production and development scopes`
 	c, _ = OptionsParser.AddCommand("schema", "commands to manage schemas", "check or update schemas", &SchemaOptions{})
 	_, _ = c.AddCommand("check", "Check schema", "check the schema", newSchemaCheck(provideAdminClient))
 	_, _ = c.AddCommand("upsert", "Upsert schema", "insert or update the schema", newSchemaUpsert(provideAdminClient))
+	_, _ = c.AddCommand("drop", "Drop tables", "drop the tables of the entities, and all of their data", newSchemaDrop(provideAdminClient))
 	_, _ = c.AddCommand("dump", "Dump schema", "display the schema in a given format", &SchemaDump{})
+	_, _ = c.AddCommand("export", "Export schema", "write the schema of each entity to a YAML file", &SchemaExport{})
+	_, _ = c.AddCommand("import", "Import schema", "check or upsert the schema exported to YAML files", newSchemaImport(provideAdminClient))
 	_, _ = c.AddCommand("status", "Check schema status", "Check application status of schema", newSchemaStatus(provideAdminClient))
 
 	c, _ = OptionsParser.AddCommand("query", "commands to do query", "fetch one or multiple rows", &QueryOptions{})
 	_, _ = c.AddCommand("read", "Read query", "read a row by primary keys", newQueryRead(provideShellQueryClient))
 	_, _ = c.AddCommand("range", "Range query", "read rows with range of primary keys and indexes", newQueryRange(provideShellQueryClient))
 
+	c, _ = OptionsParser.AddCommand("generate", "commands to generate code", "generate DOSA entities", &GenerateOptions{})
+	_, _ = c.AddCommand("entity", "Generate an entity", "scaffold a Go file with a new DOSA entity", &GenerateEntity{})
+	_, _ = c.AddCommand("from-sql", "Generate from SQL", "generate a DOSA entity from the schema of an existing SQL table", newGenerateFromSQL(provideSQLSchemaReader))
+
 	// TODO: implement admin subcommand
 	// c, _ = OptionsParser.AddCommand("admin", "commands to administrate", "", &AdminOptions{})
 
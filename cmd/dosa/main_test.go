@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
 )
 
 func TestNoSubcommand(t *testing.T) {
@@ -95,6 +96,8 @@ func TestVersionFlag(t *testing.T) {
 	main()
 	output := c.stop(false)
 	assert.Contains(t, output, "Version:")
+	assert.Contains(t, output, "Library Version:\t"+dosa.Version)
+	assert.Contains(t, output, "Schema Format:\t1")
 	assert.Contains(t, output, "Git Commit:")
 	assert.Contains(t, output, "UTC Build Time:")
 }
@@ -106,6 +109,8 @@ func TestVersionCommand(t *testing.T) {
 	main()
 	output := c.stop(false)
 	assert.Contains(t, output, "Version:")
+	assert.Contains(t, output, "Library Version:\t"+dosa.Version)
+	assert.Contains(t, output, "Schema Format:\t1")
 	assert.Contains(t, output, "Git Commit:")
 	assert.Contains(t, output, "UTC Build Time:")
 }
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -35,6 +36,7 @@ import (
 	"github.com/uber-go/dosa/schema/avro"
 	"github.com/uber-go/dosa/schema/cql"
 	"github.com/uber-go/dosa/schema/uql"
+	"github.com/uber-go/dosa/schema/yaml"
 )
 
 var (
@@ -49,6 +51,7 @@ type scopeFlag string
 
 const schemaCheck = "schema check"
 const schemaUpsert = "schema upsert"
+const schemaDrop = "schema drop"
 const java = "java"
 
 func (s *scopeFlag) setString(value string) {
@@ -67,8 +70,10 @@ func (s *scopeFlag) UnmarshalFlag(value string) error {
 
 // SchemaOptions contains configuration for schema command flags.
 type SchemaOptions struct {
-	Excludes []string `short:"e" long:"exclude" description:"Exclude files matching pattern."`
-	Verbose  bool     `short:"v" long:"verbose"`
+	Excludes    []string `short:"e" long:"exclude" description:"Exclude files matching pattern."`
+	Entity      string   `long:"entity" description:"Limit the operation to the entity with this struct or entity name."`
+	SchemaFiles []string `long:"schema-file" description:"Read the entities from this YAML file instead of Go source; can be repeated."`
+	Verbose     bool     `short:"v" long:"verbose"`
 }
 
 // SchemaCmd is a placeholder for all schema commands
@@ -80,6 +85,8 @@ type SchemaCmd struct {
 	JarPath       string    `short:"j" long:"jarpath" description:"Path of the jar. This jar contains schema entities."`
 	ClassNames    []string  `short:"c" long:"classnames" description:"Classes contain schema."`
 	provideClient adminClientProvider
+	// definitions replace the entities found in the paths when set
+	definitions []*dosa.EntityDefinition
 }
 
 func getNamePrefix(namePrefix, prefix string) (string, error) {
@@ -129,6 +136,15 @@ func (c *SchemaCmd) doSchemaOp(name string, f func(dosa.AdminClient, context.Con
 	if len(c.Excludes) != 0 {
 		client.Excludes(c.Excludes)
 	}
+	if c.Entity != "" {
+		client.Entity(c.Entity)
+	}
+	if len(c.SchemaFiles) != 0 {
+		client.SchemaFiles(c.SchemaFiles)
+	}
+	if len(c.definitions) != 0 {
+		client.Definitions(c.definitions)
+	}
 	if c.Scope != "" {
 		client.Scope(c.Scope.String())
 	}
@@ -241,6 +257,33 @@ func (c *SchemaUpsert) Execute(args []string) error {
 	return c.doSchemaOp(schemaUpsert, dosa.AdminClient.UpsertSchema, c.Args.Paths)
 }
 
+// SchemaDrop contains data for executing schema drop command.
+type SchemaDrop struct {
+	*SchemaCmd
+	Args struct {
+		Paths []string `positional-arg-name:"paths"`
+	} `positional-args:"yes"`
+}
+
+func newSchemaDrop(provideClient adminClientProvider) *SchemaDrop {
+	return &SchemaDrop{
+		SchemaCmd: &SchemaCmd{
+			provideClient: provideClient,
+		},
+	}
+}
+
+// Execute executes a schema drop command, dropping the tables of the entities
+// and all of their data
+func (c *SchemaDrop) Execute(args []string) error {
+	return c.doSchemaOp(schemaDrop, func(client dosa.AdminClient, ctx context.Context, namePrefix string) (*dosa.SchemaStatus, error) {
+		if err := client.DropTables(ctx, namePrefix); err != nil {
+			return nil, err
+		}
+		return &dosa.SchemaStatus{Version: dosa.InvalidVersion, Status: "dropped"}, nil
+	}, c.Args.Paths)
+}
+
 // SchemaStatus contains data for executing schema status command
 type SchemaStatus struct {
 	*SchemaCmd
@@ -337,6 +380,12 @@ func (c *SchemaDump) Execute(args []string) error {
 	if len(c.Excludes) != 0 {
 		client.Excludes(c.Excludes)
 	}
+	if c.Entity != "" {
+		client.Entity(c.Entity)
+	}
+	if len(c.SchemaFiles) != 0 {
+		client.SchemaFiles(c.SchemaFiles)
+	}
 
 	// try to parse entities in each directory
 	defs, err := client.GetSchema()
@@ -360,6 +409,168 @@ func (c *SchemaDump) Execute(args []string) error {
 	return nil
 }
 
+// SchemaExport contains data for executing the schema export command
+type SchemaExport struct {
+	*SchemaOptions
+	Output string `short:"o" long:"output" description:"Directory to write the YAML files to." default:"schemas"`
+	Args   struct {
+		Paths []string `positional-arg-name:"paths"`
+	} `positional-args:"yes"`
+}
+
+// Execute executes a schema export command. Every entity is written to
+// <entity name>.yaml in the output directory, overwriting the existing file.
+// Files of entities that no longer exist are removed.
+func (c *SchemaExport) Execute(args []string) error {
+	if c.Verbose {
+		fmt.Printf("executing schema export with %v\n", args)
+		fmt.Printf("options are %+v\n", *c)
+		fmt.Printf("global options are %+v\n", options)
+	}
+
+	// no connection necessary
+	client := dosa.NewAdminClient(&devnull.Connector{})
+	if len(c.Args.Paths) != 0 {
+		dirs, err := expandDirectories(c.Args.Paths)
+		if err != nil {
+			return errors.Wrap(err, "could not expand directories")
+		}
+		client.Directories(dirs)
+	}
+	if len(c.Excludes) != 0 {
+		client.Excludes(c.Excludes)
+	}
+	if c.Entity != "" {
+		client.Entity(c.Entity)
+	}
+	if len(c.SchemaFiles) != 0 {
+		client.SchemaFiles(c.SchemaFiles)
+	}
+
+	defs, err := client.GetSchema()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.Output, 0755); err != nil {
+		return errors.Wrapf(err, "could not create output directory %q", c.Output)
+	}
+	exported := make(map[string]bool, len(defs))
+	for _, d := range defs {
+		data, err := yaml.ToYAML(d)
+		if err != nil {
+			return errors.Wrapf(err, "could not export entity %q", d.Name)
+		}
+		name := d.Name + ".yaml"
+		if err := ioutil.WriteFile(filepath.Join(c.Output, name), data, 0644); err != nil {
+			return errors.Wrapf(err, "could not write %q", name)
+		}
+		exported[name] = true
+		if c.Verbose {
+			fmt.Printf("exported %s to %s\n", d.Name, filepath.Join(c.Output, name))
+		}
+	}
+
+	// the files left over from a previous export are deleted entities; only
+	// files with a fingerprint were written by us
+	files, err := filepath.Glob(filepath.Join(c.Output, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if exported[filepath.Base(file)] {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return errors.Wrapf(err, "could not read %q", file)
+		}
+		if yaml.ReadFingerprint(data) == "" {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return errors.Wrapf(err, "could not remove %q", file)
+		}
+		if c.Verbose {
+			fmt.Printf("removed %s\n", file)
+		}
+	}
+
+	return nil
+}
+
+// SchemaImport contains data for executing the schema import command
+type SchemaImport struct {
+	*SchemaCmd
+	Check bool `long:"check" description:"Only check that the schema can be upserted."`
+	Args  struct {
+		Paths []string `positional-arg-name:"paths" required:"1"`
+	} `positional-args:"yes"`
+}
+
+func newSchemaImport(provideClient adminClientProvider) *SchemaImport {
+	return &SchemaImport{
+		SchemaCmd: &SchemaCmd{
+			provideClient: provideClient,
+		},
+	}
+}
+
+// Execute executes a schema import command. It reads back the YAML files
+// written by schema export, or the files exported to a directory, and checks
+// or upserts their entities like schema check and schema upsert do.
+func (c *SchemaImport) Execute(args []string) error {
+	defs, err := readSchemaExports(c.Args.Paths)
+	if err != nil {
+		return err
+	}
+	c.definitions = defs
+	if c.Check {
+		return c.doSchemaOp(schemaCheck, dosa.AdminClient.CanUpsertSchema, nil)
+	}
+	return c.doSchemaOp(schemaUpsert, dosa.AdminClient.UpsertSchema, nil)
+}
+
+// readSchemaExports reads the entity definitions of the YAML files. The
+// directories are searched for the *.yaml files that schema export wrote,
+// which have a fingerprint; other files in them are skipped.
+func readSchemaExports(paths []string) ([]*dosa.EntityDefinition, error) {
+	var defs []*dosa.EntityDefinition
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read %q", path)
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = filepath.Glob(filepath.Join(path, "*.yaml")); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not read %q", file)
+			}
+			if info.IsDir() && yaml.ReadFingerprint(data) == "" {
+				continue
+			}
+			def, err := yaml.FromYAML(data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not import %q", file)
+			}
+			if err := def.EnsureValid(); err != nil {
+				return nil, errors.Wrapf(err, "could not import %q", file)
+			}
+			defs = append(defs, def)
+		}
+	}
+	if len(defs) == 0 {
+		return nil, errors.Errorf("no exported entities found in %v", paths)
+	}
+	return defs, nil
+}
+
 func (c *SchemaDump) doSchemaDumpInJavaClient() {
 	var format string
 
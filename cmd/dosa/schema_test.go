@@ -23,14 +23,20 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/devnull"
 	"github.com/uber-go/dosa/mocks"
+	"github.com/uber-go/dosa/schema/yaml"
 )
 
 func getTestEntityNameMap() map[string]bool {
@@ -422,6 +428,164 @@ func TestSchema_Upsert_Happy(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSchema_Import(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "dosa-import")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defs, err := dosa.NewAdminClient(&devnull.Connector{}).Directories([]string{"../../testentity"}).GetSchema()
+	assert.NoError(t, err)
+	// several test entities share a name, so the files are numbered
+	var fingerprints []string
+	for i, d := range defs {
+		data, err := yaml.ToYAML(d)
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.yaml", i)), data, 0644))
+		fingerprints = append(fingerprints, yaml.ReadFingerprint(data))
+	}
+	sort.Strings(fingerprints)
+	// files that schema export didn't write are skipped
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.yaml"), []byte("name: other\n"), 0644))
+
+	mc := mocks.NewMockConnector(ctrl)
+	mc.EXPECT().UpsertSchema(gomock.Any(), "scope", "foo", gomock.Any()).
+		Do(func(ctx context.Context, scope string, namePrefix string, ed []*dosa.EntityDefinition) {
+			var imported []string
+			for _, e := range ed {
+				fp, err := yaml.Fingerprint(e)
+				assert.NoError(t, err)
+				imported = append(imported, fp)
+			}
+			sort.Strings(imported)
+			assert.Equal(t, fingerprints, imported)
+		}).Return(&dosa.SchemaStatus{Version: int32(1)}, nil)
+	mc.EXPECT().CanUpsertSchema(gomock.Any(), "scope", "foo", gomock.Any()).
+		Do(func(ctx context.Context, scope string, namePrefix string, ed []*dosa.EntityDefinition) {
+			assert.Len(t, ed, 1)
+		}).Return(int32(1), nil)
+	mc.EXPECT().Shutdown().Return(nil).Times(2)
+	provideClient := func(opts GlobalOptions) (dosa.AdminClient, error) {
+		return dosa.NewAdminClient(mc), nil
+	}
+
+	schemaImport := newSchemaImport(provideClient)
+	schemaImport.SchemaOptions = &SchemaOptions{}
+	schemaImport.Scope = scopeFlag("scope")
+	schemaImport.NamePrefix = "foo"
+	schemaImport.Args.Paths = []string{dir}
+	assert.NoError(t, schemaImport.Execute(nil))
+
+	// a single file, with --check
+	schemaImport.Check = true
+	schemaImport.Args.Paths = []string{filepath.Join(dir, "0.yaml")}
+	assert.NoError(t, schemaImport.Execute(nil))
+
+	// a file given explicitly must be a valid export
+	schemaImport.Args.Paths = []string{filepath.Join(dir, "other.yaml")}
+	err = schemaImport.Execute(nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not import")
+
+	empty, err := ioutil.TempDir("", "dosa-import-empty")
+	assert.NoError(t, err)
+	defer os.RemoveAll(empty)
+	schemaImport.Args.Paths = []string{empty}
+	err = schemaImport.Execute(nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no exported entities found")
+}
+
+func TestSchema_Drop_Entity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mc := mocks.NewMockConnector(ctrl)
+	mc.EXPECT().DropTable(gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, ei *dosa.EntityInfo) {
+			dl, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.True(t, dl.After(time.Now()))
+			assert.Equal(t, &dosa.SchemaRef{Scope: "scope", NamePrefix: "foo", EntityName: "named_import_entity"}, ei.Ref)
+			assert.Equal(t, "named_import_entity", ei.Def.Name)
+		}).Return(nil)
+	mc.EXPECT().Shutdown().Return(nil)
+
+	provideClient := func(opts GlobalOptions) (dosa.AdminClient, error) {
+		return dosa.NewAdminClient(mc), nil
+	}
+
+	schemaDrop := SchemaDrop{
+		SchemaCmd: &SchemaCmd{
+			SchemaOptions: &SchemaOptions{
+				Excludes: []string{"_test.go", "excludeme.go"},
+				Entity:   "TestNamedImportEntity",
+			},
+			Scope:         scopeFlag("scope"),
+			NamePrefix:    "foo",
+			provideClient: provideClient,
+		},
+	}
+	schemaDrop.Args.Paths = []string{"../../testentity"}
+
+	err := schemaDrop.Execute(nil)
+	assert.NoError(t, err)
+}
+
+func TestSchema_Check_UnknownEntity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mc := mocks.NewMockConnector(ctrl)
+	mc.EXPECT().Shutdown().Return(nil)
+
+	provideClient := func(opts GlobalOptions) (dosa.AdminClient, error) {
+		return dosa.NewAdminClient(mc), nil
+	}
+
+	schemaCheck := SchemaCheck{
+		SchemaCmd: &SchemaCmd{
+			SchemaOptions: &SchemaOptions{
+				Entity: "NoSuchEntity",
+			},
+			Scope:         scopeFlag("scope"),
+			NamePrefix:    "foo",
+			provideClient: provideClient,
+		},
+	}
+	schemaCheck.Args.Paths = []string{"../../testentity"}
+
+	err := schemaCheck.Execute(nil)
+	assert.Contains(t, err.Error(), `entity "NoSuchEntity" not found`)
+}
+
+func TestSchema_Dump_Entity(t *testing.T) {
+	c := StartCapture()
+	exit = func(r int) {}
+	os.Args = []string{"dosa", "schema", "dump", "--entity", "TestNamedImportEntity", "../../testentity"}
+	main()
+	output := c.stop(false)
+	assert.Contains(t, output, "create table \"named_import_entity\"")
+	assert.NotContains(t, output, "awesome_test_entity")
+}
+
+func TestSchema_Dump_SchemaFile(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "schemafile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	path := filepath.Join(tmpdir, "users.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("name: users\ncolumns:\n- {name: id, type: UUID, key: partition}\n"), 0600))
+
+	c := StartCapture()
+	exit = func(r int) {}
+	os.Args = []string{"dosa", "schema", "dump", "--schema-file", path}
+	main()
+	output := c.stop(false)
+	assert.Contains(t, output, "create table \"users\" (\"id\" uuid")
+	assert.NotContains(t, output, "awesome_test_entity")
+}
+
 func TestSchema_Dump_InvalidFormat(t *testing.T) {
 	c := StartCapture()
 	exit = func(r int) {}
@@ -460,3 +624,31 @@ func TestSchema_Dump_Avro(t *testing.T) {
 	output := c.stop(false)
 	assert.Contains(t, output, "executing schema dump")
 }
+
+func TestSchema_Export(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dosa-export")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a file from a previous export of a deleted entity, and an unrelated file
+	stale := filepath.Join(dir, "deleted_entity.yaml")
+	assert.NoError(t, ioutil.WriteFile(stale, []byte("# DOSA schema version 1\n# fingerprint: sha256:00\nname: deleted_entity\n"), 0644))
+	other := filepath.Join(dir, "other.yaml")
+	assert.NoError(t, ioutil.WriteFile(other, []byte("name: other\n"), 0644))
+
+	c := StartCapture()
+	exit = func(r int) {}
+	os.Args = []string{"dosa", "schema", "export", "-v", "-o", dir, "../../testentity"}
+	main()
+	output := c.stop(false)
+	assert.Contains(t, output, "executing schema export")
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "awesome_test_entity.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# DOSA schema version 2\n# fingerprint: sha256:")
+	assert.Contains(t, string(data), "name: awesome_test_entity\n")
+	_, err = os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(other)
+	assert.NoError(t, err)
+}
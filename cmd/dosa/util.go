@@ -79,27 +79,27 @@ func strToFieldValue(t dosa.Type, s string) (dosa.FieldValue, error) {
 		if err != nil {
 			return nil, err
 		}
-		return dosa.FieldValue(int32(i)), nil
+		return dosa.Int32Value(int32(i)), nil
 	case dosa.Int64:
 		i, err := strconv.ParseInt(s, 10, 64)
 		if err != nil {
 			return nil, err
 		}
-		return dosa.FieldValue(int64(i)), nil
+		return dosa.Int64Value(i), nil
 	case dosa.Bool:
 		b, err := strconv.ParseBool(s)
 		if err != nil {
 			return nil, err
 		}
-		return dosa.FieldValue(b), nil
+		return dosa.BoolValue(b), nil
 	case dosa.String:
-		return dosa.FieldValue(s), nil
+		return dosa.StringValue(s), nil
 	case dosa.Double:
 		d, err := strconv.ParseFloat(s, 64)
 		if err != nil {
 			return nil, err
 		}
-		return dosa.FieldValue(d), nil
+		return dosa.DoubleValue(d), nil
 	case dosa.Timestamp:
 		t, err := time.Parse(time.RFC3339Nano, s)
 		if err != nil {
@@ -111,12 +111,11 @@ func strToFieldValue(t dosa.Type, s string) (dosa.FieldValue, error) {
 			if i < 0 {
 				return nil, errors.Errorf("timestamp should not be negative")
 			}
-			return dosa.FieldValue(time.Unix(0, i*int64(time.Millisecond)).UTC()), nil
+			return dosa.TimestampValue(time.Unix(0, i*int64(time.Millisecond)).UTC()), nil
 		}
-		return dosa.FieldValue(t), nil
+		return dosa.TimestampValue(t), nil
 	case dosa.TUUID:
-		u := dosa.UUID(s)
-		return dosa.FieldValue(u), nil
+		return dosa.UUIDValue(dosa.UUID(s)), nil
 	case dosa.Blob:
 		// TODO: support query with binary arrays
 		return nil, errors.Errorf("blob query not supported for now")
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Codec converts field values to and from the wire types of a backend, e.g.
+// a DynamoDB AttributeValue. Connectors that serialize values look up their
+// codec instead of hard-coding the conversions, so that custom codecs can
+// handle specialized types without forking the connector.
+type Codec interface {
+	// Encode converts a value of a column of the given type to the wire type
+	Encode(typ Type, val FieldValue) (interface{}, error)
+	// Decode converts a wire value of a column of the given type back to a field value
+	Decode(typ Type, raw interface{}) (FieldValue, error)
+}
+
+// IdentityCodec is a Codec that passes values through unchanged. It's used by
+// connectors that store field values as they are, like the memory connector.
+var IdentityCodec Codec = identityCodec{}
+
+type identityCodec struct{}
+
+func (identityCodec) Encode(_ Type, val FieldValue) (interface{}, error) {
+	return val, nil
+}
+
+func (identityCodec) Decode(_ Type, raw interface{}) (FieldValue, error) {
+	return raw, nil
+}
+
+// EncodeValues encodes each of the values with the codec, using the types of
+// the entity's columns
+func EncodeValues(codec Codec, ed *EntityDefinition, values map[string]FieldValue) (map[string]interface{}, error) {
+	types := ed.ColumnTypes()
+	encoded := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		typ, ok := types[name]
+		if !ok {
+			return nil, errors.Errorf("column %q not found in entity %q", name, ed.Name)
+		}
+		raw, err := codec.Encode(typ, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot encode column %q of entity %q", name, ed.Name)
+		}
+		encoded[name] = raw
+	}
+	return encoded, nil
+}
+
+// DecodeValues decodes each of the wire values with the codec, using the
+// types of the entity's columns
+func DecodeValues(codec Codec, ed *EntityDefinition, raw map[string]interface{}) (map[string]FieldValue, error) {
+	types := ed.ColumnTypes()
+	decoded := make(map[string]FieldValue, len(raw))
+	for name, value := range raw {
+		typ, ok := types[name]
+		if !ok {
+			return nil, errors.Errorf("column %q not found in entity %q", name, ed.Name)
+		}
+		fv, err := codec.Decode(typ, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decode column %q of entity %q", name, ed.Name)
+		}
+		decoded[name] = fv
+	}
+	return decoded, nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// bytesCodec stores strings as byte slices and rejects everything else
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(typ Type, val FieldValue) (interface{}, error) {
+	if typ != String {
+		return nil, errors.Errorf("unsupported type %s", typ)
+	}
+	return []byte(val.(string)), nil
+}
+
+func (bytesCodec) Decode(typ Type, raw interface{}) (FieldValue, error) {
+	if typ != String {
+		return nil, errors.Errorf("unsupported type %s", typ)
+	}
+	return string(raw.([]byte)), nil
+}
+
+func codecTestDefinition() *EntityDefinition {
+	return &EntityDefinition{
+		Name: "t",
+		Key:  &PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*ColumnDefinition{
+			{Name: "id", Type: Int64},
+			{Name: "name", Type: String},
+		},
+	}
+}
+
+func TestIdentityCodec(t *testing.T) {
+	raw, err := IdentityCodec.Encode(Int64, int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), raw)
+
+	fv, err := IdentityCodec.Decode(Int64, raw)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), fv)
+}
+
+func TestEncodeDecodeValues(t *testing.T) {
+	ed := codecTestDefinition()
+	values := map[string]FieldValue{"name": "foo"}
+
+	raw, err := EncodeValues(bytesCodec{}, ed, values)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": []byte("foo")}, raw)
+
+	decoded, err := DecodeValues(bytesCodec{}, ed, raw)
+	assert.NoError(t, err)
+	assert.Equal(t, values, decoded)
+}
+
+func TestEncodeDecodeValuesErrors(t *testing.T) {
+	ed := codecTestDefinition()
+
+	_, err := EncodeValues(bytesCodec{}, ed, map[string]FieldValue{"id": int64(1)})
+	assert.Contains(t, err.Error(), "cannot encode column \"id\"")
+	_, err = EncodeValues(bytesCodec{}, ed, map[string]FieldValue{"nope": "x"})
+	assert.Contains(t, err.Error(), "column \"nope\" not found")
+
+	_, err = DecodeValues(bytesCodec{}, ed, map[string]interface{}{"id": []byte("1")})
+	assert.Contains(t, err.Error(), "cannot decode column \"id\"")
+	_, err = DecodeValues(bytesCodec{}, ed, map[string]interface{}{"nope": []byte("x")})
+	assert.Contains(t, err.Error(), "column \"nope\" not found")
+}
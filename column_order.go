@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SortDirection is the direction of a column in the order of Range results
+type SortDirection int
+
+const (
+	// Ascending sorts the smallest values first
+	Ascending SortDirection = iota
+	// Descending sorts the largest values first
+	Descending
+)
+
+// String returns "ASC" or "DESC"
+func (d SortDirection) String() string {
+	if d == Descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// ToSortDirection returns the direction named by s, "asc" or "desc" in any case
+func ToSortDirection(s string) (SortDirection, error) {
+	switch strings.ToLower(s) {
+	case asc:
+		return Ascending, nil
+	case desc:
+		return Descending, nil
+	}
+	return Ascending, errors.Errorf("invalid sort direction %q, should be %q or %q", s, asc, desc)
+}
+
+// orderedByString returns the String of the direction, or "" for nil
+func orderedByString(d *SortDirection) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+// orderedByFromString reverses orderedByString
+func orderedByFromString(s string) (*SortDirection, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := ToSortDirection(s)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ColumnOrder is a column of the order of Range results and its direction
+type ColumnOrder struct {
+	Column    string
+	Direction SortDirection
+}
+
+// sortColumnsKey is the context key of the sort columns of a Range
+type sortColumnsKey struct{}
+
+// WithSortColumns returns a copy of the context that carries the sort columns
+// of a Range to the connector, by column name. Client.Range sets it from
+// RangeOp.WithSortColumns.
+func WithSortColumns(ctx context.Context, cols []ColumnOrder) context.Context {
+	return context.WithValue(ctx, sortColumnsKey{}, cols)
+}
+
+// SortColumnsFromContext returns the sort columns requested for a Range with
+// RangeOp.WithSortColumns, and whether there were any. Connectors check them
+// with ReverseSort.
+func SortColumnsFromContext(ctx context.Context) ([]ColumnOrder, bool) {
+	cols, ok := ctx.Value(sortColumnsKey{}).([]ColumnOrder)
+	return cols, ok && len(cols) > 0
+}
+
+// ReverseSort returns whether the requested sort order is the reverse of the
+// order of the clustering keys in pk. The columns must be a prefix of the
+// clustering keys, and either all have the direction of the schema or all
+// have the opposite direction, because other orders can only be had by
+// sorting the whole partition.
+func ReverseSort(pk *PrimaryKey, cols []ColumnOrder) (bool, error) {
+	if len(cols) > len(pk.ClusteringKeys) {
+		return false, errors.Errorf("cannot sort on %d columns, there are only %d clustering keys", len(cols), len(pk.ClusteringKeys))
+	}
+	reverse := false
+	for i, col := range cols {
+		ck := pk.ClusteringKeys[i]
+		if col.Column != ck.Name {
+			return false, errors.Errorf("sort column %d is %q, but the clustering key is %q", i, col.Column, ck.Name)
+		}
+		opposite := (col.Direction == Descending) != ck.Descending
+		if i > 0 && opposite != reverse {
+			return false, errors.Errorf("sort direction %s of %q doesn't match the clustering key order", col.Direction, col.Column)
+		}
+		reverse = opposite
+	}
+	return reverse, nil
+}
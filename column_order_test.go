@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortColumnsFromContext(t *testing.T) {
+	_, ok := SortColumnsFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = SortColumnsFromContext(WithSortColumns(context.Background(), nil))
+	assert.False(t, ok)
+
+	cols := []ColumnOrder{{Column: "ts", Direction: Descending}}
+	got, ok := SortColumnsFromContext(WithSortColumns(context.Background(), cols))
+	assert.True(t, ok)
+	assert.Equal(t, cols, got)
+}
+
+func TestReverseSort(t *testing.T) {
+	pk := &PrimaryKey{
+		PartitionKeys: []string{"id"},
+		ClusteringKeys: []*ClusteringKey{
+			{Name: "day"},
+			{Name: "ts", Descending: true},
+		},
+	}
+	tests := []struct {
+		cols    []ColumnOrder
+		reverse bool
+		err     string
+	}{
+		{cols: nil},
+		{cols: []ColumnOrder{{"day", Ascending}}},
+		{cols: []ColumnOrder{{"day", Ascending}, {"ts", Descending}}},
+		{cols: []ColumnOrder{{"day", Descending}}, reverse: true},
+		{cols: []ColumnOrder{{"day", Descending}, {"ts", Ascending}}, reverse: true},
+		{cols: []ColumnOrder{{"day", Ascending}, {"ts", Ascending}}, err: `sort direction ASC of "ts" doesn't match the clustering key order`},
+		{cols: []ColumnOrder{{"ts", Descending}}, err: `sort column 0 is "ts", but the clustering key is "day"`},
+		{cols: []ColumnOrder{{"day", Ascending}, {"ts", Descending}, {"id", Ascending}}, err: "cannot sort on 3 columns, there are only 2 clustering keys"},
+	}
+	for _, test := range tests {
+		reverse, err := ReverseSort(pk, test.cols)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.reverse, reverse)
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 //go:generate stringer -type=Operator
@@ -77,6 +79,37 @@ type EntityInfo struct {
 	TTL *time.Duration
 }
 
+// NewEntityInfo validates the table, scope and name prefix, and returns the
+// EntityInfo for calling connector methods on the table, the same one the
+// registrar would return. Prefer it to an EntityInfo literal, which can leave
+// out the schema reference or hold a definition that isn't valid.
+func NewEntityInfo(scope, namePrefix string, t *Table) (*EntityInfo, error) {
+	if t == nil {
+		return nil, errors.New("cannot create an EntityInfo without a table")
+	}
+	if scope == "" {
+		return nil, errors.Errorf("cannot create an EntityInfo for %q without a scope", t.Name)
+	}
+	if err := IsValidNamePrefix(namePrefix); err != nil {
+		return nil, errors.Wrapf(err, "cannot create an EntityInfo for %q", t.Name)
+	}
+	if err := t.EnsureValid(); err != nil {
+		return nil, errors.Wrapf(err, "cannot create an EntityInfo for %q", t.Name)
+	}
+	return NewRegisteredEntity(scope, namePrefix, t).EntityInfo(), nil
+}
+
+// WithFields returns a copy of the EntityInfo whose definition only has the
+// given columns and the primary key columns, see EntityDefinition.Projection.
+// The schema reference and TTL are shared with the original.
+func (ei *EntityInfo) WithFields(columns []string) (*EntityInfo, error) {
+	def, err := ei.Def.Projection(columns)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityInfo{Ref: ei.Ref, Def: def, TTL: ei.TTL}, nil
+}
+
 // StringSet is a set of strings.
 type StringSet map[string]struct{}
 
@@ -149,6 +182,12 @@ type SchemaStatus struct {
 // When fields are returned from read/range/scan methods, it's legal for the connector
 // to return more fields than originally requested. The caller of the connector should never mutate
 // the returned columns either, in case they are from a cache
+//
+// A column missing from the values passed to a write is not written: Upsert keeps its current
+// value, and a new row doesn't have one. A null value (a nil pointer, such as NullTimestamp) is
+// written as null. Connectors keep the two apart where the backend can, so that a column that was
+// never written is missing from the values returned by reads while a null column is returned as a
+// nil pointer. A zero value, such as the zero time.Time, is never null.
 type Connector interface {
 	// DML operations (CRUD + range + scan)
 	// CreateIfNotExists creates a row, but only if it does not exist.
@@ -161,12 +200,41 @@ type Connector interface {
 	MultiRead(ctx context.Context, ei *EntityInfo, keys []map[string]FieldValue, minimumFields []string) (results []*FieldValuesOrError, err error)
 	// Upsert updates some columns of a row, or creates a new one if it doesn't exist yet.
 	Upsert(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error
+	// CompareAndSwap updates some columns of a row, but only if the current values of the row match conditions.
+	// The conditions must contain all of the primary key columns and may contain other columns as guards.
+	// ErrNotFound is returned when the row does not exist and ErrConflict when a guard column doesn't match.
+	CompareAndSwap(ctx context.Context, ei *EntityInfo, conditions map[string]FieldValue, newValues map[string]FieldValue) error
+	// UpsertWithConditions updates some columns of an existing row, but only if the current row passes all of
+	// the conditions, which are keyed by column name and may reference any column. The check and the write
+	// are atomic. ErrNotFound is returned when the row does not exist and ErrConflict when a condition fails.
+	UpsertWithConditions(ctx context.Context, ei *EntityInfo, values map[string]FieldValue, conditions map[string][]*Condition) error
+	// UpsertAndRead works like Upsert, but also returns the whole row as it is after the write, including
+	// columns that were not in values. Connectors use a native facility (e.g. INSERT ... RETURNING) where
+	// the backend has one; otherwise this is an Upsert followed by a Read, with the latency of both.
+	UpsertAndRead(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) (map[string]FieldValue, error)
+	// Replace removes the row with the primary key in values, if there is one, and inserts values as a
+	// new row, so that columns not in values are cleared rather than kept as Upsert does. Connectors
+	// whose backend can't replace a row atomically do a Remove followed by an Upsert.
+	Replace(ctx context.Context, ei *EntityInfo, values map[string]FieldValue) error
+	// AtomicAdd adds delta to an Int64 column of the row with the given primary key and returns the
+	// new value. The row is created if it doesn't exist, and a null column counts as 0. Connectors use
+	// the backend's native atomic increment; ErrInvalidOperation is returned for columns that are not
+	// Int64 or are part of the primary key (see CheckAtomicAdd).
+	AtomicAdd(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue, column string, delta int64) (int64, error)
+	// Aggregate computes aggFunc over a column of the rows matching columnConditions, or over the whole
+	// table when there are no conditions. Connectors push the aggregation down to the backend where it
+	// can do it; otherwise the rows are read and aggregated client-side (see AggregateByScanning).
+	Aggregate(ctx context.Context, ei *EntityInfo, aggFunc AggFunc, column string, columnConditions map[string][]*Condition) (FieldValue, error)
 	// MultiUpsert updates some columns of several rows, or creates a new ones if they doesn't exist yet
 	MultiUpsert(ctx context.Context, ei *EntityInfo, multiValues []map[string]FieldValue) (result []error, err error)
 	// Remove deletes a row
 	Remove(ctx context.Context, ei *EntityInfo, keys map[string]FieldValue) error
 	// RemoveRange removes all entities in a particular range
 	RemoveRange(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) error
+	// DeletePartition removes every row of a partition; pk has the values of the partition key
+	// columns only. Connectors use the backend's native partition delete; otherwise the rows are
+	// read and removed in chunks (see DeletePartitionByScanning).
+	DeletePartition(ctx context.Context, ei *EntityInfo, pk map[string]FieldValue) error
 	// MultiRemove removes multiple rows
 	MultiRemove(ctx context.Context, ei *EntityInfo, multiKeys []map[string]FieldValue) (result []error, err error)
 	// Range does a range scan using a set of conditions.
@@ -175,6 +243,19 @@ type Connector interface {
 	// Scan reads the whole table, for doing a sequential search or dump/load use cases
 	// If minimumFields is empty or nil, all fields (including key fields) would be fetched.
 	Scan(ctx context.Context, ei *EntityInfo, minimumFields []string, token string, limit int) (multiValues []map[string]FieldValue, nextToken string, err error)
+	// CopyTable copies all the rows of src to dst, updating rows that dst already has. The primary
+	// keys must match and every column of src must exist in dst (see CheckCopyTable). Connectors
+	// copy natively where the backend can; otherwise the rows are scanned and written in pages
+	// (see CopyTableByScanning).
+	CopyTable(ctx context.Context, src, dst *EntityInfo) error
+	// ExplainQuery describes how a Range with the given conditions would be served: which index
+	// it reads, about how many rows, and whether it needs a full scan or filtering. It doesn't
+	// read or change any rows.
+	ExplainQuery(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) (*QueryPlan, error)
+	// CountRange returns the number of rows a Range with the given conditions would read.
+	// Connectors whose backend can't count return ErrNotSupported, and the client counts the
+	// rows of the range itself.
+	CountRange(ctx context.Context, ei *EntityInfo, columnConditions map[string][]*Condition) (int64, error)
 
 	// DDL operations (schema)
 	// CheckSchema validates that the set of entities you have provided is valid and registered already
@@ -191,6 +272,12 @@ type Connector interface {
 	CheckSchemaStatus(ctx context.Context, scope string, namePrefix string, version int32) (*SchemaStatus, error)
 	// GetEntitySchema returns the entity info for a given entity in a given scope and prefix.
 	GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*EntityDefinition, error)
+	// DescribeTable returns the definition of the entity as the backend currently stores it, so that
+	// it can be compared with the registered definition (see EntityDefinition.Differences).
+	DescribeTable(ctx context.Context, ei *EntityInfo) (*EntityDefinition, error)
+	// DropTable removes the entity's table and all of its rows; it's not an error if there is no
+	// such table. Later reads of the entity return ErrNotFound.
+	DropTable(ctx context.Context, ei *EntityInfo) error
 
 	// Datastore management
 	// CreateScope creates a scope for storage of data, usually implemented by a keyspace for this data
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build (linux && cgo) || (darwin && cgo)
+// +build linux,cgo darwin,cgo
+
+package dosa
+
+import (
+	"plugin"
+
+	"github.com/pkg/errors"
+)
+
+// RegisterConnectorPlugin loads a connector plugin, a shared object built with
+// "go build -buildmode=plugin", and registers its connector with
+// RegisterConnector. The plugin's main package must export:
+//
+//	var ConnectorScheme = "myscheme"
+//	func NewConnector() (dosa.Connector, error)
+//
+// Go plugins are only supported on Linux and macOS, and require cgo. The plugin
+// must be built with the same version of Go, and the same versions of every
+// package it shares with the program loading it (including this one), or
+// plugin.Open fails. Plugins can't be unloaded. See examples/_plugin-connector.
+func RegisterConnectorPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open connector plugin %q", path)
+	}
+
+	sym, err := p.Lookup(PluginSchemeSymbol)
+	if err != nil {
+		return errors.Wrapf(err, "connector plugin %q", path)
+	}
+	scheme, ok := sym.(*string)
+	if !ok {
+		return errors.Errorf("connector plugin %q: %s is a %T, not a string variable", path, PluginSchemeSymbol, sym)
+	}
+
+	sym, err = p.Lookup(PluginFactorySymbol)
+	if err != nil {
+		return errors.Wrapf(err, "connector plugin %q", path)
+	}
+	factory, ok := sym.(func() (Connector, error))
+	if !ok {
+		return errors.Errorf("connector plugin %q: %s is a %T, not a func() (dosa.Connector, error)", path, PluginFactorySymbol, sym)
+	}
+
+	return errors.Wrapf(RegisterConnector(*scheme, factory), "connector plugin %q", path)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ConnectorFactory creates a connector. Factories are registered under a
+// scheme name with RegisterConnector, or loaded from a plugin with
+// RegisterConnectorPlugin. Factories that take a configuration are registered
+// with RegisterConnectorFactory.
+type ConnectorFactory func() (Connector, error)
+
+// configFactory is how factories are kept in the registry; those registered
+// with RegisterConnector ignore the configuration
+type configFactory func(config []byte) (Connector, error)
+
+const (
+	// PluginSchemeSymbol is the name of the string variable a connector plugin
+	// exports with the scheme to register its connector under
+	PluginSchemeSymbol = "ConnectorScheme"
+
+	// PluginFactorySymbol is the name of the func() (dosa.Connector, error) a
+	// connector plugin exports to create its connector
+	PluginFactorySymbol = "NewConnector"
+)
+
+var (
+	connectorFactoriesMu sync.RWMutex
+	connectorFactories   = map[string]configFactory{}
+)
+
+// RegisterConnector makes a connector factory available under the scheme
+// name. It returns an error if the scheme is empty or already registered.
+func RegisterConnector(scheme string, factory ConnectorFactory) error {
+	if factory == nil {
+		return errors.Errorf("connector factory for scheme %q is nil", scheme)
+	}
+	return registerConnectorFactory(scheme, func([]byte) (Connector, error) {
+		return factory()
+	})
+}
+
+// RegisterConnectorFactory makes a factory that creates a connector from its
+// configuration available under the scheme name. It's meant to be called from
+// the init function of a connector package, so that importing the package for
+// its side effects registers the connector:
+//
+//	import _ "github.com/uber-go/dosa/connectors/memory" // registers "memory"
+//
+// It panics if the scheme is empty or already registered, as two packages
+// claiming one scheme is a programming error.
+func RegisterConnectorFactory(scheme string, f func(config []byte) (Connector, error)) {
+	if f == nil {
+		panic(fmt.Sprintf("connector factory for scheme %q is nil", scheme))
+	}
+	if err := registerConnectorFactory(scheme, f); err != nil {
+		panic(err.Error())
+	}
+}
+
+// GetConnectorFactory returns the factory registered under the scheme name,
+// with either RegisterConnectorFactory or RegisterConnector; the factories
+// registered with RegisterConnector ignore the configuration
+func GetConnectorFactory(scheme string) (func(config []byte) (Connector, error), bool) {
+	connectorFactoriesMu.RLock()
+	defer connectorFactoriesMu.RUnlock()
+	factory, ok := connectorFactories[scheme]
+	return factory, ok
+}
+
+func registerConnectorFactory(scheme string, factory configFactory) error {
+	if scheme == "" {
+		return errors.New("connector scheme cannot be empty")
+	}
+	connectorFactoriesMu.Lock()
+	defer connectorFactoriesMu.Unlock()
+	if _, ok := connectorFactories[scheme]; ok {
+		return errors.Errorf("connector scheme %q is already registered", scheme)
+	}
+	connectorFactories[scheme] = factory
+	return nil
+}
+
+// NewConnectorForScheme creates a connector with the factory registered under
+// the scheme name, without a configuration
+func NewConnectorForScheme(scheme string) (Connector, error) {
+	factory, ok := GetConnectorFactory(scheme)
+	if !ok {
+		return nil, errors.Errorf("no connector registered for scheme %q", scheme)
+	}
+	conn, err := factory(nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create connector for scheme %q", scheme)
+	}
+	return conn, nil
+}
+
+// RegisteredConnectorSchemes returns the registered scheme names, sorted
+func RegisteredConnectorSchemes() []string {
+	connectorFactoriesMu.RLock()
+	defer connectorFactoriesMu.RUnlock()
+	schemes := make([]string, 0, len(connectorFactories))
+	for scheme := range connectorFactories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
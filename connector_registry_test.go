@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterConnector(t *testing.T) {
+	defer func(saved map[string]configFactory) { connectorFactories = saved }(connectorFactories)
+	connectorFactories = map[string]configFactory{}
+
+	calls := 0
+	factory := func() (Connector, error) {
+		calls++
+		return nil, nil
+	}
+	assert.NoError(t, RegisterConnector("b", factory))
+	assert.NoError(t, RegisterConnector("a", factory))
+	assert.Error(t, RegisterConnector("a", factory))
+	assert.Error(t, RegisterConnector("", factory))
+	assert.Error(t, RegisterConnector("c", nil))
+	assert.Equal(t, []string{"a", "b"}, RegisteredConnectorSchemes())
+
+	_, err := NewConnectorForScheme("a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	_, err = NewConnectorForScheme("c")
+	assert.Error(t, err)
+
+	assert.NoError(t, RegisterConnector("failing", func() (Connector, error) {
+		return nil, errors.New("boom")
+	}))
+	_, err = NewConnectorForScheme("failing")
+	assert.EqualError(t, err, `failed to create connector for scheme "failing": boom`)
+}
+
+func TestRegisterConnectorFactory(t *testing.T) {
+	defer func(saved map[string]configFactory) { connectorFactories = saved }(connectorFactories)
+	connectorFactories = map[string]configFactory{}
+
+	var configs []string
+	RegisterConnectorFactory("configured", func(config []byte) (Connector, error) {
+		configs = append(configs, string(config))
+		return nil, nil
+	})
+	assert.NoError(t, RegisterConnector("plain", func() (Connector, error) { return nil, nil }))
+	assert.Equal(t, []string{"configured", "plain"}, RegisteredConnectorSchemes())
+
+	factory, ok := GetConnectorFactory("configured")
+	assert.True(t, ok)
+	_, err := factory([]byte("size: 10"))
+	assert.NoError(t, err)
+	_, err = NewConnectorForScheme("configured")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"size: 10", ""}, configs)
+
+	// factories registered without a configuration ignore it
+	factory, ok = GetConnectorFactory("plain")
+	assert.True(t, ok)
+	_, err = factory([]byte("ignored"))
+	assert.NoError(t, err)
+	_, ok = GetConnectorFactory("missing")
+	assert.False(t, ok)
+
+	// the schemes are shared, and registering one twice panics
+	assert.Panics(t, func() {
+		RegisterConnectorFactory("plain", func([]byte) (Connector, error) { return nil, nil })
+	})
+	assert.Error(t, RegisterConnector("configured", func() (Connector, error) { return nil, nil }))
+	assert.Panics(t, func() { RegisterConnectorFactory("", func([]byte) (Connector, error) { return nil, nil }) })
+	assert.Panics(t, func() { RegisterConnectorFactory("nil", nil) })
+}
+
+func TestRegisterConnectorPluginMissing(t *testing.T) {
+	err := RegisterConnectorPlugin("/nonexistent/connector.so")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "/nonexistent/connector.so")
+}
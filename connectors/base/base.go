@@ -23,6 +23,7 @@ package base
 import (
 	"context"
 	"runtime/debug"
+	"time"
 
 	"github.com/uber-go/dosa"
 )
@@ -45,14 +46,158 @@ func (e ErrNoMoreConnector) Error() string {
 	return "no more connectors"
 }
 
+// Logger is the interface connectors use for diagnostic output; it's the
+// same as dosa.Logger, so one logger can serve the client and its connectors
+type Logger = dosa.Logger
+
+// nopLogger discards everything, it's used when no logger is configured
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// ConnectorOption configures a base Connector
+type ConnectorOption func(*Connector)
+
+// WithLogger routes the connector's diagnostic output to the given logger
+func WithLogger(logger Logger) ConnectorOption {
+	return func(c *Connector) {
+		c.logger = logger
+	}
+}
+
+// WithSlowQueryThreshold logs a warning for every call to Next that takes
+// longer than the given threshold. A threshold of 0 disables the warning.
+// There is a single threshold, shared with WithSlowQueryReporter: when both
+// options are given, the one applied last sets it.
+func WithSlowQueryThreshold(threshold time.Duration) ConnectorOption {
+	return func(c *Connector) {
+		c.slowQueryThreshold = threshold
+	}
+}
+
+// SlowQueryReporter is told about each call to Next that takes longer than
+// the slow query threshold. Operations are named after the Connector
+// methods, e.g. "Read", and target is the entity, or the scope for
+// scope-wide operations.
+type SlowQueryReporter interface {
+	ReportSlowQuery(op string, target string, duration time.Duration, threshold time.Duration)
+}
+
+// logSlowQueryReporter warns about slow calls on a logger
+type logSlowQueryReporter struct {
+	logger Logger
+}
+
+// LogSlowQueryReporter returns a SlowQueryReporter that logs a warning for
+// each slow call, like WithSlowQueryThreshold does
+func LogSlowQueryReporter(logger Logger) SlowQueryReporter {
+	return logSlowQueryReporter{logger: logger}
+}
+
+func (r logSlowQueryReporter) ReportSlowQuery(op string, target string, duration time.Duration, threshold time.Duration) {
+	r.logger.Warnf("%s: slow %s on %s took %v (threshold %v)", name, op, target, duration, threshold)
+}
+
+// WithSlowQueryReporter calls the reporter, instead of logging a warning, for
+// every call to Next that takes longer than the given threshold, e.g. to
+// export slow queries to a monitoring system. A threshold of 0 disables it.
+// The threshold replaces one set by an earlier WithSlowQueryThreshold, and a
+// later WithSlowQueryThreshold replaces it but keeps the reporter.
+func WithSlowQueryReporter(reporter SlowQueryReporter, threshold time.Duration) ConnectorOption {
+	return func(c *Connector) {
+		c.slowQueryReporter = reporter
+		c.slowQueryThreshold = threshold
+	}
+}
+
+// WithCodec sets the codec the connector uses to convert field values to and
+// from the wire types of its backend
+func WithCodec(codec dosa.Codec) ConnectorOption {
+	return func(c *Connector) {
+		c.codec = codec
+	}
+}
+
+// WithRedaction overrides which columns have their values redacted from the
+// connector's log output, instead of the columns tagged sensitive; with no
+// columns nothing is redacted, which can help when debugging tests
+func WithRedaction(columns []string) ConnectorOption {
+	return func(c *Connector) {
+		c.redact = columns
+		c.redactOverride = true
+	}
+}
+
 // Connector always calls Next Connector in all the functions
 type Connector struct {
-	Next dosa.Connector
+	Next               dosa.Connector
+	logger             Logger
+	slowQueryThreshold time.Duration
+	slowQueryReporter  SlowQueryReporter
+	codec              dosa.Codec
+	redact             []string
+	redactOverride     bool
 }
 
 // NewConnector creates new base Connector
-func NewConnector(next dosa.Connector) dosa.Connector {
-	return &Connector{Next: next}
+func NewConnector(next dosa.Connector, opts ...ConnectorOption) dosa.Connector {
+	c := &Connector{Next: next}
+	c.Apply(opts...)
+	return c
+}
+
+// Apply applies the given options to the connector. This is useful for
+// connectors that embed a base Connector rather than calling NewConnector.
+func (c *Connector) Apply(opts ...ConnectorOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// Logger returns the configured logger, or a logger that discards
+// everything if none was set
+func (c *Connector) Logger() Logger {
+	if c.logger == nil {
+		return nopLogger{}
+	}
+	return c.logger
+}
+
+// Codec returns the configured codec, or dosa.IdentityCodec if none was set
+func (c *Connector) Codec() dosa.Codec {
+	if c.codec == nil {
+		return dosa.IdentityCodec
+	}
+	return c.codec
+}
+
+// Redact returns values ready for log output: the values of the entity's
+// sensitive columns, or of the columns set with WithRedaction, are replaced
+// with dosa.RedactedValue
+func (c *Connector) Redact(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) map[string]dosa.FieldValue {
+	if c.redactOverride {
+		return dosa.Redact(values, c.redact)
+	}
+	return dosa.Redact(values, ei.Def.SensitiveColumns())
+}
+
+// observe reports calls that exceed the slow query threshold, as a warning
+// unless a reporter is set; it's meant to be deferred at the start of each
+// call to Next
+func (c *Connector) observe(op, target string, start time.Time) {
+	if c.slowQueryThreshold <= 0 {
+		return
+	}
+	if elapsed := time.Since(start); elapsed > c.slowQueryThreshold {
+		reporter := c.slowQueryReporter
+		if reporter == nil {
+			reporter = LogSlowQueryReporter(c.Logger())
+		}
+		reporter.ReportSlowQuery(op, target, elapsed, c.slowQueryThreshold)
+	}
 }
 
 // CreateIfNotExists calls Next
@@ -60,6 +205,7 @@ func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo,
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("CreateIfNotExists", ei.Def.Name, time.Now())
 	return c.Next.CreateIfNotExists(ctx, ei, values)
 }
 
@@ -68,6 +214,7 @@ func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, values map[st
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("Read", ei.Def.Name, time.Now())
 	return c.Next.Read(ctx, ei, values, minimumFields)
 }
 
@@ -76,6 +223,7 @@ func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, values [
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("MultiRead", ei.Def.Name, time.Now())
 	return c.Next.MultiRead(ctx, ei, values, minimumFields)
 }
 
@@ -84,14 +232,88 @@ func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("Upsert", ei.Def.Name, time.Now())
 	return c.Next.Upsert(ctx, ei, values)
 }
 
+// CompareAndSwap calls Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("CompareAndSwap", ei.Def.Name, time.Now())
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions calls Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("UpsertWithConditions", ei.Def.Name, time.Now())
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// UpsertAndRead calls Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if c.Next == nil {
+		return nil, NewErrNoMoreConnector()
+	}
+	defer c.observe("UpsertAndRead", ei.Def.Name, time.Now())
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Aggregate calls Next
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	if c.Next == nil {
+		return nil, NewErrNoMoreConnector()
+	}
+	defer c.observe("Aggregate", ei.Def.Name, time.Now())
+	return c.Next.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+}
+
+// ExplainQuery calls Next
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	if c.Next == nil {
+		return nil, NewErrNoMoreConnector()
+	}
+	defer c.observe("ExplainQuery", ei.Def.Name, time.Now())
+	return c.Next.ExplainQuery(ctx, ei, columnConditions)
+}
+
+// CountRange calls Next
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	if c.Next == nil {
+		return 0, NewErrNoMoreConnector()
+	}
+	defer c.observe("CountRange", ei.Def.Name, time.Now())
+	return c.Next.CountRange(ctx, ei, columnConditions)
+}
+
+// Replace calls Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("Replace", ei.Def.Name, time.Now())
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// AtomicAdd calls Next
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if c.Next == nil {
+		return 0, NewErrNoMoreConnector()
+	}
+	defer c.observe("AtomicAdd", ei.Def.Name, time.Now())
+	return c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
 // MultiUpsert calls Next
 func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("MultiUpsert", ei.Def.Name, time.Now())
 	return c.Next.MultiUpsert(ctx, ei, values)
 }
 
@@ -100,6 +322,7 @@ func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, values map[
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("Remove", ei.Def.Name, time.Now())
 	return c.Next.Remove(ctx, ei, values)
 }
 
@@ -108,14 +331,25 @@ func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, column
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("RemoveRange", ei.Def.Name, time.Now())
 	return c.Next.RemoveRange(ctx, ei, columnConditions)
 }
 
+// DeletePartition calls Next.
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("DeletePartition", ei.Def.Name, time.Now())
+	return c.Next.DeletePartition(ctx, ei, pk)
+}
+
 // MultiRemove calls Next
 func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("MultiRemove", ei.Def.Name, time.Now())
 	return c.Next.MultiRemove(ctx, ei, multiValues)
 }
 
@@ -124,6 +358,7 @@ func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnCondit
 	if c.Next == nil {
 		return nil, "", NewErrNoMoreConnector()
 	}
+	defer c.observe("Range", ei.Def.Name, time.Now())
 	return c.Next.Range(ctx, ei, columnConditions, minimumFields, token, limit)
 }
 
@@ -132,14 +367,25 @@ func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields
 	if c.Next == nil {
 		return nil, "", NewErrNoMoreConnector()
 	}
+	defer c.observe("Scan", ei.Def.Name, time.Now())
 	return c.Next.Scan(ctx, ei, minimumFields, token, limit)
 }
 
+// CopyTable calls Next
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("CopyTable", dst.Def.Name, time.Now())
+	return c.Next.CopyTable(ctx, src, dst)
+}
+
 // CheckSchema calls Next
 func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, ed []*dosa.EntityDefinition) (int32, error) {
 	if c.Next == nil {
 		return dosa.InvalidVersion, NewErrNoMoreConnector()
 	}
+	defer c.observe("CheckSchema", scope, time.Now())
 	return c.Next.CheckSchema(ctx, scope, namePrefix, ed)
 }
 
@@ -148,6 +394,7 @@ func (c *Connector) CanUpsertSchema(ctx context.Context, scope, namePrefix strin
 	if c.Next == nil {
 		return dosa.InvalidVersion, NewErrNoMoreConnector()
 	}
+	defer c.observe("CanUpsertSchema", scope, time.Now())
 	return c.Next.CanUpsertSchema(ctx, scope, namePrefix, ed)
 }
 
@@ -156,6 +403,7 @@ func (c *Connector) UpsertSchema(ctx context.Context, scope, namePrefix string,
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("UpsertSchema", scope, time.Now())
 	return c.Next.UpsertSchema(ctx, scope, namePrefix, ed)
 }
 
@@ -164,6 +412,7 @@ func (c *Connector) CheckSchemaStatus(ctx context.Context, scope string, namePre
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("CheckSchemaStatus", scope, time.Now())
 	return c.Next.CheckSchemaStatus(ctx, scope, namePrefix, version)
 }
 
@@ -172,14 +421,34 @@ func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, enti
 	if c.Next == nil {
 		return nil, NewErrNoMoreConnector()
 	}
+	defer c.observe("GetEntitySchema", scope, time.Now())
 	return c.Next.GetEntitySchema(ctx, scope, namePrefix, entityName, version)
 }
 
+// DescribeTable calls Next
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	if c.Next == nil {
+		return nil, NewErrNoMoreConnector()
+	}
+	defer c.observe("DescribeTable", ei.Def.Name, time.Now())
+	return c.Next.DescribeTable(ctx, ei)
+}
+
+// DropTable calls Next
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	if c.Next == nil {
+		return NewErrNoMoreConnector()
+	}
+	defer c.observe("DropTable", ei.Def.Name, time.Now())
+	return c.Next.DropTable(ctx, ei)
+}
+
 // CreateScope calls Next
 func (c *Connector) CreateScope(ctx context.Context, md *dosa.ScopeMetadata) error {
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("CreateScope", md.Name, time.Now())
 	return c.Next.CreateScope(ctx, md)
 }
 
@@ -188,6 +457,7 @@ func (c *Connector) TruncateScope(ctx context.Context, scope string) error {
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("TruncateScope", scope, time.Now())
 	return c.Next.TruncateScope(ctx, scope)
 }
 
@@ -196,6 +466,7 @@ func (c *Connector) DropScope(ctx context.Context, scope string) error {
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	defer c.observe("DropScope", scope, time.Now())
 	return c.Next.DropScope(ctx, scope)
 }
 
@@ -204,14 +475,16 @@ func (c *Connector) ScopeExists(ctx context.Context, scope string) (bool, error)
 	if c.Next == nil {
 		return false, NewErrNoMoreConnector()
 	}
+	defer c.observe("ScopeExists", scope, time.Now())
 	return c.Next.ScopeExists(ctx, scope)
 }
 
-// Shutdown always returns nil
+// Shutdown calls Next
 func (c *Connector) Shutdown() error {
 	if c.Next == nil {
 		return NewErrNoMoreConnector()
 	}
+	c.Logger().Infof("%s: shutting down", name)
 	return c.Next.Shutdown()
 }
 
@@ -22,7 +22,9 @@ package base_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/dosa"
@@ -43,7 +45,7 @@ var (
 			NamePrefix: "testPrefix",
 			EntityName: "testEntityName",
 		},
-		Def: &dosa.EntityDefinition{},
+		Def: &dosa.EntityDefinition{Name: "testEntityName"},
 	}
 	testPairs       = dosa.FieldNameValuePair{}
 	testValues      = make(map[string]dosa.FieldValue)
@@ -85,6 +87,89 @@ func TestBase_Upsert(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestBase_CompareAndSwap(t *testing.T) {
+	err := bc.CompareAndSwap(ctx, testInfo, testValues, testValues)
+	assert.Error(t, err)
+
+	err = bcWNext.CompareAndSwap(ctx, testInfo, testValues, testValues)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestBase_UpsertAndRead(t *testing.T) {
+	_, err := bc.UpsertAndRead(ctx, testInfo, testValues)
+	assert.Error(t, err)
+
+	_, err = bcWNext.UpsertAndRead(ctx, testInfo, testValues)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestBase_Replace(t *testing.T) {
+	assert.Error(t, bc.Replace(ctx, testInfo, testValues))
+	assert.NoError(t, bcWNext.Replace(ctx, testInfo, testValues))
+}
+
+func TestBase_UpsertWithConditions(t *testing.T) {
+	assert.Error(t, bc.UpsertWithConditions(ctx, testInfo, testValues, nil))
+
+	err := bcWNext.UpsertWithConditions(ctx, testInfo, testValues, nil)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestBase_CopyTable(t *testing.T) {
+	assert.Error(t, bc.CopyTable(ctx, testInfo, testInfo))
+	assert.NoError(t, bcWNext.CopyTable(ctx, testInfo, testInfo))
+}
+
+func TestBase_DescribeTable(t *testing.T) {
+	_, err := bc.DescribeTable(ctx, testInfo)
+	assert.Error(t, err)
+
+	ed, err := bcWNext.DescribeTable(ctx, testInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, testInfo.Def, ed)
+}
+
+func TestBase_DropTable(t *testing.T) {
+	assert.Error(t, bc.DropTable(ctx, testInfo))
+	assert.NoError(t, bcWNext.DropTable(ctx, testInfo))
+}
+
+func TestBase_ExplainQuery(t *testing.T) {
+	_, err := bc.ExplainQuery(ctx, testInfo, nil)
+	assert.Error(t, err)
+	plan, err := bcWNext.ExplainQuery(ctx, testInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{}, plan)
+}
+
+func TestBase_CountRange(t *testing.T) {
+	_, err := bc.CountRange(ctx, testInfo, nil)
+	assert.Error(t, err)
+	count, err := bcWNext.CountRange(ctx, testInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestBase_AtomicAdd(t *testing.T) {
+	_, err := bc.AtomicAdd(ctx, testInfo, testValues, "c1", 1)
+	assert.Error(t, err)
+
+	// the devnull connector has no column c1
+	_, err = bcWNext.AtomicAdd(ctx, testInfo, testValues, "c1", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `column "c1" not found`)
+}
+
+func TestBase_Aggregate(t *testing.T) {
+	_, err := bc.Aggregate(ctx, testInfo, dosa.AggCount, "c1", nil)
+	assert.Error(t, err)
+
+	// the devnull connector has no column c1
+	_, err = bcWNext.Aggregate(ctx, testInfo, dosa.AggCount, "c1", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `column "c1" not found`)
+}
+
 func TestBase_MultiUpsert(t *testing.T) {
 	_, err := bc.MultiUpsert(ctx, testInfo, testMultiValues)
 	assert.Error(t, err)
@@ -191,6 +276,100 @@ func TestBase_Shutdown(t *testing.T) {
 	assert.NoError(t, bcWNext.Shutdown())
 }
 
+type recordingLogger struct {
+	debug, info, warn, err []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.info = append(l.info, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.warn = append(l.warn, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.err = append(l.err, fmt.Sprintf(format, args...))
+}
+
+type slowConnector struct {
+	devnull.Connector
+}
+
+func (c *slowConnector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func TestBase_WithLogger(t *testing.T) {
+	assert.NotNil(t, bc.Logger())
+
+	logger := &recordingLogger{}
+	c := base.NewConnector(&dl, base.WithLogger(logger)).(*base.Connector)
+	assert.Equal(t, logger, c.Logger())
+	assert.NoError(t, c.Shutdown())
+	assert.Len(t, logger.info, 1)
+}
+
+func TestBase_WithSlowQueryThreshold(t *testing.T) {
+	logger := &recordingLogger{}
+	c := base.NewConnector(&slowConnector{}, base.WithLogger(logger), base.WithSlowQueryThreshold(time.Millisecond))
+
+	assert.NoError(t, c.CreateIfNotExists(ctx, testInfo, testValues))
+	assert.Empty(t, logger.warn)
+
+	assert.NoError(t, c.Upsert(ctx, testInfo, testValues))
+	assert.Len(t, logger.warn, 1)
+	assert.Contains(t, logger.warn[0], "slow Upsert on testEntityName")
+
+	// no threshold means no warnings
+	logger = &recordingLogger{}
+	c = base.NewConnector(&slowConnector{}, base.WithLogger(logger))
+	assert.NoError(t, c.Upsert(ctx, testInfo, testValues))
+	assert.Empty(t, logger.warn)
+}
+
+type slowQueryRecorder struct {
+	ops []string
+}
+
+func (r *slowQueryRecorder) ReportSlowQuery(op string, target string, duration time.Duration, threshold time.Duration) {
+	r.ops = append(r.ops, fmt.Sprintf("%s %s above %v: %t", op, target, threshold, duration > threshold))
+}
+
+func TestBase_WithSlowQueryReporter(t *testing.T) {
+	logger := &recordingLogger{}
+	reporter := &slowQueryRecorder{}
+	c := base.NewConnector(&slowConnector{}, base.WithLogger(logger), base.WithSlowQueryReporter(reporter, time.Millisecond))
+
+	assert.NoError(t, c.CreateIfNotExists(ctx, testInfo, testValues))
+	assert.NoError(t, c.Upsert(ctx, testInfo, testValues))
+	assert.Equal(t, []string{"Upsert testEntityName above 1ms: true"}, reporter.ops)
+	// the reporter replaces the warning
+	assert.Empty(t, logger.warn)
+
+	// the threshold of the option applied last wins, and the reporter stays
+	reporter.ops = nil
+	c = base.NewConnector(&slowConnector{}, base.WithSlowQueryReporter(reporter, time.Millisecond), base.WithSlowQueryThreshold(time.Hour))
+	assert.NoError(t, c.Upsert(ctx, testInfo, testValues))
+	assert.Empty(t, reporter.ops)
+	c = base.NewConnector(&slowConnector{}, base.WithSlowQueryThreshold(time.Hour), base.WithSlowQueryReporter(reporter, time.Millisecond))
+	assert.NoError(t, c.Upsert(ctx, testInfo, testValues))
+	assert.Equal(t, []string{"Upsert testEntityName above 1ms: true"}, reporter.ops)
+
+	base.LogSlowQueryReporter(logger).ReportSlowQuery("Range", "users", 1500*time.Millisecond, time.Second)
+	assert.Equal(t, []string{"base: slow Range on users took 1.5s (threshold 1s)"}, logger.warn)
+}
+
+func TestBase_DeletePartition(t *testing.T) {
+	assert.Error(t, bc.DeletePartition(ctx, testInfo, testValues))
+	assert.NoError(t, bcWNext.DeletePartition(ctx, testInfo, testValues))
+}
+
 func TestBase_CheckSchemaStatus(t *testing.T) {
 	_, err := bc.CheckSchemaStatus(ctx, "testScope", "testPrefix", int32(1))
 	assert.Error(t, err)
@@ -199,3 +378,47 @@ func TestBase_CheckSchemaStatus(t *testing.T) {
 	assert.NotNil(t, versions)
 	assert.NoError(t, err)
 }
+
+type stubCodec struct{}
+
+func (stubCodec) Encode(_ dosa.Type, val dosa.FieldValue) (interface{}, error) {
+	return val, nil
+}
+
+func (stubCodec) Decode(_ dosa.Type, raw interface{}) (dosa.FieldValue, error) {
+	return raw, nil
+}
+
+func TestBase_WithCodec(t *testing.T) {
+	assert.Equal(t, dosa.IdentityCodec, bc.Codec())
+
+	c := base.NewConnector(&dl, base.WithCodec(stubCodec{})).(*base.Connector)
+	assert.Equal(t, stubCodec{}, c.Codec())
+}
+
+func TestBase_WithRedaction(t *testing.T) {
+	ei := &dosa.EntityInfo{
+		Def: &dosa.EntityDefinition{
+			Name: "t",
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "id", Type: dosa.Int64},
+				{Name: "ssn", Type: dosa.String, SensitiveData: true},
+			},
+		},
+	}
+	values := map[string]dosa.FieldValue{"id": int64(1), "ssn": "123-45-6789"}
+
+	// sensitive columns are redacted by default
+	redacted := bc.Redact(ei, values)
+	assert.Equal(t, int64(1), redacted["id"])
+	assert.Equal(t, dosa.RedactedValue, redacted["ssn"])
+	assert.Equal(t, "123-45-6789", values["ssn"])
+
+	c := base.NewConnector(&dl, base.WithRedaction([]string{"id"})).(*base.Connector)
+	redacted = c.Redact(ei, values)
+	assert.Equal(t, dosa.RedactedValue, redacted["id"])
+	assert.Equal(t, "123-45-6789", redacted["ssn"])
+
+	c = base.NewConnector(&dl, base.WithRedaction(nil)).(*base.Connector)
+	assert.Equal(t, values, c.Redact(ei, values))
+}
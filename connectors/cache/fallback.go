@@ -60,6 +60,15 @@ func WithSkipWriteInvalidateEntities(entities ...dosa.DomainObject) Options {
 	}
 }
 
+// WithLogger provides the option for client to log errors from cache writes,
+// which are otherwise swallowed
+func WithLogger(logger base.Logger) Options {
+	return func(c *Connector) error {
+		c.Connector.Apply(base.WithLogger(logger))
+		return nil
+	}
+}
+
 // NewConnector creates a fallback cache connector
 func NewConnector(origin, fallback dosa.Connector, scope metrics.Scope, entities []dosa.DomainObject, options ...Options) *Connector {
 	c := newConnector(origin, fallback, scope, encoding.NewGobEncoder(), entities)
@@ -109,6 +118,61 @@ func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[
 	return c.Next.Upsert(ctx, ei, values)
 }
 
+// UpsertAndRead removes (invalidates) the entry from the fallback like Upsert does
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if c.isCacheable(ei) {
+		w := func() error {
+			return c.removeValueFromFallback(ctx, ei, createCacheKey(ei, values))
+		}
+		_ = c.cacheWrite(w)
+	}
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Replace removes (invalidates) the entry from the fallback like Upsert does
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if c.isCacheable(ei) {
+		w := func() error {
+			return c.removeValueFromFallback(ctx, ei, createCacheKey(ei, values))
+		}
+		_ = c.cacheWrite(w)
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// AtomicAdd removes (invalidates) the entry from the fallback like Upsert does
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if c.isCacheable(ei) {
+		w := func() error {
+			return c.removeValueFromFallback(ctx, ei, createCacheKey(ei, keys))
+		}
+		_ = c.cacheWrite(w)
+	}
+	return c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+// CompareAndSwap removes (invalidates) the entry from the fallback like Upsert does
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	if c.isCacheable(ei) {
+		w := func() error {
+			return c.removeValueFromFallback(ctx, ei, createCacheKey(ei, conditions))
+		}
+		_ = c.cacheWrite(w)
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions removes (invalidates) the entry from the fallback like Upsert does
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if c.isCacheable(ei) {
+		w := func() error {
+			return c.removeValueFromFallback(ctx, ei, createCacheKey(ei, values))
+		}
+		_ = c.cacheWrite(w)
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
 func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (values map[string]dosa.FieldValue, err error) {
 	// Read from source of truth first
 	source, sourceErr := c.Next.Read(ctx, ei, keys, dosa.All())
@@ -211,6 +275,15 @@ func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields
 	return c.Range(ctx, ei, nil, minimumFields, token, limit)
 }
 
+// CopyTable scans and writes through this connector when the destination is
+// cached, so that each copied row is invalidated like MultiUpsert does
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	if c.isCacheable(dst) {
+		return dosa.CopyTableByScanning(ctx, c, src, dst)
+	}
+	return c.Next.CopyTable(ctx, src, dst)
+}
+
 // MultiRead reads from fallback for the keys that failed
 // There are a few scenarios for the fallback:
 // 1. The original multiread call fails overall with an error XYZ. The fallback will try to read as many keys as possible.
@@ -403,12 +476,20 @@ func (c *Connector) setSynchronousMode(sync bool) {
 
 func (c *Connector) cacheWrite(w func() error) error {
 	if c.synchronous {
-		return w()
+		return c.logCacheWriteError(w())
 	}
-	go func() { _ = w() }()
+	go func() { _ = c.logCacheWriteError(w()) }()
 	return nil
 }
 
+// logCacheWriteError logs errors from writes to the fallback since callers ignore them
+func (c *Connector) logCacheWriteError(err error) error {
+	if err != nil {
+		c.Logger().Errorf("fallback: cache write failed: %v", err)
+	}
+	return err
+}
+
 func (c *Connector) shouldSkipInvalidateCacheOnWrite(ei *dosa.EntityInfo) bool {
 	return c.skipWriteInvalidateEntitiesMap[ei.Def.Name]
 }
@@ -1000,6 +1000,90 @@ func TestRemove(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestAtomicAdd(t *testing.T) {
+	originCtrl := gomock.NewController(t)
+	defer originCtrl.Finish()
+	mockOrigin := mocks.NewMockConnector(originCtrl)
+
+	fallbackCtrl := gomock.NewController(t)
+	defer fallbackCtrl.Finish()
+	mockFallback := mocks.NewMockConnector(fallbackCtrl)
+
+	keys := map[string]dosa.FieldValue{}
+	mockOrigin.EXPECT().AtomicAdd(context.TODO(), testEi, keys, "c1", int64(1)).Return(int64(1), nil)
+	mockFallback.EXPECT().Remove(gomock.Not(context.TODO()), adaptedEi, gomock.Any()).Return(nil)
+
+	connector := NewConnector(mockOrigin, mockFallback, nil, cacheableEntities)
+	connector.setSynchronousMode(true)
+	v, err := connector.AtomicAdd(context.TODO(), testEi, keys, "c1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+}
+
+func TestUpsertWithConditions(t *testing.T) {
+	originCtrl := gomock.NewController(t)
+	defer originCtrl.Finish()
+	mockOrigin := mocks.NewMockConnector(originCtrl)
+
+	fallbackCtrl := gomock.NewController(t)
+	defer fallbackCtrl.Finish()
+	mockFallback := mocks.NewMockConnector(fallbackCtrl)
+
+	values := map[string]dosa.FieldValue{}
+	mockOrigin.EXPECT().UpsertWithConditions(context.TODO(), testEi, values, nil).Return(&dosa.ErrConflict{})
+	mockFallback.EXPECT().Remove(gomock.Not(context.TODO()), adaptedEi, gomock.Any()).Return(nil)
+
+	connector := NewConnector(mockOrigin, mockFallback, nil, cacheableEntities)
+	connector.setSynchronousMode(true)
+	err := connector.UpsertWithConditions(context.TODO(), testEi, values, nil)
+	assert.True(t, dosa.ErrorIsConflict(err))
+}
+
+func TestCopyTable(t *testing.T) {
+	originCtrl := gomock.NewController(t)
+	defer originCtrl.Finish()
+	mockOrigin := mocks.NewMockConnector(originCtrl)
+
+	fallbackCtrl := gomock.NewController(t)
+	defer fallbackCtrl.Finish()
+	mockFallback := mocks.NewMockConnector(fallbackCtrl)
+
+	other := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	other.Def.Name = "other"
+	connector := NewConnector(mockOrigin, mockFallback, nil, cacheableEntities)
+	connector.setSynchronousMode(true)
+
+	// an uncached destination is copied by the origin
+	mockOrigin.EXPECT().CopyTable(context.TODO(), testEi, other).Return(nil)
+	assert.NoError(t, connector.CopyTable(context.TODO(), testEi, other))
+
+	// a cached destination is copied row by row, invalidating each row
+	rows := []map[string]dosa.FieldValue{{"an_uuid_key": dosa.NewUUID()}}
+	mockOrigin.EXPECT().Range(gomock.Any(), other, gomock.Any(), dosa.All(), "", gomock.Any()).Return(rows, "", nil)
+	mockOrigin.EXPECT().MultiUpsert(gomock.Any(), testEi, rows).Return([]error{nil}, nil)
+	mockFallback.EXPECT().Remove(gomock.Any(), adaptedEi, gomock.Any()).Return(nil)
+	assert.NoError(t, connector.CopyTable(context.TODO(), other, testEi))
+}
+
+func TestReplace(t *testing.T) {
+	originCtrl := gomock.NewController(t)
+	defer originCtrl.Finish()
+	mockOrigin := mocks.NewMockConnector(originCtrl)
+
+	fallbackCtrl := gomock.NewController(t)
+	defer fallbackCtrl.Finish()
+	mockFallback := mocks.NewMockConnector(fallbackCtrl)
+
+	values := map[string]dosa.FieldValue{}
+	mockOrigin.EXPECT().Replace(context.TODO(), testEi, values).Return(nil)
+	mockFallback.EXPECT().Remove(gomock.Not(context.TODO()), adaptedEi, gomock.Any()).Return(nil)
+
+	connector := NewConnector(mockOrigin, mockFallback, nil, cacheableEntities)
+	connector.setSynchronousMode(true)
+	err := connector.Replace(context.TODO(), testEi, values)
+	assert.NoError(t, err)
+}
+
 // Test that if a Connector interface method is not defined in fallback.Connector, revert to
 // using the origin's implementation of the method
 func TestCreateIfNotExists(t *testing.T) {
@@ -0,0 +1,401 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+const (
+	// DefaultFailureThreshold is used when Options.FailureThreshold is zero
+	DefaultFailureThreshold = 5
+
+	// DefaultRecoveryTimeout is used when Options.RecoveryTimeout is zero
+	DefaultRecoveryTimeout = 30 * time.Second
+
+	// DefaultHalfOpenMaxRequests is used when Options.HalfOpenMaxRequests is zero
+	DefaultHalfOpenMaxRequests = 1
+)
+
+// ErrCircuitOpen is returned instead of calling Next while the breaker is open
+type ErrCircuitOpen struct{}
+
+// Error satisfies the error interface
+func (e *ErrCircuitOpen) Error() string {
+	return "circuit breaker is open"
+}
+
+// ErrorIsCircuitOpen checks if the error is caused by "ErrCircuitOpen"
+func ErrorIsCircuitOpen(err error) bool {
+	_, ok := errors.Cause(err).(*ErrCircuitOpen)
+	return ok
+}
+
+// Options configure a circuit breaker. The breaker opens after
+// FailureThreshold consecutive failures, and stays open for RecoveryTimeout.
+// It then lets up to HalfOpenMaxRequests calls through at a time to probe
+// Next: the first success closes the breaker, a failure opens it again.
+// Zero values are replaced with the defaults.
+type Options struct {
+	FailureThreshold    int
+	RecoveryTimeout     time.Duration
+	HalfOpenMaxRequests int
+}
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Connector fails the data operations fast while Next is failing, so that
+// callers don't all block on a storage outage. Schema and scope operations
+// are passed through unguarded.
+//
+// Errors that come from the request rather than from Next being unhealthy
+// (not found, already exists and conflicts) count as successes, since Next
+// did answer. Calls whose context was canceled are not counted at all.
+type Connector struct {
+	base.Connector
+	opts Options
+
+	lock       sync.Mutex
+	state      state
+	generation int // changes with the state, so late results are ignored
+	failures   int
+	openedAt   time.Time
+	probes     int
+}
+
+// NewConnector creates a new circuit breaking connector
+func NewConnector(next dosa.Connector, opts Options) (*Connector, error) {
+	if opts.FailureThreshold < 0 {
+		return nil, errors.Errorf("failure threshold must not be negative, got %d", opts.FailureThreshold)
+	}
+	if opts.RecoveryTimeout < 0 {
+		return nil, errors.Errorf("recovery timeout must not be negative, got %v", opts.RecoveryTimeout)
+	}
+	if opts.HalfOpenMaxRequests < 0 {
+		return nil, errors.Errorf("half-open max requests must not be negative, got %d", opts.HalfOpenMaxRequests)
+	}
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = DefaultFailureThreshold
+	}
+	if opts.RecoveryTimeout == 0 {
+		opts.RecoveryTimeout = DefaultRecoveryTimeout
+	}
+	if opts.HalfOpenMaxRequests == 0 {
+		opts.HalfOpenMaxRequests = DefaultHalfOpenMaxRequests
+	}
+	return &Connector{Connector: base.Connector{Next: next}, opts: opts}, nil
+}
+
+// setState moves the breaker to a new state; the caller must hold the lock
+func (c *Connector) setState(s state, now time.Time) {
+	c.state = s
+	c.generation++
+	c.failures = 0
+	c.probes = 0
+	if s == open {
+		c.openedAt = now
+	}
+}
+
+// allow returns the generation of the state a call is let through in, or
+// ErrCircuitOpen
+func (c *Connector) allow() (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	if c.state == open && now.Sub(c.openedAt) >= c.opts.RecoveryTimeout {
+		c.setState(halfOpen, now)
+	}
+	switch c.state {
+	case open:
+		return 0, &ErrCircuitOpen{}
+	case halfOpen:
+		if c.probes >= c.opts.HalfOpenMaxRequests {
+			return 0, &ErrCircuitOpen{}
+		}
+		c.probes++
+	}
+	return c.generation, nil
+}
+
+// done records the result of a call let through by allow
+func (c *Connector) done(generation int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if errors.Cause(err) == context.Canceled {
+		// the caller gave up, which says nothing about the health of Next
+		if c.state == halfOpen {
+			c.probes--
+		}
+		return
+	}
+	failed := isFailure(err)
+	switch c.state {
+	case closed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= c.opts.FailureThreshold {
+			c.setState(open, time.Now())
+		}
+	case halfOpen:
+		if failed {
+			c.setState(open, time.Now())
+		} else {
+			c.setState(closed, time.Now())
+		}
+	}
+}
+
+// isFailure tells whether an error means that Next is unhealthy
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !dosa.ErrorIsNotFound(err) && !dosa.ErrorIsAlreadyExists(err) && !dosa.ErrorIsConflict(err) &&
+		!dosa.ErrorIsNotSupported(err)
+}
+
+// CreateIfNotExists calls Next unless the breaker is open
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.CreateIfNotExists(ctx, ei, values)
+	c.done(generation, err)
+	return err
+}
+
+// Read calls Next unless the breaker is open
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Next.Read(ctx, ei, values, minimumFields)
+	c.done(generation, err)
+	return result, err
+}
+
+// MultiRead calls Next unless the breaker is open
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.Next.MultiRead(ctx, ei, values, minimumFields)
+	c.done(generation, err)
+	return results, err
+}
+
+// Upsert calls Next unless the breaker is open
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.Upsert(ctx, ei, values)
+	c.done(generation, err)
+	return err
+}
+
+// UpsertAndRead calls Next unless the breaker is open
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Next.UpsertAndRead(ctx, ei, values)
+	c.done(generation, err)
+	return result, err
+}
+
+// Aggregate calls Next unless the breaker is open
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Next.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+	c.done(generation, err)
+	return result, err
+}
+
+// CountRange calls Next unless the breaker is open
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return 0, err
+	}
+	count, err := c.Next.CountRange(ctx, ei, columnConditions)
+	c.done(generation, err)
+	return count, err
+}
+
+// Replace calls Next unless the breaker is open
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.Replace(ctx, ei, values)
+	c.done(generation, err)
+	return err
+}
+
+// AtomicAdd calls Next unless the breaker is open
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return 0, err
+	}
+	sum, err := c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+	c.done(generation, err)
+	return sum, err
+}
+
+// CompareAndSwap calls Next unless the breaker is open
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+	c.done(generation, err)
+	return err
+}
+
+// UpsertWithConditions calls Next unless the breaker is open
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+	c.done(generation, err)
+	return err
+}
+
+// MultiUpsert calls Next unless the breaker is open
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.Next.MultiUpsert(ctx, ei, values)
+	c.done(generation, err)
+	return results, err
+}
+
+// Remove calls Next unless the breaker is open
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.Remove(ctx, ei, values)
+	c.done(generation, err)
+	return err
+}
+
+// RemoveRange calls Next unless the breaker is open
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.RemoveRange(ctx, ei, columnConditions)
+	c.done(generation, err)
+	return err
+}
+
+// DeletePartition calls Next unless the breaker is open
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.DeletePartition(ctx, ei, pk)
+	c.done(generation, err)
+	return err
+}
+
+// MultiRemove calls Next unless the breaker is open
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.Next.MultiRemove(ctx, ei, multiValues)
+	c.done(generation, err)
+	return results, err
+}
+
+// Range calls Next unless the breaker is open
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, "", err
+	}
+	rows, next, err := c.Next.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+	c.done(generation, err)
+	return rows, next, err
+}
+
+// Scan calls Next unless the breaker is open
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	generation, err := c.allow()
+	if err != nil {
+		return nil, "", err
+	}
+	rows, next, err := c.Next.Scan(ctx, ei, minimumFields, token, limit)
+	c.done(generation, err)
+	return rows, next, err
+}
+
+// CopyTable calls Next unless the breaker is open
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	generation, err := c.allow()
+	if err != nil {
+		return err
+	}
+	err = c.Next.CopyTable(ctx, src, dst)
+	c.done(generation, err)
+	return err
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/circuitbreaker"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var testValues = map[string]dosa.FieldValue{"id": int64(1), "name": "foo"}
+
+var ctx = context.Background()
+
+// flakyConnector fails every Upsert while err is set
+type flakyConnector struct {
+	*memory.Connector
+	err   error
+	calls int
+}
+
+func (f *flakyConnector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	return f.Connector.Upsert(ctx, ei, values)
+}
+
+func TestNewConnector_InvalidOptions(t *testing.T) {
+	_, err := circuitbreaker.NewConnector(memory.NewConnector(), circuitbreaker.Options{FailureThreshold: -1})
+	assert.EqualError(t, err, "failure threshold must not be negative, got -1")
+	_, err = circuitbreaker.NewConnector(memory.NewConnector(), circuitbreaker.Options{RecoveryTimeout: -time.Second})
+	assert.EqualError(t, err, "recovery timeout must not be negative, got -1s")
+	_, err = circuitbreaker.NewConnector(memory.NewConnector(), circuitbreaker.Options{HalfOpenMaxRequests: -1})
+	assert.EqualError(t, err, "half-open max requests must not be negative, got -1")
+}
+
+func TestConnector_Opens(t *testing.T) {
+	next := &flakyConnector{Connector: memory.NewConnector(), err: errors.New("backend down")}
+	c, err := circuitbreaker.NewConnector(next, circuitbreaker.Options{FailureThreshold: 3})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, c.Upsert(ctx, testEi, testValues), "backend down")
+	}
+	err = c.Upsert(ctx, testEi, testValues)
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(err))
+	assert.EqualError(t, err, "circuit breaker is open")
+	assert.Equal(t, 3, next.calls)
+
+	// every data operation fails fast while the breaker is open
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(err))
+	_, _, err = c.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(err))
+}
+
+func TestConnector_SuccessResetsFailures(t *testing.T) {
+	next := &flakyConnector{Connector: memory.NewConnector(), err: errors.New("backend down")}
+	c, err := circuitbreaker.NewConnector(next, circuitbreaker.Options{FailureThreshold: 2})
+	assert.NoError(t, err)
+
+	assert.Error(t, c.Upsert(ctx, testEi, testValues))
+	next.err = nil
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	next.err = errors.New("backend down")
+	assert.EqualError(t, c.Upsert(ctx, testEi, testValues), "backend down")
+
+	// errors caused by the request are successful answers from Next
+	for i := 0; i < 3; i++ {
+		_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2)}, dosa.All())
+		assert.True(t, dosa.ErrorIsNotFound(err))
+	}
+	assert.EqualError(t, c.Upsert(ctx, testEi, testValues), "backend down")
+	assert.EqualError(t, c.Upsert(ctx, testEi, testValues), "backend down")
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(c.Upsert(ctx, testEi, testValues)))
+}
+
+func TestConnector_HalfOpen(t *testing.T) {
+	next := &flakyConnector{Connector: memory.NewConnector(), err: errors.New("backend down")}
+	c, err := circuitbreaker.NewConnector(next, circuitbreaker.Options{
+		FailureThreshold: 1,
+		RecoveryTimeout:  20 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	assert.Error(t, c.Upsert(ctx, testEi, testValues))
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(c.Upsert(ctx, testEi, testValues)))
+
+	// a failed probe opens the breaker again
+	time.Sleep(25 * time.Millisecond)
+	assert.EqualError(t, c.Upsert(ctx, testEi, testValues), "backend down")
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(c.Upsert(ctx, testEi, testValues)))
+
+	// a successful probe closes it
+	time.Sleep(25 * time.Millisecond)
+	next.err = nil
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+}
+
+// blockingConnector blocks every Read until release is closed
+type blockingConnector struct {
+	*memory.Connector
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingConnector) Read(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return nil, errors.New("backend down")
+}
+
+func TestConnector_HalfOpenMaxRequests(t *testing.T) {
+	next := &blockingConnector{
+		Connector: memory.NewConnector(),
+		started:   make(chan struct{}, 10),
+		release:   make(chan struct{}),
+	}
+	c, err := circuitbreaker.NewConnector(next, circuitbreaker.Options{
+		FailureThreshold:    1,
+		RecoveryTimeout:     time.Millisecond,
+		HalfOpenMaxRequests: 2,
+	})
+	assert.NoError(t, err)
+
+	close(next.release)
+	_, err = c.Read(ctx, testEi, testValues, dosa.All())
+	assert.EqualError(t, err, "backend down")
+	<-next.started
+	next.release = make(chan struct{})
+	time.Sleep(5 * time.Millisecond)
+
+	// two probes are let through, the third call is rejected until they finish
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.Read(ctx, testEi, testValues, dosa.All())
+			errs <- err
+		}()
+		<-next.started
+	}
+	_, err = c.Read(ctx, testEi, testValues, dosa.All())
+	assert.True(t, circuitbreaker.ErrorIsCircuitOpen(err))
+	close(next.release)
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, <-errs, "backend down")
+	}
+}
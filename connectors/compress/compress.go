@@ -0,0 +1,311 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// magic is the first byte of every value written compressed. Values that
+// do not start with it are returned as they are, so rows written before
+// compression was turned on can still be read.
+const magic byte = 0xdc
+
+// DefaultThresholdBytes is the size above which Blob values are compressed
+// when Options.ThresholdBytes is not set
+const DefaultThresholdBytes = 1024
+
+// Compressor compresses and decompresses Blob values. Compress must return
+// the same output for the same input, because conditions of CompareAndSwap
+// are compressed before they are compared with the stored values.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Gzip is a Compressor that uses gzip with the default compression level
+type Gzip struct{}
+
+// Compress satisfies the Compressor interface
+func (Gzip) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress satisfies the Compressor interface
+func (Gzip) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return ioutil.ReadAll(r)
+}
+
+// Options configures a compressing connector
+type Options struct {
+	// ThresholdBytes is the length above which Blob values are compressed;
+	// DefaultThresholdBytes if zero
+	ThresholdBytes int
+	// Compressor compresses the values; Gzip if nil
+	Compressor Compressor
+}
+
+// Connector compresses the values of Blob columns that are longer than the
+// threshold before they are written to Next, and decompresses them when they
+// are read back. Compressed values start with a magic byte; values read
+// without it are returned unchanged. Values that start with the magic byte
+// are always compressed, whatever their length, so they cannot be mistaken
+// for compressed ones.
+//
+// Key columns are never compressed, so that Range conditions keep working.
+// Compressed columns cannot be used in Range conditions either, and the
+// values of a row must all be written through this connector for the row
+// to be readable.
+type Connector struct {
+	base.Connector
+	threshold  int
+	compressor Compressor
+}
+
+// NewConnector creates a new compressing connector
+func NewConnector(next dosa.Connector, opts Options) *Connector {
+	c := &Connector{
+		Connector:  base.Connector{Next: next},
+		threshold:  opts.ThresholdBytes,
+		compressor: opts.Compressor,
+	}
+	if c.threshold == 0 {
+		c.threshold = DefaultThresholdBytes
+	}
+	if c.compressor == nil {
+		c.compressor = Gzip{}
+	}
+	return c
+}
+
+// CreateIfNotExists compresses the values before calling Next
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	values, err := c.compress(ei, values)
+	if err != nil {
+		return err
+	}
+	return c.Next.CreateIfNotExists(ctx, ei, values)
+}
+
+// Read decompresses the values returned by Next
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	values, err := c.Next.Read(ctx, ei, keys, minimumFields)
+	if err != nil {
+		return nil, err
+	}
+	return c.decompress(ei, values)
+}
+
+// MultiRead decompresses the values of each row returned by Next
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, keys []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	results, err := c.Next.MultiRead(ctx, ei, keys, minimumFields)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if result == nil || result.Error != nil {
+			continue
+		}
+		if result.Values, err = c.decompress(ei, result.Values); err != nil {
+			result.Values, result.Error = nil, err
+		}
+	}
+	return results, nil
+}
+
+// Upsert compresses the values before calling Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	values, err := c.compress(ei, values)
+	if err != nil {
+		return err
+	}
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// CompareAndSwap compresses the conditions and the new values before calling Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	conditions, err := c.compress(ei, conditions)
+	if err != nil {
+		return err
+	}
+	newValues, err = c.compress(ei, newValues)
+	if err != nil {
+		return err
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions compresses the values before calling Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	values, err := c.compress(ei, values)
+	if err != nil {
+		return err
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// UpsertAndRead compresses the values before calling Next, and decompresses
+// the values it returns
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	values, err := c.compress(ei, values)
+	if err != nil {
+		return nil, err
+	}
+	if values, err = c.Next.UpsertAndRead(ctx, ei, values); err != nil {
+		return nil, err
+	}
+	return c.decompress(ei, values)
+}
+
+// Replace compresses the values before calling Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	values, err := c.compress(ei, values)
+	if err != nil {
+		return err
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// MultiUpsert compresses the values of each row before calling Next
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	compressed := make([]map[string]dosa.FieldValue, len(multiValues))
+	for i, values := range multiValues {
+		var err error
+		if compressed[i], err = c.compress(ei, values); err != nil {
+			return nil, err
+		}
+	}
+	return c.Next.MultiUpsert(ctx, ei, compressed)
+}
+
+// Range decompresses the values of the rows returned by Next
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	rows, token, err := c.Next.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := c.decompressRows(ei, rows); err != nil {
+		return nil, "", err
+	}
+	return rows, token, nil
+}
+
+// Scan decompresses the values of the rows returned by Next
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	rows, token, err := c.Next.Scan(ctx, ei, minimumFields, token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := c.decompressRows(ei, rows); err != nil {
+		return nil, "", err
+	}
+	return rows, token, nil
+}
+
+// compress returns a copy of values in which the Blob values of non-key
+// columns are compressed if they are longer than the threshold, or start
+// with the magic byte. values itself is not modified.
+func (c *Connector) compress(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	var result map[string]dosa.FieldValue
+	for _, cd := range blobColumns(ei) {
+		data, ok := values[cd.Name].([]byte)
+		if !ok || len(data) == 0 {
+			continue
+		}
+		if len(data) <= c.threshold && data[0] != magic {
+			continue
+		}
+		compressed, err := c.compressor.Compress(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compress %s.%s", ei.Def.Name, cd.Name)
+		}
+		if result == nil {
+			result = make(map[string]dosa.FieldValue, len(values))
+			for k, v := range values {
+				result[k] = v
+			}
+		}
+		result[cd.Name] = append([]byte{magic}, compressed...)
+	}
+	if result == nil {
+		return values, nil
+	}
+	return result, nil
+}
+
+// decompress replaces the compressed Blob values of a row with their
+// decompressed values, in place
+func (c *Connector) decompress(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	for _, cd := range blobColumns(ei) {
+		data, ok := values[cd.Name].([]byte)
+		if !ok || len(data) == 0 || data[0] != magic {
+			continue
+		}
+		decompressed, err := c.compressor.Decompress(data[1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decompress %s.%s", ei.Def.Name, cd.Name)
+		}
+		values[cd.Name] = decompressed
+	}
+	return values, nil
+}
+
+func (c *Connector) decompressRows(ei *dosa.EntityInfo, rows []map[string]dosa.FieldValue) error {
+	for _, row := range rows {
+		if _, err := c.decompress(ei, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobColumns returns the Blob columns of an entity that are not part of its
+// primary key
+func blobColumns(ei *dosa.EntityInfo) []*dosa.ColumnDefinition {
+	keys := ei.Def.KeySet()
+	var cols []*dosa.ColumnDefinition
+	for _, cd := range ei.Def.Columns {
+		if _, isKey := keys[cd.Name]; cd.Type == dosa.Blob && !isKey {
+			cols = append(cols, cd)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/compress"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "docs",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "docs",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String},
+			{Name: "body", Type: dosa.Blob},
+			{Name: "title", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{
+			PartitionKeys: []string{"id"},
+		},
+	},
+}
+
+var ctx = context.Background()
+
+var large = bytes.Repeat([]byte("all work and no play "), 100)
+
+func TestCompress_UpsertRead(t *testing.T) {
+	next := memory.NewConnector()
+	c := compress.NewConnector(next, compress.Options{ThresholdBytes: 16})
+	small := []byte("short")
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "body": large, "title": "t"}))
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "2", "body": small}))
+
+	// large values are stored compressed, small ones as they are
+	stored, err := next.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.True(t, len(stored["body"].([]byte)) < len(large))
+	stored, err = next.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, small, stored["body"])
+
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, large, values["body"])
+	assert.Equal(t, "t", values["title"])
+	values, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, small, values["body"])
+}
+
+func TestCompress_UncompressedRows(t *testing.T) {
+	next := memory.NewConnector()
+	c := compress.NewConnector(next, compress.Options{})
+
+	// rows written before compression was turned on are read as they are
+	assert.NoError(t, next.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "body": large}))
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, large, values["body"])
+
+	// short values that look compressed are compressed anyway
+	tricky := []byte{0xdc, 1, 2}
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "2", "body": tricky}))
+	stored, err := next.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}, dosa.All())
+	assert.NoError(t, err)
+	assert.NotEqual(t, tricky, stored["body"])
+	values, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, tricky, values["body"])
+
+	// a corrupted compressed value is an error
+	assert.NoError(t, next.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "3", "body": tricky}))
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "3"}, dosa.All())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot decompress docs.body")
+}
+
+func TestCompress_ReadPaths(t *testing.T) {
+	c := compress.NewConnector(memory.NewConnector(), compress.Options{ThresholdBytes: 16})
+	results, err := c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{
+		{"id": "1", "body": large},
+		{"id": "2", "body": large},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, nil}, results)
+
+	multi, err := c.MultiRead(ctx, testEi, []map[string]dosa.FieldValue{{"id": "1"}, {"id": "2"}}, dosa.All())
+	assert.NoError(t, err)
+	for _, result := range multi {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, large, result.Values["body"])
+	}
+
+	rows, _, err := c.Range(ctx, testEi, map[string][]*dosa.Condition{
+		"id": {{Op: dosa.Eq, Value: "1"}},
+	}, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, large, rows[0]["body"])
+
+	rows, _, err = c.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	for _, row := range rows {
+		assert.Equal(t, large, row["body"])
+	}
+
+	values, err := c.UpsertAndRead(ctx, testEi, map[string]dosa.FieldValue{"id": "3", "body": large})
+	assert.NoError(t, err)
+	assert.Equal(t, large, values["body"])
+}
+
+func TestCompress_CompareAndSwap(t *testing.T) {
+	c := compress.NewConnector(memory.NewConnector(), compress.Options{ThresholdBytes: 16})
+	other := bytes.Repeat([]byte("and a dull boy "), 100)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "body": large}))
+
+	assert.NoError(t, c.CompareAndSwap(ctx, testEi,
+		map[string]dosa.FieldValue{"id": "1", "body": large},
+		map[string]dosa.FieldValue{"id": "1", "body": other}))
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, other, values["body"])
+}
+
+func benchmarkUpsertRead(b *testing.B, c dosa.Connector) {
+	var body []byte
+	for i := 0; len(body) < 64*1024; i++ {
+		body = append(body, []byte("the quick brown fox jumps over the lazy dog ")...)
+		body = append(body, byte(i))
+	}
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "body": body}); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUncompressed and BenchmarkCompressed compare the latency of
+// writing and reading back a 64KB blob; BenchmarkCompressed also logs how
+// much space the compressed value takes
+func BenchmarkUncompressed(b *testing.B) {
+	benchmarkUpsertRead(b, memory.NewConnector())
+}
+
+func BenchmarkCompressed(b *testing.B) {
+	next := memory.NewConnector()
+	benchmarkUpsertRead(b, compress.NewConnector(next, compress.Options{}))
+	stored, err := next.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Logf("64KB blob stored in %d bytes", len(stored["body"].([]byte)))
+}
@@ -53,6 +53,53 @@ func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[
 	return nil
 }
 
+// CompareAndSwap always returns a not found error
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	return &dosa.ErrNotFound{}
+}
+
+// UpsertWithConditions always returns a not found error
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	return &dosa.ErrNotFound{}
+}
+
+// UpsertAndRead throws away the data you upsert, so there is never a row to return
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	return nil, &dosa.ErrNotFound{}
+}
+
+// Replace throws away the data you replace
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return nil
+}
+
+// AtomicAdd throws away the data, so every counter starts from zero
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if err := dosa.CheckAtomicAdd(ei.Def, column); err != nil {
+		return 0, err
+	}
+	return delta, nil
+}
+
+// Aggregate sees an empty table, so counts are zero and sums are
+// zero values; the other aggregations return a not found error
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	if err := dosa.CheckAggregate(ei.Def, aggFunc, column); err != nil {
+		return nil, err
+	}
+	return dosa.NewAggregator(aggFunc, ei.Def.FindColumnDefinition(column).Type).Result()
+}
+
+// ExplainQuery returns a plan that reads no rows
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	return &dosa.QueryPlan{}, nil
+}
+
+// CountRange always counts zero rows
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	return 0, nil
+}
+
 // makeErrorSlice is a handy function to make a slice of errors or nil errors
 func makeErrorSlice(len int, e error) []error {
 	errors := make([]error, len)
@@ -77,6 +124,11 @@ func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, column
 	return nil
 }
 
+// DeletePartition always returns nil
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	return nil
+}
+
 // MultiRemove returns a not found error for each value
 func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
 	return makeErrorSlice(len(multiValues), &dosa.ErrNotFound{}), nil
@@ -92,6 +144,11 @@ func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields
 	return nil, "", &dosa.ErrNotFound{}
 }
 
+// CopyTable throws away the rows, since there are none to copy
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return nil
+}
+
 // CheckSchema always returns schema version 1
 func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, ed []*dosa.EntityDefinition) (int32, error) {
 	return int32(1), nil
@@ -120,6 +177,16 @@ func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, enti
 	return &dosa.EntityDefinition{}, nil
 }
 
+// DescribeTable returns a copy of the entity's definition, since any schema is accepted
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	return ei.Def.Clone(), nil
+}
+
+// DropTable returns success
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return nil
+}
+
 // CreateScope returns success
 func (c *Connector) CreateScope(ctx context.Context, _ *dosa.ScopeMetadata) error {
 	return nil
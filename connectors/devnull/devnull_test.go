@@ -71,6 +71,93 @@ func TestDevNull_Upsert(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestDevNull_CompareAndSwap(t *testing.T) {
+	err := sut.CompareAndSwap(ctx, testInfo, testValues, testValues)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestDevNull_UpsertAndRead(t *testing.T) {
+	_, err := sut.UpsertAndRead(ctx, testInfo, testValues)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestDevNull_Replace(t *testing.T) {
+	assert.NoError(t, sut.Replace(ctx, testInfo, testValues))
+}
+
+func TestDevNull_UpsertWithConditions(t *testing.T) {
+	err := sut.UpsertWithConditions(ctx, testInfo, testValues, nil)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestDevNull_CopyTable(t *testing.T) {
+	assert.NoError(t, sut.CopyTable(ctx, testInfo, testInfo))
+}
+
+func TestDevNull_DescribeTable(t *testing.T) {
+	ed, err := sut.DescribeTable(ctx, testInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, testInfo.Def, ed)
+}
+
+func TestDevNull_DropTable(t *testing.T) {
+	assert.NoError(t, sut.DropTable(ctx, testInfo))
+	_, err := sut.Read(ctx, testInfo, testValues, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestDevNull_ExplainQuery(t *testing.T) {
+	plan, err := sut.ExplainQuery(ctx, testInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), plan.EstimatedRows)
+}
+
+func TestDevNull_CountRange(t *testing.T) {
+	count, err := sut.CountRange(ctx, testInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDevNull_AtomicAdd(t *testing.T) {
+	ei := &dosa.EntityInfo{
+		Ref: testInfo.Ref,
+		Def: &dosa.EntityDefinition{
+			Name:    "testEntityName",
+			Columns: []*dosa.ColumnDefinition{{Name: "c1", Type: dosa.Int64}, {Name: "c2", Type: dosa.Double}},
+			Key:     &dosa.PrimaryKey{},
+		},
+	}
+	v, err := sut.AtomicAdd(ctx, ei, testValues, "c1", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), v)
+
+	_, err = sut.AtomicAdd(ctx, ei, testValues, "c2", 3)
+	assert.True(t, dosa.ErrorIsInvalidOperation(err))
+}
+
+func TestDevNull_Aggregate(t *testing.T) {
+	ei := &dosa.EntityInfo{
+		Ref: testInfo.Ref,
+		Def: &dosa.EntityDefinition{
+			Name: "testEntityName",
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "c1", Type: dosa.Int64},
+				{Name: "c2", Type: dosa.Double},
+			},
+		},
+	}
+	v, err := sut.Aggregate(ctx, ei, dosa.AggCount, "c1", testConditions)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(0)), v)
+
+	v, err = sut.Aggregate(ctx, ei, dosa.AggSum, "c2", testConditions)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(float64(0)), v)
+
+	_, err = sut.Aggregate(ctx, ei, dosa.AggMax, "c1", testConditions)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
 func TestDevNull_MultiUpsert(t *testing.T) {
 	errs, err := sut.MultiUpsert(ctx, testInfo, testMultiValues)
 	assert.NotNil(t, errs)
@@ -0,0 +1,252 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package errtransform passes the errors of a connector through a function,
+// so that applications can translate the DOSA errors, such as
+// dosa.ErrNotFound, to their own error types or to RPC status codes without
+// changing the connectors.
+package errtransform
+
+import (
+	"context"
+
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// Transformer returns the error to return instead of err, which is never
+// nil. Returning nil keeps err.
+type Transformer func(err error) error
+
+// Connector passes every error returned by Next through a Transformer,
+// including the errors of the individual rows of MultiRead, MultiUpsert and
+// MultiRemove
+type Connector struct {
+	base.Connector
+	transformer Transformer
+}
+
+// NewConnector returns a connector that transforms the errors of next
+func NewConnector(next dosa.Connector, transformer Transformer) *Connector {
+	return &Connector{Connector: base.Connector{Next: next}, transformer: transformer}
+}
+
+// transform returns the transformed error, or err if the transformer
+// returned nil
+func (c *Connector) transform(err error) error {
+	if err == nil {
+		return nil
+	}
+	if transformed := c.transformer(err); transformed != nil {
+		return transformed
+	}
+	return err
+}
+
+// transformAll transforms the errors of a result of MultiUpsert or MultiRemove
+func (c *Connector) transformAll(result []error) []error {
+	for i, err := range result {
+		result[i] = c.transform(err)
+	}
+	return result
+}
+
+// CreateIfNotExists transforms the error of Next
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.CreateIfNotExists(ctx, ei, values))
+}
+
+// Read transforms the error of Next
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	values, err := c.Connector.Read(ctx, ei, keys, minimumFields)
+	return values, c.transform(err)
+}
+
+// MultiRead transforms the error of Next and those of the rows
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, keys []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	results, err := c.Connector.MultiRead(ctx, ei, keys, minimumFields)
+	for _, result := range results {
+		if result != nil {
+			result.Error = c.transform(result.Error)
+		}
+	}
+	return results, c.transform(err)
+}
+
+// Upsert transforms the error of Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.Upsert(ctx, ei, values))
+}
+
+// CompareAndSwap transforms the error of Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.CompareAndSwap(ctx, ei, conditions, newValues))
+}
+
+// UpsertWithConditions transforms the error of Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	return c.transform(c.Connector.UpsertWithConditions(ctx, ei, values, conditions))
+}
+
+// UpsertAndRead transforms the error of Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	result, err := c.Connector.UpsertAndRead(ctx, ei, values)
+	return result, c.transform(err)
+}
+
+// Replace transforms the error of Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.Replace(ctx, ei, values))
+}
+
+// AtomicAdd transforms the error of Next
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	value, err := c.Connector.AtomicAdd(ctx, ei, keys, column, delta)
+	return value, c.transform(err)
+}
+
+// Aggregate transforms the error of Next
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	value, err := c.Connector.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+	return value, c.transform(err)
+}
+
+// MultiUpsert transforms the error of Next and those of the rows
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	result, err := c.Connector.MultiUpsert(ctx, ei, multiValues)
+	return c.transformAll(result), c.transform(err)
+}
+
+// Remove transforms the error of Next
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.Remove(ctx, ei, keys))
+}
+
+// RemoveRange transforms the error of Next
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	return c.transform(c.Connector.RemoveRange(ctx, ei, columnConditions))
+}
+
+// DeletePartition transforms the error of Next
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	return c.transform(c.Connector.DeletePartition(ctx, ei, pk))
+}
+
+// MultiRemove transforms the error of Next and those of the rows
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiKeys []map[string]dosa.FieldValue) ([]error, error) {
+	result, err := c.Connector.MultiRemove(ctx, ei, multiKeys)
+	return c.transformAll(result), c.transform(err)
+}
+
+// Range transforms the error of Next
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	rows, next, err := c.Connector.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+	return rows, next, c.transform(err)
+}
+
+// Scan transforms the error of Next
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	rows, next, err := c.Connector.Scan(ctx, ei, minimumFields, token, limit)
+	return rows, next, c.transform(err)
+}
+
+// CopyTable transforms the error of Next
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return c.transform(c.Connector.CopyTable(ctx, src, dst))
+}
+
+// ExplainQuery transforms the error of Next
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	plan, err := c.Connector.ExplainQuery(ctx, ei, columnConditions)
+	return plan, c.transform(err)
+}
+
+// CountRange transforms the error of Next
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	count, err := c.Connector.CountRange(ctx, ei, columnConditions)
+	return count, c.transform(err)
+}
+
+// CheckSchema transforms the error of Next
+func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	version, err := c.Connector.CheckSchema(ctx, scope, namePrefix, eds)
+	return version, c.transform(err)
+}
+
+// CanUpsertSchema transforms the error of Next
+func (c *Connector) CanUpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	version, err := c.Connector.CanUpsertSchema(ctx, scope, namePrefix, eds)
+	return version, c.transform(err)
+}
+
+// UpsertSchema transforms the error of Next
+func (c *Connector) UpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (*dosa.SchemaStatus, error) {
+	status, err := c.Connector.UpsertSchema(ctx, scope, namePrefix, eds)
+	return status, c.transform(err)
+}
+
+// CheckSchemaStatus transforms the error of Next
+func (c *Connector) CheckSchemaStatus(ctx context.Context, scope, namePrefix string, version int32) (*dosa.SchemaStatus, error) {
+	status, err := c.Connector.CheckSchemaStatus(ctx, scope, namePrefix, version)
+	return status, c.transform(err)
+}
+
+// GetEntitySchema transforms the error of Next
+func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*dosa.EntityDefinition, error) {
+	ed, err := c.Connector.GetEntitySchema(ctx, scope, namePrefix, entityName, version)
+	return ed, c.transform(err)
+}
+
+// DescribeTable transforms the error of Next
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	ed, err := c.Connector.DescribeTable(ctx, ei)
+	return ed, c.transform(err)
+}
+
+// DropTable transforms the error of Next
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return c.transform(c.Connector.DropTable(ctx, ei))
+}
+
+// CreateScope transforms the error of Next
+func (c *Connector) CreateScope(ctx context.Context, md *dosa.ScopeMetadata) error {
+	return c.transform(c.Connector.CreateScope(ctx, md))
+}
+
+// TruncateScope transforms the error of Next
+func (c *Connector) TruncateScope(ctx context.Context, scope string) error {
+	return c.transform(c.Connector.TruncateScope(ctx, scope))
+}
+
+// DropScope transforms the error of Next
+func (c *Connector) DropScope(ctx context.Context, scope string) error {
+	return c.transform(c.Connector.DropScope(ctx, scope))
+}
+
+// ScopeExists transforms the error of Next
+func (c *Connector) ScopeExists(ctx context.Context, scope string) (bool, error) {
+	exists, err := c.Connector.ScopeExists(ctx, scope)
+	return exists, c.transform(err)
+}
+
+// Shutdown transforms the error of Next
+func (c *Connector) Shutdown() error {
+	return c.transform(c.Connector.Shutdown())
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errtransform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/errtransform"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{Scope: "testScope", NamePrefix: "testPrefix", EntityName: "users"},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+var errMissing = errors.New("missing")
+
+// toMissing translates not found errors to errMissing, and keeps the others
+func toMissing(err error) error {
+	if dosa.ErrorIsNotFound(err) {
+		return errMissing
+	}
+	return nil
+}
+
+func TestErrTransform(t *testing.T) {
+	c := errtransform.NewConnector(memory.NewConnector(), toMissing)
+
+	_, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.Equal(t, errMissing, err)
+
+	// no error, nothing to transform
+	assert.NoError(t, c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "a"}))
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", values["name"])
+
+	// errors the transformer returns nil for are kept
+	err = c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "a"})
+	assert.True(t, dosa.ErrorIsAlreadyExists(err))
+}
+
+func TestErrTransform_Rows(t *testing.T) {
+	c := errtransform.NewConnector(memory.NewConnector(), toMissing)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "a"}))
+
+	results, err := c.MultiRead(ctx, testEi, []map[string]dosa.FieldValue{{"id": "1"}, {"id": "2"}}, dosa.All())
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, errMissing, results[1].Error)
+}
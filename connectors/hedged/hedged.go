@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hedged sends the reads of an entity to several replicas of the
+// same data, one after the other, and returns the first answer, to cut the
+// latency of the slowest reads.
+package hedged
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// Connector sends each Read, MultiRead, Range and Scan to the first backend,
+// and to the next one every time delay passes without an answer, or as soon
+// as a call fails. The first answer wins and the calls still running are
+// canceled; the error of the last backend is returned only when every
+// backend failed. ErrNotFound is an answer rather than a failure, so reads
+// of missing rows are not sent to every backend.
+//
+// The other operations, writes included, only go to the first backend: the
+// backends must replicate the data between themselves. Shutdown shuts down
+// all of them.
+type Connector struct {
+	base.Connector
+	backends []dosa.Connector
+	delay    time.Duration
+}
+
+// NewConnector creates a connector that hedges the reads over the backends
+func NewConnector(backends []dosa.Connector, delay time.Duration) (*Connector, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("at least one backend is required")
+	}
+	if delay < 0 {
+		return nil, errors.Errorf("delay must not be negative, got %v", delay)
+	}
+	return &Connector{
+		Connector: base.Connector{Next: backends[0]},
+		backends:  append([]dosa.Connector(nil), backends...),
+		delay:     delay,
+	}, nil
+}
+
+// result is what a call to a backend returned
+type result struct {
+	value interface{}
+	err   error
+}
+
+// hedge calls f on the backends as described on Connector, and returns the
+// value of the winning call
+func (c *Connector) hedge(ctx context.Context, f func(ctx context.Context, backend dosa.Connector) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancels the calls that lost
+
+	// buffered so that the calls that lost don't block once we returned
+	results := make(chan result, len(c.backends))
+	started, running := 0, 0
+	var hedge <-chan time.Time
+	start := func() {
+		backend := c.backends[started]
+		started++
+		running++
+		go func() {
+			value, err := f(ctx, backend)
+			results <- result{value: value, err: err}
+		}()
+		hedge = nil
+		if started < len(c.backends) {
+			hedge = time.After(c.delay)
+		}
+	}
+
+	start()
+	for {
+		select {
+		case r := <-results:
+			running--
+			if r.err == nil || dosa.ErrorIsNotFound(r.err) {
+				return r.value, r.err
+			}
+			if started < len(c.backends) {
+				start()
+			} else if running == 0 {
+				return nil, r.err
+			}
+		case <-hedge:
+			start()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Read hedges the read over the backends
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	value, err := c.hedge(ctx, func(ctx context.Context, backend dosa.Connector) (interface{}, error) {
+		return backend.Read(ctx, ei, keys, minimumFields)
+	})
+	values, _ := value.(map[string]dosa.FieldValue)
+	return values, err
+}
+
+// MultiRead hedges the read over the backends. An answer with errors for
+// some of the rows still wins.
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, keys []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	value, err := c.hedge(ctx, func(ctx context.Context, backend dosa.Connector) (interface{}, error) {
+		return backend.MultiRead(ctx, ei, keys, minimumFields)
+	})
+	results, _ := value.([]*dosa.FieldValuesOrError)
+	return results, err
+}
+
+// page is a page of rows returned by Range or Scan
+type page struct {
+	rows  []map[string]dosa.FieldValue
+	token string
+}
+
+// Range hedges the read over the backends. Page tokens must be valid on all
+// the backends, which is the case when they are the same kind of connector.
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	value, err := c.hedge(ctx, func(ctx context.Context, backend dosa.Connector) (interface{}, error) {
+		rows, next, err := backend.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+		return page{rows: rows, token: next}, err
+	})
+	p, _ := value.(page)
+	return p.rows, p.token, err
+}
+
+// Scan hedges the read over the backends, with the same restriction on page
+// tokens as Range
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	value, err := c.hedge(ctx, func(ctx context.Context, backend dosa.Connector) (interface{}, error) {
+		rows, next, err := backend.Scan(ctx, ei, minimumFields, token, limit)
+		return page{rows: rows, token: next}, err
+	})
+	p, _ := value.(page)
+	return p.rows, p.token, err
+}
+
+// Shutdown shuts down all the backends, and returns the first error
+func (c *Connector) Shutdown() error {
+	var first error
+	for _, backend := range c.backends {
+		if err := backend.Shutdown(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
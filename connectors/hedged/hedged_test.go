@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedged_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+	"github.com/uber-go/dosa/connectors/hedged"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{Scope: "testScope", NamePrefix: "testPrefix", EntityName: "users"},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+var key = map[string]dosa.FieldValue{"id": "1"}
+
+// slowConnector is a memory connector whose reads take latency, or fail with
+// err if it is set
+type slowConnector struct {
+	base.Connector
+	latency  time.Duration
+	err      error
+	calls    int32
+	canceled int32
+}
+
+// newSlowConnector returns a slowConnector holding a user with the name
+func newSlowConnector(t *testing.T, name string, latency time.Duration) *slowConnector {
+	c := &slowConnector{Connector: base.Connector{Next: memory.NewConnector()}, latency: latency}
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": name}))
+	return c
+}
+
+func (c *slowConnector) wait(ctx context.Context) error {
+	atomic.AddInt32(&c.calls, 1)
+	select {
+	case <-time.After(c.latency):
+		return c.err
+	case <-ctx.Done():
+		atomic.AddInt32(&c.canceled, 1)
+		return ctx.Err()
+	}
+}
+
+func (c *slowConnector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.Connector.Read(ctx, ei, keys, minimumFields)
+}
+
+func (c *slowConnector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, "", err
+	}
+	return c.Connector.Scan(ctx, ei, minimumFields, token, limit)
+}
+
+func TestNewConnector(t *testing.T) {
+	_, err := hedged.NewConnector(nil, time.Millisecond)
+	assert.Error(t, err)
+	_, err = hedged.NewConnector([]dosa.Connector{memory.NewConnector()}, -time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestHedged_FastFirst(t *testing.T) {
+	first := newSlowConnector(t, "first", 0)
+	second := newSlowConnector(t, "second", 0)
+	c, err := hedged.NewConnector([]dosa.Connector{first, second}, time.Second)
+	assert.NoError(t, err)
+
+	values, err := c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", values["name"])
+	assert.Equal(t, int32(0), atomic.LoadInt32(&second.calls))
+}
+
+func TestHedged_SlowFirst(t *testing.T) {
+	first := newSlowConnector(t, "first", time.Second)
+	second := newSlowConnector(t, "second", 0)
+	c, err := hedged.NewConnector([]dosa.Connector{first, second}, 10*time.Millisecond)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	values, err := c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", values["name"])
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	// the losing call is canceled
+	for i := 0; i < 100 && atomic.LoadInt32(&first.canceled) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&first.canceled))
+
+	rows, _, err := c.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", rows[0]["name"])
+}
+
+func TestHedged_Failures(t *testing.T) {
+	first := newSlowConnector(t, "first", 0)
+	first.err = errors.New("unavailable")
+	second := newSlowConnector(t, "second", 0)
+	c, err := hedged.NewConnector([]dosa.Connector{first, second}, time.Second)
+	assert.NoError(t, err)
+
+	// a failure moves on to the next backend without waiting for the delay
+	start := time.Now()
+	values, err := c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", values["name"])
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	// the last error is returned when all fail
+	second.err = errors.New("also unavailable")
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.EqualError(t, err, "also unavailable")
+
+	// not found is an answer
+	second.err = nil
+	first.err = &dosa.ErrNotFound{}
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&second.calls))
+}
+
+func TestHedged_Writes(t *testing.T) {
+	first := newSlowConnector(t, "first", 0)
+	second := newSlowConnector(t, "second", 0)
+	c, err := hedged.NewConnector([]dosa.Connector{first, second}, time.Second)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "updated"}))
+	values, err := first.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", values["name"])
+	values, err = second.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", values["name"])
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package immutable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// ErrImmutableViolation is returned when an upsert tries to change the value
+// of a column that is marked as immutable
+type ErrImmutableViolation struct {
+	Entity  string
+	Columns []string
+}
+
+// Error satisfies the error interface
+func (e *ErrImmutableViolation) Error() string {
+	return fmt.Sprintf("cannot change immutable columns of %s: %s", e.Entity, strings.Join(e.Columns, ", "))
+}
+
+// ErrorIsImmutableViolation checks if the error is caused by "ErrImmutableViolation"
+func ErrorIsImmutableViolation(err error) bool {
+	_, ok := errors.Cause(err).(*ErrImmutableViolation)
+	return ok
+}
+
+// Connector rejects upserts that would change the value of an immutable
+// column. Before each upsert the current row is read from Next and the
+// immutable columns are compared with the new values. Columns that were never
+// written (missing or null) may still be set. CreateIfNotExists is passed
+// through unchecked since the row can't exist yet.
+type Connector struct {
+	base.Connector
+}
+
+// NewConnector creates a new immutable connector
+func NewConnector(next dosa.Connector) *Connector {
+	return &Connector{Connector: base.Connector{Next: next}}
+}
+
+// Upsert checks the immutable columns against the current row before calling Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// MultiUpsert checks each row like Upsert does; only rows that pass are sent to Next
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	result := make([]error, len(multiValues))
+	var pass []map[string]dosa.FieldValue
+	var passIdx []int
+	for i, values := range multiValues {
+		if err := c.check(ctx, ei, values); err != nil {
+			result[i] = err
+			continue
+		}
+		pass = append(pass, values)
+		passIdx = append(passIdx, i)
+	}
+	if len(pass) == 0 {
+		return result, nil
+	}
+	nextResult, err := c.Next.MultiUpsert(ctx, ei, pass)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range passIdx {
+		if i < len(nextResult) {
+			result[idx] = nextResult[i]
+		}
+	}
+	return result, nil
+}
+
+// UpsertAndRead checks the immutable columns against the current row before calling Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if err := c.check(ctx, ei, values); err != nil {
+		return nil, err
+	}
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Replace checks the immutable columns against the current row before calling Next. An
+// immutable column missing from values would be cleared, so that is a violation too.
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	replaced := make(map[string]dosa.FieldValue, len(values))
+	for k, v := range values {
+		replaced[k] = v
+	}
+	for _, name := range ei.Def.ImmutableColumns() {
+		if _, ok := replaced[name]; !ok {
+			replaced[name] = nil
+		}
+	}
+	if err := c.check(ctx, ei, replaced); err != nil {
+		return err
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// AtomicAdd rejects adding to an immutable column that already holds a value
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if cd := ei.Def.FindColumnDefinition(column); cd != nil && cd.Immutable {
+		current, err := c.Next.Read(ctx, ei, keys, []string{column})
+		if err != nil && !dosa.ErrorIsNotFound(err) {
+			return 0, errors.Wrap(err, "failed to read current value of immutable column")
+		}
+		if err == nil && !dosa.IsNull(current[column]) {
+			return 0, &ErrImmutableViolation{Entity: ei.Def.Name, Columns: []string{column}}
+		}
+	}
+	return c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+// CompareAndSwap checks the immutable columns in newValues against the current row before calling Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	values := make(map[string]dosa.FieldValue, len(newValues))
+	for k, v := range newValues {
+		values[k] = v
+	}
+	for k := range ei.Def.KeySet() {
+		values[k] = conditions[k]
+	}
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions checks the immutable columns in values against the current row before calling Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// CopyTable scans src and writes the rows to dst through this connector when
+// dst has immutable columns, so each row is checked like MultiUpsert does
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	if len(dst.Def.ImmutableColumns()) == 0 {
+		return c.Next.CopyTable(ctx, src, dst)
+	}
+	return dosa.CopyTableByScanning(ctx, c, src, dst)
+}
+
+// check returns an ErrImmutableViolation if values would change an immutable
+// column that already holds a value
+func (c *Connector) check(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	var columns []string
+	for _, name := range ei.Def.ImmutableColumns() {
+		if _, ok := values[name]; ok {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	keys := make(map[string]dosa.FieldValue)
+	for k := range ei.Def.KeySet() {
+		keys[k] = values[k]
+	}
+	current, err := c.Next.Read(ctx, ei, keys, columns)
+	if dosa.ErrorIsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read current values of immutable columns")
+	}
+
+	var changed []string
+	for _, name := range columns {
+		if dosa.IsNull(current[name]) {
+			continue
+		}
+		if !dosa.SameFieldValue(current[name], values[name]) {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) > 0 {
+		return &ErrImmutableViolation{Entity: ei.Def.Name, Columns: changed}
+	}
+	return nil
+}
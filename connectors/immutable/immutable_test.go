@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package immutable_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/immutable"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+			{Name: "createdat", Type: dosa.Timestamp, Immutable: true},
+			{Name: "createdby", Type: dosa.String, IsPointer: true, Immutable: true},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+func TestImmutable_CreateIfNotExists(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	}))
+	err := c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	})
+	assert.True(t, dosa.ErrorIsAlreadyExists(err))
+}
+
+func TestImmutable_Upsert(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+
+	// the row doesn't exist yet
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created, "createdby": (*string)(nil),
+	}))
+
+	// mutable columns can change, and immutable ones can be rewritten with the same value
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "bar", "createdat": created.UTC(),
+	}))
+
+	// immutable columns that were never set can be written once
+	creator := "alice"
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdby": &creator,
+	}))
+
+	// but not changed afterwards
+	other := "bob"
+	err := c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdat": created.Add(time.Second), "createdby": &other,
+	})
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+	assert.EqualError(t, err, "cannot change immutable columns of users: createdat, createdby")
+
+	// nor with a value of another type, which is never the same
+	err = c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdat": created.Unix(),
+	})
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", values["name"])
+	assert.Equal(t, &creator, values["createdby"])
+}
+
+func TestImmutable_Replace(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.Replace(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	}))
+
+	// the immutable columns have to be replaced with the same values
+	assert.NoError(t, c.Replace(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "bar", "createdat": created,
+	}))
+
+	// leaving an immutable column out would clear it
+	err := c.Replace(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "baz",
+	})
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+	assert.EqualError(t, err, "cannot change immutable columns of users: createdat")
+}
+
+func TestImmutable_AtomicAdd(t *testing.T) {
+	ei := &dosa.EntityInfo{
+		Ref: testEi.Ref,
+		Def: &dosa.EntityDefinition{
+			Name: "counters",
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "id", Type: dosa.Int64},
+				{Name: "views", Type: dosa.Int64},
+				{Name: "initial", Type: dosa.Int64, Immutable: true},
+			},
+			Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		},
+	}
+	c := immutable.NewConnector(memory.NewConnector())
+	key := map[string]dosa.FieldValue{"id": int64(1)}
+
+	v, err := c.AtomicAdd(ctx, ei, key, "views", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+
+	// an immutable column can be set once
+	v, err = c.AtomicAdd(ctx, ei, key, "initial", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), v)
+	_, err = c.AtomicAdd(ctx, ei, key, "initial", 10)
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+}
+
+func TestImmutable_MultiUpsert(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdat": created,
+	}))
+
+	errs, err := c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{
+		{"id": int64(1), "createdat": created.Add(time.Second)},
+		{"id": int64(2), "createdat": created},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, errs, 2)
+	assert.True(t, immutable.ErrorIsImmutableViolation(errs[0]))
+	assert.NoError(t, errs[1])
+
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2)}, dosa.All())
+	assert.NoError(t, err)
+}
+
+func TestImmutable_UpsertWithConditions(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	}))
+	conds := map[string][]*dosa.Condition{"name": {{Op: dosa.Eq, Value: "foo"}}}
+
+	err := c.UpsertWithConditions(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdat": created.Add(time.Second),
+	}, conds)
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+
+	assert.NoError(t, c.UpsertWithConditions(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "bar", "createdat": created,
+	}, conds))
+}
+
+func TestImmutable_CopyTable(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	src := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	src.Def.Name = "users_src"
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.Upsert(ctx, src, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	}))
+
+	// copying to a table without immutable columns goes straight to Next
+	plain := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	plain.Def.Name = "users_plain"
+	for _, cd := range plain.Def.Columns {
+		cd.Immutable = false
+	}
+	assert.NoError(t, c.CopyTable(ctx, src, plain))
+
+	// each copied row is checked against the destination
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "createdat": created.Add(time.Second),
+	}))
+	err := c.CopyTable(ctx, src, testEi)
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+}
+
+func TestImmutable_CompareAndSwap(t *testing.T) {
+	c := immutable.NewConnector(memory.NewConnector())
+	created := time.Unix(100, 0)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo", "createdat": created,
+	}))
+
+	err := c.CompareAndSwap(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo",
+	}, map[string]dosa.FieldValue{
+		"createdat": created.Add(time.Second),
+	})
+	assert.True(t, immutable.ErrorIsImmutableViolation(err))
+
+	assert.NoError(t, c.CompareAndSwap(ctx, testEi, map[string]dosa.FieldValue{
+		"id": int64(1), "name": "foo",
+	}, map[string]dosa.FieldValue{
+		"name": "bar",
+	}))
+}
@@ -21,14 +21,14 @@
 package memory
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
+	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/uber-go/dosa"
 	"github.com/uber-go/dosa/connectors/base"
@@ -49,13 +49,29 @@ import (
 // writes are not. There is no attempt to improve the concurrency of the read or write path by
 // adding more granular locks.
 //
+// Columns that were never written are missing from the row, while null values (see
+// dosa.NullTimestamp) are stored as the nil pointers they are, so reads return them as null.
+//
+// Every change is also appended to an in-process log, which StreamChanges reads from; the log is
+// only discarded by Shutdown.
+//
 // NOTE: The memory connector doesn't support TTL. All the data is stored in memory until a manual delete.
+// The one exception is the TTL of an index (see dosa.IndexDefinition): its entries record when
+// they expire, and reads through the index skip the expired ones.
 type Connector struct {
 	base.Connector
-	data map[string]map[string][]map[string]dosa.FieldValue
-	lock sync.RWMutex
+	data    map[string]map[string][]map[string]dosa.FieldValue
+	schemas map[string]*dosa.EntityDefinition // the definitions the tables were created with
+	lock    sync.RWMutex
+	changes []dosa.ChangeEvent
+	changed chan struct{}
+	now     func() time.Time // the clock for index TTLs
 }
 
+// indexExpiresColumn holds the time an entry of an index with a TTL expires. It's not a valid
+// column name, so it can't clash with a column, and it's removed from the rows that are returned.
+const indexExpiresColumn = "$expires"
+
 // partitionRange represents one section of a partition.
 type partitionRange struct {
 	entityRef    map[string][]map[string]dosa.FieldValue
@@ -120,20 +136,8 @@ func findInsertionPoint(pk *dosa.PrimaryKey, data []map[string]dosa.FieldValue,
 }
 
 // compareRows compares two maps of row data based on clustering keys.
-func compareRows(pk *dosa.PrimaryKey, v1 map[string]dosa.FieldValue, v2 map[string]dosa.FieldValue) (cmp int8) {
-	keys := pk.ClusteringKeys
-	for _, key := range keys {
-		d1 := v1[key.Name]
-		d2 := v2[key.Name]
-		cmp = compareType(d1, d2)
-		if key.Descending {
-			cmp = -cmp
-		}
-		if cmp != 0 {
-			return cmp
-		}
-	}
-	return cmp
+func compareRows(pk *dosa.PrimaryKey, v1 map[string]dosa.FieldValue, v2 map[string]dosa.FieldValue) int8 {
+	return int8(dosa.NewSortKey(pk, v1).Compare(dosa.NewSortKey(pk, v2)))
 }
 
 // copyRows copies all the rows in the given slice and returns a new slice with copies
@@ -156,99 +160,46 @@ func copyRow(row map[string]dosa.FieldValue) map[string]dosa.FieldValue {
 	return copied
 }
 
-// compareType compares a single DOSA field based on the type. This code assumes the types of each
-// of the columns are the same, or it will panic
-func compareType(d1 dosa.FieldValue, d2 dosa.FieldValue) int8 {
-	switch d1 := d1.(type) {
-	case dosa.UUID:
-		u1 := uuid.FromStringOrNil(string(d1))
-		u2 := uuid.FromStringOrNil(string(d2.(dosa.UUID)))
-		if u1.Version() != u2.Version() {
-			if u1.Version() < u2.Version() {
-				return -1
-			}
-			return 1
-		}
-		if u1.Version() == 1 {
-			// compare time UUIDs
-			t1, _ := uuid.TimestampFromV1(u1)
-			t2, _ := uuid.TimestampFromV1(u2)
-			if t1 == t2 {
-				return 0
-			}
-			if t1 < t2 {
-				return -1
-			}
-			return 1
-		}
+// copyRowOrNil works like copyRow, but keeps a nil row nil
+func copyRowOrNil(row map[string]dosa.FieldValue) map[string]dosa.FieldValue {
+	if row == nil {
+		return nil
+	}
+	return copyRow(row)
+}
 
-		// version
-		if string(d1) == string(d2.(dosa.UUID)) {
-			return 0
-		}
-		if string(d1) < string(d2.(dosa.UUID)) {
-			return -1
-		}
-		return 1
-	case string:
-		if d1 == d2.(string) {
-			return 0
-		}
-		if d1 < d2.(string) {
-			return -1
-		}
-		return 1
-	case int64:
-		if d1 == d2.(int64) {
-			return 0
-		}
-		if d1 < d2.(int64) {
-			return -1
-		}
-		return 1
-	case int32:
-		if d1 == d2.(int32) {
-			return 0
-		}
-		if d1 < d2.(int32) {
-			return -1
-		}
-		return 1
-	case float64:
-		if d1 == d2.(float64) {
-			return 0
-		}
-		if d1 < d2.(float64) {
-			return -1
-		}
-		return 1
-	case []byte:
-		c := bytes.Compare(d1, d2.([]byte))
-		if c == 0 {
-			return 0
-		}
-		if c < 0 {
-			return -1
-		}
-		return 1
-	case time.Time:
-		if d1.Equal(d2.(time.Time)) {
-			return 0
-		}
-		if d1.Before(d2.(time.Time)) {
-			return -1
-		}
-		return 1
-	case bool:
-		if d1 == d2.(bool) {
-			return 0
-		}
-		if d1 == false {
-			return -1
+// indexEntry returns the values to insert into an index. The entries of an index with a TTL
+// are copies that record when they expire; the others share the values of the row.
+func (c *Connector) indexEntry(iDef *dosa.IndexDefinition, values map[string]dosa.FieldValue) map[string]dosa.FieldValue {
+	if iDef.TTL <= 0 {
+		return values
+	}
+	entry := copyRow(values)
+	entry[indexExpiresColumn] = c.now().Add(iDef.TTL)
+	return entry
+}
+
+// expired tells whether row is an index entry whose TTL has passed
+func (c *Connector) expired(row map[string]dosa.FieldValue) bool {
+	expires, ok := row[indexExpiresColumn].(time.Time)
+	return ok && !c.now().Before(expires)
+}
+
+// unexpired returns the rows, leaving out the expired index entries
+func (c *Connector) unexpired(rows []map[string]dosa.FieldValue) []map[string]dosa.FieldValue {
+	live := make([]map[string]dosa.FieldValue, 0, len(rows))
+	for _, row := range rows {
+		if !c.expired(row) {
+			live = append(live, row)
 		}
-		return 1
 	}
-	panic(d1)
+	return live
+}
+
+// compareType compares a single DOSA field based on the type. This code assumes the types of each
+// of the columns are the same, or it will panic
+func compareType(d1 dosa.FieldValue, d2 dosa.FieldValue) int8 {
+	return int8(dosa.CompareFieldValues(d1, d2))
 }
 
 // CreateIfNotExists inserts a row if it isn't already there. The basic flow is:
@@ -260,18 +211,21 @@ func (c *Connector) CreateIfNotExists(_ context.Context, ei *dosa.EntityInfo, va
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	c.createTable(ei)
 	valsCopy := copyRow(values)
-	_, err := c.mergedInsert(ei.Def.Name, ei.Def.Key, valsCopy, func(into map[string]dosa.FieldValue, from map[string]dosa.FieldValue) error {
+	_, _, err := c.mergedInsert(ei.Def.Name, ei.Def.Key, valsCopy, func(into map[string]dosa.FieldValue, from map[string]dosa.FieldValue) error {
 		return &dosa.ErrAlreadyExists{}
 	}, false)
 	if err != nil {
+		c.Logger().Debugf("memory: CreateIfNotExists on %q failed: %v", ei.Def.Name, err)
 		return err
 	}
 	for iName, iDef := range ei.Def.Indexes {
 		// this error must be ignored, so we skip indexes when the value
 		// for one of the index fields is not specified
-		_, _ = c.mergedInsert(iName, ei.Def.UniqueKey(iDef.Key), valsCopy, overwriteValuesFunc, false)
+		_, _, _ = c.mergedInsert(iName, ei.Def.UniqueKey(iDef.Key), c.indexEntry(iDef, valsCopy), overwriteValuesFunc, false)
 	}
+	c.publish(ei, dosa.ChangeUpsert, nil, valsCopy)
 	return nil
 }
 
@@ -284,6 +238,7 @@ func (c *Connector) Read(_ context.Context, ei *dosa.EntityInfo, values map[stri
 	entityRef := c.data[ei.Def.Name]
 	encodedPartitionKey, err := partitionKeyBuilder(ei.Def.Key, values)
 	if err != nil {
+		c.Logger().Debugf("memory: Read on %q with incomplete key %v", ei.Def.Name, c.Redact(ei, values))
 		return nil, errors.Wrapf(err, "Cannot build partition key for entity %q", ei.Def.Name)
 	}
 	if c.data[ei.Def.Name] == nil {
@@ -357,28 +312,257 @@ func overwriteValuesFunc(into map[string]dosa.FieldValue, from map[string]dosa.F
 func (c *Connector) Upsert(_ context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	_, err := c.upsert(ei, values)
+	return err
+}
 
-	valsCopy := copyRow(values)
-	var oldValues map[string]dosa.FieldValue
-	var err error
-	if oldValues, err = c.mergedInsert(ei.Def.Name, ei.Def.Key, valsCopy, overwriteValuesFunc, true); err != nil {
+// UpsertAndRead is an Upsert followed by a Read, both done while holding the write lock
+func (c *Connector) UpsertAndRead(_ context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	row, err := c.upsert(ei, values)
+	if err != nil {
+		return nil, err
+	}
+	return copyRow(row), nil
+}
+
+// Replace removes the current row, if any, and inserts values, both done while holding the write lock
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, err := partitionKeyBuilder(ei.Def.Key, values); err != nil {
+		return errors.Wrapf(err, "Cannot build partition key for %q", ei.Def.Name)
+	}
+	old := c.findRow(ei.Def.Name, ei.Def.Key, values)
+	if old != nil {
+		c.removeItem(ei.Def.Name, ei.Def.Key, old)
+		for iName, iDef := range ei.Def.Indexes {
+			c.removeItem(iName, ei.Def.UniqueKey(iDef.Key), old)
+		}
+	}
+	_, row, err := c.store(ei, values)
+	if err != nil {
 		return err
 	}
+	c.publish(ei, dosa.ChangeUpsert, old, row)
+	return nil
+}
+
+// AtomicAdd reads the counter and writes back the sum, both done while holding the write lock
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if err := dosa.CheckAtomicAdd(ei.Def, column); err != nil {
+		return 0, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	sum := delta
+	if row := c.findRow(ei.Def.Name, ei.Def.Key, keys); row != nil {
+		if current, ok := dosa.AsInt64(row[column]); ok {
+			sum += current
+		}
+	}
+	values := copyRow(keys)
+	values[column] = sum
+	if ei.Def.FindColumnDefinition(column).IsPointer {
+		values[column] = &sum
+	}
+	if _, err := c.upsert(ei, values); err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// Aggregate always aggregates client-side, by scanning the matching rows
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	return dosa.AggregateByScanning(ctx, c, ei, aggFunc, column, columnConditions)
+}
+
+// ExplainQuery returns a synthetic plan: the one that follows from the schema (see
+// dosa.PlanFromConditions), with the exact number of rows Range would read
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	plan := dosa.PlanFromConditions(ei, columnConditions)
+	plan.EstimatedRows = 0
+	if plan.FullScan {
+		for _, partition := range c.data[ei.Def.Name] {
+			plan.EstimatedRows += int64(len(partition))
+		}
+		return plan, nil
+	}
+	partitionRange, _, err := c.findRange(ctx, ei, columnConditions, true)
+	if err != nil {
+		return nil, err
+	}
+	if partitionRange != nil {
+		plan.EstimatedRows = int64(len(c.unexpired(partitionRange.values())))
+	}
+	return plan, nil
+}
+
+// CountRange counts the rows Range would read with the same conditions
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	partitionRange, _, err := c.findRange(ctx, ei, columnConditions, true)
+	if err != nil {
+		return 0, errors.Wrap(err, "Invalid range conditions")
+	}
+	if partitionRange == nil {
+		return 0, nil
+	}
+	var count int64
+	for _, row := range partitionRange.values() {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if !c.expired(row) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// upsert does the work of Upsert and returns the stored row (not a copy), the
+// caller must hold the write lock
+func (c *Connector) upsert(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	oldValues, row, err := c.store(ei, values)
+	if err != nil {
+		return nil, err
+	}
+	c.publish(ei, dosa.ChangeUpsert, oldValues, row)
+	return row, nil
+}
+
+// store merges values into the entity and its indexes, returning a copy of
+// the row as it was before, if any, and the stored row after the merge; the
+// caller must hold the write lock
+func (c *Connector) store(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, map[string]dosa.FieldValue, error) {
+	c.createTable(ei)
+	valsCopy := copyRow(values)
+	oldValues, row, err := c.mergedInsert(ei.Def.Name, ei.Def.Key, valsCopy, overwriteValuesFunc, true)
+	if err != nil {
+		c.Logger().Debugf("memory: Upsert on %q failed: %v", ei.Def.Name, err)
+		return nil, nil, err
+	}
 	for iName, iDef := range ei.Def.Indexes {
 		if oldValues != nil {
 			c.removeItem(iName, ei.Def.UniqueKey(iDef.Key), oldValues)
 		}
-		_, _ = c.mergedInsert(iName, ei.Def.UniqueKey(iDef.Key), valsCopy, overwriteValuesFunc, false)
+		_, _, _ = c.mergedInsert(iName, ei.Def.UniqueKey(iDef.Key), c.indexEntry(iDef, valsCopy), overwriteValuesFunc, false)
 	}
 
+	return oldValues, row, nil
+}
+
+// createTable records the definition of the entity when its table is created
+// by the first write; the caller must hold the write lock
+func (c *Connector) createTable(ei *dosa.EntityInfo) {
+	if _, ok := c.schemas[ei.Def.Name]; !ok {
+		c.schemas[ei.Def.Name] = ei.Def.Clone()
+	}
+}
+
+// CompareAndSwap updates a row while holding the write lock, so that the guard columns in conditions
+// are compared to the current row and the new values written without any other writer in between.
+// The conditions must contain every primary key column; the new values cannot change them.
+func (c *Connector) CompareAndSwap(_ context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k := range ei.Def.KeySet() {
+		cond, ok := conditions[k]
+		if !ok {
+			return errors.Errorf("missing key column %q in conditions for entity %q", k, ei.Def.Name)
+		}
+		if v, ok := newValues[k]; ok && !dosa.SameFieldValue(cond, v) {
+			return errors.Errorf("cannot change key column %q of entity %q", k, ei.Def.Name)
+		}
+	}
+
+	row := c.findRow(ei.Def.Name, ei.Def.Key, conditions)
+	if row == nil {
+		return &dosa.ErrNotFound{}
+	}
+	for k, cond := range conditions {
+		if !dosa.SameFieldValue(row[k], cond) {
+			return &dosa.ErrConflict{}
+		}
+	}
+
+	oldValues := copyRow(row)
+	_ = overwriteValuesFunc(row, newValues)
+	for iName, iDef := range ei.Def.Indexes {
+		c.removeItem(iName, ei.Def.UniqueKey(iDef.Key), oldValues)
+		_, _, _ = c.mergedInsert(iName, ei.Def.UniqueKey(iDef.Key), c.indexEntry(iDef, copyRow(row)), overwriteValuesFunc, false)
+	}
+	c.publish(ei, dosa.ChangeUpsert, oldValues, row)
 	return nil
 }
 
+// UpsertWithConditions updates a row while holding the write lock, so that the row is checked against
+// the conditions and written without any other writer in between. The values must contain every
+// primary key column.
+func (c *Connector) UpsertWithConditions(_ context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k := range ei.Def.KeySet() {
+		if _, ok := values[k]; !ok {
+			return errors.Errorf("missing key column %q in values for entity %q", k, ei.Def.Name)
+		}
+	}
+
+	row := c.findRow(ei.Def.Name, ei.Def.Key, values)
+	if row == nil {
+		return &dosa.ErrNotFound{}
+	}
+	for col, conds := range conditions {
+		for _, cond := range conds {
+			if !passNullableCol(row[col], cond) {
+				return &dosa.ErrConflict{}
+			}
+		}
+	}
+	_, err := c.upsert(ei, values)
+	return err
+}
+
+// findRow returns the stored row (not a copy) with the primary key in values, or nil
+// if there is no such row or values lack a clustering key column. The caller must
+// hold the lock.
+func (c *Connector) findRow(name string, pk *dosa.PrimaryKey, values map[string]dosa.FieldValue) map[string]dosa.FieldValue {
+	encodedPartitionKey, err := partitionKeyBuilder(pk, values)
+	if err != nil {
+		return nil
+	}
+	partitionRef := c.data[name][encodedPartitionKey]
+	if len(partitionRef) == 0 {
+		return nil
+	}
+	if len(pk.ClusteringKeySet()) == 0 {
+		return partitionRef[0]
+	}
+	for _, ck := range pk.ClusteringKeys {
+		if _, ok := values[ck.Name]; !ok {
+			return nil
+		}
+	}
+	found, inx := findInsertionPoint(pk, partitionRef, values)
+	if !found {
+		return nil
+	}
+	return partitionRef[inx]
+}
+
 func (c *Connector) mergedInsert(name string,
 	pk *dosa.PrimaryKey,
 	values map[string]dosa.FieldValue,
 	mergeFunc func(map[string]dosa.FieldValue, map[string]dosa.FieldValue) error,
-	returnCopy bool) (map[string]dosa.FieldValue, error) {
+	returnCopy bool) (old map[string]dosa.FieldValue, row map[string]dosa.FieldValue, err error) {
 
 	if c.data[name] == nil {
 		c.data[name] = make(map[string][]map[string]dosa.FieldValue)
@@ -386,7 +570,7 @@ func (c *Connector) mergedInsert(name string,
 	entityRef := c.data[name]
 	encodedPartitionKey, err := partitionKeyBuilder(pk, values)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Cannot build partition key for %q", name)
+		return nil, nil, errors.Wrapf(err, "Cannot build partition key for %q", name)
 	}
 	if entityRef[encodedPartitionKey] == nil {
 		entityRef[encodedPartitionKey] = make([]map[string]dosa.FieldValue, 0, 1)
@@ -395,23 +579,27 @@ func (c *Connector) mergedInsert(name string,
 	// no data in this partition? easy out!
 	if len(partitionRef) == 0 {
 		entityRef[encodedPartitionKey] = append(entityRef[encodedPartitionKey], values)
-		return nil, nil
+		return nil, values, nil
 	}
 
-	if len(pk.ClusteringKeySet()) == 0 {
-		// no clustering key, so the row must already exist, merge it
+	// merge merges values into the existing row
+	merge := func(existing map[string]dosa.FieldValue) (map[string]dosa.FieldValue, map[string]dosa.FieldValue, error) {
 		if returnCopy {
-			return copyRow(partitionRef[0]), mergeFunc(partitionRef[0], values)
+			old = copyRow(existing)
+		}
+		if err := mergeFunc(existing, values); err != nil {
+			return old, nil, err
 		}
-		return nil, mergeFunc(partitionRef[0], values)
+		return old, existing, nil
+	}
+	if len(pk.ClusteringKeySet()) == 0 {
+		// no clustering key, so the row must already exist, merge it
+		return merge(partitionRef[0])
 	}
 	// there is a clustering key, find the insertion point (binary search would be fastest)
 	found, offset := findInsertionPoint(pk, partitionRef, values)
 	if found {
-		if returnCopy {
-			return copyRow(partitionRef[offset]), mergeFunc(partitionRef[offset], values)
-		}
-		return nil, mergeFunc(partitionRef[offset], values)
+		return merge(partitionRef[offset])
 	}
 	// perform slice magic to insert value at given offset
 	l := len(entityRef[encodedPartitionKey])                                                                     // get length
@@ -420,7 +608,7 @@ func (c *Connector) mergedInsert(name string,
 	copy(entityRef[encodedPartitionKey][offset+1:], entityRef[encodedPartitionKey][offset:])
 	// and plunk value into appropriate location
 	entityRef[encodedPartitionKey][offset] = values
-	return nil, nil
+	return nil, values, nil
 }
 
 // Remove deletes a single row
@@ -431,6 +619,9 @@ func (c *Connector) Remove(_ context.Context, ei *dosa.EntityInfo, values map[st
 	if c.data[ei.Def.Name] == nil {
 		return nil
 	}
+	if row := c.findRow(ei.Def.Name, ei.Def.Key, values); row != nil {
+		c.publish(ei, dosa.ChangeRemove, row, nil)
+	}
 	removedValues := c.removeItem(ei.Def.Name, ei.Def.Key, values)
 	if removedValues != nil {
 		for iName, iDef := range ei.Def.Indexes {
@@ -470,15 +661,18 @@ func (c *Connector) removeItem(name string, key *dosa.PrimaryKey, values map[str
 }
 
 // RemoveRange removes all of the elements in the range specified by the entity info and the column conditions.
-func (c *Connector) RemoveRange(_ context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	partitionRange, _, err := c.findRange(ei, columnConditions, false)
+	partitionRange, _, err := c.findRange(ctx, ei, columnConditions, false)
 	if err != nil {
 		return err
 	}
 	if partitionRange != nil {
+		for _, vals := range partitionRange.values() {
+			c.publish(ei, dosa.ChangeRemove, vals, nil)
+		}
 		for iName, iDef := range ei.Def.Indexes {
 			for _, vals := range partitionRange.values() {
 				c.removeItem(iName, ei.Def.UniqueKey(iDef.Key), vals)
@@ -490,15 +684,42 @@ func (c *Connector) RemoveRange(_ context.Context, ei *dosa.EntityInfo, columnCo
 	return nil
 }
 
-// Range returns a slice of data from the datastore
-func (c *Connector) Range(_ context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+// DeletePartition removes all of the rows of the partition pk
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	conditions, err := dosa.PartitionKeyConditions(ei.Def, pk)
+	if err != nil {
+		return err
+	}
+	return c.RemoveRange(ctx, ei, conditions)
+}
+
+// Range returns a slice of data from the datastore. The context is checked between rows, so
+// that a canceled Range returns the context's error promptly, even on a large partition.
+// Sort columns requested with RangeOp.WithSortColumns are checked with dosa.ReverseSort,
+// and may reverse the order of the rows. An index named with dosa.WithIndexName is
+// read instead of the table or index matching the conditions.
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 
-	partitionRange, key, err := c.findRange(ei, columnConditions, true)
+	partitionRange, key, err := c.findRange(ctx, ei, columnConditions, true)
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
 	if err != nil {
+		c.Logger().Debugf("memory: Range on %q with invalid conditions %v: %v", ei.Def.Name, columnConditions, err)
 		return nil, "", errors.Wrap(err, "Invalid range conditions")
 	}
+	reverse := false
+	if sortColumns, ok := dosa.SortColumnsFromContext(ctx); ok {
+		sortKey := key
+		if sortKey == nil {
+			sortKey = ei.Def.Key
+		}
+		if reverse, err = dosa.ReverseSort(sortKey, sortColumns); err != nil {
+			return nil, "", errors.Wrap(err, "Invalid sort columns")
+		}
+	}
 	if partitionRange == nil {
 		return []map[string]dosa.FieldValue{}, "", nil
 	}
@@ -510,9 +731,13 @@ func (c *Connector) Range(_ context.Context, ei *dosa.EntityInfo, columnConditio
 			return nil, "", errors.Wrapf(err, "Invalid token %q", token)
 		}
 		found, offset := findInsertionPoint(key, partitionRange.values(), values)
-		if found {
+		switch {
+		case reverse:
+			// the next page has the rows before the token
+			partitionRange.end = partitionRange.start + offset - 1
+		case found:
 			partitionRange.start += offset + 1
-		} else {
+		default:
 			partitionRange.start += offset
 		}
 	}
@@ -521,19 +746,42 @@ func (c *Connector) Range(_ context.Context, ei *dosa.EntityInfo, columnConditio
 		limit = defaultRangeLimit
 	}
 
-	slice := partitionRange.values()
+	slice := c.unexpired(partitionRange.values())
+	if reverse {
+		reversed := make([]map[string]dosa.FieldValue, len(slice))
+		for i, row := range slice {
+			reversed[len(slice)-1-i] = row
+		}
+		slice = reversed
+	}
 	token = ""
 	if len(slice) > limit {
 		token = makeToken(slice[limit-1])
 		slice = slice[:limit]
 	}
 
-	return copyRows(slice), token, nil
+	rows := make([]map[string]dosa.FieldValue, len(slice))
+	for i, row := range slice {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		rows[i] = copyRow(row)
+		delete(rows[i], indexExpiresColumn)
+	}
+	return rows, token, nil
 }
 
 func makeToken(v map[string]dosa.FieldValue) string {
+	// gob can't encode nil pointers; null columns are never part of a key,
+	// so they can be left out
+	nonNull := make(map[string]dosa.FieldValue, len(v))
+	for k, fv := range v {
+		if !dosa.IsNull(fv) {
+			nonNull[k] = fv
+		}
+	}
 	encoder := encoding.NewGobEncoder()
-	encodedKey, err := encoder.Encode(v)
+	encodedKey, err := encoder.Encode(nonNull)
 	if err != nil {
 		// this should really be impossible, unless someone forgot to
 		// register some newly supported type with the encoder
@@ -557,8 +805,9 @@ func decodeToken(token string) (values map[string]dosa.FieldValue, err error) {
 // In the case that no entities are found an empty partitionRange with a nil partition field will be returned.
 //
 // Note that this function reads from the connector's data map. Any calling functions should hold
-// at least a read lock on the map.
-func (c *Connector) findRange(ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, searchIndexes bool) (*partitionRange, *dosa.PrimaryKey, error) {
+// at least a read lock on the map. The context's error is returned if it is canceled while the
+// partition is searched.
+func (c *Connector) findRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, searchIndexes bool) (*partitionRange, *dosa.PrimaryKey, error) {
 	// no data at all, fine
 	if c.data[ei.Def.Name] == nil {
 		return nil, nil, nil
@@ -567,8 +816,17 @@ func (c *Connector) findRange(ei *dosa.EntityInfo, columnConditions map[string][
 	// find the equals conditions on each of the partition keys
 	values := make(map[string]dosa.FieldValue)
 
-	// figure out which "table" or "index" to use based on the supplied conditions
-	name, key, err := ei.IndexFromConditions(columnConditions, searchIndexes)
+	// figure out which "table" or "index" to use, from the context or else
+	// based on the supplied conditions
+	var name string
+	var key *dosa.PrimaryKey
+	var err error
+	if indexName, ok := dosa.IndexNameFromContext(ctx); ok && searchIndexes {
+		name = indexName
+		key, err = ei.NamedIndex(indexName, columnConditions)
+	} else {
+		name, key, err = ei.IndexFromConditions(columnConditions, searchIndexes)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -589,9 +847,15 @@ func (c *Connector) findRange(ei *dosa.EntityInfo, columnConditions map[string][
 	// TODO: This can be done much faster using a binary search
 	startinx, endinx := 0, len(partitionRef)-1
 	for startinx < len(partitionRef) && !matchesClusteringConditions(key, columnConditions, partitionRef[startinx]) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		startinx++
 	}
 	for endinx >= startinx && !matchesClusteringConditions(key, columnConditions, partitionRef[endinx]) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		endinx--
 	}
 	if endinx < startinx {
@@ -641,6 +905,19 @@ func passCol(data dosa.FieldValue, cond *dosa.Condition) bool {
 	panic("invalid operator " + cond.Op.String())
 }
 
+// passNullableCol works like passCol, but also accepts pointer and nil values. A nil value only
+// passes an Eq condition against nil.
+func passNullableCol(data dosa.FieldValue, cond *dosa.Condition) bool {
+	data, value := dosa.DerefFieldValue(data), dosa.DerefFieldValue(cond.Value)
+	if data == nil || value == nil {
+		return cond.Op == dosa.Eq && data == nil && value == nil
+	}
+	if reflect.TypeOf(data) != reflect.TypeOf(value) {
+		return false
+	}
+	return passCol(data, &dosa.Condition{Op: cond.Op, Value: value})
+}
+
 // Scan returns all the rows
 func (c *Connector) Scan(_ context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
 	c.lock.RLock()
@@ -697,6 +974,26 @@ func (c *Connector) Scan(_ context.Context, ei *dosa.EntityInfo, minimumFields [
 	return copyRows(allTheThings), token, nil
 }
 
+// CopyTable upserts a copy of every row of src into dst while holding the write lock
+func (c *Connector) CopyTable(_ context.Context, src, dst *dosa.EntityInfo) error {
+	if err := dosa.CheckCopyTable(src.Def, dst.Def); err != nil {
+		return errors.Wrapf(err, "cannot copy %q to %q", src.Def.Name, dst.Def.Name)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// gather the rows first, since dst may be src
+	var rows []map[string]dosa.FieldValue
+	for _, partition := range c.data[src.Def.Name] {
+		rows = append(rows, partition...)
+	}
+	for _, row := range rows {
+		if _, err := c.upsert(dst, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getStartingPoint determines the partition key of the starting point to resume a scan
 // when a token is provided
 func getStartingPoint(ei *dosa.EntityInfo, token string) (start string, startPartKey map[string]dosa.FieldValue, err error) {
@@ -720,17 +1017,190 @@ func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, e
 	return 1, nil
 }
 
+// DescribeTable returns a copy of the definition the entity's table was
+// created with, by the first write to it, so an entity whose definition has
+// changed since then shows the drift. A table that hasn't been written yet
+// will take whatever definition comes first, so the entity's own is returned.
+func (c *Connector) DescribeTable(_ context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if ed, ok := c.schemas[ei.Def.Name]; ok {
+		return ed.Clone(), nil
+	}
+	return ei.Def.Clone(), nil
+}
+
+// DropTable deletes all the rows of the entity, and its definition
+func (c *Connector) DropTable(_ context.Context, ei *dosa.EntityInfo) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.data, ei.Def.Name)
+	delete(c.schemas, ei.Def.Name)
+	return nil
+}
+
+// publish appends a change to the log and wakes up the change streams; the
+// caller must hold the write lock
+func (c *Connector) publish(ei *dosa.EntityInfo, op dosa.ChangeOp, oldValues, newValues map[string]dosa.FieldValue) {
+	event := dosa.ChangeEvent{
+		Op:         op,
+		EntityName: ei.Def.Name,
+		OldValues:  copyRowOrNil(oldValues),
+		NewValues:  copyRowOrNil(newValues),
+		Checkpoint: dosa.Checkpoint(strconv.Itoa(len(c.changes) + 1)),
+	}
+	c.changes = append(c.changes, event)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// StreamChanges sends the changes made to the entity after the checkpoint,
+// which is the position in the connector's change log. The log is kept until
+// Shutdown, so the empty checkpoint replays every change since the connector
+// was created.
+func (c *Connector) StreamChanges(ctx context.Context, ei *dosa.EntityInfo, from dosa.Checkpoint) (<-chan dosa.ChangeEvent, error) {
+	next := 0
+	if from != "" {
+		var err error
+		if next, err = strconv.Atoi(string(from)); err != nil || next < 0 {
+			return nil, errors.Errorf("invalid checkpoint %q", from)
+		}
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if next > len(c.changes) {
+		return nil, errors.Errorf("checkpoint %q is past the end of the change log", from)
+	}
+	events := make(chan dosa.ChangeEvent)
+	go c.streamChanges(ctx, ei.Def.Name, next, events)
+	return events, nil
+}
+
+// streamChanges sends the entity's changes from the log, starting at next,
+// until the context is done or the connector shuts down
+func (c *Connector) streamChanges(ctx context.Context, name string, next int, events chan<- dosa.ChangeEvent) {
+	defer close(events)
+	for {
+		c.lock.RLock()
+		if c.data == nil {
+			c.lock.RUnlock()
+			return
+		}
+		// the log is only appended to, so the pending events can be sent
+		// without holding the lock
+		pending := c.changes[next:]
+		changed := c.changed
+		c.lock.RUnlock()
+
+		for _, event := range pending {
+			next++
+			if event.EntityName != name {
+				continue
+			}
+			event.OldValues = copyRowOrNil(event.OldValues)
+			event.NewValues = copyRowOrNil(event.NewValues)
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(pending) > 0 {
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Shutdown deletes all the data
 func (c *Connector) Shutdown() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.Logger().Infof("memory: shutting down, discarding %d tables", len(c.data))
 	c.data = nil
+	c.schemas = nil
+	c.changes = nil
+	// wake up the change streams, so they see the data is gone and stop
+	close(c.changed)
+	c.changed = make(chan struct{})
 	return nil
 }
 
-// NewConnector creates a new in-memory connector
-func NewConnector() *Connector {
+// Codec always returns dosa.IdentityCodec: the memory connector stores field
+// values as they are, so there are no wire types to convert to
+func (c *Connector) Codec() dosa.Codec {
+	return dosa.IdentityCodec
+}
+
+// ConnectorState is a copy of the rows stored in a memory connector,
+// returned by Snapshot
+type ConnectorState struct {
+	data    map[string]map[string][]map[string]dosa.FieldValue
+	schemas map[string]*dosa.EntityDefinition
+}
+
+// Snapshot returns a copy of all the stored rows, so that tests sharing a
+// connector can Restore it to a known state, e.g. after seeding it in TestMain
+func (c *Connector) Snapshot() ConnectorState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return ConnectorState{data: copyData(c.data), schemas: copySchemas(c.schemas)}
+}
+
+// Restore replaces all the stored rows with the ones in the state, while
+// holding the write lock. The state isn't changed, so it can be restored
+// again. The change log is kept and no changes are streamed for the restore.
+func (c *Connector) Restore(state ConnectorState) error {
+	if state.data == nil {
+		return errors.New("cannot restore a state that was not returned by Snapshot")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.data = copyData(state.data)
+	c.schemas = copySchemas(state.schemas)
+	return nil
+}
+
+// copySchemas makes a copy of the table definitions; the definitions are
+// never changed, so they are shared
+func copySchemas(schemas map[string]*dosa.EntityDefinition) map[string]*dosa.EntityDefinition {
+	copied := make(map[string]*dosa.EntityDefinition, len(schemas))
+	for name, ed := range schemas {
+		copied[name] = ed
+	}
+	return copied
+}
+
+// copyData makes a copy of the tables, their partitions and the rows in them
+func copyData(data map[string]map[string][]map[string]dosa.FieldValue) map[string]map[string][]map[string]dosa.FieldValue {
+	copied := make(map[string]map[string][]map[string]dosa.FieldValue, len(data))
+	for name, partitions := range data {
+		copiedPartitions := make(map[string][]map[string]dosa.FieldValue, len(partitions))
+		for key, rows := range partitions {
+			// partitions emptied by a removal are nil, and Scan relies on them being kept
+			if rows == nil {
+				copiedPartitions[key] = nil
+				continue
+			}
+			copiedPartitions[key] = copyRows(rows)
+		}
+		copied[name] = copiedPartitions
+	}
+	return copied
+}
+
+// NewConnector creates a new in-memory connector. The options are applied to
+// the embedded base connector; use base.WithLogger to debug test failures.
+func NewConnector(opts ...base.ConnectorOption) *Connector {
 	c := Connector{}
 	c.data = make(map[string]map[string][]map[string]dosa.FieldValue)
+	c.schemas = make(map[string]*dosa.EntityDefinition)
+	c.changed = make(chan struct{})
+	c.now = time.Now
+	c.Connector.Apply(opts...)
 	return &c
 }
@@ -22,6 +22,7 @@ package memory
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"sort"
@@ -32,6 +33,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
 )
 
 var testSchemaRef = dosa.SchemaRef{
@@ -184,6 +186,500 @@ func TestConnector_Upsert(t *testing.T) {
 	assert.Equal(t, 0, len(rangeVals))
 }
 
+func TestConnector_CompareAndSwap(t *testing.T) {
+	sut := NewConnector()
+
+	// key column missing from the conditions
+	err := sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{}, map[string]dosa.FieldValue{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `missing key column "p1"`)
+
+	// row doesn't exist
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	err = sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	})
+	assert.NoError(t, err)
+
+	// key columns cannot change
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+	}, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("other"),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `cannot change key column "p1"`)
+
+	// guard column doesn't match
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(5)),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+
+	// values of the wrong type don't match, rather than panic
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(1),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+	}, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue([]byte("data")),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `cannot change key column "p1"`)
+
+	// guard column that was never set
+	c3 := "value"
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c3": dosa.FieldValue(&c3),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+
+	// guard column matches, pointers are dereferenced
+	c1 := int64(1)
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(&c1),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.NoError(t, err)
+
+	vals, err := sut.Read(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data")}, []string{"c1"})
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(2)), vals["c1"])
+
+	// the index follows the new value
+	rangeVals, _, err := sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+	}, []string{"c1"}, "", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, rangeVals)
+	rangeVals, _, err = sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(2))}},
+	}, []string{"c1"}, "", 5)
+	assert.NoError(t, err)
+	assert.Len(t, rangeVals, 1)
+
+	// the old value no longer matches
+	err = sut.CompareAndSwap(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(3)),
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+}
+
+func TestConnector_UpsertAndRead(t *testing.T) {
+	sut := NewConnector()
+
+	_, err := sut.UpsertAndRead(context.TODO(), testEi, map[string]dosa.FieldValue{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `partition key "p1"`)
+
+	vals, err := sut.UpsertAndRead(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]dosa.FieldValue{"p1": "data", "c1": int64(1)}, vals)
+
+	// the returned row includes the columns that were not upserted
+	vals, err = sut.UpsertAndRead(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c3": dosa.FieldValue("more"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]dosa.FieldValue{"p1": "data", "c1": int64(1), "c3": "more"}, vals)
+
+	// and is a copy
+	vals["c1"] = int64(5)
+	vals, err = sut.Read(context.TODO(), testEi, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), vals["c1"])
+}
+
+func TestConnector_Replace(t *testing.T) {
+	sut := NewConnector()
+
+	err := sut.Replace(context.TODO(), testEi, map[string]dosa.FieldValue{"c1": dosa.FieldValue(int64(1))})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `partition key "p1"`)
+
+	// replacing a row that isn't there creates it
+	err = sut.Replace(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+		"c3": dosa.FieldValue("three"),
+	})
+	assert.NoError(t, err)
+
+	// the columns not in values are cleared
+	err = sut.Replace(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(2)),
+	})
+	assert.NoError(t, err)
+	vals, err := sut.Read(context.TODO(), testEi, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]dosa.FieldValue{"p1": "data", "c1": int64(2)}, vals)
+
+	// and the index no longer has the old value
+	data, _, err := sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+	data, _, err = sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(2))}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+
+	// rows with clustering keys
+	key := map[string]dosa.FieldValue{"f1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1)), "c7": dosa.FieldValue(dosa.NewUUID())}
+	for _, c3 := range []string{"one", "two"} {
+		values := copyRow(key)
+		values["c3"] = dosa.FieldValue(c3)
+		assert.NoError(t, sut.Upsert(context.TODO(), clusteredEi, values))
+	}
+	values := copyRow(key)
+	values["c2"] = dosa.FieldValue(float64(2))
+	assert.NoError(t, sut.Replace(context.TODO(), clusteredEi, values))
+	vals, err = sut.Read(context.TODO(), clusteredEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Nil(t, vals["c3"])
+	assert.Equal(t, float64(2), vals["c2"])
+}
+
+func TestConnector_UpsertWithConditions(t *testing.T) {
+	sut := NewConnector()
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(2))}
+	conds := map[string][]*dosa.Condition{"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}}}
+
+	// the row must exist
+	err := sut.UpsertWithConditions(context.TODO(), testEi, values, conds)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	assert.NoError(t, sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+		"c2": dosa.FieldValue(float64(1.5)),
+	}))
+
+	// a failing condition on a non-key column leaves the row alone
+	err = sut.UpsertWithConditions(context.TODO(), testEi, values, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+		"c2": {{Op: dosa.Gt, Value: dosa.FieldValue(float64(2))}},
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+	vals, err := sut.Read(context.TODO(), testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), vals["c1"])
+
+	// a null column only matches nil
+	err = sut.UpsertWithConditions(context.TODO(), testEi, values, map[string][]*dosa.Condition{
+		"c3": {{Op: dosa.Eq, Value: dosa.FieldValue("x")}},
+	})
+	assert.True(t, dosa.ErrorIsConflict(err))
+
+	assert.NoError(t, sut.UpsertWithConditions(context.TODO(), testEi, values, conds))
+	vals, err = sut.Read(context.TODO(), testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), vals["c1"])
+	assert.Equal(t, float64(1.5), vals["c2"])
+
+	// the condition no longer holds, and the index follows the new value
+	err = sut.UpsertWithConditions(context.TODO(), testEi, values, conds)
+	assert.True(t, dosa.ErrorIsConflict(err))
+	data, _, err := sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(2))}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+
+	err = sut.UpsertWithConditions(context.TODO(), testEi, map[string]dosa.FieldValue{"c1": dosa.FieldValue(int64(3))}, conds)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing key column")
+}
+
+func TestConnector_CopyTable(t *testing.T) {
+	sut := NewConnector()
+	dst := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	dst.Def.Name = "t2"
+	dst.Def.Indexes = nil
+	dst.Def.Columns = append(dst.Def.Columns, &dosa.ColumnDefinition{Name: "extra", Type: dosa.String})
+
+	// nothing to copy
+	assert.NoError(t, sut.CopyTable(context.TODO(), testEi, dst))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+			"p1": dosa.FieldValue(fmt.Sprintf("data%d", i)),
+			"c1": dosa.FieldValue(int64(i)),
+		}))
+	}
+	// rows already in dst are updated, not replaced
+	assert.NoError(t, sut.Upsert(context.TODO(), dst, map[string]dosa.FieldValue{
+		"p1":    dosa.FieldValue("data0"),
+		"extra": dosa.FieldValue("kept"),
+	}))
+
+	assert.NoError(t, sut.CopyTable(context.TODO(), testEi, dst))
+	rows, _, err := sut.Scan(context.TODO(), dst, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	row, err := sut.Read(context.TODO(), dst, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data0")}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), row["c1"])
+	assert.Equal(t, "kept", row["extra"])
+
+	// the copies are independent of the source
+	assert.NoError(t, sut.Remove(context.TODO(), testEi, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data1")}))
+	_, err = sut.Read(context.TODO(), dst, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data1")}, dosa.All())
+	assert.NoError(t, err)
+
+	// dst must have every column of src
+	err = sut.CopyTable(context.TODO(), dst, testEi)
+	assert.Contains(t, err.Error(), "the column extra")
+}
+
+func TestConnector_DescribeTable(t *testing.T) {
+	sut := NewConnector()
+	ed, err := sut.DescribeTable(context.TODO(), testEi)
+	assert.NoError(t, err)
+	assert.Equal(t, testEi.Def, ed)
+	assert.Empty(t, testEi.Def.Differences(ed))
+}
+
+func TestConnector_NullTimestamp(t *testing.T) {
+	sut := NewConnector()
+	ei := &dosa.EntityInfo{
+		Ref: &testSchemaRef,
+		Def: &dosa.EntityDefinition{
+			Name: "nullts",
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "p1", Type: dosa.String},
+				{Name: "c1", Type: dosa.Int64},
+				{Name: "ts", Type: dosa.Timestamp, IsPointer: true},
+			},
+			Key: &dosa.PrimaryKey{
+				PartitionKeys:  []string{"p1"},
+				ClusteringKeys: []*dosa.ClusteringKey{{Name: "c1"}},
+			},
+		},
+	}
+	key := func(c1 int64) map[string]dosa.FieldValue {
+		return map[string]dosa.FieldValue{"p1": "data", "c1": c1}
+	}
+
+	// a column that was never written is missing
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, key(1)))
+	vals, err := sut.Read(context.TODO(), ei, key(1), dosa.All())
+	assert.NoError(t, err)
+	_, ok := vals["ts"]
+	assert.False(t, ok)
+
+	// an explicit zero is a timestamp
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, map[string]dosa.FieldValue{"p1": "data", "c1": int64(2), "ts": dosa.TimestampValue(time.Time{})}))
+	vals, err = sut.Read(context.TODO(), ei, key(2), dosa.All())
+	assert.NoError(t, err)
+	ts, ok := dosa.AsTimestamp(vals["ts"])
+	assert.True(t, ok)
+	assert.True(t, ts.IsZero())
+
+	// an explicit null is kept as null, and clears the column
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, map[string]dosa.FieldValue{"p1": "data", "c1": int64(2), "ts": dosa.NullTimestamp}))
+	vals, err = sut.Read(context.TODO(), ei, key(2), dosa.All())
+	assert.NoError(t, err)
+	v, ok := vals["ts"]
+	assert.True(t, ok)
+	assert.True(t, dosa.IsNull(v))
+
+	// rows with nulls can be paged through
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, map[string]dosa.FieldValue{"p1": "data", "c1": int64(3), "ts": dosa.TimestampValue(time.Now())}))
+	rows, token, err := sut.Range(context.TODO(), ei, map[string][]*dosa.Condition{"p1": {{Op: dosa.Eq, Value: "data"}}}, dosa.All(), "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	rows, _, err = sut.Range(context.TODO(), ei, map[string][]*dosa.Condition{"p1": {{Op: dosa.Eq, Value: "data"}}}, dosa.All(), token, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, int64(3), rows[0]["c1"])
+	}
+}
+
+func TestConnector_DropTable(t *testing.T) {
+	sut := NewConnector()
+	other := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	other.Def.Name = "t2"
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	assert.NoError(t, sut.Upsert(context.TODO(), testEi, values))
+	assert.NoError(t, sut.Upsert(context.TODO(), other, values))
+
+	assert.NoError(t, sut.DropTable(context.TODO(), testEi))
+	_, err := sut.Read(context.TODO(), testEi, key, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+	// other tables are kept
+	_, err = sut.Read(context.TODO(), other, key, dosa.All())
+	assert.NoError(t, err)
+
+	// dropping a table that's gone already is fine
+	assert.NoError(t, sut.DropTable(context.TODO(), testEi))
+}
+
+func TestConnector_DescribeTableDrift(t *testing.T) {
+	sut := NewConnector()
+	changed := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	changed.Def.Columns = append(changed.Def.Columns, &dosa.ColumnDefinition{Name: "c8", Type: dosa.String})
+
+	// before the first write, the table takes the entity's definition
+	ed, err := sut.DescribeTable(context.TODO(), changed)
+	assert.NoError(t, err)
+	assert.Empty(t, changed.Def.Differences(ed))
+
+	// afterwards it keeps the definition it was created with
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	assert.NoError(t, sut.Upsert(context.TODO(), testEi, values))
+	assert.NoError(t, sut.Upsert(context.TODO(), changed, values))
+	ed, err = sut.DescribeTable(context.TODO(), changed)
+	assert.NoError(t, err)
+	assert.Empty(t, testEi.Def.Differences(ed))
+	assert.Equal(t, []string{`column "c8" is missing from the live schema`}, changed.Def.Differences(ed))
+
+	// until the table is dropped
+	assert.NoError(t, sut.DropTable(context.TODO(), testEi))
+	ed, err = sut.DescribeTable(context.TODO(), changed)
+	assert.NoError(t, err)
+	assert.Empty(t, changed.Def.Differences(ed))
+}
+
+func TestConnector_AtomicAdd(t *testing.T) {
+	sut := NewConnector()
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+
+	// the row is created
+	v, err := sut.AtomicAdd(context.TODO(), testEi, key, "c1", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), v)
+	v, err = sut.AtomicAdd(context.TODO(), testEi, key, "c1", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), v)
+
+	// the index follows the new value
+	data, _, err := sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(5))}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	data, _, err = sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(2))}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	// concurrent adds are not lost
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sut.AtomicAdd(context.TODO(), testEi, key, "c1", 1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	vals, err := sut.Read(context.TODO(), testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(55), vals["c1"])
+
+	_, err = sut.AtomicAdd(context.TODO(), testEi, key, "c6", 1)
+	assert.True(t, dosa.ErrorIsInvalidOperation(err))
+	_, err = sut.AtomicAdd(context.TODO(), testEi, map[string]dosa.FieldValue{}, "c1", 1)
+	assert.Error(t, err)
+}
+
+func TestConnector_Aggregate(t *testing.T) {
+	sut := NewConnector()
+
+	// no data
+	v, err := sut.Aggregate(context.TODO(), clusteredEi, dosa.AggCount, "c1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(0)), v)
+	_, err = sut.Aggregate(context.TODO(), clusteredEi, dosa.AggMin, "c1", nil)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	// enough rows in partition "a" to need more than one page
+	for x := 1; x <= 250; x++ {
+		err := sut.CreateIfNotExists(context.TODO(), clusteredEi, map[string]dosa.FieldValue{
+			"f1": dosa.FieldValue("a"),
+			"c1": dosa.FieldValue(int64(x)),
+			"c2": dosa.FieldValue(float64(x) / 2),
+			"c7": dosa.FieldValue(dosa.NewUUID())})
+		assert.NoError(t, err)
+	}
+	err = sut.CreateIfNotExists(context.TODO(), clusteredEi, map[string]dosa.FieldValue{
+		"f1": dosa.FieldValue("b"),
+		"c1": dosa.FieldValue(int64(1000)),
+		"c7": dosa.FieldValue(dosa.NewUUID())})
+	assert.NoError(t, err)
+
+	partitionA := map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("a")}},
+	}
+	for _, tc := range []struct {
+		aggFunc    dosa.AggFunc
+		column     string
+		conditions map[string][]*dosa.Condition
+		expected   dosa.FieldValue
+	}{
+		{dosa.AggCount, "c1", nil, int64(251)},
+		{dosa.AggCount, "c1", partitionA, int64(250)},
+		// c2 is null in partition b
+		{dosa.AggCount, "c2", nil, int64(250)},
+		{dosa.AggSum, "c1", partitionA, int64(250 * 251 / 2)},
+		{dosa.AggSum, "c2", nil, float64(250*251/2) / 2},
+		{dosa.AggAvg, "c1", partitionA, float64(125.5)},
+		{dosa.AggMin, "c1", nil, int64(1)},
+		{dosa.AggMax, "c1", nil, int64(1000)},
+		{dosa.AggMax, "c1", partitionA, int64(250)},
+		{dosa.AggMax, "f1", nil, "b"},
+	} {
+		v, err := sut.Aggregate(context.TODO(), clusteredEi, tc.aggFunc, tc.column, tc.conditions)
+		assert.NoError(t, err, tc.aggFunc.String()+"("+tc.column+")")
+		assert.Equal(t, tc.expected, v, tc.aggFunc.String()+"("+tc.column+")")
+	}
+
+	// numeric aggregations of non-numeric columns
+	_, err = sut.Aggregate(context.TODO(), clusteredEi, dosa.AggSum, "f1", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot apply SUM")
+	_, err = sut.Aggregate(context.TODO(), clusteredEi, dosa.AggMax, "c7", nil)
+	assert.Error(t, err)
+	_, err = sut.Aggregate(context.TODO(), clusteredEi, dosa.AggCount, "nope", nil)
+	assert.Error(t, err)
+}
+
 func TestConnector_Read(t *testing.T) {
 	sut := NewConnector()
 
@@ -510,6 +1006,35 @@ func TestConnector_RemoveRange(t *testing.T) {
 	assert.Empty(t, data)
 }
 
+func TestConnector_DeletePartition(t *testing.T) {
+	sut := NewConnector()
+	for _, f1 := range []string{"gone", "kept"} {
+		for x := 0; x < 5; x++ {
+			assert.NoError(t, sut.Upsert(context.TODO(), clusteredEi, map[string]dosa.FieldValue{
+				"f1": f1, "c1": int64(x), "c7": dosa.NewUUID(),
+			}))
+		}
+	}
+
+	assert.NoError(t, sut.DeletePartition(context.TODO(), clusteredEi, map[string]dosa.FieldValue{"f1": "gone"}))
+	data, _, err := sut.Range(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: "gone"}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+	data, _, err = sut.Range(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: "kept"}},
+	}, dosa.All(), "", 200)
+	assert.NoError(t, err)
+	assert.Len(t, data, 5)
+
+	// deleting a partition that has no rows is fine
+	assert.NoError(t, sut.DeletePartition(context.TODO(), clusteredEi, map[string]dosa.FieldValue{"f1": "gone"}))
+
+	err = sut.DeletePartition(context.TODO(), clusteredEi, map[string]dosa.FieldValue{"f1": "kept", "c1": int64(1)})
+	assert.EqualError(t, err, `column "c1" is not in the partition key of "t2"`)
+}
+
 func TestConnector_Shutdown(t *testing.T) {
 	sut := NewConnector()
 
@@ -518,6 +1043,206 @@ func TestConnector_Shutdown(t *testing.T) {
 	assert.Nil(t, sut.data)
 }
 
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *testLogger) Infof(format string, args ...interface{})  { l.log(format, args...) }
+func (l *testLogger) Warnf(format string, args ...interface{})  { l.log(format, args...) }
+func (l *testLogger) Errorf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *testLogger) log(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestConnector_WithLogger(t *testing.T) {
+	logger := &testLogger{}
+	sut := NewConnector(base.WithLogger(logger))
+
+	err := sut.CreateIfNotExists(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, logger.lines)
+
+	err = sut.CreateIfNotExists(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	})
+	assert.True(t, dosa.ErrorIsAlreadyExists(err))
+	assert.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], "CreateIfNotExists")
+
+	assert.NoError(t, sut.Shutdown())
+	assert.Len(t, logger.lines, 2)
+	assert.Contains(t, logger.lines[1], "shutting down")
+}
+
+func TestConnector_RedactsLogOutput(t *testing.T) {
+	logger := &testLogger{}
+	sut := NewConnector(base.WithLogger(logger))
+	ei := &dosa.EntityInfo{
+		Ref: &testSchemaRef,
+		Def: &dosa.EntityDefinition{
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "id", Type: dosa.Int64},
+				{Name: "region", Type: dosa.String},
+				{Name: "ssn", Type: dosa.String, SensitiveData: true},
+			},
+			Key: &dosa.PrimaryKey{
+				PartitionKeys: []string{"id", "region"},
+			},
+			Name: "sensitiveTable",
+		},
+	}
+
+	_, err := sut.Read(context.TODO(), ei, map[string]dosa.FieldValue{
+		"id":  dosa.FieldValue(int64(1)),
+		"ssn": dosa.FieldValue("123-45-6789"),
+	}, dosa.All())
+	assert.Error(t, err)
+	assert.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], dosa.RedactedValue)
+	assert.NotContains(t, logger.lines[0], "123-45-6789")
+}
+
+// nextChange receives the next change from the stream, failing the test if none arrives promptly
+func nextChange(t *testing.T, events <-chan dosa.ChangeEvent) dosa.ChangeEvent {
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok, "change stream closed")
+		return event
+	case <-time.After(time.Second):
+		assert.Fail(t, "timed out waiting for a change")
+		return dosa.ChangeEvent{}
+	}
+}
+
+func TestConnector_StreamChanges(t *testing.T) {
+	sut := NewConnector()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+	assert.NoError(t, sut.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+	}))
+	assert.NoError(t, sut.Upsert(ctx, clusteredEi, map[string]dosa.FieldValue{
+		"f1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+		"c7": dosa.FieldValue(dosa.NewUUID()),
+	}))
+
+	events, err := sut.StreamChanges(ctx, testEi, "")
+	assert.NoError(t, err)
+	first := nextChange(t, events)
+	assert.Equal(t, dosa.ChangeUpsert, first.Op)
+	assert.Equal(t, "t1", first.EntityName)
+	assert.Nil(t, first.OldValues)
+	assert.Equal(t, int64(1), first.NewValues["c1"])
+
+	// changes made after the stream started are sent too, skipping other entities
+	assert.NoError(t, sut.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c2": dosa.FieldValue(2.0),
+	}))
+	update := nextChange(t, events)
+	assert.Equal(t, dosa.ChangeUpsert, update.Op)
+	assert.Equal(t, map[string]dosa.FieldValue{"p1": "data", "c1": int64(1)}, update.OldValues)
+	assert.Equal(t, map[string]dosa.FieldValue{"p1": "data", "c1": int64(1), "c2": 2.0}, update.NewValues)
+
+	assert.NoError(t, sut.Remove(ctx, testEi, key))
+	remove := nextChange(t, events)
+	assert.Equal(t, dosa.ChangeRemove, remove.Op)
+	assert.Equal(t, update.NewValues, remove.OldValues)
+	assert.Nil(t, remove.NewValues)
+
+	// resuming from a checkpoint starts right after that change
+	resumed, err := sut.StreamChanges(ctx, testEi, first.Checkpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, update, nextChange(t, resumed))
+	assert.Equal(t, remove, nextChange(t, resumed))
+
+	_, err = sut.StreamChanges(ctx, testEi, "bogus")
+	assert.Error(t, err)
+	_, err = sut.StreamChanges(ctx, testEi, "100")
+	assert.Error(t, err)
+
+	// canceling the context closes the stream
+	cancel()
+	for range events {
+	}
+
+	// and so does shutting down the connector
+	stream, err := sut.StreamChanges(context.Background(), testEi, remove.Checkpoint)
+	assert.NoError(t, err)
+	assert.NoError(t, sut.Shutdown())
+	for range stream {
+	}
+}
+
+func TestConnector_StreamChangesPartialKey(t *testing.T) {
+	sut := NewConnector()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := sut.StreamChanges(ctx, clusteredEi, "")
+	assert.NoError(t, err)
+
+	// the values lack the c7 clustering key column
+	partial := map[string]dosa.FieldValue{"f1": "upserted", "c1": int64(1)}
+	assert.NoError(t, sut.Upsert(ctx, clusteredEi, partial))
+	assert.Equal(t, partial, nextChange(t, events).NewValues)
+
+	partial = map[string]dosa.FieldValue{"f1": "replaced", "c1": int64(1)}
+	assert.NoError(t, sut.Replace(ctx, clusteredEi, partial))
+	assert.Equal(t, partial, nextChange(t, events).NewValues)
+
+	partial = map[string]dosa.FieldValue{"f1": "read", "c1": int64(1)}
+	row, err := sut.UpsertAndRead(ctx, clusteredEi, partial)
+	assert.NoError(t, err)
+	assert.Equal(t, partial, row)
+	assert.Equal(t, partial, nextChange(t, events).NewValues)
+}
+
+func TestConnector_SnapshotRestore(t *testing.T) {
+	sut := NewConnector()
+	assert.Error(t, sut.Restore(ConnectorState{}))
+
+	assert.NoError(t, sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("seeded"),
+		"c1": dosa.FieldValue(int64(1)),
+	}))
+	state := sut.Snapshot()
+
+	for i := 0; i < 2; i++ {
+		// change the seeded row, add one and remove the seeded one
+		assert.NoError(t, sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+			"p1": dosa.FieldValue("seeded"),
+			"c1": dosa.FieldValue(int64(2)),
+		}))
+		assert.NoError(t, sut.Upsert(context.TODO(), testEi, map[string]dosa.FieldValue{
+			"p1": dosa.FieldValue("added"),
+			"c1": dosa.FieldValue(int64(3)),
+		}))
+		assert.NoError(t, sut.Remove(context.TODO(), testEi, map[string]dosa.FieldValue{"p1": dosa.FieldValue("seeded")}))
+
+		// the state can be restored any number of times
+		assert.NoError(t, sut.Restore(state))
+		rows, _, err := sut.Scan(context.TODO(), testEi, dosa.All(), "", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]dosa.FieldValue{{"p1": "seeded", "c1": int64(1)}}, rows)
+
+		// indexes are restored too
+		rows, _, err = sut.Range(context.TODO(), testEi, map[string][]*dosa.Condition{
+			"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+		}, dosa.All(), "", 10)
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+	}
+}
+
 // test CreateIfNotExists with partitioning
 func TestConnector_CreateIfNotExists2(t *testing.T) {
 	sut := NewConnector()
@@ -618,6 +1343,124 @@ func TestConnector_Upsert2(t *testing.T) {
 	assert.NotNil(t, data[0]["c6"])
 }
 
+// cancelAfterContext cancels itself once its error has been checked n times
+type cancelAfterContext struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int
+	checks int
+}
+
+func newCancelAfterContext(n int) *cancelAfterContext {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cancelAfterContext{Context: ctx, cancel: cancel, n: n}
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.checks++
+	if c.checks > c.n {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestConnector_RangeCanceled(t *testing.T) {
+	const rows = 10000
+	sut := NewConnector()
+	for i := 0; i < rows; i++ {
+		assert.NoError(t, sut.Upsert(context.TODO(), clusteredEi, map[string]dosa.FieldValue{
+			"f1": dosa.FieldValue("data"),
+			"c1": dosa.FieldValue(int64(i)),
+			"c7": dosa.FieldValue(dosa.NewUUID()),
+		}))
+	}
+	conditions := map[string][]*dosa.Condition{"f1": {{Op: dosa.Eq, Value: "data"}}}
+
+	// canceled while the rows are copied
+	ctx := newCancelAfterContext(100)
+	start := time.Now()
+	data, _, err := sut.Range(ctx, clusteredEi, conditions, dosa.All(), "", rows)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, data)
+	assert.True(t, ctx.checks < 200, "Range kept going after the context was canceled")
+	assert.True(t, time.Since(start) < time.Second)
+
+	// canceled while the partition is searched for the clustering conditions
+	ctx = newCancelAfterContext(100)
+	_, _, err = sut.Range(ctx, clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: "data"}},
+		"c1": {{Op: dosa.Gt, Value: int64(rows - 10)}},
+	}, dosa.All(), "", rows)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, ctx.checks < 200, "Range kept going after the context was canceled")
+
+	// already canceled
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = sut.Range(canceled, clusteredEi, conditions, dosa.All(), "", rows)
+	assert.Equal(t, context.Canceled, err)
+
+	data, _, err = sut.Range(context.TODO(), clusteredEi, conditions, dosa.All(), "", rows)
+	assert.NoError(t, err)
+	assert.Len(t, data, rows)
+}
+
+func TestConnector_RangeSortOrder(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		typ    dosa.Type
+		values []dosa.FieldValue // in ascending order
+	}{
+		{dosa.Int64, []dosa.FieldValue{int64(-5), int64(0), int64(3), int64(42)}},
+		{dosa.String, []dosa.FieldValue{"", "a", "ab", "b"}},
+		{dosa.Timestamp, []dosa.FieldValue{now.Add(-time.Hour), now, now.Add(time.Second), now.Add(time.Hour)}},
+		// by their bytes, even for time UUIDs
+		{dosa.TUUID, []dosa.FieldValue{
+			dosa.UUID("0b0c17e4-a7d4-11e8-98d0-529269fb1459"),
+			dosa.UUID("0c2c17e4-a7d3-11e8-98d0-529269fb1459"),
+			dosa.UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459"),
+			dosa.UUID("1a2b17e4-a7d3-11e8-98d0-529269fb1459"),
+		}},
+	}
+	for _, test := range tests {
+		for _, descending := range []bool{false, true} {
+			ei := &dosa.EntityInfo{
+				Ref: &testSchemaRef,
+				Def: &dosa.EntityDefinition{
+					Name: "sorted",
+					Columns: []*dosa.ColumnDefinition{
+						{Name: "p", Type: dosa.String},
+						{Name: "c", Type: test.typ},
+					},
+					Key: &dosa.PrimaryKey{
+						PartitionKeys:  []string{"p"},
+						ClusteringKeys: []*dosa.ClusteringKey{{Name: "c", Descending: descending}},
+					},
+				},
+			}
+			sut := NewConnector()
+			// write them out of order
+			for _, i := range []int{2, 0, 3, 1} {
+				assert.NoError(t, sut.Upsert(context.TODO(), ei, map[string]dosa.FieldValue{
+					"p": "key", "c": test.values[i],
+				}))
+			}
+			rows, _, err := sut.Range(context.TODO(), ei, map[string][]*dosa.Condition{
+				"p": {{Op: dosa.Eq, Value: "key"}},
+			}, dosa.All(), "", 10)
+			assert.NoError(t, err)
+			assert.Len(t, rows, len(test.values))
+			for i, row := range rows {
+				want := test.values[i]
+				if descending {
+					want = test.values[len(test.values)-1-i]
+				}
+				assert.Equal(t, want, row["c"], test.typ.String())
+			}
+		}
+	}
+}
+
 func TestConnector_Range(t *testing.T) {
 	const idcount = 10
 	sut := NewConnector()
@@ -857,9 +1700,10 @@ func BenchmarkConnector_Read(b *testing.B) {
 }
 
 func TestCompareType(t *testing.T) {
-	tuuid := dosa.NewUUID()
-	v1uuid := dosa.UUID(uuid.Must(uuid.NewV1()).String())
-	v1newer := dosa.UUID(uuid.Must(uuid.NewV1()).String())
+	// UUIDs are ordered by their bytes, whatever their version
+	tuuid := dosa.UUID("4f7a85a6-4bb0-4a3e-9f3c-7a6c4b3ea5d0")
+	v1uuid := dosa.UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459")
+	v1newer := dosa.UUID("1a2b17e4-a7d3-11e8-98d0-529269fb1459")
 	tests := []struct {
 		t1, t2 dosa.FieldValue
 		result int8
@@ -1084,6 +1928,172 @@ func TestRangePager(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+func TestRangeSortColumns(t *testing.T) {
+	sut := NewConnector()
+	createTestData(t, sut, func(_ int) string { return "data" }, 5)
+	conditions := map[string][]*dosa.Condition{"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}}}
+
+	walk := func(ctx context.Context) []int32 {
+		var order []int32
+		token := ""
+		for {
+			data, next, err := sut.Range(ctx, clusteredEi, conditions, dosa.All(), token, 2)
+			if !assert.NoError(t, err) {
+				return order
+			}
+			for _, row := range data {
+				order = append(order, row["c6"].(int32))
+			}
+			if next == "" {
+				return order
+			}
+			token = next
+		}
+	}
+
+	// c7 is descending, so the newest rows come first
+	assert.Equal(t, []int32{4, 3, 2, 1, 0}, walk(context.TODO()))
+	assert.Equal(t, []int32{4, 3, 2, 1, 0}, walk(dosa.WithSortColumns(context.TODO(), []dosa.ColumnOrder{
+		{Column: "c1", Direction: dosa.Ascending},
+		{Column: "c7", Direction: dosa.Descending},
+	})))
+	assert.Equal(t, []int32{0, 1, 2, 3, 4}, walk(dosa.WithSortColumns(context.TODO(), []dosa.ColumnOrder{
+		{Column: "c1", Direction: dosa.Descending},
+		{Column: "c7", Direction: dosa.Ascending},
+	})))
+	// a prefix of the clustering keys is enough
+	assert.Equal(t, []int32{0, 1, 2, 3, 4}, walk(dosa.WithSortColumns(context.TODO(), []dosa.ColumnOrder{
+		{Column: "c1", Direction: dosa.Descending},
+	})))
+
+	_, _, err := sut.Range(dosa.WithSortColumns(context.TODO(), []dosa.ColumnOrder{
+		{Column: "c1", Direction: dosa.Descending},
+		{Column: "c7", Direction: dosa.Descending},
+	}), clusteredEi, conditions, dosa.All(), "", 2)
+	assert.Contains(t, err.Error(), "Invalid sort columns")
+}
+
+func TestConnector_ExplainQuery(t *testing.T) {
+	sut := NewConnector()
+	createTestData(t, sut, func(id int) string {
+		if id < 5 {
+			return "data"
+		}
+		return "other"
+	}, 7)
+
+	plan, err := sut.ExplainQuery(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{EstimatedRows: 5}, plan)
+
+	plan, err = sut.ExplainQuery(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{Index: "i2", EstimatedRows: 7}, plan)
+
+	plan, err = sut.ExplainQuery(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"c6": {{Op: dosa.Eq, Value: dosa.FieldValue(int32(1))}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{EstimatedRows: 7, FullScan: true, ServerSideFiltering: true}, plan)
+
+	plan, err = sut.ExplainQuery(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("missing")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), plan.EstimatedRows)
+}
+
+func TestConnector_CountRange(t *testing.T) {
+	sut := NewConnector()
+
+	// no data at all
+	count, err := sut.CountRange(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	createTestData(t, sut, func(id int) string {
+		if id < 5 {
+			return "data"
+		}
+		return "other"
+	}, 7)
+
+	count, err = sut.CountRange(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+
+	// an index is used like Range does
+	count, err = sut.CountRange(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), count)
+
+	count, err = sut.CountRange(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("missing")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	_, err = sut.CountRange(context.TODO(), clusteredEi, map[string][]*dosa.Condition{
+		"c6": {{Op: dosa.Eq, Value: dosa.FieldValue(int32(1))}},
+	})
+	assert.Contains(t, err.Error(), "Invalid range conditions")
+}
+
+func TestConnector_IndexTTL(t *testing.T) {
+	ei := &dosa.EntityInfo{Ref: &testSchemaRef, Def: clusteredEi.Def.Clone()}
+	ei.Def.Name = "t2ttl"
+	ei.Def.Indexes["i2"].TTL = time.Hour
+	sut := NewConnector()
+	now := time.Unix(1000, 0)
+	sut.now = func() time.Time { return now }
+
+	row := func(id int) map[string]dosa.FieldValue {
+		return map[string]dosa.FieldValue{"f1": "data", "c1": int64(1), "c7": dosa.UUID(fmt.Sprintf("00000000-0000-0000-0000-%012d", id))}
+	}
+	byIndex := map[string][]*dosa.Condition{"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}}}
+	byKey := map[string][]*dosa.Condition{"f1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}}}
+
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, row(1)))
+	now = now.Add(30 * time.Minute)
+	assert.NoError(t, sut.CreateIfNotExists(context.TODO(), ei, row(2)))
+	rows, _, err := sut.Range(context.TODO(), ei, byIndex, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.NotContains(t, rows[0], indexExpiresColumn)
+
+	// the first entry expires from the index, but not its row
+	now = now.Add(30 * time.Minute)
+	rows, _, err = sut.Range(context.TODO(), ei, byIndex, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]dosa.FieldValue{row(2)}, rows)
+	count, err := sut.CountRange(context.TODO(), ei, byIndex)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	rows, _, err = sut.Range(context.TODO(), ei, byKey, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	// writing the row again renews the entry
+	assert.NoError(t, sut.Upsert(context.TODO(), ei, row(1)))
+	now = now.Add(45 * time.Minute)
+	rows, _, err = sut.Range(context.TODO(), ei, byIndex, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]dosa.FieldValue{row(1)}, rows)
+	plan, err := sut.ExplainQuery(context.TODO(), ei, byIndex)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), plan.EstimatedRows)
+}
+
 func TestInvalidToken(t *testing.T) {
 	sut := NewConnector()
 
@@ -1573,3 +2583,30 @@ func createTestData(t *testing.T, sut *Connector, keyGenFunc func(int) string, i
 		assert.NoError(t, err)
 	}
 }
+
+func TestConnector_Codec(t *testing.T) {
+	sut := NewConnector(base.WithCodec(stubCodec{}))
+	assert.Equal(t, dosa.IdentityCodec, sut.Codec())
+}
+
+type stubCodec struct{}
+
+func (stubCodec) Encode(_ dosa.Type, val dosa.FieldValue) (interface{}, error) {
+	return val, nil
+}
+
+func (stubCodec) Decode(_ dosa.Type, raw interface{}) (dosa.FieldValue, error) {
+	return raw, nil
+}
+
+func TestConnector_RegisteredScheme(t *testing.T) {
+	factory, ok := dosa.GetConnectorFactory(Scheme)
+	assert.True(t, ok)
+	conn, err := factory([]byte("ignored"))
+	assert.NoError(t, err)
+	assert.IsType(t, &Connector{}, conn)
+
+	conn, err = dosa.NewConnectorForScheme(Scheme)
+	assert.NoError(t, err)
+	assert.IsType(t, &Connector{}, conn)
+}
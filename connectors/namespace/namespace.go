@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"context"
+	"strings"
+
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// separator joins the namespace and the entity name
+const separator = "_"
+
+// Connector keeps the entities of one namespace apart from those of others
+// sharing the same backend, by renaming every entity it passes to Next to
+// the namespace, "_" and the entity name. The namespace is fixed when the
+// connector is created, for services whose namespace is known at startup.
+// Entity definitions returned by Next get their original names back.
+type Connector struct {
+	base.Connector
+	namespace string
+}
+
+// NewConnector creates a new namespace connector; namespace must be a valid
+// DOSA name, and the names it creates must not be longer than the backend
+// allows
+func NewConnector(next dosa.Connector, namespace string) *Connector {
+	return &Connector{Connector: base.Connector{Next: next}, namespace: namespace}
+}
+
+// Namespace returns the name of an entity in namespace
+func Namespace(name, namespace string) string {
+	return namespace + separator + name
+}
+
+// StripNamespace returns the name of an entity without its namespace, the
+// inverse of Namespace. Names outside of namespace are returned unchanged.
+func StripNamespace(name, namespace string) string {
+	return strings.TrimPrefix(name, namespace+separator)
+}
+
+// ei returns a copy of ei with the entity renamed into the namespace
+func (c *Connector) ei(ei *dosa.EntityInfo) *dosa.EntityInfo {
+	if ei == nil {
+		return nil
+	}
+	renamed := *ei
+	if ei.Ref != nil {
+		ref := *ei.Ref
+		ref.EntityName = Namespace(ref.EntityName, c.namespace)
+		renamed.Ref = &ref
+	}
+	renamed.Def = c.ed(ei.Def)
+	return &renamed
+}
+
+// ed returns a copy of ed with the entity renamed into the namespace
+func (c *Connector) ed(ed *dosa.EntityDefinition) *dosa.EntityDefinition {
+	if ed == nil {
+		return nil
+	}
+	def := *ed
+	def.Name = Namespace(def.Name, c.namespace)
+	return &def
+}
+
+// eds renames every entity definition into the namespace
+func (c *Connector) eds(eds []*dosa.EntityDefinition) []*dosa.EntityDefinition {
+	renamed := make([]*dosa.EntityDefinition, len(eds))
+	for i, ed := range eds {
+		renamed[i] = c.ed(ed)
+	}
+	return renamed
+}
+
+// strip returns ed with its namespace removed from its name
+func (c *Connector) strip(ed *dosa.EntityDefinition) *dosa.EntityDefinition {
+	if ed != nil {
+		ed.Name = StripNamespace(ed.Name, c.namespace)
+	}
+	return ed
+}
+
+// CreateIfNotExists calls Next with the entity renamed into the namespace
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.Next.CreateIfNotExists(ctx, c.ei(ei), values)
+}
+
+// Read calls Next with the entity renamed into the namespace
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	return c.Next.Read(ctx, c.ei(ei), keys, minimumFields)
+}
+
+// MultiRead calls Next with the entity renamed into the namespace
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, keys []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	return c.Next.MultiRead(ctx, c.ei(ei), keys, minimumFields)
+}
+
+// Upsert calls Next with the entity renamed into the namespace
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.Next.Upsert(ctx, c.ei(ei), values)
+}
+
+// CompareAndSwap calls Next with the entity renamed into the namespace
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	return c.Next.CompareAndSwap(ctx, c.ei(ei), conditions, newValues)
+}
+
+// UpsertWithConditions calls Next with the entity renamed into the namespace
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	return c.Next.UpsertWithConditions(ctx, c.ei(ei), values, conditions)
+}
+
+// UpsertAndRead calls Next with the entity renamed into the namespace
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	return c.Next.UpsertAndRead(ctx, c.ei(ei), values)
+}
+
+// Replace calls Next with the entity renamed into the namespace
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return c.Next.Replace(ctx, c.ei(ei), values)
+}
+
+// AtomicAdd calls Next with the entity renamed into the namespace
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	return c.Next.AtomicAdd(ctx, c.ei(ei), keys, column, delta)
+}
+
+// Aggregate calls Next with the entity renamed into the namespace
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	return c.Next.Aggregate(ctx, c.ei(ei), aggFunc, column, columnConditions)
+}
+
+// MultiUpsert calls Next with the entity renamed into the namespace
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	return c.Next.MultiUpsert(ctx, c.ei(ei), multiValues)
+}
+
+// Remove calls Next with the entity renamed into the namespace
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue) error {
+	return c.Next.Remove(ctx, c.ei(ei), keys)
+}
+
+// RemoveRange calls Next with the entity renamed into the namespace
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	return c.Next.RemoveRange(ctx, c.ei(ei), columnConditions)
+}
+
+// DeletePartition calls Next with the entity renamed into the namespace
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	return c.Next.DeletePartition(ctx, c.ei(ei), pk)
+}
+
+// MultiRemove calls Next with the entity renamed into the namespace
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiKeys []map[string]dosa.FieldValue) ([]error, error) {
+	return c.Next.MultiRemove(ctx, c.ei(ei), multiKeys)
+}
+
+// Range calls Next with the entity renamed into the namespace
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	return c.Next.Range(ctx, c.ei(ei), columnConditions, minimumFields, token, limit)
+}
+
+// Scan calls Next with the entity renamed into the namespace
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	return c.Next.Scan(ctx, c.ei(ei), minimumFields, token, limit)
+}
+
+// CopyTable calls Next with both entities renamed into the namespace
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return c.Next.CopyTable(ctx, c.ei(src), c.ei(dst))
+}
+
+// ExplainQuery calls Next with the entity renamed into the namespace
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	return c.Next.ExplainQuery(ctx, c.ei(ei), columnConditions)
+}
+
+// CountRange calls Next with the entity renamed into the namespace
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	return c.Next.CountRange(ctx, c.ei(ei), columnConditions)
+}
+
+// CheckSchema calls Next with the entities renamed into the namespace
+func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	return c.Next.CheckSchema(ctx, scope, namePrefix, c.eds(eds))
+}
+
+// CanUpsertSchema calls Next with the entities renamed into the namespace
+func (c *Connector) CanUpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	return c.Next.CanUpsertSchema(ctx, scope, namePrefix, c.eds(eds))
+}
+
+// UpsertSchema calls Next with the entities renamed into the namespace
+func (c *Connector) UpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (*dosa.SchemaStatus, error) {
+	return c.Next.UpsertSchema(ctx, scope, namePrefix, c.eds(eds))
+}
+
+// GetEntitySchema reads the schema of the entity in the namespace from Next
+func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*dosa.EntityDefinition, error) {
+	ed, err := c.Next.GetEntitySchema(ctx, scope, namePrefix, Namespace(entityName, c.namespace), version)
+	return c.strip(ed), err
+}
+
+// DescribeTable describes the table of the entity in the namespace
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	ed, err := c.Next.DescribeTable(ctx, c.ei(ei))
+	return c.strip(ed), err
+}
+
+// DropTable drops the table of the entity in the namespace
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return c.Next.DropTable(ctx, c.ei(ei))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/namespace"
+	"github.com/uber-go/dosa/mocks"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+func TestNamespace_Isolation(t *testing.T) {
+	shared := memory.NewConnector()
+	blue := namespace.NewConnector(shared, "blue")
+	green := namespace.NewConnector(shared, "green")
+
+	assert.NoError(t, blue.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "name": "blue"}))
+	assert.NoError(t, green.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "name": "green"}))
+	assert.NoError(t, green.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2), "name": "green"}))
+
+	keys := map[string]dosa.FieldValue{"id": int64(1)}
+	values, err := blue.Read(ctx, testEi, keys, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "blue", values["name"])
+	values, err = green.Read(ctx, testEi, keys, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "green", values["name"])
+
+	rows, _, err := blue.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	// nothing was written under the plain name
+	_, err = shared.Read(ctx, testEi, keys, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	assert.NoError(t, green.Remove(ctx, testEi, keys))
+	_, err = green.Read(ctx, testEi, keys, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+	_, err = blue.Read(ctx, testEi, keys, dosa.All())
+	assert.NoError(t, err)
+
+	// the caller's entity info is left alone
+	assert.Equal(t, "users", testEi.Def.Name)
+	assert.Equal(t, "users", testEi.Ref.EntityName)
+}
+
+func TestNamespace_Schema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockConn := mocks.NewMockConnector(ctrl)
+	c := namespace.NewConnector(mockConn, "blue")
+
+	mockConn.EXPECT().UpsertSchema(ctx, "testScope", "testPrefix", gomock.Any()).
+		Do(func(_ context.Context, _, _ string, eds []*dosa.EntityDefinition) {
+			assert.Equal(t, "blue_users", eds[0].Name)
+		}).Return(&dosa.SchemaStatus{Version: 1}, nil)
+	_, err := c.UpsertSchema(ctx, "testScope", "testPrefix", []*dosa.EntityDefinition{testEi.Def})
+	assert.NoError(t, err)
+
+	live := testEi.Def.Clone()
+	live.Name = "blue_users"
+	mockConn.EXPECT().GetEntitySchema(ctx, "testScope", "testPrefix", "blue_users", int32(1)).Return(live, nil)
+	ed, err := c.GetEntitySchema(ctx, "testScope", "testPrefix", "users", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "users", ed.Name)
+}
+
+func TestStripNamespace(t *testing.T) {
+	assert.Equal(t, "blue_users", namespace.Namespace("users", "blue"))
+	assert.Equal(t, "users", namespace.StripNamespace("blue_users", "blue"))
+	assert.Equal(t, "green_users", namespace.StripNamespace("green_users", "blue"))
+	assert.Equal(t, "users", namespace.StripNamespace(namespace.Namespace("users", "blue"), "blue"))
+}
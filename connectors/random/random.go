@@ -118,6 +118,64 @@ func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[
 	return nil
 }
 
+// CompareAndSwap throws away the data you upsert, pretending the conditions were met
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	return nil
+}
+
+// UpsertWithConditions throws away the data you upsert, pretending the conditions were met
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	return nil
+}
+
+// UpsertAndRead throws away the data you upsert and returns a random row
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	columns := make([]string, len(ei.Def.Columns))
+	for i, cd := range ei.Def.Columns {
+		columns[i] = cd.Name
+	}
+	return Data(ei, columns), nil
+}
+
+// Replace throws away the data you replace
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	return nil
+}
+
+// AtomicAdd returns a random counter value
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if err := dosa.CheckAtomicAdd(ei.Def, column); err != nil {
+		return 0, err
+	}
+	return rand.Int63(), nil
+}
+
+// Aggregate aggregates a random number of random rows
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	if err := dosa.CheckAggregate(ei.Def, aggFunc, column); err != nil {
+		return nil, err
+	}
+	agg := dosa.NewAggregator(aggFunc, ei.Def.FindColumnDefinition(column).Type)
+	rows := rand.Intn(10) + 1
+	for i := 0; i < rows; i++ {
+		agg.Add(Data(ei, []string{column})[column])
+	}
+	return agg.Result()
+}
+
+// ExplainQuery returns the plan that follows from the schema, with a random
+// number of rows
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	plan := dosa.PlanFromConditions(ei, columnConditions)
+	plan.EstimatedRows = int64(rand.Intn(100))
+	return plan, nil
+}
+
+// CountRange returns a random number of rows
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	return int64(rand.Intn(100)), nil
+}
+
 // makeErrorSlice is a handy function to make a slice of errors or nil errors
 func makeErrorSlice(len int, e error) []error {
 	errors := make([]error, len)
@@ -142,6 +200,11 @@ func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, column
 	return nil
 }
 
+// DeletePartition always returns nil
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	return nil
+}
+
 // MultiRemove returns a not found error for each value
 func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
 	return makeErrorSlice(len(multiValues), &dosa.ErrNotFound{}), nil
@@ -164,6 +227,11 @@ func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields
 	return c.Range(ctx, ei, map[string][]*dosa.Condition{}, minimumFields, token, limit)
 }
 
+// CopyTable does nothing, since the random connector doesn't store anything
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return nil
+}
+
 // CheckSchema always returns schema version 1
 func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, ed []*dosa.EntityDefinition) (int32, error) {
 	return int32(1), nil
@@ -192,6 +260,16 @@ func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, enti
 	return &dosa.EntityDefinition{}, nil
 }
 
+// DescribeTable returns a copy of the entity's definition, since any schema is accepted
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	return ei.Def.Clone(), nil
+}
+
+// DropTable returns success
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return nil
+}
+
 // CreateScope returns success
 func (c *Connector) CreateScope(ctx context.Context, _ *dosa.ScopeMetadata) error {
 	return nil
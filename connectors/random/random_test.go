@@ -93,6 +93,67 @@ func TestRandom_Upsert(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestRandom_CompareAndSwap(t *testing.T) {
+	err := sut.CompareAndSwap(ctx, testInfo, testValues, testValues)
+	assert.Nil(t, err)
+}
+
+func TestRandom_UpsertAndRead(t *testing.T) {
+	values, err := sut.UpsertAndRead(ctx, testInfo, testValues)
+	assert.NoError(t, err)
+	assert.Len(t, values, len(testInfo.Def.Columns))
+}
+
+func TestRandom_Replace(t *testing.T) {
+	assert.NoError(t, sut.Replace(ctx, testInfo, testValues))
+}
+
+func TestRandom_UpsertWithConditions(t *testing.T) {
+	assert.NoError(t, sut.UpsertWithConditions(ctx, testInfo, testValues, testConditions))
+}
+
+func TestRandom_CopyTable(t *testing.T) {
+	assert.NoError(t, sut.CopyTable(ctx, testInfo, testInfo))
+}
+
+func TestRandom_DescribeTable(t *testing.T) {
+	ed, err := sut.DescribeTable(ctx, testInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, testInfo.Def, ed)
+}
+
+func TestRandom_DropTable(t *testing.T) {
+	assert.NoError(t, sut.DropTable(ctx, testInfo))
+}
+
+func TestRandom_ExplainQuery(t *testing.T) {
+	plan, err := sut.ExplainQuery(ctx, testInfo, nil)
+	assert.NoError(t, err)
+	assert.True(t, plan.FullScan)
+	assert.True(t, plan.EstimatedRows >= 0)
+}
+
+func TestRandom_AtomicAdd(t *testing.T) {
+	_, err := sut.AtomicAdd(ctx, testInfo, testValues, "int64type", 1)
+	assert.NoError(t, err)
+
+	_, err = sut.AtomicAdd(ctx, testInfo, testValues, "int32type", 1)
+	assert.True(t, dosa.ErrorIsInvalidOperation(err))
+}
+
+func TestRandom_Aggregate(t *testing.T) {
+	v, err := sut.Aggregate(ctx, testInfo, dosa.AggCount, "uuidtype", testConditions)
+	assert.NoError(t, err)
+	assert.True(t, v.(int64) > 0)
+
+	v, err = sut.Aggregate(ctx, testInfo, dosa.AggMax, "timetype", testConditions)
+	assert.NoError(t, err)
+	assert.IsType(t, time.Time{}, v)
+
+	_, err = sut.Aggregate(ctx, testInfo, dosa.AggSum, "stringtype", testConditions)
+	assert.Error(t, err)
+}
+
 func TestRandom_MultiUpsert(t *testing.T) {
 	errs, err := sut.MultiUpsert(ctx, testInfo, testMultiValues)
 	assert.NotNil(t, errs)
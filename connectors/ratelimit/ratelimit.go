@@ -0,0 +1,326 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// ErrRateLimited is returned when an operation exceeds the rate of a rule
+// that doesn't wait for a token
+type ErrRateLimited struct {
+	Entity    string
+	Operation string
+}
+
+// Error satisfies the error interface
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s on %s", e.Operation, e.Entity)
+}
+
+// ErrorIsRateLimited checks if the error is caused by "ErrRateLimited"
+func ErrorIsRateLimited(err error) bool {
+	_, ok := errors.Cause(err).(*ErrRateLimited)
+	return ok
+}
+
+// Rule limits the rate of an operation on an entity with a token bucket that
+// holds up to Burst tokens and is refilled with Rate tokens per second. Every
+// operation takes one token; Multi* operations take one token per call.
+//
+// An empty Entity matches every entity and an empty Operation matches every
+// operation. Operations are named after the Connector methods, e.g. "Read" or
+// "MultiUpsert". When WaitOnLimit is set, operations block until a token is
+// available (or the context is done) instead of failing with ErrRateLimited.
+type Rule struct {
+	Entity      string
+	Operation   string
+	Rate        float64
+	Burst       int
+	WaitOnLimit bool
+}
+
+// Connector throttles the data operations sent to Next. Schema and scope
+// operations are passed through unlimited. An operation must get a token from
+// every rule that matches it.
+type Connector struct {
+	base.Connector
+	limiters []*limiter
+}
+
+// NewConnector creates a new rate limiting connector
+func NewConnector(next dosa.Connector, rules []Rule) (*Connector, error) {
+	c := &Connector{Connector: base.Connector{Next: next}}
+	for _, r := range rules {
+		if r.Rate <= 0 {
+			return nil, errors.Errorf("rate of rule for %s on %q must be positive, got %v", r.Operation, r.Entity, r.Rate)
+		}
+		if r.Burst < 1 {
+			return nil, errors.Errorf("burst of rule for %s on %q must be at least 1, got %d", r.Operation, r.Entity, r.Burst)
+		}
+		c.limiters = append(c.limiters, newLimiter(r, time.Now()))
+	}
+	return c, nil
+}
+
+// limiter is a token bucket for a single rule
+type limiter struct {
+	Rule
+	sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(r Rule, now time.Time) *limiter {
+	return &limiter{Rule: r, tokens: float64(r.Burst), last: now}
+}
+
+func (l *limiter) matches(entity, op string) bool {
+	return (l.Entity == "" || l.Entity == entity) && (l.Operation == "" || l.Operation == op)
+}
+
+// reserve takes a token and returns how long to wait before it may be used.
+// ok is false when no token is available and the rule doesn't wait.
+func (l *limiter) reserve(now time.Time) (wait time.Duration, ok bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.Rate
+		if l.tokens > float64(l.Burst) {
+			l.tokens = float64(l.Burst)
+		}
+		l.last = now
+	}
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	if !l.WaitOnLimit {
+		return 0, false
+	}
+	l.tokens--
+	return time.Duration(-l.tokens / l.Rate * float64(time.Second)), true
+}
+
+// cancel returns a reserved token that won't be used
+func (l *limiter) cancel() {
+	l.Lock()
+	defer l.Unlock()
+	l.tokens++
+}
+
+// wait gets a token from every rule matching the operation, blocking when a rule asks for it
+func (c *Connector) wait(ctx context.Context, ei *dosa.EntityInfo, op string) error {
+	var reserved []*limiter
+	var delay time.Duration
+	now := time.Now()
+	for _, l := range c.limiters {
+		if !l.matches(ei.Def.Name, op) {
+			continue
+		}
+		d, ok := l.reserve(now)
+		if !ok {
+			for _, r := range reserved {
+				r.cancel()
+			}
+			return &ErrRateLimited{Entity: ei.Def.Name, Operation: op}
+		}
+		reserved = append(reserved, l)
+		if d > delay {
+			delay = d
+		}
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		for _, r := range reserved {
+			r.cancel()
+		}
+		return ctx.Err()
+	}
+}
+
+// CreateIfNotExists waits for a token before calling Next
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "CreateIfNotExists"); err != nil {
+		return err
+	}
+	return c.Next.CreateIfNotExists(ctx, ei, values)
+}
+
+// Read waits for a token before calling Next
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	if err := c.wait(ctx, ei, "Read"); err != nil {
+		return nil, err
+	}
+	return c.Next.Read(ctx, ei, values, minimumFields)
+}
+
+// MultiRead waits for a token before calling Next
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	if err := c.wait(ctx, ei, "MultiRead"); err != nil {
+		return nil, err
+	}
+	return c.Next.MultiRead(ctx, ei, values, minimumFields)
+}
+
+// Upsert waits for a token before calling Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "Upsert"); err != nil {
+		return err
+	}
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// UpsertAndRead waits for a token before calling Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if err := c.wait(ctx, ei, "UpsertAndRead"); err != nil {
+		return nil, err
+	}
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Aggregate waits for a token before calling Next
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	if err := c.wait(ctx, ei, "Aggregate"); err != nil {
+		return nil, err
+	}
+	return c.Next.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+}
+
+// CountRange waits for a token before calling Next
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	if err := c.wait(ctx, ei, "CountRange"); err != nil {
+		return 0, err
+	}
+	return c.Next.CountRange(ctx, ei, columnConditions)
+}
+
+// Replace waits for a token before calling Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "Replace"); err != nil {
+		return err
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// AtomicAdd waits for a token before calling Next
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	if err := c.wait(ctx, ei, "AtomicAdd"); err != nil {
+		return 0, err
+	}
+	return c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+// CompareAndSwap waits for a token before calling Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "CompareAndSwap"); err != nil {
+		return err
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions waits for a token before calling Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if err := c.wait(ctx, ei, "UpsertWithConditions"); err != nil {
+		return err
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// MultiUpsert waits for a token before calling Next
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
+	if err := c.wait(ctx, ei, "MultiUpsert"); err != nil {
+		return nil, err
+	}
+	return c.Next.MultiUpsert(ctx, ei, values)
+}
+
+// Remove waits for a token before calling Next
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "Remove"); err != nil {
+		return err
+	}
+	return c.Next.Remove(ctx, ei, values)
+}
+
+// RemoveRange waits for a token before calling Next
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	if err := c.wait(ctx, ei, "RemoveRange"); err != nil {
+		return err
+	}
+	return c.Next.RemoveRange(ctx, ei, columnConditions)
+}
+
+// DeletePartition waits for a token before calling Next
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	if err := c.wait(ctx, ei, "DeletePartition"); err != nil {
+		return err
+	}
+	return c.Next.DeletePartition(ctx, ei, pk)
+}
+
+// MultiRemove waits for a token before calling Next
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	if err := c.wait(ctx, ei, "MultiRemove"); err != nil {
+		return nil, err
+	}
+	return c.Next.MultiRemove(ctx, ei, multiValues)
+}
+
+// Range waits for a token before calling Next
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	if err := c.wait(ctx, ei, "Range"); err != nil {
+		return nil, "", err
+	}
+	return c.Next.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+}
+
+// Scan waits for a token before calling Next
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	if err := c.wait(ctx, ei, "Scan"); err != nil {
+		return nil, "", err
+	}
+	return c.Next.Scan(ctx, ei, minimumFields, token, limit)
+}
+
+// CopyTable waits for a token for the destination before calling Next
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	if err := c.wait(ctx, dst, "CopyTable"); err != nil {
+		return err
+	}
+	return c.Next.CopyTable(ctx, src, dst)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/ratelimit"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var testValues = map[string]dosa.FieldValue{"id": int64(1), "name": "foo"}
+
+var ctx = context.Background()
+
+func TestNewConnector_InvalidRules(t *testing.T) {
+	_, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{{Entity: "users", Operation: "Read", Burst: 1}})
+	assert.EqualError(t, err, `rate of rule for Read on "users" must be positive, got 0`)
+
+	_, err = ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{{Entity: "users", Operation: "Read", Rate: 1}})
+	assert.EqualError(t, err, `burst of rule for Read on "users" must be at least 1, got 0`)
+}
+
+func TestConnector_Reject(t *testing.T) {
+	c, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{
+		{Entity: "users", Operation: "Upsert", Rate: 0.001, Burst: 2},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	err = c.Upsert(ctx, testEi, testValues)
+	assert.True(t, ratelimit.ErrorIsRateLimited(err))
+	assert.EqualError(t, err, "rate limit exceeded for Upsert on users")
+
+	// other operations are not limited by the rule
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.NoError(t, err)
+}
+
+func TestConnector_Wildcards(t *testing.T) {
+	c, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{
+		{Rate: 0.001, Burst: 3},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.NoError(t, err)
+	_, _, err = c.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.NoError(t, err)
+	assert.True(t, ratelimit.ErrorIsRateLimited(c.Remove(ctx, testEi, testValues)))
+}
+
+func TestConnector_AllRulesMustPass(t *testing.T) {
+	c, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{
+		{Entity: "users", Rate: 0.001, Burst: 2},
+		{Entity: "users", Operation: "Upsert", Rate: 0.001, Burst: 1},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	assert.True(t, ratelimit.ErrorIsRateLimited(c.Upsert(ctx, testEi, testValues)))
+	// the rejected upsert didn't use up a token of the entity rule
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1)}, dosa.All())
+	assert.NoError(t, err)
+}
+
+func TestConnector_Wait(t *testing.T) {
+	c, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{
+		{Entity: "users", Operation: "Upsert", Rate: 50, Burst: 1, WaitOnLimit: true},
+	})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	}
+	// the first upsert uses the burst, the other two wait 20ms each
+	assert.True(t, time.Since(start) >= 35*time.Millisecond)
+}
+
+func TestConnector_WaitCanceled(t *testing.T) {
+	c, err := ratelimit.NewConnector(memory.NewConnector(), []ratelimit.Rule{
+		{Entity: "users", Operation: "Upsert", Rate: 0.001, Burst: 1, WaitOnLimit: true},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, testValues))
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Upsert(timeoutCtx, testEi, testValues))
+}
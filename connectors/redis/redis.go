@@ -132,6 +132,11 @@ func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, column
 	return new(ErrNotImplemented)
 }
 
+// DeletePartition not implemented
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	return new(ErrNotImplemented)
+}
+
 // MultiRemove not implemented
 func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiKeys []map[string]dosa.FieldValue) (result []error, err error) {
 	return nil, new(ErrNotImplemented)
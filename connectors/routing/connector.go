@@ -95,6 +95,78 @@ func (rc *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map
 	return connector.Upsert(ctx, ei, values)
 }
 
+// CompareAndSwap selects corresponding connector
+func (rc *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	return connector.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions selects corresponding connector
+func (rc *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	return connector.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// UpsertAndRead selects corresponding connector
+func (rc *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return connector.UpsertAndRead(ctx, ei, values)
+}
+
+// Aggregate selects corresponding connector
+func (rc *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+}
+
+// ExplainQuery selects corresponding connector
+func (rc *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return connector.ExplainQuery(ctx, ei, columnConditions)
+}
+
+// CountRange selects corresponding connector
+func (rc *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return 0, err
+	}
+	return connector.CountRange(ctx, ei, columnConditions)
+}
+
+// Replace selects corresponding connector
+func (rc *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	return connector.Replace(ctx, ei, values)
+}
+
+// AtomicAdd selects corresponding connector
+func (rc *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return 0, err
+	}
+	return connector.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
 // MultiUpsert selects corresponding connector
 func (rc *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
 	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
@@ -124,6 +196,15 @@ func (rc *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, colum
 	return connector.RemoveRange(ctx, ei, columnConditions)
 }
 
+// DeletePartition selects corresponding connector
+func (rc *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	return connector.DeletePartition(ctx, ei, pk)
+}
+
 // MultiRemove selects corresponding connector
 func (rc *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
 	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
@@ -151,6 +232,23 @@ func (rc *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumField
 	return connector.Scan(ctx, ei, minimumFields, token, limit)
 }
 
+// CopyTable calls the selected connector when src and dst route to the same
+// one; otherwise src is scanned and the rows are written to dst
+func (rc *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	srcConnector, err := rc.getConnector(src.Ref.Scope, src.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	dstConnector, err := rc.getConnector(dst.Ref.Scope, dst.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	if srcConnector == dstConnector {
+		return srcConnector.CopyTable(ctx, src, dst)
+	}
+	return dosa.CopyTableByScanning(ctx, rc, src, dst)
+}
+
 // CheckSchema calls selected connector
 func (rc *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, ed []*dosa.EntityDefinition) (int32, error) {
 	connector, err := rc.getConnector(scope, namePrefix)
@@ -187,6 +285,24 @@ func (rc *Connector) CheckSchemaStatus(ctx context.Context, scope string, namePr
 	return connector.CheckSchemaStatus(ctx, scope, namePrefix, version)
 }
 
+// DescribeTable calls selected connector
+func (rc *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return connector.DescribeTable(ctx, ei)
+}
+
+// DropTable calls selected connector
+func (rc *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	connector, err := rc.getConnector(ei.Ref.Scope, ei.Ref.NamePrefix)
+	if err != nil {
+		return err
+	}
+	return connector.DropTable(ctx, ei)
+}
+
 // GetEntitySchema calls the selected connector
 func (rc *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*dosa.EntityDefinition, error) {
 	connector, err := rc.getConnector(scope, namePrefix)
@@ -432,6 +432,159 @@ func TestConnector_Upsert(t *testing.T) {
 	assert.NotNil(t, data[0]["c6"])
 }
 
+func TestConnector_CompareAndSwap(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	err := rc.Upsert(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1))})
+	assert.NoError(t, err)
+
+	err = rc.CompareAndSwap(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(2))}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(3))})
+	assert.True(t, dosa.ErrorIsConflict(err))
+
+	err = rc.CompareAndSwap(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1))}, map[string]dosa.FieldValue{
+		"c1": dosa.FieldValue(int64(3))})
+	assert.NoError(t, err)
+}
+
+func TestConnector_UpsertAndRead(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	values, err := rc.UpsertAndRead(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1))})
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(1)), values["c1"])
+}
+
+func TestConnector_Replace(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	err := rc.Upsert(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(1)),
+		"c3": dosa.FieldValue("three")})
+	assert.NoError(t, err)
+	err = rc.Replace(ctx, testInfo, map[string]dosa.FieldValue{
+		"p1": dosa.FieldValue("data"),
+		"c1": dosa.FieldValue(int64(2))})
+	assert.NoError(t, err)
+	values, err := rc.Read(ctx, testInfo, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(2)), values["c1"])
+	assert.Nil(t, values["c3"])
+}
+
+func TestConnector_UpsertWithConditions(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	err := rc.UpsertWithConditions(ctx, testInfo, values, nil)
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	assert.NoError(t, rc.Upsert(ctx, testInfo, values))
+	assert.NoError(t, rc.UpsertWithConditions(ctx, testInfo, values, map[string][]*dosa.Condition{
+		"c1": {{Op: dosa.Eq, Value: dosa.FieldValue(int64(1))}},
+	}))
+}
+
+func TestConnector_CopyTable(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	assert.NoError(t, rc.Upsert(ctx, testInfo, values))
+
+	// both route to the memory connector
+	dst := &dosa.EntityInfo{
+		Ref: &dosa.SchemaRef{Scope: "development", NamePrefix: "map", EntityName: "testEntityName"},
+		Def: testInfo.Def.Clone(),
+	}
+	dst.Def.Name = "t2"
+	dst.Def.Indexes = nil
+	assert.NoError(t, rc.CopyTable(ctx, testInfo, dst))
+	row, err := rc.Read(ctx, dst, map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), row["c1"])
+
+	// memory to devnull is copied by scanning
+	dst.Ref = &dosa.SchemaRef{Scope: "ebook", NamePrefix: "other", EntityName: "testEntityName"}
+	assert.NoError(t, rc.CopyTable(ctx, testInfo, dst))
+}
+
+func TestConnector_DescribeTable(t *testing.T) {
+	rc := NewConnector(cfg, getConnectorMap())
+	ed, err := rc.DescribeTable(ctx, testInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, testInfo.Def, ed)
+}
+
+func TestConnector_DropTable(t *testing.T) {
+	rc := NewConnector(cfg, getConnectorMap())
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	assert.NoError(t, rc.Upsert(ctx, testInfo, values))
+
+	assert.NoError(t, rc.DropTable(ctx, testInfo))
+	_, err := rc.Read(ctx, testInfo, key, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+}
+
+func TestConnector_ExplainQuery(t *testing.T) {
+	rc := NewConnector(cfg, getConnectorMap())
+	values := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data"), "c1": dosa.FieldValue(int64(1))}
+	assert.NoError(t, rc.Upsert(ctx, testInfo, values))
+
+	plan, err := rc.ExplainQuery(ctx, testInfo, map[string][]*dosa.Condition{
+		"p1": {{Op: dosa.Eq, Value: dosa.FieldValue("data")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), plan.EstimatedRows)
+	assert.False(t, plan.FullScan)
+}
+
+func TestConnector_AtomicAdd(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	key := map[string]dosa.FieldValue{"p1": dosa.FieldValue("data")}
+	for i := 1; i <= 3; i++ {
+		v, err := rc.AtomicAdd(ctx, testInfo, key, "c1", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2*i), v)
+	}
+}
+
+func TestConnector_Aggregate(t *testing.T) {
+	connectorMap := getConnectorMap()
+	rc := NewConnector(cfg, connectorMap)
+
+	for i, p1 := range []string{"a", "b", "c"} {
+		err := rc.Upsert(ctx, testInfo, map[string]dosa.FieldValue{
+			"p1": dosa.FieldValue(p1),
+			"c1": dosa.FieldValue(int64(i + 1))})
+		assert.NoError(t, err)
+	}
+	v, err := rc.Aggregate(ctx, testInfo, dosa.AggSum, "c1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(6)), v)
+
+	// the random connector aggregates random rows
+	v, err = rc.Aggregate(ctx, testInfoRandom, dosa.AggCount, "c1", nil)
+	assert.NoError(t, err)
+	assert.True(t, v.(int64) > 0)
+}
+
 func TestConnector_MultiUpsert(t *testing.T) {
 	connectorMap := getConnectorMap()
 	rc := NewConnector(cfg, connectorMap)
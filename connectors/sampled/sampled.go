@@ -0,0 +1,305 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sampled mirrors the writes of an entity to a shadow backend and
+// sends a sample of the reads there too, to compare the answers of the two
+// backends during a storage migration.
+package sampled
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// Reporter is given the answers of the primary and the shadow backend to a
+// sampled Read. A row that wasn't found is nil.
+type Reporter func(primary, shadow map[string]dosa.FieldValue)
+
+// Connector sends everything to the primary backend, and returns its
+// answers. The writes of rows and the copies of tables are then repeated on
+// the shadow backend, whose failures are only logged; schema and scope
+// operations don't go there.
+//
+// A fraction of the Reads, picked at random, are also sent to the shadow
+// backend once the primary answered. That read runs in the background, so it
+// doesn't add to the latency of the caller: its answer is compared to the
+// primary's, a warning is logged when they differ, and both are given to the
+// reporter. The other reads only go to the primary backend.
+type Connector struct {
+	base.Connector
+	shadow     dosa.Connector
+	sampleRate float64
+	reporter   Reporter
+	// pending tracks the shadow reads still running
+	pending sync.WaitGroup
+}
+
+// NewConnector creates a connector that shadows the primary backend. The
+// sample rate is the fraction of the Reads sent to the shadow backend, from 0
+// to 1; the reporter can be nil.
+func NewConnector(primary, shadow dosa.Connector, sampleRate float64, reporter Reporter, opts ...base.ConnectorOption) (*Connector, error) {
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, errors.Errorf("sample rate must be between 0 and 1, got %v", sampleRate)
+	}
+	c := &Connector{
+		Connector:  base.Connector{Next: primary},
+		shadow:     shadow,
+		sampleRate: sampleRate,
+		reporter:   reporter,
+	}
+	c.Apply(opts...)
+	return c, nil
+}
+
+// Read reads from the primary backend, and from the shadow backend too for
+// the sampled reads that the primary answered
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	values, err := c.Next.Read(ctx, ei, keys, minimumFields)
+	if (err == nil || dosa.ErrorIsNotFound(err)) && c.sampled() {
+		// the caller owns values, and may change it while the shadow is read
+		primary := copyRow(values)
+		c.pending.Add(1)
+		go func() {
+			defer c.pending.Done()
+			c.compareRead(ei, keys, minimumFields, primary)
+		}()
+	}
+	return values, err
+}
+
+func (c *Connector) sampled() bool {
+	return c.sampleRate > 0 && rand.Float64() < c.sampleRate
+}
+
+// compareRead reads the keys from the shadow backend and compares the row to
+// the one the primary returned. The read outlives the call to Read, so it
+// doesn't use the caller's context.
+func (c *Connector) compareRead(ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string, primary map[string]dosa.FieldValue) {
+	shadow, err := c.shadow.Read(context.Background(), ei, keys, minimumFields)
+	if err != nil && !dosa.ErrorIsNotFound(err) {
+		c.Logger().Warnf("shadow read of %s failed: %v", ei.Def.Name, err)
+		return
+	}
+	if err != nil {
+		shadow = nil
+	}
+	if !sameRow(primary, shadow) {
+		c.Logger().Warnf("shadow read of %s differs from the primary: primary %v, shadow %v",
+			ei.Def.Name, c.Redact(ei, primary), c.Redact(ei, shadow))
+	}
+	if c.reporter != nil {
+		c.reporter(primary, shadow)
+	}
+}
+
+// copyRow returns a shallow copy of the row, nil for nil
+func copyRow(row map[string]dosa.FieldValue) map[string]dosa.FieldValue {
+	if row == nil {
+		return nil
+	}
+	copied := make(map[string]dosa.FieldValue, len(row))
+	for name, value := range row {
+		copied[name] = value
+	}
+	return copied
+}
+
+// sameRow returns true if both rows have the same columns with the same
+// values; both are nil when the row wasn't found
+func sameRow(a, b map[string]dosa.FieldValue) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		other, ok := b[name]
+		if !ok || !dosa.SameFieldValue(value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// mirror repeats a write that succeeded on the primary backend on the shadow
+// backend, and logs its failure
+func (c *Connector) mirror(op string, ei *dosa.EntityInfo, err error, write func() error) {
+	if err != nil {
+		return
+	}
+	if err := write(); err != nil {
+		c.Logger().Warnf("shadow %s of %s failed: %v", op, ei.Def.Name, err)
+	}
+}
+
+// mirrorMulti is mirror for the writes of several rows, which also logs the
+// failures of the rows
+func (c *Connector) mirrorMulti(op string, ei *dosa.EntityInfo, err error, write func() ([]error, error)) {
+	c.mirror(op, ei, err, func() error {
+		results, err := write()
+		if err != nil {
+			return err
+		}
+		for _, err := range results {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateIfNotExists creates the row on both backends
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	err := c.Next.CreateIfNotExists(ctx, ei, values)
+	c.mirror("CreateIfNotExists", ei, err, func() error {
+		return c.shadow.CreateIfNotExists(ctx, ei, values)
+	})
+	return err
+}
+
+// Upsert writes the row to both backends
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	err := c.Next.Upsert(ctx, ei, values)
+	c.mirror("Upsert", ei, err, func() error {
+		return c.shadow.Upsert(ctx, ei, values)
+	})
+	return err
+}
+
+// CompareAndSwap swaps the row on the primary backend, and on the shadow
+// backend when it succeeded; the conditions are checked on both
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	err := c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+	c.mirror("CompareAndSwap", ei, err, func() error {
+		return c.shadow.CompareAndSwap(ctx, ei, conditions, newValues)
+	})
+	return err
+}
+
+// UpsertWithConditions writes the row to the primary backend, and to the
+// shadow backend when it succeeded; the conditions are checked on both
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	err := c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+	c.mirror("UpsertWithConditions", ei, err, func() error {
+		return c.shadow.UpsertWithConditions(ctx, ei, values, conditions)
+	})
+	return err
+}
+
+// UpsertAndRead writes the row to both backends, and returns the row read
+// from the primary
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	result, err := c.Next.UpsertAndRead(ctx, ei, values)
+	c.mirror("Upsert", ei, err, func() error {
+		return c.shadow.Upsert(ctx, ei, values)
+	})
+	return result, err
+}
+
+// Replace replaces the row on both backends
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	err := c.Next.Replace(ctx, ei, values)
+	c.mirror("Replace", ei, err, func() error {
+		return c.shadow.Replace(ctx, ei, values)
+	})
+	return err
+}
+
+// AtomicAdd adds the delta on both backends, and returns the primary's total
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	total, err := c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+	c.mirror("AtomicAdd", ei, err, func() error {
+		_, err := c.shadow.AtomicAdd(ctx, ei, keys, column, delta)
+		return err
+	})
+	return total, err
+}
+
+// MultiUpsert writes the rows to both backends, and returns the primary's
+// results
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
+	results, err := c.Next.MultiUpsert(ctx, ei, values)
+	c.mirrorMulti("MultiUpsert", ei, err, func() ([]error, error) {
+		return c.shadow.MultiUpsert(ctx, ei, values)
+	})
+	return results, err
+}
+
+// Remove removes the row from both backends
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	err := c.Next.Remove(ctx, ei, values)
+	c.mirror("Remove", ei, err, func() error {
+		return c.shadow.Remove(ctx, ei, values)
+	})
+	return err
+}
+
+// RemoveRange removes the rows from both backends
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	err := c.Next.RemoveRange(ctx, ei, columnConditions)
+	c.mirror("RemoveRange", ei, err, func() error {
+		return c.shadow.RemoveRange(ctx, ei, columnConditions)
+	})
+	return err
+}
+
+// DeletePartition removes the partition from both backends
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	err := c.Next.DeletePartition(ctx, ei, pk)
+	c.mirror("DeletePartition", ei, err, func() error {
+		return c.shadow.DeletePartition(ctx, ei, pk)
+	})
+	return err
+}
+
+// MultiRemove removes the rows from both backends, and returns the primary's
+// results
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	results, err := c.Next.MultiRemove(ctx, ei, multiValues)
+	c.mirrorMulti("MultiRemove", ei, err, func() ([]error, error) {
+		return c.shadow.MultiRemove(ctx, ei, multiValues)
+	})
+	return results, err
+}
+
+// CopyTable copies the table on both backends; the shadow copies the rows it
+// was sent
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	err := c.Next.CopyTable(ctx, src, dst)
+	c.mirror("CopyTable", dst, err, func() error {
+		return c.shadow.CopyTable(ctx, src, dst)
+	})
+	return err
+}
+
+// Shutdown waits for the shadow reads still running, then shuts down both
+// backends and returns the first error
+func (c *Connector) Shutdown() error {
+	c.pending.Wait()
+	err := c.Next.Shutdown()
+	if shadowErr := c.shadow.Shutdown(); err == nil {
+		err = shadowErr
+	}
+	return err
+}
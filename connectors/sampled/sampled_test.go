@@ -0,0 +1,222 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sampled_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+	"github.com/uber-go/dosa/connectors/devnull"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/sampled"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{Scope: "testScope", NamePrefix: "testPrefix", EntityName: "users"},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+var key = map[string]dosa.FieldValue{"id": "1"}
+
+type warnLogger struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+func (l *warnLogger) Debugf(string, ...interface{}) {}
+func (l *warnLogger) Infof(string, ...interface{})  {}
+func (l *warnLogger) Errorf(string, ...interface{}) {}
+
+func (l *warnLogger) Warnf(format string, args ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+// report is what a Reporter was given
+type report struct {
+	primary, shadow map[string]dosa.FieldValue
+}
+
+func TestNewConnector(t *testing.T) {
+	_, err := sampled.NewConnector(memory.NewConnector(), memory.NewConnector(), 1.5, nil)
+	assert.EqualError(t, err, "sample rate must be between 0 and 1, got 1.5")
+	_, err = sampled.NewConnector(memory.NewConnector(), memory.NewConnector(), -0.1, nil)
+	assert.Error(t, err)
+}
+
+func TestSampled_Writes(t *testing.T) {
+	primary, shadow := memory.NewConnector(), memory.NewConnector()
+	c, err := sampled.NewConnector(primary, shadow, 0, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "one"}))
+	assert.NoError(t, c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{"id": "2", "name": "two"}))
+	results, err := c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{{"id": "3", "name": "three"}})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0])
+	assert.NoError(t, c.Remove(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}))
+	for _, backend := range []dosa.Connector{primary, shadow} {
+		values, err := backend.Read(ctx, testEi, key, dosa.All())
+		assert.NoError(t, err)
+		assert.Equal(t, "one", values["name"])
+		_, err = backend.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "2"}, dosa.All())
+		assert.True(t, dosa.ErrorIsNotFound(err))
+		_, err = backend.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "3"}, dosa.All())
+		assert.NoError(t, err)
+	}
+
+	// writes that failed on the primary don't reach the shadow
+	err = c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "uno"})
+	assert.True(t, dosa.ErrorIsAlreadyExists(err))
+	values, err := shadow.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "one", values["name"])
+}
+
+func TestSampled_CopyTable(t *testing.T) {
+	primary, shadow := memory.NewConnector(), memory.NewConnector()
+	c, err := sampled.NewConnector(primary, shadow, 0, nil)
+	assert.NoError(t, err)
+
+	copyEi := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	copyEi.Def.Name = "users_copy"
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "one"}))
+	assert.NoError(t, c.CopyTable(ctx, testEi, copyEi))
+	for _, backend := range []dosa.Connector{primary, shadow} {
+		values, err := backend.Read(ctx, copyEi, key, dosa.All())
+		assert.NoError(t, err)
+		assert.Equal(t, "one", values["name"])
+	}
+}
+
+// failingConnector fails every Upsert and Read
+type failingConnector struct {
+	base.Connector
+}
+
+func (*failingConnector) Upsert(context.Context, *dosa.EntityInfo, map[string]dosa.FieldValue) error {
+	return errors.New("shadow is down")
+}
+
+func (*failingConnector) Read(context.Context, *dosa.EntityInfo, map[string]dosa.FieldValue, []string) (map[string]dosa.FieldValue, error) {
+	return nil, errors.New("shadow is down")
+}
+
+func TestSampled_ShadowFailures(t *testing.T) {
+	logger := &warnLogger{}
+	c, err := sampled.NewConnector(memory.NewConnector(), &failingConnector{Connector: base.Connector{Next: memory.NewConnector()}}, 1, nil, base.WithLogger(logger))
+	assert.NoError(t, err)
+
+	// the failures of the shadow are logged, not returned
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "one"}))
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.NoError(t, c.Shutdown())
+	assert.Equal(t, []string{
+		"shadow Upsert of users failed: shadow is down",
+		"shadow read of users failed: shadow is down",
+	}, logger.warnings)
+}
+
+func TestSampled_Read(t *testing.T) {
+	primary, shadow := memory.NewConnector(), memory.NewConnector()
+	logger := &warnLogger{}
+	reports := make(chan report, 10)
+	c, err := sampled.NewConnector(primary, shadow, 1, func(primary, shadow map[string]dosa.FieldValue) {
+		reports <- report{primary: primary, shadow: shadow}
+	}, base.WithLogger(logger))
+	assert.NoError(t, err)
+
+	// the same row on both backends
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "one"}))
+	values, err := c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	r := <-reports
+	assert.Equal(t, values, r.primary)
+	assert.Equal(t, r.primary, r.shadow)
+
+	// the caller can change the row it was returned
+	values, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	values["name"] = "changed"
+	r = <-reports
+	assert.Equal(t, "one", r.primary["name"])
+	assert.Equal(t, r.primary, r.shadow)
+
+	// the row differs on the shadow
+	assert.NoError(t, shadow.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "uno"}))
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	r = <-reports
+	assert.Equal(t, "one", r.primary["name"])
+	assert.Equal(t, "uno", r.shadow["name"])
+
+	// the row is missing on the shadow
+	assert.NoError(t, shadow.Remove(ctx, testEi, key))
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	r = <-reports
+	assert.NotNil(t, r.primary)
+	assert.Nil(t, r.shadow)
+
+	// the row is missing on both
+	assert.NoError(t, primary.Remove(ctx, testEi, key))
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+	r = <-reports
+	assert.Nil(t, r.primary)
+	assert.Nil(t, r.shadow)
+
+	assert.NoError(t, c.Shutdown())
+	assert.Len(t, logger.warnings, 2)
+	for _, warning := range logger.warnings {
+		assert.Contains(t, warning, "shadow read of users differs from the primary")
+	}
+}
+
+func TestSampled_NotSampled(t *testing.T) {
+	reported := false
+	c, err := sampled.NewConnector(memory.NewConnector(), devnull.NewConnector(), 0, func(_, _ map[string]dosa.FieldValue) {
+		reported = true
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1", "name": "one"}))
+	_, err = c.Read(ctx, testEi, key, dosa.All())
+	assert.NoError(t, err)
+	assert.NoError(t, c.Shutdown())
+	assert.False(t, reported)
+}
@@ -0,0 +1,823 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sharding spreads the rows of every entity over several connectors,
+// choosing the shard of a row from a hash of its partition key.
+package sharding
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// reshardPageSize is the number of rows Reshard scans at a time
+const reshardPageSize = 100
+
+// HashFunc returns the hash of the partition key columns of a row. Rows with
+// the same partition key must have the same hash, in every process.
+type HashFunc func(pk map[string]dosa.FieldValue) uint64
+
+// ErrRebalanceRequired is returned by the data operations on an entity while
+// its rows may be on the wrong shards, from the time the shards are changed
+// with SetShards until the entity is moved with Reshard
+type ErrRebalanceRequired struct {
+	Entity string
+}
+
+// Error satisfies the error interface
+func (e *ErrRebalanceRequired) Error() string {
+	return fmt.Sprintf("rebalance required: the shards changed and %q has not been resharded", e.Entity)
+}
+
+// ErrorIsRebalanceRequired checks if the error is caused by "ErrRebalanceRequired"
+func ErrorIsRebalanceRequired(err error) bool {
+	_, ok := errors.Cause(err).(*ErrRebalanceRequired)
+	return ok
+}
+
+// Connector routes each operation on a single partition to one of its
+// shards, picked with a jump consistent hash of the HashFunc of the partition
+// key, so that changing the number of shards only moves the rows it has to.
+// Operations that span partitions fan out to all the shards: RemoveRange and
+// aggregates are run on every shard, while Scan, and Range without an Eq
+// condition on every partition key (e.g. on an index), read the shards one
+// after the other, so rows are only ordered within a shard. Schema and scope
+// operations are applied to every shard.
+//
+// Changing the shards with SetShards puts every entity in a rebalancing
+// state, where its data operations fail with ErrRebalanceRequired until
+// Reshard has moved its rows. Shards are compared with ==, so they must be
+// comparable, like the pointers to the connectors in this repository.
+type Connector struct {
+	hash HashFunc
+
+	lock       sync.RWMutex
+	shards     []dosa.Connector
+	retired    []dosa.Connector // shards removed by SetShards, which may still have rows
+	generation int              // the number of times SetShards was called
+	resharded  map[string]bool  // the entities resharded since the shards last changed
+}
+
+// NewConnector creates a connector that shards the data over the given
+// connectors; a nil hashFn is replaced with DefaultHash
+func NewConnector(shards []dosa.Connector, hashFn HashFunc) (*Connector, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("at least one shard is required")
+	}
+	if hashFn == nil {
+		hashFn = DefaultHash
+	}
+	return &Connector{
+		hash:      hashFn,
+		shards:    append([]dosa.Connector(nil), shards...),
+		resharded: make(map[string]bool),
+	}, nil
+}
+
+// DefaultHash is an FNV-1a hash of the names and values of the partition key
+// columns. Timestamps are hashed by their instant, so the location and
+// monotonic clock reading of a time.Time don't matter.
+func DefaultHash(pk map[string]dosa.FieldValue) uint64 {
+	names := make([]string, 0, len(pk))
+	for name := range pk {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := fnv.New64a()
+	for _, name := range names {
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte{0})
+		switch v := pk[name].(type) {
+		case []byte:
+			_, _ = h.Write(v)
+		case time.Time:
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], uint64(v.UnixNano()))
+			_, _ = h.Write(b[:])
+		default:
+			_, _ = fmt.Fprintf(h, "%v", v)
+		}
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach, it maps key to
+// one of n buckets
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// SetShards replaces the shards. Shards that are left out are kept as
+// retired shards, so that Reshard can move their rows, and are shut down by
+// Shutdown. Every entity then needs to be resharded before it can be used.
+func (c *Connector) SetShards(shards []dosa.Connector) error {
+	if len(shards) == 0 {
+		return errors.New("at least one shard is required")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	retired := c.retired[:0:0]
+	for _, old := range append(c.retired, c.shards...) {
+		if !contains(shards, old) && !contains(retired, old) {
+			retired = append(retired, old)
+		}
+	}
+	c.shards = append([]dosa.Connector(nil), shards...)
+	c.retired = retired
+	c.generation++
+	c.resharded = make(map[string]bool)
+	return nil
+}
+
+// Reshard moves the rows of the entity that are not on the shard the
+// current shards assign them to, including all the rows of retired shards,
+// and then lets the data operations on the entity through again. Each row is
+// upserted into its new shard before it's removed from the old one, so a
+// Reshard that failed part way can be run again.
+func (c *Connector) Reshard(ctx context.Context, ei *dosa.EntityInfo) error {
+	c.lock.RLock()
+	shards := c.shards
+	sources := append(append([]dosa.Connector(nil), c.shards...), c.retired...)
+	generation := c.generation
+	c.lock.RUnlock()
+
+	for i, source := range sources {
+		if err := c.reshardFrom(ctx, ei, source, shards); err != nil {
+			return errors.Wrapf(err, "could not reshard %q from shard %d", ei.Def.Name, i)
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.generation != generation {
+		return errors.Errorf("the shards changed while %q was resharded", ei.Def.Name)
+	}
+	c.resharded[ei.Def.Name] = true
+	return nil
+}
+
+// reshardFrom moves the rows of source that belong on other shards
+func (c *Connector) reshardFrom(ctx context.Context, ei *dosa.EntityInfo, source dosa.Connector, shards []dosa.Connector) error {
+	keys := ei.Def.KeySet()
+	token := ""
+	for {
+		rows, next, err := source.Scan(ctx, ei, nil, token, reshardPageSize)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			pk, err := partitionKey(ei, row)
+			if err != nil {
+				return err
+			}
+			target := shards[jumpHash(c.hash(pk), len(shards))]
+			if target == source {
+				continue
+			}
+			if err := target.Upsert(ctx, ei, row); err != nil {
+				return err
+			}
+			key := make(map[string]dosa.FieldValue, len(keys))
+			for name := range keys {
+				key[name] = row[name]
+			}
+			if err := source.Remove(ctx, ei, key); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
+
+// topology returns the shards for a data operation on the entity
+func (c *Connector) topology(ei *dosa.EntityInfo) ([]dosa.Connector, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.generation > 0 && !c.resharded[ei.Def.Name] {
+		return nil, &ErrRebalanceRequired{Entity: ei.Def.Name}
+	}
+	return c.shards, nil
+}
+
+// allShards returns the shards and the retired shards
+func (c *Connector) allShards() []dosa.Connector {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append(append([]dosa.Connector(nil), c.shards...), c.retired...)
+}
+
+// currentShards returns the shards, for the schema and scope operations
+func (c *Connector) currentShards() []dosa.Connector {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.shards
+}
+
+// shardIndex returns the index of the shard of a partition key
+func (c *Connector) shardIndex(pk map[string]dosa.FieldValue, shards []dosa.Connector) int {
+	return jumpHash(c.hash(pk), len(shards))
+}
+
+// shard returns the shard of the row with the given key or values
+func (c *Connector) shard(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (dosa.Connector, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := partitionKey(ei, values)
+	if err != nil {
+		return nil, err
+	}
+	return shards[c.shardIndex(pk, shards)], nil
+}
+
+// partitionKey returns the partition key columns of values
+func partitionKey(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	pk := make(map[string]dosa.FieldValue, len(ei.Def.Key.PartitionKeys))
+	for _, name := range ei.Def.Key.PartitionKeys {
+		v, ok := values[name]
+		if !ok {
+			return nil, errors.Errorf("partition key %q of %q is missing", name, ei.Def.Name)
+		}
+		pk[name] = v
+	}
+	return pk, nil
+}
+
+// conditionsPartition returns the partition key selected by Eq conditions on
+// every partition key, or false if the conditions may span partitions
+func conditionsPartition(ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (map[string]dosa.FieldValue, bool) {
+	pk := make(map[string]dosa.FieldValue, len(ei.Def.Key.PartitionKeys))
+	for _, name := range ei.Def.Key.PartitionKeys {
+		conds := columnConditions[name]
+		if len(conds) != 1 || conds[0].Op != dosa.Eq {
+			return nil, false
+		}
+		pk[name] = conds[0].Value
+	}
+	return pk, true
+}
+
+// rangeShard returns the only shard with rows matching the conditions, or nil
+// if they may be on any of the shards
+func (c *Connector) rangeShard(ei *dosa.EntityInfo, shards []dosa.Connector, columnConditions map[string][]*dosa.Condition) dosa.Connector {
+	pk, ok := conditionsPartition(ei, columnConditions)
+	if !ok {
+		return nil
+	}
+	return shards[c.shardIndex(pk, shards)]
+}
+
+// fanOut calls f for every shard in parallel and returns the first error of
+// the lowest numbered shard
+func fanOut(shards []dosa.Connector, f func(i int, shard dosa.Connector) error) error {
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard dosa.Connector) {
+			defer wg.Done()
+			errs[i] = f(i, shard)
+		}(i, shard)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return errors.Wrapf(err, "shard %d", i)
+		}
+	}
+	return nil
+}
+
+// groupByShard returns, for every shard, the indexes of the rows on it
+func (c *Connector) groupByShard(ei *dosa.EntityInfo, shards []dosa.Connector, rows []map[string]dosa.FieldValue) ([][]int, error) {
+	groups := make([][]int, len(shards))
+	for i, row := range rows {
+		pk, err := partitionKey(ei, row)
+		if err != nil {
+			return nil, err
+		}
+		s := c.shardIndex(pk, shards)
+		groups[s] = append(groups[s], i)
+	}
+	return groups, nil
+}
+
+// pageShards reads the shards one after the other with page. The token is
+// the index of the shard being read and the token of that shard.
+func pageShards(shards []dosa.Connector, token string, page func(shard dosa.Connector, token string) ([]map[string]dosa.FieldValue, string, error)) ([]map[string]dosa.FieldValue, string, error) {
+	i, shardToken, err := decodeToken(token, len(shards))
+	if err != nil {
+		return nil, "", err
+	}
+	for ; i < len(shards); i++ {
+		rows, next, err := page(shards[i], shardToken)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "shard %d", i)
+		}
+		if next != "" {
+			return rows, encodeToken(i, next), nil
+		}
+		shardToken = ""
+		if len(rows) > 0 {
+			if i+1 < len(shards) {
+				return rows, encodeToken(i+1, ""), nil
+			}
+			return rows, "", nil
+		}
+	}
+	return []map[string]dosa.FieldValue{}, "", nil
+}
+
+func encodeToken(shard int, token string) string {
+	return strconv.Itoa(shard) + ":" + token
+}
+
+func decodeToken(token string, shards int) (int, string, error) {
+	if token == "" {
+		return 0, "", nil
+	}
+	parts := strings.SplitN(token, ":", 2)
+	i, err := strconv.Atoi(parts[0])
+	if len(parts) != 2 || err != nil || i < 0 || i >= shards {
+		return 0, "", errors.Errorf("invalid token %q", token)
+	}
+	return i, parts[1], nil
+}
+
+func contains(shards []dosa.Connector, shard dosa.Connector) bool {
+	for _, s := range shards {
+		if s == shard {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateIfNotExists calls the shard of the row
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, values)
+	if err != nil {
+		return err
+	}
+	return shard.CreateIfNotExists(ctx, ei, values)
+}
+
+// Read calls the shard of the row
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	shard, err := c.shard(ei, keys)
+	if err != nil {
+		return nil, err
+	}
+	return shard.Read(ctx, ei, keys, minimumFields)
+}
+
+// MultiRead calls every shard with the keys of its rows
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, keys []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := c.groupByShard(ei, shards, keys)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*dosa.FieldValuesOrError, len(keys))
+	err = fanOut(shards, func(i int, shard dosa.Connector) error {
+		if len(groups[i]) == 0 {
+			return nil
+		}
+		shardKeys := make([]map[string]dosa.FieldValue, len(groups[i]))
+		for j, idx := range groups[i] {
+			shardKeys[j] = keys[idx]
+		}
+		shardResults, err := shard.MultiRead(ctx, ei, shardKeys, minimumFields)
+		if err != nil {
+			return err
+		}
+		for j, idx := range groups[i] {
+			if j < len(shardResults) {
+				results[idx] = shardResults[j]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Upsert calls the shard of the row
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, values)
+	if err != nil {
+		return err
+	}
+	return shard.Upsert(ctx, ei, values)
+}
+
+// CompareAndSwap calls the shard of the row
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, conditions)
+	if err != nil {
+		return err
+	}
+	return shard.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions calls the shard of the row
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	shard, err := c.shard(ei, values)
+	if err != nil {
+		return err
+	}
+	return shard.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// UpsertAndRead calls the shard of the row
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	shard, err := c.shard(ei, values)
+	if err != nil {
+		return nil, err
+	}
+	return shard.UpsertAndRead(ctx, ei, values)
+}
+
+// Replace calls the shard of the row
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, values)
+	if err != nil {
+		return err
+	}
+	return shard.Replace(ctx, ei, values)
+}
+
+// AtomicAdd calls the shard of the row
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	shard, err := c.shard(ei, keys)
+	if err != nil {
+		return 0, err
+	}
+	return shard.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+// Aggregate calls the shard of the partition when the conditions select a
+// single one; otherwise the rows are read from every shard and aggregated here
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, err
+	}
+	if shard := c.rangeShard(ei, shards, columnConditions); shard != nil {
+		return shard.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+	}
+	return dosa.AggregateByScanning(ctx, c, ei, aggFunc, column, columnConditions)
+}
+
+// CountRange asks the shard of the partition when the conditions select a
+// single one; otherwise every shard counts its rows and the counts are added up
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return 0, err
+	}
+	if shard := c.rangeShard(ei, shards, columnConditions); shard != nil {
+		return shard.CountRange(ctx, ei, columnConditions)
+	}
+	counts := make([]int64, len(shards))
+	err = fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		counts[i], err = shard.CountRange(ctx, ei, columnConditions)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, n := range counts {
+		count += n
+	}
+	return count, nil
+}
+
+// ExplainQuery asks the shard of the partition when the conditions select a
+// single one; otherwise every shard is asked and the plans are combined
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, err
+	}
+	if shard := c.rangeShard(ei, shards, columnConditions); shard != nil {
+		return shard.ExplainQuery(ctx, ei, columnConditions)
+	}
+	plans := make([]*dosa.QueryPlan, len(shards))
+	err = fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		plans[i], err = shard.ExplainQuery(ctx, ei, columnConditions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan := &dosa.QueryPlan{Index: plans[0].Index, Details: fmt.Sprintf("read from all %d shards", len(shards))}
+	for _, p := range plans {
+		if p.EstimatedRows == dosa.UnknownRows || plan.EstimatedRows == dosa.UnknownRows {
+			plan.EstimatedRows = dosa.UnknownRows
+		} else {
+			plan.EstimatedRows += p.EstimatedRows
+		}
+		plan.FullScan = plan.FullScan || p.FullScan
+		plan.ServerSideFiltering = plan.ServerSideFiltering || p.ServerSideFiltering
+	}
+	return plan, nil
+}
+
+// MultiUpsert calls every shard with its rows
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	return c.multiWrite(ei, multiValues, func(shard dosa.Connector, rows []map[string]dosa.FieldValue) ([]error, error) {
+		return shard.MultiUpsert(ctx, ei, rows)
+	})
+}
+
+// Remove calls the shard of the row
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, keys)
+	if err != nil {
+		return err
+	}
+	return shard.Remove(ctx, ei, keys)
+}
+
+// RemoveRange calls the shard of the partition when the conditions select a
+// single one, and every shard otherwise
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return err
+	}
+	if shard := c.rangeShard(ei, shards, columnConditions); shard != nil {
+		return shard.RemoveRange(ctx, ei, columnConditions)
+	}
+	return fanOut(shards, func(_ int, shard dosa.Connector) error {
+		return shard.RemoveRange(ctx, ei, columnConditions)
+	})
+}
+
+// DeletePartition calls the shard of the partition
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	shard, err := c.shard(ei, pk)
+	if err != nil {
+		return err
+	}
+	return shard.DeletePartition(ctx, ei, pk)
+}
+
+// MultiRemove calls every shard with the keys of its rows
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiKeys []map[string]dosa.FieldValue) ([]error, error) {
+	return c.multiWrite(ei, multiKeys, func(shard dosa.Connector, rows []map[string]dosa.FieldValue) ([]error, error) {
+		return shard.MultiRemove(ctx, ei, rows)
+	})
+}
+
+// multiWrite calls write for every shard with its rows, and puts the results
+// back in the order of rows
+func (c *Connector) multiWrite(ei *dosa.EntityInfo, rows []map[string]dosa.FieldValue, write func(dosa.Connector, []map[string]dosa.FieldValue) ([]error, error)) ([]error, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := c.groupByShard(ei, shards, rows)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]error, len(rows))
+	err = fanOut(shards, func(i int, shard dosa.Connector) error {
+		if len(groups[i]) == 0 {
+			return nil
+		}
+		shardRows := make([]map[string]dosa.FieldValue, len(groups[i]))
+		for j, idx := range groups[i] {
+			shardRows[j] = rows[idx]
+		}
+		shardResult, err := write(shard, shardRows)
+		if err != nil {
+			return err
+		}
+		for j, idx := range groups[i] {
+			if j < len(shardResult) {
+				result[idx] = shardResult[j]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Range calls the shard of the partition when the conditions select a single
+// one; otherwise the shards are read one after the other
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, "", err
+	}
+	if shard := c.rangeShard(ei, shards, columnConditions); shard != nil {
+		return shard.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+	}
+	return pageShards(shards, token, func(shard dosa.Connector, token string) ([]map[string]dosa.FieldValue, string, error) {
+		return shard.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+	})
+}
+
+// Scan reads the shards one after the other
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	shards, err := c.topology(ei)
+	if err != nil {
+		return nil, "", err
+	}
+	return pageShards(shards, token, func(shard dosa.Connector, token string) ([]map[string]dosa.FieldValue, string, error) {
+		return shard.Scan(ctx, ei, minimumFields, token, limit)
+	})
+}
+
+// CopyTable scans src and writes the rows to dst, since they are spread over
+// the shards
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return dosa.CopyTableByScanning(ctx, c, src, dst)
+}
+
+// sameVersion returns the version of the first shard, or an error if the
+// shards have different versions
+func sameVersion(versions []int32) (int32, error) {
+	for i, v := range versions {
+		if v != versions[0] {
+			return dosa.InvalidVersion, errors.Errorf("shard %d has schema version %d, shard 0 has %d", i, v, versions[0])
+		}
+	}
+	return versions[0], nil
+}
+
+// CheckSchema calls every shard; they must have the same schema version
+func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	shards := c.currentShards()
+	versions := make([]int32, len(shards))
+	err := fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		versions[i], err = shard.CheckSchema(ctx, scope, namePrefix, eds)
+		return err
+	})
+	if err != nil {
+		return dosa.InvalidVersion, err
+	}
+	return sameVersion(versions)
+}
+
+// CanUpsertSchema calls every shard; they must have the same schema version
+func (c *Connector) CanUpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	shards := c.currentShards()
+	versions := make([]int32, len(shards))
+	err := fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		versions[i], err = shard.CanUpsertSchema(ctx, scope, namePrefix, eds)
+		return err
+	})
+	if err != nil {
+		return dosa.InvalidVersion, err
+	}
+	return sameVersion(versions)
+}
+
+// UpsertSchema calls every shard and returns the status of the first one
+func (c *Connector) UpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (*dosa.SchemaStatus, error) {
+	shards := c.currentShards()
+	statuses := make([]*dosa.SchemaStatus, len(shards))
+	err := fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		statuses[i], err = shard.UpsertSchema(ctx, scope, namePrefix, eds)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+// CheckSchemaStatus calls every shard and returns the status of the first one
+func (c *Connector) CheckSchemaStatus(ctx context.Context, scope, namePrefix string, version int32) (*dosa.SchemaStatus, error) {
+	shards := c.currentShards()
+	statuses := make([]*dosa.SchemaStatus, len(shards))
+	err := fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		statuses[i], err = shard.CheckSchemaStatus(ctx, scope, namePrefix, version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+// GetEntitySchema calls the first shard
+func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, entityName string, version int32) (*dosa.EntityDefinition, error) {
+	return c.currentShards()[0].GetEntitySchema(ctx, scope, namePrefix, entityName, version)
+}
+
+// DescribeTable calls the first shard
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	return c.currentShards()[0].DescribeTable(ctx, ei)
+}
+
+// DropTable calls every shard, including the retired ones
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return fanOut(c.allShards(), func(_ int, shard dosa.Connector) error {
+		return shard.DropTable(ctx, ei)
+	})
+}
+
+// CreateScope calls every shard
+func (c *Connector) CreateScope(ctx context.Context, md *dosa.ScopeMetadata) error {
+	return fanOut(c.currentShards(), func(_ int, shard dosa.Connector) error {
+		return shard.CreateScope(ctx, md)
+	})
+}
+
+// TruncateScope calls every shard, including the retired ones
+func (c *Connector) TruncateScope(ctx context.Context, scope string) error {
+	return fanOut(c.allShards(), func(_ int, shard dosa.Connector) error {
+		return shard.TruncateScope(ctx, scope)
+	})
+}
+
+// DropScope calls every shard, including the retired ones
+func (c *Connector) DropScope(ctx context.Context, scope string) error {
+	return fanOut(c.allShards(), func(_ int, shard dosa.Connector) error {
+		return shard.DropScope(ctx, scope)
+	})
+}
+
+// ScopeExists returns true if the scope exists on every shard
+func (c *Connector) ScopeExists(ctx context.Context, scope string) (bool, error) {
+	shards := c.currentShards()
+	exists := make([]bool, len(shards))
+	err := fanOut(shards, func(i int, shard dosa.Connector) error {
+		var err error
+		exists[i], err = shard.ScopeExists(ctx, scope)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, e := range exists {
+		if !e {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Shutdown shuts down every shard, including the retired ones
+func (c *Connector) Shutdown() error {
+	return fanOut(c.allShards(), func(_ int, shard dosa.Connector) error {
+		return shard.Shutdown()
+	})
+}
@@ -0,0 +1,373 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/sharding"
+	"github.com/uber-go/dosa/mocks"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "events",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "events",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String},
+			{Name: "seq", Type: dosa.Int64},
+			{Name: "value", Type: dosa.Int64},
+		},
+		Key: &dosa.PrimaryKey{
+			PartitionKeys:  []string{"id"},
+			ClusteringKeys: []*dosa.ClusteringKey{{Name: "seq"}},
+		},
+	},
+}
+
+var ctx = context.Background()
+
+func newShards(n int) []dosa.Connector {
+	shards := make([]dosa.Connector, n)
+	for i := range shards {
+		shards[i] = memory.NewConnector()
+	}
+	return shards
+}
+
+func row(id string, seq int64) map[string]dosa.FieldValue {
+	return map[string]dosa.FieldValue{"id": id, "seq": seq, "value": seq * 10}
+}
+
+// count returns the number of rows of testEi on the connector
+func count(t *testing.T, c dosa.Connector) int {
+	rows, _, err := c.Scan(ctx, testEi, dosa.All(), "", 1000)
+	assert.NoError(t, err)
+	return len(rows)
+}
+
+// scanAll reads every row of testEi with pages of the given size
+func scanAll(t *testing.T, c dosa.Connector, limit int) []map[string]dosa.FieldValue {
+	var all []map[string]dosa.FieldValue
+	token := ""
+	for {
+		rows, next, err := c.Scan(ctx, testEi, dosa.All(), token, limit)
+		if !assert.NoError(t, err) {
+			return all
+		}
+		all = append(all, rows...)
+		if next == "" {
+			return all
+		}
+		token = next
+	}
+}
+
+func TestNewConnector(t *testing.T) {
+	_, err := sharding.NewConnector(nil, nil)
+	assert.Error(t, err)
+
+	c, err := sharding.NewConnector(newShards(1), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Upsert(ctx, testEi, row("a", 1)))
+}
+
+func TestDefaultHash(t *testing.T) {
+	pk := map[string]dosa.FieldValue{"id": "a", "other": int64(1)}
+	assert.Equal(t, sharding.DefaultHash(pk), sharding.DefaultHash(map[string]dosa.FieldValue{"other": int64(1), "id": "a"}))
+	assert.NotEqual(t, sharding.DefaultHash(pk), sharding.DefaultHash(map[string]dosa.FieldValue{"id": "b", "other": int64(1)}))
+}
+
+func TestSharding_Routing(t *testing.T) {
+	shards := newShards(3)
+	c, err := sharding.NewConnector(shards, nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), 1)))
+	}
+	total := 0
+	for _, shard := range shards {
+		n := count(t, shard)
+		assert.True(t, n > 0, "every shard has some of the rows")
+		total += n
+	}
+	assert.Equal(t, 30, total)
+
+	// the rows of a partition are on the same shard
+	for i := int64(2); i <= 5; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row("id7", i)))
+	}
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "id7", "seq": int64(3)}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), values["value"])
+	rows, token, err := c.Range(ctx, testEi, map[string][]*dosa.Condition{
+		"id": {{Op: dosa.Eq, Value: "id7"}},
+	}, dosa.All(), "", 100)
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	assert.Len(t, rows, 5)
+
+	assert.NoError(t, c.Remove(ctx, testEi, map[string]dosa.FieldValue{"id": "id7", "seq": int64(3)}))
+	_, err = c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "id7", "seq": int64(3)}, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	err = c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"seq": int64(1)})
+	assert.Error(t, err)
+}
+
+func TestSharding_HashFunc(t *testing.T) {
+	shards := newShards(2)
+	// every row goes to the last shard
+	c, err := sharding.NewConnector(shards, func(map[string]dosa.FieldValue) uint64 { return 1 << 63 })
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), 1)))
+	}
+	assert.Equal(t, 0, count(t, shards[0]))
+	assert.Equal(t, 5, count(t, shards[1]))
+}
+
+func TestSharding_Multi(t *testing.T) {
+	c, err := sharding.NewConnector(newShards(3), nil)
+	assert.NoError(t, err)
+
+	var rows, keys []map[string]dosa.FieldValue
+	for i := 0; i < 10; i++ {
+		rows = append(rows, row(fmt.Sprintf("id%d", i), int64(i)))
+		keys = append(keys, map[string]dosa.FieldValue{"id": fmt.Sprintf("id%d", i), "seq": int64(i)})
+	}
+	errs, err := c.MultiUpsert(ctx, testEi, rows)
+	assert.NoError(t, err)
+	assert.Len(t, errs, 10)
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	results, err := c.MultiRead(ctx, testEi, keys, dosa.All())
+	assert.NoError(t, err)
+	assert.Len(t, results, 10)
+	for i, result := range results {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(i*10), result.Values["value"], "the results are in the order of the keys")
+	}
+
+	errs, err = c.MultiRemove(ctx, testEi, keys[:5])
+	assert.NoError(t, err)
+	assert.Len(t, errs, 5)
+	assert.Len(t, scanAll(t, c, 100), 5)
+}
+
+func TestSharding_Scan(t *testing.T) {
+	c, err := sharding.NewConnector(newShards(4), nil)
+	assert.NoError(t, err)
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), 1)))
+	}
+
+	for _, limit := range []int{1, 3, 100} {
+		seen := map[string]int{}
+		for _, r := range scanAll(t, c, limit) {
+			seen[r["id"].(string)]++
+		}
+		assert.Len(t, seen, 25)
+		for id, n := range seen {
+			assert.Equal(t, 1, n, id)
+		}
+	}
+
+	_, _, err = c.Scan(ctx, testEi, dosa.All(), "garbage", 10)
+	assert.Error(t, err)
+}
+
+func TestSharding_RemoveRangeAndAggregate(t *testing.T) {
+	c, err := sharding.NewConnector(newShards(3), nil)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		for seq := int64(1); seq <= 3; seq++ {
+			assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), seq)))
+		}
+	}
+
+	total, err := c.Aggregate(ctx, testEi, dosa.AggCount, "value", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 30, total)
+	sum, err := c.Aggregate(ctx, testEi, dosa.AggSum, "value", map[string][]*dosa.Condition{
+		"id": {{Op: dosa.Eq, Value: "id3"}},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 60, sum)
+
+	assert.NoError(t, c.RemoveRange(ctx, testEi, map[string][]*dosa.Condition{
+		"id": {{Op: dosa.Eq, Value: "id0"}},
+	}))
+	assert.Len(t, scanAll(t, c, 100), 27)
+	assert.NoError(t, c.DeletePartition(ctx, testEi, map[string]dosa.FieldValue{"id": "id1"}))
+	assert.Len(t, scanAll(t, c, 100), 24)
+
+	// without the partition key every shard is called
+	err = c.RemoveRange(ctx, testEi, map[string][]*dosa.Condition{
+		"seq": {{Op: dosa.Eq, Value: int64(3)}},
+	})
+	assert.Contains(t, err.Error(), "shard 0: missing Eq condition on partition keys")
+}
+
+func TestSharding_ExplainQuery(t *testing.T) {
+	c, err := sharding.NewConnector(newShards(3), nil)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		for seq := int64(1); seq <= 3; seq++ {
+			assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), seq)))
+		}
+	}
+
+	plan, err := c.ExplainQuery(ctx, testEi, map[string][]*dosa.Condition{
+		"id": {{Op: dosa.Eq, Value: "id3"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{EstimatedRows: 3}, plan)
+
+	plan, err = c.ExplainQuery(ctx, testEi, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.QueryPlan{EstimatedRows: 30, FullScan: true, Details: "read from all 3 shards"}, plan)
+}
+
+func TestSharding_CountRange(t *testing.T) {
+	c, err := sharding.NewConnector(newShards(3), nil)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		for seq := int64(1); seq <= 3; seq++ {
+			assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), seq)))
+		}
+	}
+
+	count, err := c.CountRange(ctx, testEi, map[string][]*dosa.Condition{
+		"id":  {{Op: dosa.Eq, Value: "id3"}},
+		"seq": {{Op: dosa.GtOrEq, Value: int64(2)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// without a partition every shard is asked, and fails like Range does
+	_, err = c.CountRange(ctx, testEi, map[string][]*dosa.Condition{
+		"seq": {{Op: dosa.Eq, Value: int64(1)}},
+	})
+	assert.Contains(t, err.Error(), "shard 0: ")
+}
+
+func TestSharding_Reshard(t *testing.T) {
+	shards := newShards(2)
+	c, err := sharding.NewConnector(shards, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 40; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), 1)))
+	}
+
+	// replace the second shard with two new ones
+	newer := append([]dosa.Connector{shards[0]}, newShards(2)...)
+	assert.NoError(t, c.SetShards(newer))
+
+	err = c.Upsert(ctx, testEi, row("id1", 2))
+	assert.True(t, sharding.ErrorIsRebalanceRequired(err))
+	assert.EqualError(t, err, `rebalance required: the shards changed and "events" has not been resharded`)
+	_, _, err = c.Scan(ctx, testEi, dosa.All(), "", 10)
+	assert.True(t, sharding.ErrorIsRebalanceRequired(err))
+
+	assert.NoError(t, c.Reshard(ctx, testEi))
+	assert.Equal(t, 0, count(t, shards[1]), "the retired shard was emptied")
+	total := 0
+	for _, shard := range newer {
+		total += count(t, shard)
+	}
+	assert.Equal(t, 40, total)
+
+	// every row is on the shard it's routed to
+	for i := 0; i < 40; i++ {
+		_, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": fmt.Sprintf("id%d", i), "seq": int64(1)}, dosa.All())
+		assert.NoError(t, err)
+	}
+	assert.Len(t, scanAll(t, c, 7), 40)
+
+	// resharding again moves nothing
+	assert.NoError(t, c.Reshard(ctx, testEi))
+	assert.Len(t, scanAll(t, c, 100), 40)
+
+	assert.Error(t, c.SetShards(nil))
+}
+
+func TestSharding_Schema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	first, second := mocks.NewMockConnector(ctrl), mocks.NewMockConnector(ctrl)
+	c, err := sharding.NewConnector([]dosa.Connector{first, second}, nil)
+	assert.NoError(t, err)
+	eds := []*dosa.EntityDefinition{testEi.Def}
+
+	first.EXPECT().UpsertSchema(ctx, "testScope", "testPrefix", eds).Return(&dosa.SchemaStatus{Version: 2}, nil)
+	second.EXPECT().UpsertSchema(ctx, "testScope", "testPrefix", eds).Return(&dosa.SchemaStatus{Version: 2}, nil)
+	status, err := c.UpsertSchema(ctx, "testScope", "testPrefix", eds)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), status.Version)
+
+	first.EXPECT().CheckSchema(ctx, "testScope", "testPrefix", eds).Return(int32(2), nil).Times(2)
+	second.EXPECT().CheckSchema(ctx, "testScope", "testPrefix", eds).Return(int32(2), nil)
+	version, err := c.CheckSchema(ctx, "testScope", "testPrefix", eds)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), version)
+
+	// the shards no longer agree on the version
+	second.EXPECT().CheckSchema(ctx, "testScope", "testPrefix", eds).Return(int32(3), nil)
+	_, err = c.CheckSchema(ctx, "testScope", "testPrefix", eds)
+	assert.EqualError(t, err, "shard 1 has schema version 3, shard 0 has 2")
+
+	first.EXPECT().ScopeExists(ctx, "testScope").Return(true, nil)
+	second.EXPECT().ScopeExists(ctx, "testScope").Return(false, nil)
+	exists, err := c.ScopeExists(ctx, "testScope")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	first.EXPECT().DropTable(ctx, testEi).Return(nil)
+	second.EXPECT().DropTable(ctx, testEi).Return(errors.New("unavailable"))
+	assert.EqualError(t, c.DropTable(ctx, testEi), "shard 1: unavailable")
+}
+
+func TestSharding_DropTable(t *testing.T) {
+	shards := newShards(2)
+	c, err := sharding.NewConnector(shards, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, c.Upsert(ctx, testEi, row(fmt.Sprintf("id%d", i), 1)))
+	}
+	assert.NoError(t, c.DropTable(ctx, testEi))
+	assert.Empty(t, scanAll(t, c, 100))
+	assert.NoError(t, c.Shutdown())
+}
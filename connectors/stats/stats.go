@@ -0,0 +1,270 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// defaultWindow is the number of recent latencies kept per operation
+const defaultWindow = 1024
+
+// Stats holds in-process counts and recent latencies of the operations of a
+// Connector. Operations are named after the Connector methods, e.g. "Read".
+// Counts include failed operations.
+type Stats struct {
+	sync.Mutex
+	window int
+	ops    map[string]*opStats
+}
+
+// opStats keeps the latencies of an operation in a ring buffer
+type opStats struct {
+	count     int64
+	latencies []time.Duration
+	next      int
+}
+
+func newStats(window int) *Stats {
+	return &Stats{window: window, ops: map[string]*opStats{}}
+}
+
+func (s *Stats) record(op string, d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	o, ok := s.ops[op]
+	if !ok {
+		o = &opStats{latencies: make([]time.Duration, 0, s.window)}
+		s.ops[op] = o
+	}
+	o.count++
+	if len(o.latencies) < s.window {
+		o.latencies = append(o.latencies, d)
+		return
+	}
+	o.latencies[o.next] = d
+	o.next = (o.next + 1) % s.window
+}
+
+// OperationCount returns the number of times the operation was called
+func (s *Stats) OperationCount(op string) int64 {
+	s.Lock()
+	defer s.Unlock()
+	if o, ok := s.ops[op]; ok {
+		return o.count
+	}
+	return 0
+}
+
+// LatencyP99 returns the 99th percentile of the recent latencies of the operation
+func (s *Stats) LatencyP99(op string) time.Duration {
+	return s.LatencyPercentile(op, 0.99)
+}
+
+// LatencyPercentile returns the p-th percentile (0 < p <= 1) of the recent latencies
+// of the operation, using the nearest-rank method. It is 0 if the operation wasn't called.
+func (s *Stats) LatencyPercentile(op string, p float64) time.Duration {
+	s.Lock()
+	o, ok := s.ops[op]
+	if !ok || len(o.latencies) == 0 {
+		s.Unlock()
+		return 0
+	}
+	latencies := make([]time.Duration, len(o.latencies))
+	copy(latencies, o.latencies)
+	s.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(latencies) {
+		rank = len(latencies) - 1
+	}
+	return latencies[rank]
+}
+
+// Reset forgets all counts and latencies
+func (s *Stats) Reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.ops = map[string]*opStats{}
+}
+
+// Connector records the count and latency of each data operation sent to Next,
+// without depending on a metrics library. Schema and scope operations are not recorded.
+type Connector struct {
+	base.Connector
+	stats *Stats
+}
+
+// NewConnector creates a new stats connector
+func NewConnector(next dosa.Connector) *Connector {
+	return &Connector{
+		Connector: base.Connector{Next: next},
+		stats:     newStats(defaultWindow),
+	}
+}
+
+// ConnectorStats returns the stats of a stats connector, or nil if c isn't one
+func ConnectorStats(c dosa.Connector) *Stats {
+	if sc, ok := c.(*Connector); ok {
+		return sc.stats
+	}
+	return nil
+}
+
+// Stats returns the stats recorded by the connector
+func (c *Connector) Stats() *Stats {
+	return c.stats
+}
+
+// ResetStats forgets all the recorded stats, e.g. between test assertions
+func (c *Connector) ResetStats() {
+	c.stats.Reset()
+}
+
+func (c *Connector) record(op string, start time.Time) {
+	c.stats.record(op, time.Since(start))
+}
+
+// CreateIfNotExists calls Next and records the operation
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	defer c.record("CreateIfNotExists", time.Now())
+	return c.Next.CreateIfNotExists(ctx, ei, values)
+}
+
+// Read calls Next and records the operation
+func (c *Connector) Read(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, minimumFields []string) (map[string]dosa.FieldValue, error) {
+	defer c.record("Read", time.Now())
+	return c.Next.Read(ctx, ei, values, minimumFields)
+}
+
+// MultiRead calls Next and records the operation
+func (c *Connector) MultiRead(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue, minimumFields []string) ([]*dosa.FieldValuesOrError, error) {
+	defer c.record("MultiRead", time.Now())
+	return c.Next.MultiRead(ctx, ei, values, minimumFields)
+}
+
+// Upsert calls Next and records the operation
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	defer c.record("Upsert", time.Now())
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// UpsertAndRead calls Next and records the operation
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	defer c.record("UpsertAndRead", time.Now())
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Aggregate calls Next and records the operation
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	defer c.record("Aggregate", time.Now())
+	return c.Next.Aggregate(ctx, ei, aggFunc, column, columnConditions)
+}
+
+// CountRange calls Next and records the operation
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	defer c.record("CountRange", time.Now())
+	return c.Next.CountRange(ctx, ei, columnConditions)
+}
+
+// Replace calls Next and records the operation
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	defer c.record("Replace", time.Now())
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// AtomicAdd calls Next and records the operation
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	defer c.record("AtomicAdd", time.Now())
+	return c.Next.AtomicAdd(ctx, ei, keys, column, delta)
+}
+
+// CompareAndSwap calls Next and records the operation
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	defer c.record("CompareAndSwap", time.Now())
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions calls Next and records the operation
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	defer c.record("UpsertWithConditions", time.Now())
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// MultiUpsert calls Next and records the operation
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, values []map[string]dosa.FieldValue) ([]error, error) {
+	defer c.record("MultiUpsert", time.Now())
+	return c.Next.MultiUpsert(ctx, ei, values)
+}
+
+// Remove calls Next and records the operation
+func (c *Connector) Remove(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	defer c.record("Remove", time.Now())
+	return c.Next.Remove(ctx, ei, values)
+}
+
+// RemoveRange calls Next and records the operation
+func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) error {
+	defer c.record("RemoveRange", time.Now())
+	return c.Next.RemoveRange(ctx, ei, columnConditions)
+}
+
+// DeletePartition calls Next and records the operation
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	defer c.record("DeletePartition", time.Now())
+	return c.Next.DeletePartition(ctx, ei, pk)
+}
+
+// MultiRemove calls Next and records the operation
+func (c *Connector) MultiRemove(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	defer c.record("MultiRemove", time.Now())
+	return c.Next.MultiRemove(ctx, ei, multiValues)
+}
+
+// Range calls Next and records the operation
+func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	defer c.record("Range", time.Now())
+	return c.Next.Range(ctx, ei, columnConditions, minimumFields, token, limit)
+}
+
+// Scan calls Next and records the operation
+func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	defer c.record("Scan", time.Now())
+	return c.Next.Scan(ctx, ei, minimumFields, token, limit)
+}
+
+// CopyTable calls Next and records the operation
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	defer c.record("CopyTable", time.Now())
+	return c.Next.CopyTable(ctx, src, dst)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/devnull"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+func TestConnector_OperationCount(t *testing.T) {
+	c := NewConnector(memory.NewConnector())
+	s := ConnectorStats(c)
+	assert.Equal(t, c.Stats(), s)
+
+	values := map[string]dosa.FieldValue{"id": int64(1), "name": "foo"}
+	assert.NoError(t, c.Upsert(ctx, testEi, values))
+	assert.NoError(t, c.Upsert(ctx, testEi, values))
+	_, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2)}, dosa.All())
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	assert.Equal(t, int64(2), s.OperationCount("Upsert"))
+	assert.Equal(t, int64(1), s.OperationCount("Read"))
+	assert.Equal(t, int64(0), s.OperationCount("Remove"))
+	assert.Equal(t, time.Duration(0), s.LatencyP99("Remove"))
+
+	c.ResetStats()
+	assert.Equal(t, int64(0), s.OperationCount("Upsert"))
+}
+
+func TestConnectorStats_NotStatsConnector(t *testing.T) {
+	assert.Nil(t, ConnectorStats(&devnull.Connector{}))
+}
+
+func TestStats_Latency(t *testing.T) {
+	s := newStats(100)
+	for i := 1; i <= 100; i++ {
+		s.record("Read", time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 99*time.Millisecond, s.LatencyP99("Read"))
+	assert.Equal(t, 50*time.Millisecond, s.LatencyPercentile("Read", 0.5))
+	assert.Equal(t, 100*time.Millisecond, s.LatencyPercentile("Read", 1))
+	assert.Equal(t, 1*time.Millisecond, s.LatencyPercentile("Read", 0))
+
+	// only the most recent latencies are kept
+	for i := 0; i < 100; i++ {
+		s.record("Read", time.Second)
+	}
+	assert.Equal(t, int64(200), s.OperationCount("Read"))
+	assert.Equal(t, time.Second, s.LatencyPercentile("Read", 0))
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unique
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// ErrUniqueViolation is returned when a write would give a column with a
+// unique constraint a value that another row already holds
+type ErrUniqueViolation struct {
+	Entity string
+	Column string
+}
+
+// Error satisfies the error interface
+func (e *ErrUniqueViolation) Error() string {
+	return fmt.Sprintf("value of unique column %s.%s is already used by another row", e.Entity, e.Column)
+}
+
+// ErrorIsUniqueViolation checks if the error is caused by "ErrUniqueViolation"
+func ErrorIsUniqueViolation(err error) bool {
+	_, ok := errors.Cause(err).(*ErrUniqueViolation)
+	return ok
+}
+
+// Connector rejects writes that would give a unique column (set with the
+// unique tag) a value already held by a row with a different primary key.
+// Before each write, every unique column being set to a non-null value is
+// looked up with a Range on Next, which reads the index that has the column
+// as its only partition key (see EntityDefinition.UniqueIndex). Null values
+// are never checked.
+//
+// The check is not atomic with the write: two concurrent writers of the same
+// value can both find it unused and both succeed. Indexes are also often
+// updated asynchronously, so a value written moments ago may not be found
+// yet. This connector catches accidental reuse of a value; it does not
+// replace a backend that enforces uniqueness itself.
+type Connector struct {
+	base.Connector
+}
+
+// NewConnector creates a new unique connector
+func NewConnector(next dosa.Connector) *Connector {
+	return &Connector{Connector: base.Connector{Next: next}}
+}
+
+// CreateIfNotExists checks the unique columns before calling Next
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.CreateIfNotExists(ctx, ei, values)
+}
+
+// Upsert checks the unique columns before calling Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// UpsertAndRead checks the unique columns before calling Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if err := c.check(ctx, ei, values); err != nil {
+		return nil, err
+	}
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Replace checks the unique columns before calling Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// CompareAndSwap checks the unique columns in newValues before calling Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	values := make(map[string]dosa.FieldValue, len(newValues))
+	for k, v := range newValues {
+		values[k] = v
+	}
+	for k := range ei.Def.KeySet() {
+		values[k] = conditions[k]
+	}
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions checks the unique columns before calling Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if err := c.check(ctx, ei, values); err != nil {
+		return err
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// MultiUpsert checks each row like Upsert does; only rows that pass are sent to Next.
+// Rows of the same batch are not checked against each other.
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	result := make([]error, len(multiValues))
+	var pass []map[string]dosa.FieldValue
+	var passIdx []int
+	for i, values := range multiValues {
+		if err := c.check(ctx, ei, values); err != nil {
+			result[i] = err
+			continue
+		}
+		pass = append(pass, values)
+		passIdx = append(passIdx, i)
+	}
+	if len(pass) == 0 {
+		return result, nil
+	}
+	nextResult, err := c.Next.MultiUpsert(ctx, ei, pass)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range passIdx {
+		if i < len(nextResult) {
+			result[idx] = nextResult[i]
+		}
+	}
+	return result, nil
+}
+
+// CopyTable scans src and writes the rows to dst through this connector when
+// dst has unique columns, so each row is checked like MultiUpsert does
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	if len(dst.Def.UniqueColumns()) == 0 {
+		return c.Next.CopyTable(ctx, src, dst)
+	}
+	return dosa.CopyTableByScanning(ctx, c, src, dst)
+}
+
+// check returns an ErrUniqueViolation for the first unique column in values
+// whose value is held by a row with a different primary key
+func (c *Connector) check(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	keySet := ei.Def.KeySet()
+	var keyColumns []string
+	for k := range keySet {
+		keyColumns = append(keyColumns, k)
+	}
+	for _, name := range ei.Def.UniqueColumns() {
+		v := dosa.DerefFieldValue(values[name])
+		if v == nil {
+			continue
+		}
+		conditions := map[string][]*dosa.Condition{name: {{Op: dosa.Eq, Value: v}}}
+		rows, _, err := c.Next.Range(ctx, ei, conditions, keyColumns, "", 2)
+		if err != nil && !dosa.ErrorIsNotFound(err) {
+			return errors.Wrapf(err, "failed to look up value of unique column %s", name)
+		}
+		for _, row := range rows {
+			if !sameKey(keySet, row, values) {
+				return &ErrUniqueViolation{Entity: ei.Def.Name, Column: name}
+			}
+		}
+	}
+	return nil
+}
+
+// sameKey reports whether a and b have the same primary key values
+func sameKey(keySet map[string]struct{}, a, b map[string]dosa.FieldValue) bool {
+	for k := range keySet {
+		if !dosa.SameFieldValue(a[k], b[k]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unique_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/unique"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.Int64},
+			{Name: "name", Type: dosa.String},
+			{Name: "email", Type: dosa.String, IsPointer: true, UniqueConstraint: true},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		Indexes: map[string]*dosa.IndexDefinition{
+			"by_email": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"email"}}},
+		},
+	},
+}
+
+var ctx = context.Background()
+
+func TestUnique_Upsert(t *testing.T) {
+	c := unique.NewConnector(memory.NewConnector())
+	a, b := "a@example.com", "b@example.com"
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "email": &a}))
+	// a row can rewrite its own value
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "name": "one", "email": &a}))
+
+	err := c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2), "email": &a})
+	assert.True(t, unique.ErrorIsUniqueViolation(err))
+	assert.EqualError(t, err, "value of unique column users.email is already used by another row")
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2), "email": &b}))
+
+	// a key of another type is another row, rather than a panic
+	err = c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": 1, "email": &a})
+	assert.True(t, unique.ErrorIsUniqueViolation(err))
+
+	// writes without the column are not checked
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(3), "name": "three"}))
+
+	// once row 1 moves to another value, its old one is free
+	c2 := "c@example.com"
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "email": &c2}))
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(3), "email": &a}))
+}
+
+func TestUnique_Writes(t *testing.T) {
+	c := unique.NewConnector(memory.NewConnector())
+	a := "a@example.com"
+	assert.NoError(t, c.CreateIfNotExists(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "email": &a}))
+	taken := map[string]dosa.FieldValue{"id": int64(2), "email": &a}
+
+	assert.True(t, unique.ErrorIsUniqueViolation(c.CreateIfNotExists(ctx, testEi, taken)))
+	_, err := c.UpsertAndRead(ctx, testEi, taken)
+	assert.True(t, unique.ErrorIsUniqueViolation(err))
+	assert.True(t, unique.ErrorIsUniqueViolation(c.Replace(ctx, testEi, taken)))
+	assert.True(t, unique.ErrorIsUniqueViolation(c.UpsertWithConditions(ctx, testEi, taken, nil)))
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2), "name": "two"}))
+	err = c.CompareAndSwap(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2), "name": "two"},
+		map[string]dosa.FieldValue{"email": &a})
+	assert.True(t, unique.ErrorIsUniqueViolation(err))
+
+	// the rejected writes never reached the memory connector
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": int64(2)}, dosa.All())
+	assert.NoError(t, err)
+	assert.Nil(t, values["email"])
+}
+
+func TestUnique_MultiUpsert(t *testing.T) {
+	c := unique.NewConnector(memory.NewConnector())
+	a, b := "a@example.com", "b@example.com"
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": int64(1), "email": &a}))
+
+	result, err := c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{
+		{"id": int64(2), "email": &b},
+		{"id": int64(3), "email": &a},
+		{"id": int64(1), "email": &a},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.NoError(t, result[0])
+	assert.True(t, unique.ErrorIsUniqueViolation(result[1]))
+	assert.NoError(t, result[2])
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validating
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+)
+
+// ErrTooLong is returned when a String or Blob value is longer than the
+// MaxLength of its column
+type ErrTooLong struct {
+	Entity    string
+	Column    string
+	Length    int
+	MaxLength int
+}
+
+// Error satisfies the error interface
+func (e *ErrTooLong) Error() string {
+	return fmt.Sprintf("value of %s.%s is %d bytes long, more than the maximum of %d", e.Entity, e.Column, e.Length, e.MaxLength)
+}
+
+// ErrorIsTooLong checks if the error is caused by "ErrTooLong"
+func ErrorIsTooLong(err error) bool {
+	_, ok := errors.Cause(err).(*ErrTooLong)
+	return ok
+}
+
+// Connector rejects writes of String and Blob values that are longer than the
+// MaxLength of their column (set with the maxlen tag), before they reach a
+// backend that might truncate or reject them. Lengths are in bytes. Columns
+// without a MaxLength are not checked.
+//
+// Writes of columns marked deprecated are let through, but the first write
+// of each deprecated column of an entity logs a warning to the connector's
+// logger (see base.WithLogger).
+type Connector struct {
+	base.Connector
+
+	mu     sync.Mutex
+	warned map[string]struct{} // entity and column names of deprecated columns already warned about
+}
+
+// NewConnector creates a new validating connector
+func NewConnector(next dosa.Connector, opts ...base.ConnectorOption) *Connector {
+	c := &Connector{Connector: base.Connector{Next: next}, warned: make(map[string]struct{})}
+	c.Apply(opts...)
+	return c
+}
+
+// CreateIfNotExists checks the lengths of the values before calling Next
+func (c *Connector) CreateIfNotExists(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ei, values); err != nil {
+		return err
+	}
+	return c.Next.CreateIfNotExists(ctx, ei, values)
+}
+
+// Upsert checks the lengths of the values before calling Next
+func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ei, values); err != nil {
+		return err
+	}
+	return c.Next.Upsert(ctx, ei, values)
+}
+
+// UpsertAndRead checks the lengths of the values before calling Next
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if err := c.check(ei, values); err != nil {
+		return nil, err
+	}
+	return c.Next.UpsertAndRead(ctx, ei, values)
+}
+
+// Replace checks the lengths of the values before calling Next
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := c.check(ei, values); err != nil {
+		return err
+	}
+	return c.Next.Replace(ctx, ei, values)
+}
+
+// CompareAndSwap checks the lengths of the new values before calling Next
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	if err := c.check(ei, newValues); err != nil {
+		return err
+	}
+	return c.Next.CompareAndSwap(ctx, ei, conditions, newValues)
+}
+
+// UpsertWithConditions checks the lengths of the values before calling Next
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	if err := c.check(ei, values); err != nil {
+		return err
+	}
+	return c.Next.UpsertWithConditions(ctx, ei, values, conditions)
+}
+
+// MultiUpsert checks each row like Upsert does; only rows that pass are sent to Next
+func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
+	result := make([]error, len(multiValues))
+	var pass []map[string]dosa.FieldValue
+	var passIdx []int
+	for i, values := range multiValues {
+		if err := c.check(ei, values); err != nil {
+			result[i] = err
+			continue
+		}
+		pass = append(pass, values)
+		passIdx = append(passIdx, i)
+	}
+	if len(pass) == 0 {
+		return result, nil
+	}
+	nextResult, err := c.Next.MultiUpsert(ctx, ei, pass)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range passIdx {
+		if i < len(nextResult) {
+			result[idx] = nextResult[i]
+		}
+	}
+	return result, nil
+}
+
+// CopyTable scans src and writes the rows to dst through this connector when
+// dst has columns with a MaxLength, so each row is checked like MultiUpsert does
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	for _, cd := range dst.Def.Columns {
+		if cd.MaxLength != 0 {
+			return dosa.CopyTableByScanning(ctx, c, src, dst)
+		}
+	}
+	return c.Next.CopyTable(ctx, src, dst)
+}
+
+// check returns an ErrTooLong for the first value that is longer than the
+// MaxLength of its column; if there is none it warns about deprecated columns
+func (c *Connector) check(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	if err := checkLengths(ei, values); err != nil {
+		return err
+	}
+	c.warnDeprecated(ei, values)
+	return nil
+}
+
+// warnDeprecated logs a warning the first time each deprecated column of an
+// entity is written
+func (c *Connector) warnDeprecated(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) {
+	for _, cd := range ei.Def.Columns {
+		if !cd.Deprecated {
+			continue
+		}
+		if _, ok := values[cd.Name]; !ok {
+			continue
+		}
+		key := ei.Def.Name + "." + cd.Name
+		c.mu.Lock()
+		_, seen := c.warned[key]
+		c.warned[key] = struct{}{}
+		c.mu.Unlock()
+		if seen {
+			continue
+		}
+		if cd.DeprecatedSince != "" {
+			c.Logger().Warnf("writing deprecated column %s (deprecated since %s)", key, cd.DeprecatedSince)
+		} else {
+			c.Logger().Warnf("writing deprecated column %s", key)
+		}
+	}
+}
+
+// checkLengths returns an ErrTooLong for the first value that is longer than
+// the MaxLength of its column
+func checkLengths(ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	for _, cd := range ei.Def.Columns {
+		if cd.MaxLength == 0 {
+			continue
+		}
+		v, ok := values[cd.Name]
+		if !ok {
+			continue
+		}
+		if l := length(v); l > cd.MaxLength {
+			return &ErrTooLong{Entity: ei.Def.Name, Column: cd.Name, Length: l, MaxLength: cd.MaxLength}
+		}
+	}
+	return nil
+}
+
+// length returns the length in bytes of a string or []byte value, or of the
+// value pointed to by a nullable one; other values have a length of 0
+func length(v dosa.FieldValue) int {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return 0
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice:
+		return rv.Len()
+	}
+	return 0
+}
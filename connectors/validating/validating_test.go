@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validating_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/base"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/connectors/validating"
+)
+
+var testEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{
+		Scope:      "testScope",
+		NamePrefix: "testPrefix",
+		EntityName: "users",
+	},
+	Def: &dosa.EntityDefinition{
+		Name: "users",
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.String, MaxLength: 4},
+			{Name: "name", Type: dosa.String, IsPointer: true, MaxLength: 8},
+			{Name: "avatar", Type: dosa.Blob, MaxLength: 2},
+			{Name: "bio", Type: dosa.String},
+		},
+		Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+	},
+}
+
+var ctx = context.Background()
+
+func TestValidating_Upsert(t *testing.T) {
+	c := validating.NewConnector(memory.NewConnector())
+	long := "too long a name"
+	short := "name"
+
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": "1234", "name": &short, "avatar": []byte{1, 2}, "bio": long + long,
+	}))
+	// null values have no length
+	assert.NoError(t, c.Upsert(ctx, testEi, map[string]dosa.FieldValue{
+		"id": "1234", "name": (*string)(nil),
+	}))
+
+	err := c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1234", "name": &long})
+	assert.True(t, validating.ErrorIsTooLong(err))
+	assert.EqualError(t, err, "value of users.name is 15 bytes long, more than the maximum of 8")
+
+	err = c.Upsert(ctx, testEi, map[string]dosa.FieldValue{"id": "1234", "avatar": []byte{1, 2, 3}})
+	assert.True(t, validating.ErrorIsTooLong(err))
+
+	// the rejected writes never reached the memory connector
+	values, err := c.Read(ctx, testEi, map[string]dosa.FieldValue{"id": "1234"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, values["avatar"])
+}
+
+func TestValidating_Writes(t *testing.T) {
+	c := validating.NewConnector(memory.NewConnector())
+	tooLong := map[string]dosa.FieldValue{"id": "12345"}
+	ok := map[string]dosa.FieldValue{"id": "1234"}
+
+	assert.True(t, validating.ErrorIsTooLong(c.CreateIfNotExists(ctx, testEi, tooLong)))
+	assert.NoError(t, c.CreateIfNotExists(ctx, testEi, ok))
+
+	_, err := c.UpsertAndRead(ctx, testEi, tooLong)
+	assert.True(t, validating.ErrorIsTooLong(err))
+	_, err = c.UpsertAndRead(ctx, testEi, ok)
+	assert.NoError(t, err)
+
+	assert.True(t, validating.ErrorIsTooLong(c.Replace(ctx, testEi, tooLong)))
+	assert.NoError(t, c.Replace(ctx, testEi, ok))
+
+	err = c.CompareAndSwap(ctx, testEi, ok, map[string]dosa.FieldValue{"avatar": []byte("abc")})
+	assert.True(t, validating.ErrorIsTooLong(err))
+	assert.NoError(t, c.CompareAndSwap(ctx, testEi, ok, map[string]dosa.FieldValue{"avatar": []byte("ab")}))
+}
+
+func TestValidating_MultiUpsert(t *testing.T) {
+	c := validating.NewConnector(memory.NewConnector())
+	result, err := c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{
+		{"id": "1"},
+		{"id": "12345"},
+		{"id": "2"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.NoError(t, result[0])
+	assert.True(t, validating.ErrorIsTooLong(result[1]))
+	assert.NoError(t, result[2])
+
+	result, err = c.MultiUpsert(ctx, testEi, []map[string]dosa.FieldValue{{"id": "12345"}})
+	assert.NoError(t, err)
+	assert.True(t, validating.ErrorIsTooLong(result[0]))
+}
+
+func TestValidating_CopyTable(t *testing.T) {
+	c := validating.NewConnector(memory.NewConnector())
+	src := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	src.Def.Name = "users_src"
+	for _, cd := range src.Def.Columns {
+		cd.MaxLength = 0
+	}
+	assert.NoError(t, c.Upsert(ctx, src, map[string]dosa.FieldValue{"id": "12345"}))
+
+	// copying to a table without length limits goes straight to Next
+	assert.NoError(t, c.CopyTable(ctx, testEi, src))
+
+	// each copied row is checked against the destination
+	err := c.CopyTable(ctx, src, testEi)
+	assert.True(t, validating.ErrorIsTooLong(err))
+}
+
+type warnLogger struct {
+	warnings []string
+}
+
+func (l *warnLogger) Debugf(string, ...interface{}) {}
+func (l *warnLogger) Infof(string, ...interface{})  {}
+func (l *warnLogger) Errorf(string, ...interface{}) {}
+
+func (l *warnLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func TestValidating_DeprecatedColumns(t *testing.T) {
+	ei := &dosa.EntityInfo{Ref: testEi.Ref, Def: testEi.Def.Clone()}
+	ei.Def.Columns[1].Deprecated = true
+	ei.Def.Columns[1].DeprecatedSince = "v2"
+	ei.Def.Columns[3].Deprecated = true
+	logger := &warnLogger{}
+	c := validating.NewConnector(memory.NewConnector(), base.WithLogger(logger))
+
+	// writes without deprecated columns don't warn
+	assert.NoError(t, c.Upsert(ctx, ei, map[string]dosa.FieldValue{"id": "1"}))
+	assert.Empty(t, logger.warnings)
+
+	// writes with them succeed, and warn once per column
+	name := "name"
+	assert.NoError(t, c.Upsert(ctx, ei, map[string]dosa.FieldValue{"id": "1", "name": &name}))
+	assert.NoError(t, c.Replace(ctx, ei, map[string]dosa.FieldValue{"id": "1", "name": &name, "bio": "bio"}))
+	assert.NoError(t, c.Upsert(ctx, ei, map[string]dosa.FieldValue{"id": "1", "bio": "bio"}))
+	assert.Equal(t, []string{
+		"writing deprecated column users.name (deprecated since v2)",
+		"writing deprecated column users.bio",
+	}, logger.warnings)
+
+	values, err := c.Read(ctx, ei, map[string]dosa.FieldValue{"id": "1"}, dosa.All())
+	assert.NoError(t, err)
+	assert.Equal(t, "bio", values["bio"])
+}
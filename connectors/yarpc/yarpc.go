@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -64,6 +65,9 @@ type Config struct {
 	CallerName   string `yaml:"callerName"`
 	ServiceName  string `yaml:"serviceName"`
 	ExtraHeaders map[string]string
+	// Logger receives the warnings about schemas the gateway can't fully
+	// apply, such as indexes with a TTL; nil discards them
+	Logger dosa.Logger `yaml:"-"`
 }
 
 // Connector holds the client-side RPC interface and some schema information
@@ -71,6 +75,7 @@ type Connector struct {
 	client     dosaclient.Interface
 	dispatcher *rpc.Dispatcher
 	headers    map[string]string
+	logger     dosa.Logger
 }
 
 // NewConnector creates a new instance with user provided transport
@@ -120,6 +125,7 @@ func NewConnector(config Config) (*Connector, error) {
 		dispatcher: dispatcher,
 		client:     client,
 		headers:    checkHeaders(config.ExtraHeaders, config.CallerName),
+		logger:     config.Logger,
 	}, nil
 }
 
@@ -195,6 +201,81 @@ func (c *Connector) Upsert(ctx context.Context, ei *dosa.EntityInfo, values map[
 	return errors.Wrap(err, "failed to Upsert")
 }
 
+// UpsertAndRead upserts the values and then reads the row back. The gateway has no
+// equivalent of INSERT ... RETURNING, so this takes two round trips.
+func (c *Connector) UpsertAndRead(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	if err := c.Upsert(ctx, ei, values); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]dosa.FieldValue)
+	for k := range ei.Def.KeySet() {
+		keys[k] = values[k]
+	}
+	row, err := c.Read(ctx, ei, keys, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read row after upsert")
+	}
+	for k, v := range keys {
+		row[k] = v
+	}
+	return row, nil
+}
+
+// Replace removes the row and then upserts the values. The gateway can't replace a row
+// atomically, so a reader may see the row missing in between.
+func (c *Connector) Replace(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue) error {
+	keys := make(map[string]dosa.FieldValue)
+	for k := range ei.Def.KeySet() {
+		keys[k] = values[k]
+	}
+	if err := c.Remove(ctx, ei, keys); err != nil {
+		return errors.Wrap(err, "failed to remove row to replace")
+	}
+	return c.Upsert(ctx, ei, values)
+}
+
+// AtomicAdd is not supported by the DOSA gateway, and a read followed by an upsert
+// would not be atomic
+func (c *Connector) AtomicAdd(ctx context.Context, ei *dosa.EntityInfo, keys map[string]dosa.FieldValue, column string, delta int64) (int64, error) {
+	return 0, errNotSupported("AtomicAdd")
+}
+
+// Aggregate reads the matching rows and aggregates them client-side, as
+// the gateway has no aggregation
+func (c *Connector) Aggregate(ctx context.Context, ei *dosa.EntityInfo, aggFunc dosa.AggFunc, column string, columnConditions map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	return dosa.AggregateByScanning(ctx, c, ei, aggFunc, column, columnConditions)
+}
+
+// ExplainQuery returns the plan that follows from the schema, as the gateway
+// has no explain call and so can't estimate the number of rows
+func (c *Connector) ExplainQuery(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	plan := dosa.PlanFromConditions(ei, columnConditions)
+	plan.Details = "planned from the schema, the DOSA gateway has no explain call"
+	return plan, nil
+}
+
+// CountRange is not supported, as the gateway has no count call; the client
+// counts the rows of the range instead
+func (c *Connector) CountRange(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition) (int64, error) {
+	return 0, errNotSupported("CountRange")
+}
+
+// CompareAndSwap is not supported by the DOSA gateway
+func (c *Connector) CompareAndSwap(ctx context.Context, ei *dosa.EntityInfo, conditions map[string]dosa.FieldValue, newValues map[string]dosa.FieldValue) error {
+	return errNotSupported("CompareAndSwap")
+}
+
+// UpsertWithConditions is not supported by the DOSA gateway
+func (c *Connector) UpsertWithConditions(ctx context.Context, ei *dosa.EntityInfo, values map[string]dosa.FieldValue, conditions map[string][]*dosa.Condition) error {
+	return errNotSupported("UpsertWithConditions")
+}
+
+// errNotSupported is returned for operations that the DOSA gateway doesn't
+// provide, so that dosa.ErrorIsNotSupported recognizes them
+func errNotSupported(op string) error {
+	return &dosa.ErrNotSupported{Op: op}
+}
+
 // MultiUpsert upserts multiple entities at one time
 func (c *Connector) MultiUpsert(ctx context.Context, ei *dosa.EntityInfo, multiValues []map[string]dosa.FieldValue) ([]error, error) {
 	values, err := fieldValueMapsFromClientMaps(multiValues)
@@ -423,8 +504,28 @@ func (c *Connector) RemoveRange(ctx context.Context, ei *dosa.EntityInfo, column
 	return nil
 }
 
+// DeletePartition removes the partition with a RemoveRange call that selects
+// the whole partition, which the gateway serves as a partition delete
+func (c *Connector) DeletePartition(ctx context.Context, ei *dosa.EntityInfo, pk map[string]dosa.FieldValue) error {
+	conditions, err := dosa.PartitionKeyConditions(ei.Def, pk)
+	if err != nil {
+		return errors.Wrap(err, "DeletePartition failed")
+	}
+	return c.RemoveRange(ctx, ei, conditions)
+}
+
 // Range does a scan across a range
 func (c *Connector) Range(ctx context.Context, ei *dosa.EntityInfo, columnConditions map[string][]*dosa.Condition, minimumFields []string, token string, limit int) ([]map[string]dosa.FieldValue, string, error) {
+	if sortColumns, ok := dosa.SortColumnsFromContext(ctx); ok {
+		// the gateway always returns rows in the order of the schema
+		reverse, err := dosa.ReverseSort(ei.Def.Key, sortColumns)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Invalid sort columns")
+		}
+		if reverse {
+			return nil, "", errNotSupported("Range in reverse order")
+		}
+	}
 	limit32 := int32(limit)
 	rpcMinimumFields := makeRPCminimumFields(minimumFields)
 	rpcConditions, err := createRPCConditions(columnConditions)
@@ -502,9 +603,16 @@ func (c *Connector) Scan(ctx context.Context, ei *dosa.EntityInfo, minimumFields
 	return results, *response.NextToken, nil
 }
 
+// CopyTable scans src and writes the rows to dst, as the DOSA gateway
+// can't copy tables
+func (c *Connector) CopyTable(ctx context.Context, src, dst *dosa.EntityInfo) error {
+	return dosa.CopyTableByScanning(ctx, c, src, dst)
+}
+
 // CheckSchema is one way to register a set of entities. This can be further validated by
 // a schema service downstream.
 func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (int32, error) {
+	c.warnIndexTTLs(eds)
 	// convert the client EntityDefinition to the RPC EntityDefinition
 	rpcEntityDefinition := EntityDefsToThrift(eds)
 	csr := dosarpc.CheckSchemaRequest{
@@ -524,6 +632,26 @@ func (c *Connector) CheckSchema(ctx context.Context, scope, namePrefix string, e
 	return *response.Version, nil
 }
 
+// warnIndexTTLs logs the indexes with a TTL: the gateway has no per-index TTL,
+// so their entries stay as long as their rows
+func (c *Connector) warnIndexTTLs(eds []*dosa.EntityDefinition) {
+	if c.logger == nil {
+		return
+	}
+	for _, ed := range eds {
+		names := make([]string, 0, len(ed.Indexes))
+		for name, index := range ed.Indexes {
+			if index.TTL > 0 {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c.logger.Warnf("yarpc: index %s of %s has a TTL of %v, which the DOSA gateway doesn't support; its entries won't expire", name, ed.Name, ed.Indexes[name].TTL)
+		}
+	}
+}
+
 // CanUpsertSchema checks whether the provided entities are compatible with the latest applied schema.
 // A non-nil error indicates the entities are not backward-compatible with the latest schema, thus will fail
 // if they were to be upserted.
@@ -548,6 +676,7 @@ func (c *Connector) CanUpsertSchema(ctx context.Context, scope, namePrefix strin
 
 // UpsertSchema upserts the schema through RPC
 func (c *Connector) UpsertSchema(ctx context.Context, scope, namePrefix string, eds []*dosa.EntityDefinition) (*dosa.SchemaStatus, error) {
+	c.warnIndexTTLs(eds)
 	rpcEds := EntityDefsToThrift(eds)
 	request := &dosarpc.UpsertSchemaRequest{
 		Scope:      &scope,
@@ -613,6 +742,16 @@ func (c *Connector) GetEntitySchema(ctx context.Context, scope, namePrefix, enti
 	panic("Not implemented")
 }
 
+// DescribeTable is not supported by the DOSA gateway
+func (c *Connector) DescribeTable(ctx context.Context, ei *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	return nil, errNotSupported("DescribeTable")
+}
+
+// DropTable is not supported by the DOSA gateway
+func (c *Connector) DropTable(ctx context.Context, ei *dosa.EntityInfo) error {
+	return errNotSupported("DropTable")
+}
+
 // CreateScope creates the scope specified
 func (c *Connector) CreateScope(ctx context.Context, md *dosa.ScopeMetadata) error {
 	bytes, err := json.Marshal(*md)
@@ -767,6 +767,47 @@ func TestClient_UpsertSchema(t *testing.T) {
 	assert.Contains(t, err.Error(), "test error")
 }
 
+type warnLogger struct {
+	warnings []string
+}
+
+func (l *warnLogger) Debugf(string, ...interface{}) {}
+func (l *warnLogger) Infof(string, ...interface{})  {}
+func (l *warnLogger) Errorf(string, ...interface{}) {}
+
+func (l *warnLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func TestClient_UpsertSchemaIndexTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockedClient := dosatest.NewMockClient(ctrl)
+	logger := &warnLogger{}
+	sut := Connector{client: mockedClient, logger: logger}
+
+	ed, err := dosa.TableFromInstance(&TestDosaObject{})
+	assert.NoError(t, err)
+	ed.Indexes["by_f2"] = &dosa.IndexDefinition{Key: &dosa.PrimaryKey{PartitionKeys: []string{"f2"}}, TTL: time.Hour}
+	ed.Indexes["by_f2_f1"] = &dosa.IndexDefinition{Key: &dosa.PrimaryKey{PartitionKeys: []string{"f2", "f1"}}}
+
+	// the index is still created, without its TTL
+	v := int32(1)
+	mockedClient.EXPECT().UpsertSchema(ctx, gomock.Any(), gomock.Any()).Return(&drpc.UpsertSchemaResponse{Version: &v}, nil)
+	_, err = sut.UpsertSchema(ctx, "scope", "prefix", []*dosa.EntityDefinition{&ed.EntityDefinition})
+	assert.NoError(t, err)
+	mockedClient.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any()).Return(&drpc.CheckSchemaResponse{Version: &v}, nil)
+	_, err = sut.CheckSchema(ctx, "scope", "prefix", []*dosa.EntityDefinition{&ed.EntityDefinition})
+	assert.NoError(t, err)
+	warning := "yarpc: index by_f2 of testdosaobject has a TTL of 1h0m0s, which the DOSA gateway doesn't support; its entries won't expire"
+	assert.Equal(t, []string{warning, warning}, logger.warnings)
+
+	// without a logger the warnings are dropped
+	sut.logger = nil
+	mockedClient.EXPECT().CheckSchema(ctx, gomock.Any(), gomock.Any()).Return(&drpc.CheckSchemaResponse{Version: &v}, nil)
+	_, err = sut.CheckSchema(ctx, "scope", "prefix", []*dosa.EntityDefinition{&ed.EntityDefinition})
+	assert.NoError(t, err)
+}
+
 func TestClient_CreateScope(t *testing.T) {
 	// build a mock RPC client
 	ctrl := gomock.NewController(t)
@@ -958,6 +999,196 @@ func TestConnector_RemoveRange(t *testing.T) {
 	assert.EqualError(t, errors.Cause(err), "uuid: incorrect UUID length: baduuid")
 }
 
+func TestConnector_DeletePartition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockedClient := dosatest.NewMockClient(ctrl)
+	sut := Connector{client: mockedClient}
+
+	mockedClient.EXPECT().RemoveRange(ctx, gomock.Any(), gomock.Any()).Do(func(_ context.Context, request *drpc.RemoveRangeRequest, option yarpc2.CallOption) {
+		assert.Equal(t, testRPCSchemaRef, *request.Ref)
+		assert.Len(t, request.Conditions, 1)
+		assert.Equal(t, "f1", *request.Conditions[0].Field.Name)
+		assert.Equal(t, "user", *request.Conditions[0].Field.Value.ElemValue.StringValue)
+	}).Return(nil)
+	assert.NoError(t, sut.DeletePartition(ctx, testEi, map[string]dosa.FieldValue{"f1": "user"}))
+
+	err := sut.DeletePartition(ctx, testEi, map[string]dosa.FieldValue{"c1": int64(1)})
+	assert.Contains(t, err.Error(), "DeletePartition failed")
+}
+
+func TestConnector_UpsertAndRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockedClient := dosatest.NewMockClient(ctrl)
+	sut := Connector{client: mockedClient}
+
+	mockedClient.EXPECT().Upsert(ctx, gomock.Any(), gomock.Any()).Return(nil)
+	mockedClient.EXPECT().Read(ctx, gomock.Any(), gomock.Any()).Return(&drpc.ReadResponse{drpc.FieldValueMap{
+		"c1": {ElemValue: &drpc.RawValue{Int64Value: testutil.TestInt64Ptr(1)}},
+		"c3": {ElemValue: &drpc.RawValue{StringValue: testutil.TestStringPtr("f3value")}},
+	}}, nil)
+	values, err := sut.UpsertAndRead(ctx, testEi, map[string]dosa.FieldValue{"f1": "key", "c1": int64(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, "key", values["f1"])
+	testutil.AssertEqForPointer(testAssert(t), int64(1), values["c1"])
+	testutil.AssertEqForPointer(testAssert(t), "f3value", values["c3"])
+
+	mockedClient.EXPECT().Upsert(ctx, gomock.Any(), gomock.Any()).Return(errors.New("upsert failed"))
+	_, err = sut.UpsertAndRead(ctx, testEi, map[string]dosa.FieldValue{"f1": "key"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "upsert failed")
+}
+
+func TestConnector_Replace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockedClient := dosatest.NewMockClient(ctrl)
+	sut := Connector{client: mockedClient}
+
+	gomock.InOrder(
+		mockedClient.EXPECT().Remove(ctx, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, r *drpc.RemoveRequest, option yarpc2.CallOption) {
+				assert.Len(t, r.KeyValues, 1)
+			}).
+			Return(nil),
+		mockedClient.EXPECT().Upsert(ctx, gomock.Any(), gomock.Any()).Return(nil),
+	)
+	assert.NoError(t, sut.Replace(ctx, testEi, map[string]dosa.FieldValue{"f1": "key", "c1": int64(1)}))
+
+	mockedClient.EXPECT().Remove(ctx, gomock.Any(), gomock.Any()).Return(errors.New("remove failed"))
+	err := sut.Replace(ctx, testEi, map[string]dosa.FieldValue{"f1": "key", "c1": int64(1)})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "remove failed")
+}
+
+func TestConnector_Aggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockedClient := dosatest.NewMockClient(ctrl)
+	sut := Connector{client: mockedClient}
+
+	// the rows are read a page at a time and aggregated here
+	nextToken := "next"
+	noToken := ""
+	gomock.InOrder(
+		mockedClient.EXPECT().Scan(ctx, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, r *drpc.ScanRequest, option yarpc2.CallOption) {
+				assert.Equal(t, "", *r.Token)
+				assert.Equal(t, map[string]struct{}{"c1": {}}, r.FieldsToRead)
+			}).
+			Return(&drpc.ScanResponse{
+				Entities: []drpc.FieldValueMap{
+					{"c1": {ElemValue: &drpc.RawValue{Int64Value: testutil.TestInt64Ptr(1)}}},
+					{"c1": {ElemValue: &drpc.RawValue{Int64Value: testutil.TestInt64Ptr(2)}}},
+				},
+				NextToken: &nextToken,
+			}, nil),
+		mockedClient.EXPECT().Scan(ctx, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, r *drpc.ScanRequest, option yarpc2.CallOption) {
+				assert.Equal(t, nextToken, *r.Token)
+			}).
+			Return(&drpc.ScanResponse{
+				Entities: []drpc.FieldValueMap{
+					{"c1": {ElemValue: &drpc.RawValue{Int64Value: testutil.TestInt64Ptr(4)}}},
+				},
+				NextToken: &noToken,
+			}, nil),
+	)
+	v, err := sut.Aggregate(ctx, testEi, dosa.AggSum, "c1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.FieldValue(int64(7)), v)
+
+	mockedClient.EXPECT().Scan(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("test error"))
+	_, err = sut.Aggregate(ctx, testEi, dosa.AggCount, "c1", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "test error")
+}
+
+func TestConnector_AtomicAdd(t *testing.T) {
+	sut := Connector{}
+	_, err := sut.AtomicAdd(ctx, testEi, map[string]dosa.FieldValue{}, "c1", 1)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "AtomicAdd is not supported by the connector")
+}
+
+func TestConnector_UpsertWithConditions(t *testing.T) {
+	sut := Connector{}
+	err := sut.UpsertWithConditions(ctx, testEi, map[string]dosa.FieldValue{}, nil)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "UpsertWithConditions is not supported by the connector")
+}
+
+func TestConnector_CopyTable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockedClient := dosatest.NewMockClient(ctrl)
+
+	sut := Connector{client: mockedClient}
+
+	// the rows are read a page at a time and written with MultiUpsert
+	noToken := ""
+	mockedClient.EXPECT().Scan(ctx, gomock.Any(), gomock.Any()).
+		Return(&drpc.ScanResponse{
+			Entities: []drpc.FieldValueMap{
+				{"c1": {ElemValue: &drpc.RawValue{Int64Value: testutil.TestInt64Ptr(1)}}},
+			},
+			NextToken: &noToken,
+		}, nil)
+	mockedClient.EXPECT().MultiUpsert(ctx, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, r *drpc.MultiUpsertRequest, option yarpc2.CallOption) {
+			assert.Len(t, r.Entities, 1)
+		}).
+		Return(&drpc.MultiUpsertResponse{Errors: []*drpc.Error{nil}}, nil)
+	assert.NoError(t, sut.CopyTable(ctx, testEi, testEi))
+}
+
+func TestConnector_DescribeTable(t *testing.T) {
+	sut := Connector{}
+	_, err := sut.DescribeTable(ctx, testEi)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "DescribeTable is not supported by the connector")
+}
+
+func TestConnector_ExplainQuery(t *testing.T) {
+	sut := Connector{}
+	plan, err := sut.ExplainQuery(ctx, testEi, nil)
+	assert.NoError(t, err)
+	assert.True(t, plan.FullScan)
+	assert.Equal(t, int64(dosa.UnknownRows), plan.EstimatedRows)
+}
+
+func TestConnector_CountRange(t *testing.T) {
+	sut := Connector{}
+	_, err := sut.CountRange(ctx, testEi, nil)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "CountRange is not supported by the connector")
+}
+
+func TestConnector_RangeReverse(t *testing.T) {
+	sut := Connector{}
+	ei := newTestEi()
+	ei.Def.Key.ClusteringKeys = []*dosa.ClusteringKey{{Name: "c1"}}
+	sorted := dosa.WithSortColumns(ctx, []dosa.ColumnOrder{{Column: "c1", Direction: dosa.Descending}})
+	_, _, err := sut.Range(sorted, ei, nil, dosa.All(), "", 10)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "Range in reverse order is not supported by the connector")
+}
+
+func TestConnector_DropTable(t *testing.T) {
+	sut := Connector{}
+	err := sut.DropTable(ctx, testEi)
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "DropTable is not supported by the connector")
+}
+
+func TestConnector_CompareAndSwap(t *testing.T) {
+	sut := Connector{}
+	err := sut.CompareAndSwap(ctx, testEi, map[string]dosa.FieldValue{}, map[string]dosa.FieldValue{})
+	assert.True(t, dosa.ErrorIsNotSupported(err))
+	assert.EqualError(t, err, "CompareAndSwap is not supported by the connector")
+}
+
 func TestConnector_Scan(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
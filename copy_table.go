@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// copyTableScanLimit is the page size used when copying a table by scanning
+const copyTableScanLimit = 200
+
+// DefaultCopyParallelism is the number of pages CopyTableByScanning writes at
+// the same time unless WithParallelism says otherwise
+const DefaultCopyParallelism = 4
+
+// CopyTableOption configures CopyTableByScanning
+type CopyTableOption func(*copyTableOptions)
+
+type copyTableOptions struct {
+	parallelism int
+}
+
+// WithParallelism sets the number of pages written at the same time when
+// copying a table; values less than 1 mean 1
+func WithParallelism(n int) CopyTableOption {
+	return func(o *copyTableOptions) {
+		if n < 1 {
+			n = 1
+		}
+		o.parallelism = n
+	}
+}
+
+// CheckCopyTable verifies that the rows of src can be copied to dst: the
+// primary keys must be the same, and every column of src must exist in dst
+// with the same type. dst may have extra columns.
+func CheckCopyTable(src, dst *EntityDefinition) error {
+	if !reflect.DeepEqual(src.Key.PartitionKeys, dst.Key.PartitionKeys) {
+		return errors.Errorf("partition key mismatch: (%v vs %v)", src.Key.PartitionKeys, dst.Key.PartitionKeys)
+	}
+	if len(src.Key.ClusteringKeys) != 0 || len(dst.Key.ClusteringKeys) != 0 {
+		if !reflect.DeepEqual(src.Key.ClusteringKeys, dst.Key.ClusteringKeys) {
+			return errors.Errorf("clustering key mismatch: (%v vs %v)", src.Key.ClusteringKeys, dst.Key.ClusteringKeys)
+		}
+	}
+	dstTypes := dst.ColumnTypes()
+	for _, cd := range src.Columns {
+		typ, ok := dstTypes[cd.Name]
+		if !ok {
+			return errors.Errorf("the column %s in entity %s is not in entity %s", cd.Name, src.Name, dst.Name)
+		}
+		if typ != cd.Type {
+			return errors.Errorf("the type for column %s mismatch: (%v vs %v)", cd.Name, cd.Type, typ)
+		}
+	}
+	return nil
+}
+
+// CopyTableByScanning copies every row of src to dst with conn, for
+// connectors whose backend can't copy a table by itself. Pages of rows are
+// read with Scan and written with MultiUpsert, so rows already in dst are
+// updated rather than replaced. The copy stops at the first error.
+func CopyTableByScanning(ctx context.Context, conn Connector, src, dst *EntityInfo, opts ...CopyTableOption) error {
+	if err := CheckCopyTable(src.Def, dst.Def); err != nil {
+		return errors.Wrapf(err, "cannot copy %q to %q", src.Def.Name, dst.Def.Name)
+	}
+	options := copyTableOptions{parallelism: DefaultCopyParallelism}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	sem := make(chan struct{}, options.parallelism)
+	token := ""
+	for !failed() {
+		rows, next, err := conn.Scan(ctx, src, All(), token, copyTableScanLimit)
+		if err != nil {
+			if !ErrorIsNotFound(err) {
+				fail(errors.Wrapf(err, "scan of %q failed", src.Def.Name))
+			}
+			break
+		}
+		if len(rows) > 0 {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(rows []map[string]FieldValue) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				if err := copyTablePage(ctx, conn, dst, rows); err != nil {
+					fail(err)
+				}
+			}(rows)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func copyTablePage(ctx context.Context, conn Connector, dst *EntityInfo, rows []map[string]FieldValue) error {
+	results, err := conn.MultiUpsert(ctx, dst, rows)
+	if err != nil {
+		return errors.Wrapf(err, "write to %q failed", dst.Def.Name)
+	}
+	for _, err := range results {
+		if err != nil {
+			return errors.Wrapf(err, "write to %q failed", dst.Def.Name)
+		}
+	}
+	return nil
+}
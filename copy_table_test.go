@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/mocks"
+)
+
+func copyTableEis() (*dosa.EntityInfo, *dosa.EntityInfo) {
+	src := &dosa.EntityInfo{
+		Ref: &dosa.SchemaRef{Scope: "scope", NamePrefix: "prefix", EntityName: "src"},
+		Def: &dosa.EntityDefinition{
+			Name: "src",
+			Key:  &dosa.PrimaryKey{PartitionKeys: []string{"id"}, ClusteringKeys: []*dosa.ClusteringKey{{Name: "seq"}}},
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "id", Type: dosa.String},
+				{Name: "seq", Type: dosa.Int64},
+				{Name: "name", Type: dosa.String},
+			},
+		},
+	}
+	dst := &dosa.EntityInfo{Ref: src.Ref, Def: src.Def.Clone()}
+	dst.Def.Name = "dst"
+	return src, dst
+}
+
+func TestCheckCopyTable(t *testing.T) {
+	src, dst := copyTableEis()
+	assert.NoError(t, dosa.CheckCopyTable(src.Def, dst.Def))
+
+	// extra columns in dst are fine, missing ones are not
+	dst.Def.Columns = append(dst.Def.Columns, &dosa.ColumnDefinition{Name: "extra", Type: dosa.Bool})
+	assert.NoError(t, dosa.CheckCopyTable(src.Def, dst.Def))
+	assert.Contains(t, dosa.CheckCopyTable(dst.Def, src.Def).Error(), "the column extra")
+
+	_, dst = copyTableEis()
+	dst.Def.Columns[2].Type = dosa.Blob
+	assert.Contains(t, dosa.CheckCopyTable(src.Def, dst.Def).Error(), "the type for column name mismatch")
+
+	_, dst = copyTableEis()
+	dst.Def.Key.PartitionKeys = []string{"name"}
+	assert.Contains(t, dosa.CheckCopyTable(src.Def, dst.Def).Error(), "partition key mismatch")
+
+	_, dst = copyTableEis()
+	dst.Def.Key.ClusteringKeys[0].Descending = true
+	assert.Contains(t, dosa.CheckCopyTable(src.Def, dst.Def).Error(), "clustering key mismatch")
+}
+
+func TestCopyTableByScanning(t *testing.T) {
+	src, dst := copyTableEis()
+	conn := memory.NewConnector()
+	for i := 0; i < 450; i++ {
+		assert.NoError(t, conn.Upsert(context.TODO(), src, map[string]dosa.FieldValue{
+			"id":   fmt.Sprintf("id%d", i%7),
+			"seq":  int64(i),
+			"name": fmt.Sprintf("name%d", i),
+		}))
+	}
+
+	assert.NoError(t, dosa.CopyTableByScanning(context.TODO(), conn, src, dst, dosa.WithParallelism(3)))
+	rows, _, err := conn.Scan(context.TODO(), dst, dosa.All(), "", 1000)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 450)
+
+	// an empty table copies nothing
+	empty := &dosa.EntityInfo{Ref: src.Ref, Def: src.Def.Clone()}
+	empty.Def.Name = "empty"
+	assert.NoError(t, dosa.CopyTableByScanning(context.TODO(), conn, empty, dst, dosa.WithParallelism(0)))
+
+	// incompatible tables are rejected before anything is read
+	dst.Def.Columns = dst.Def.Columns[:2]
+	err = dosa.CopyTableByScanning(context.TODO(), conn, src, dst)
+	assert.Contains(t, err.Error(), "cannot copy \"src\" to \"dst\"")
+}
+
+func TestCopyTableByScanningErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	src, dst := copyTableEis()
+	rows := []map[string]dosa.FieldValue{{"id": "a", "seq": int64(1)}}
+
+	conn := mocks.NewMockConnector(ctrl)
+	conn.EXPECT().Scan(gomock.Any(), src, dosa.All(), "", gomock.Any()).Return(nil, "", errors.New("scan failed"))
+	err := dosa.CopyTableByScanning(context.TODO(), conn, src, dst)
+	assert.Contains(t, err.Error(), "scan failed")
+
+	conn.EXPECT().Scan(gomock.Any(), src, dosa.All(), "", gomock.Any()).Return(rows, "", nil)
+	conn.EXPECT().MultiUpsert(gomock.Any(), dst, rows).Return(nil, errors.New("write failed"))
+	err = dosa.CopyTableByScanning(context.TODO(), conn, src, dst)
+	assert.Contains(t, err.Error(), "write failed")
+
+	conn.EXPECT().Scan(gomock.Any(), src, dosa.All(), "", gomock.Any()).Return(rows, "", nil)
+	conn.EXPECT().MultiUpsert(gomock.Any(), dst, rows).Return([]error{errors.New("row failed")}, nil)
+	err = dosa.CopyTableByScanning(context.TODO(), conn, src, dst)
+	assert.Contains(t, err.Error(), "row failed")
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// deletePartitionChunkSize is the number of rows read and removed at a time
+// when deleting a partition by scanning
+const deletePartitionChunkSize = 100
+
+// PartitionKeyConditions returns Eq conditions selecting the partition given
+// by pk, which must have a value for every partition key column and no other
+// columns
+func PartitionKeyConditions(ed *EntityDefinition, pk map[string]FieldValue) (map[string][]*Condition, error) {
+	partitionKeys := ed.PartitionKeySet()
+	for name := range pk {
+		if _, ok := partitionKeys[name]; !ok {
+			return nil, errors.Errorf("column %q is not in the partition key of %q", name, ed.Name)
+		}
+	}
+	conditions := make(map[string][]*Condition, len(ed.Key.PartitionKeys))
+	for _, name := range ed.Key.PartitionKeys {
+		v, ok := pk[name]
+		if !ok {
+			return nil, errors.Errorf("partition key %q of %q is missing", name, ed.Name)
+		}
+		conditions[name] = []*Condition{{Op: Eq, Value: v}}
+	}
+	return conditions, nil
+}
+
+// DeletePartitionByScanning removes every row of the partition pk with conn,
+// for connectors whose backend can't delete a partition by itself. The keys
+// of the rows are read with Range and removed with MultiRemove, a chunk at a
+// time. Rows that are already gone are not an error; it stops at the first
+// other error, so the partition may be partly deleted.
+func DeletePartitionByScanning(ctx context.Context, conn Connector, ei *EntityInfo, pk map[string]FieldValue) error {
+	conditions, err := PartitionKeyConditions(ei.Def, pk)
+	if err != nil {
+		return err
+	}
+	keyColumns := make([]string, 0, len(ei.Def.KeySet()))
+	for name := range ei.Def.KeySet() {
+		keyColumns = append(keyColumns, name)
+	}
+	sort.Strings(keyColumns)
+
+	token := ""
+	for {
+		rows, next, err := conn.Range(ctx, ei, conditions, keyColumns, token, deletePartitionChunkSize)
+		if err != nil {
+			if ErrorIsNotFound(err) {
+				return nil
+			}
+			return errors.Wrapf(err, "range of %q failed", ei.Def.Name)
+		}
+		if len(rows) > 0 {
+			results, err := conn.MultiRemove(ctx, ei, rows)
+			if err != nil {
+				return errors.Wrapf(err, "remove from %q failed", ei.Def.Name)
+			}
+			for _, err := range results {
+				if err != nil && !ErrorIsNotFound(err) {
+					return errors.Wrapf(err, "remove from %q failed", ei.Def.Name)
+				}
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/mocks"
+)
+
+var deletePartitionEi = &dosa.EntityInfo{
+	Ref: &dosa.SchemaRef{Scope: "scope", NamePrefix: "prefix", EntityName: "logs"},
+	Def: &dosa.EntityDefinition{
+		Name: "logs",
+		Key:  &dosa.PrimaryKey{PartitionKeys: []string{"user", "day"}, ClusteringKeys: []*dosa.ClusteringKey{{Name: "seq"}}},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "user", Type: dosa.String},
+			{Name: "day", Type: dosa.Int32},
+			{Name: "seq", Type: dosa.Int64},
+			{Name: "line", Type: dosa.String},
+		},
+	},
+}
+
+func TestPartitionKeyConditions(t *testing.T) {
+	conditions, err := dosa.PartitionKeyConditions(deletePartitionEi.Def, map[string]dosa.FieldValue{"user": "a", "day": int32(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]*dosa.Condition{
+		"user": {{Op: dosa.Eq, Value: "a"}},
+		"day":  {{Op: dosa.Eq, Value: int32(3)}},
+	}, conditions)
+
+	_, err = dosa.PartitionKeyConditions(deletePartitionEi.Def, map[string]dosa.FieldValue{"user": "a"})
+	assert.EqualError(t, err, `partition key "day" of "logs" is missing`)
+	_, err = dosa.PartitionKeyConditions(deletePartitionEi.Def, map[string]dosa.FieldValue{"user": "a", "day": int32(3), "seq": int64(1)})
+	assert.EqualError(t, err, `column "seq" is not in the partition key of "logs"`)
+}
+
+func TestDeletePartitionByScanning(t *testing.T) {
+	conn := memory.NewConnector()
+	for i := 0; i < 250; i++ {
+		for _, user := range []string{"a", "b"} {
+			assert.NoError(t, conn.Upsert(context.TODO(), deletePartitionEi, map[string]dosa.FieldValue{
+				"user": user, "day": int32(1), "seq": int64(i), "line": "x",
+			}))
+		}
+	}
+
+	pk := map[string]dosa.FieldValue{"user": "a", "day": int32(1)}
+	assert.NoError(t, dosa.DeletePartitionByScanning(context.TODO(), conn, deletePartitionEi, pk))
+	rows, _, err := conn.Range(context.TODO(), deletePartitionEi, map[string][]*dosa.Condition{
+		"user": {{Op: dosa.Eq, Value: "a"}}, "day": {{Op: dosa.Eq, Value: int32(1)}},
+	}, dosa.All(), "", 300)
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+	rows, _, err = conn.Range(context.TODO(), deletePartitionEi, map[string][]*dosa.Condition{
+		"user": {{Op: dosa.Eq, Value: "b"}}, "day": {{Op: dosa.Eq, Value: int32(1)}},
+	}, dosa.All(), "", 300)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 250)
+
+	// an empty partition is fine
+	assert.NoError(t, dosa.DeletePartitionByScanning(context.TODO(), conn, deletePartitionEi, pk))
+}
+
+func TestDeletePartitionByScanning_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	conn := mocks.NewMockConnector(ctrl)
+	pk := map[string]dosa.FieldValue{"user": "a", "day": int32(1)}
+	row := map[string]dosa.FieldValue{"user": "a", "day": int32(1), "seq": int64(1)}
+
+	conn.EXPECT().Range(gomock.Any(), deletePartitionEi, gomock.Any(), []string{"day", "seq", "user"}, "", gomock.Any()).
+		Return(nil, "", errors.New("unavailable"))
+	err := dosa.DeletePartitionByScanning(context.TODO(), conn, deletePartitionEi, pk)
+	assert.EqualError(t, err, `range of "logs" failed: unavailable`)
+
+	// rows that are already gone are skipped, other failures stop the delete
+	conn.EXPECT().Range(gomock.Any(), deletePartitionEi, gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Return([]map[string]dosa.FieldValue{row, row}, "next", nil)
+	conn.EXPECT().MultiRemove(gomock.Any(), deletePartitionEi, gomock.Any()).
+		Return([]error{&dosa.ErrNotFound{}, errors.New("rejected")}, nil)
+	err = dosa.DeletePartitionByScanning(context.TODO(), conn, deletePartitionEi, pk)
+	assert.EqualError(t, err, `remove from "logs" failed: rejected`)
+
+	_, err = dosa.PartitionKeyConditions(deletePartitionEi.Def, nil)
+	assert.EqualError(t, dosa.DeletePartitionByScanning(context.TODO(), conn, deletePartitionEi, nil), err.Error())
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dosapb has the Go types of the entity messages of registry.proto.
+// They are written by hand rather than generated, since the repo doesn't
+// depend on protoc, and must be kept in sync with the .proto file; the
+// struct tags are what the proto package uses to encode them.
+package dosapb
+
+import "github.com/golang/protobuf/proto"
+
+// Type is the type of a column
+type Type int32
+
+// The values of Type, which are those of dosa.Type
+const (
+	Type_INVALID   Type = 0
+	Type_TUUID     Type = 1
+	Type_STRING    Type = 2
+	Type_INT32     Type = 3
+	Type_INT64     Type = 4
+	Type_DOUBLE    Type = 5
+	Type_BLOB      Type = 6
+	Type_TIMESTAMP Type = 7
+	Type_BOOL      Type = 8
+)
+
+// Type_name maps the values of Type to their names
+var Type_name = map[int32]string{
+	0: "INVALID",
+	1: "TUUID",
+	2: "STRING",
+	3: "INT32",
+	4: "INT64",
+	5: "DOUBLE",
+	6: "BLOB",
+	7: "TIMESTAMP",
+	8: "BOOL",
+}
+
+func (x Type) String() string {
+	return proto.EnumName(Type_name, int32(x))
+}
+
+// EntityDefinitionProto mirrors dosa.EntityDefinition
+type EntityDefinitionProto struct {
+	Name    string                           `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Columns []*ColumnDefinitionProto         `protobuf:"bytes,2,rep,name=columns" json:"columns,omitempty"`
+	Key     *PrimaryKeyProto                 `protobuf:"bytes,3,opt,name=key" json:"key,omitempty"`
+	Indexes map[string]*IndexDefinitionProto `protobuf:"bytes,4,rep,name=indexes" json:"indexes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Etl     string                           `protobuf:"bytes,5,opt,name=etl" json:"etl,omitempty"`
+}
+
+func (m *EntityDefinitionProto) Reset()         { *m = EntityDefinitionProto{} }
+func (m *EntityDefinitionProto) String() string { return proto.CompactTextString(m) }
+func (*EntityDefinitionProto) ProtoMessage()    {}
+
+// ColumnDefinitionProto mirrors dosa.ColumnDefinition
+type ColumnDefinitionProto struct {
+	Name             string            `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Type             Type              `protobuf:"varint,2,opt,name=type,enum=dosapb.Type" json:"type,omitempty"`
+	IsPointer        bool              `protobuf:"varint,3,opt,name=is_pointer,json=isPointer" json:"is_pointer,omitempty"`
+	Immutable        bool              `protobuf:"varint,4,opt,name=immutable" json:"immutable,omitempty"`
+	MaxLength        int32             `protobuf:"varint,5,opt,name=max_length,json=maxLength" json:"max_length,omitempty"`
+	EstimatedBytes   int32             `protobuf:"varint,6,opt,name=estimated_bytes,json=estimatedBytes" json:"estimated_bytes,omitempty"`
+	SensitiveData    bool              `protobuf:"varint,7,opt,name=sensitive_data,json=sensitiveData" json:"sensitive_data,omitempty"`
+	UniqueConstraint bool              `protobuf:"varint,8,opt,name=unique_constraint,json=uniqueConstraint" json:"unique_constraint,omitempty"`
+	Deprecated       bool              `protobuf:"varint,9,opt,name=deprecated" json:"deprecated,omitempty"`
+	DeprecatedSince  string            `protobuf:"bytes,10,opt,name=deprecated_since,json=deprecatedSince" json:"deprecated_since,omitempty"`
+	Tags             map[string]string `protobuf:"bytes,11,rep,name=tags" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OrderedBy        string            `protobuf:"bytes,12,opt,name=ordered_by,json=orderedBy" json:"ordered_by,omitempty"`
+}
+
+func (m *ColumnDefinitionProto) Reset()         { *m = ColumnDefinitionProto{} }
+func (m *ColumnDefinitionProto) String() string { return proto.CompactTextString(m) }
+func (*ColumnDefinitionProto) ProtoMessage()    {}
+
+// ClusteringKeyProto mirrors dosa.ClusteringKey
+type ClusteringKeyProto struct {
+	Name       string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Descending bool   `protobuf:"varint,2,opt,name=descending" json:"descending,omitempty"`
+}
+
+func (m *ClusteringKeyProto) Reset()         { *m = ClusteringKeyProto{} }
+func (m *ClusteringKeyProto) String() string { return proto.CompactTextString(m) }
+func (*ClusteringKeyProto) ProtoMessage()    {}
+
+// PrimaryKeyProto mirrors dosa.PrimaryKey
+type PrimaryKeyProto struct {
+	PartitionKeys  []string              `protobuf:"bytes,1,rep,name=partition_keys,json=partitionKeys" json:"partition_keys,omitempty"`
+	ClusteringKeys []*ClusteringKeyProto `protobuf:"bytes,2,rep,name=clustering_keys,json=clusteringKeys" json:"clustering_keys,omitempty"`
+}
+
+func (m *PrimaryKeyProto) Reset()         { *m = PrimaryKeyProto{} }
+func (m *PrimaryKeyProto) String() string { return proto.CompactTextString(m) }
+func (*PrimaryKeyProto) ProtoMessage()    {}
+
+// IndexDefinitionProto mirrors dosa.IndexDefinition
+type IndexDefinitionProto struct {
+	Key        *PrimaryKeyProto `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	TtlSeconds int64            `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds" json:"ttl_seconds,omitempty"`
+}
+
+func (m *IndexDefinitionProto) Reset()         { *m = IndexDefinitionProto{} }
+func (m *IndexDefinitionProto) String() string { return proto.CompactTextString(m) }
+func (*IndexDefinitionProto) ProtoMessage()    {}
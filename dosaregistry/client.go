@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosaregistry
+
+import (
+	"context"
+
+	"github.com/uber-go/dosa"
+)
+
+// Client gives the registered definitions as tables, like a local
+// dosa.Registry. The tables it returns are built from the definitions only,
+// with dosa.TableFromDefinition: their field names are the column names, and
+// their TTL is dosa.NoTTL().
+type Client struct {
+	service Service
+}
+
+// NewClient returns a Client of the service
+func NewClient(service Service) *Client {
+	return &Client{service: service}
+}
+
+// Register registers the definition of the table
+func (c *Client) Register(ctx context.Context, t *dosa.Table) error {
+	return c.service.RegisterEntity(ctx, &t.EntityDefinition)
+}
+
+// Lookup returns the table registered for the entity name; ok is false if
+// there is none
+func (c *Client) Lookup(ctx context.Context, name string) (t *dosa.Table, ok bool, err error) {
+	ed, err := c.service.LookupEntity(ctx, name)
+	if dosa.ErrorIsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	t, err = dosa.TableFromDefinition(ed)
+	if err != nil {
+		return nil, false, err
+	}
+	return t, true, nil
+}
+
+// All returns the registered tables
+func (c *Client) All(ctx context.Context) ([]*dosa.Table, error) {
+	var tables []*dosa.Table
+	err := c.service.ListEntities(ctx, func(ed *dosa.EntityDefinition) error {
+		t, err := dosa.TableFromDefinition(ed)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// Load returns a local registry of all the registered tables, for code that
+// takes a *dosa.Registry
+func (c *Client) Load(ctx context.Context) (*dosa.Registry, error) {
+	tables, err := c.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := dosa.NewRegistry()
+	for _, t := range tables {
+		if err := r.Register(t); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dosaregistry implements the SchemaRegistry service declared in
+// dosapb/registry.proto, through which the services of a cluster share their
+// entity definitions. Server stores the definitions with any dosa.Connector,
+// and Client reads and writes them through a Service, which is either a
+// Server in the same process or a stub calling a remote one.
+package dosaregistry
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// Service has the RPCs of the SchemaRegistry service. ListEntities calls fn
+// once per registered definition, in the order they are streamed, and stops
+// at the first error fn returns.
+type Service interface {
+	RegisterEntity(ctx context.Context, ed *dosa.EntityDefinition) error
+	LookupEntity(ctx context.Context, name string) (*dosa.EntityDefinition, error)
+	ListEntities(ctx context.Context, fn func(*dosa.EntityDefinition) error) error
+	CheckCompatibility(ctx context.Context, old, new *dosa.EntityDefinition) (*CompatibilityResult, error)
+}
+
+// CompatibilityResult tells whether a definition can replace another one.
+// Reason is empty when they are compatible.
+type CompatibilityResult struct {
+	Compatible bool
+	Reason     string
+}
+
+// ErrIncompatible is returned by RegisterEntity when the definition cannot
+// replace the one already registered under its name
+type ErrIncompatible struct {
+	Entity string
+	Reason string
+}
+
+// Error satisfies the error interface
+func (e *ErrIncompatible) Error() string {
+	return "entity " + e.Entity + " is not compatible with its registered definition: " + e.Reason
+}
+
+// ErrorIsIncompatible checks if the error is an ErrIncompatible
+func ErrorIsIncompatible(err error) bool {
+	_, ok := errors.Cause(err).(*ErrIncompatible)
+	return ok
+}
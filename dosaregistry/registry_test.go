@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosaregistry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+	"github.com/uber-go/dosa/dosaregistry"
+)
+
+var ctx = context.Background()
+
+func userDefinition() *dosa.EntityDefinition {
+	return &dosa.EntityDefinition{
+		Name: "users",
+		Key:  &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.TUUID},
+			{Name: "name", Type: dosa.String},
+		},
+		Indexes: map[string]*dosa.IndexDefinition{},
+		ETL:     dosa.EtlOff,
+	}
+}
+
+func newServer(t *testing.T) *dosaregistry.Server {
+	s := dosaregistry.NewServer(memory.NewConnector(), "scope", "prefix")
+	assert.NoError(t, s.Initialize(ctx))
+	return s
+}
+
+func TestServer_RegisterAndLookup(t *testing.T) {
+	s := newServer(t)
+
+	_, err := s.LookupEntity(ctx, "users")
+	assert.True(t, dosa.ErrorIsNotFound(err))
+
+	ed := userDefinition()
+	assert.NoError(t, s.RegisterEntity(ctx, ed))
+	found, err := s.LookupEntity(ctx, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, ed, found)
+
+	// compatible changes replace the definition
+	ed.Columns = append(ed.Columns, &dosa.ColumnDefinition{Name: "email", Type: dosa.String, IsPointer: true})
+	assert.NoError(t, s.RegisterEntity(ctx, ed))
+	found, err = s.LookupEntity(ctx, "users")
+	assert.NoError(t, err)
+	assert.Len(t, found.Columns, 3)
+
+	// incompatible ones are rejected
+	changed := userDefinition()
+	changed.Columns[1].Type = dosa.Int64
+	changed.Columns = append(changed.Columns, ed.Columns[2])
+	err = s.RegisterEntity(ctx, changed)
+	assert.True(t, dosaregistry.ErrorIsIncompatible(err))
+	assert.Contains(t, err.Error(), "the type for column name mismatch")
+
+	// and so are invalid definitions
+	assert.Error(t, s.RegisterEntity(ctx, &dosa.EntityDefinition{Name: "bad"}))
+}
+
+func TestServer_ListEntities(t *testing.T) {
+	s := newServer(t)
+	names := map[string]bool{}
+	for _, name := range []string{"a", "b", "c"} {
+		ed := userDefinition()
+		ed.Name = name
+		assert.NoError(t, s.RegisterEntity(ctx, ed))
+		names[name] = true
+	}
+
+	listed := map[string]bool{}
+	assert.NoError(t, s.ListEntities(ctx, func(ed *dosa.EntityDefinition) error {
+		listed[ed.Name] = true
+		return nil
+	}))
+	assert.Equal(t, names, listed)
+
+	// errors of fn stop the listing
+	calls := 0
+	err := s.ListEntities(ctx, func(*dosa.EntityDefinition) error {
+		calls++
+		return assert.AnError
+	})
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestServer_CheckCompatibility(t *testing.T) {
+	s := newServer(t)
+	old := userDefinition()
+
+	result, err := s.CheckCompatibility(ctx, old, userDefinition())
+	assert.NoError(t, err)
+	assert.Equal(t, &dosaregistry.CompatibilityResult{Compatible: true}, result)
+
+	changed := userDefinition()
+	changed.Key.PartitionKeys = []string{"name"}
+	result, err = s.CheckCompatibility(ctx, old, changed)
+	assert.NoError(t, err)
+	assert.False(t, result.Compatible)
+	assert.Contains(t, result.Reason, "partition key mismatch")
+
+	_, err = s.CheckCompatibility(ctx, old, nil)
+	assert.Error(t, err)
+}
+
+func TestClient(t *testing.T) {
+	c := dosaregistry.NewClient(newServer(t))
+
+	_, ok, err := c.Lookup(ctx, "users")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Register(ctx, &dosa.Table{EntityDefinition: *userDefinition()}))
+	table, ok, err := c.Lookup(ctx, "users")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, *userDefinition(), table.EntityDefinition)
+	assert.Equal(t, "name", table.ColToField["name"])
+
+	// the tables keep everything the definitions hold
+	deprecated := userDefinition()
+	deprecated.Name = "old_users"
+	deprecated.Columns[1].Deprecated = true
+	assert.NoError(t, c.Register(ctx, &dosa.Table{EntityDefinition: *deprecated}))
+	table, ok, err = c.Lookup(ctx, "old_users")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"name"}, table.DeprecatedColumns)
+	table, ok, err = c.Lookup(ctx, "users")
+	assert.NoError(t, err)
+
+	r, err := c.Load(ctx)
+	assert.NoError(t, err)
+	found, ok := r.Lookup("users")
+	assert.True(t, ok)
+	assert.Equal(t, table, found)
+}
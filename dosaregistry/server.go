@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosaregistry
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+)
+
+// EntityName is the entity in which Server stores the definitions, one row
+// per registered entity
+const EntityName = "dosa_schema_registry"
+
+// listPageSize is the number of definitions ListEntities scans at once
+const listPageSize = 100
+
+// Server implements Service on top of a connector
+type Server struct {
+	connector dosa.Connector
+	ei        *dosa.EntityInfo
+}
+
+// Definition returns the definition of the EntityName table, which must be
+// upserted in the scope of a Server before it is initialized
+func Definition() *dosa.EntityDefinition {
+	return &dosa.EntityDefinition{
+		Name: EntityName,
+		Key:  &dosa.PrimaryKey{PartitionKeys: []string{"name"}},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "name", Type: dosa.String},
+			{Name: "definition", Type: dosa.Blob},
+		},
+		Indexes: map[string]*dosa.IndexDefinition{},
+		ETL:     dosa.EtlOff,
+	}
+}
+
+// NewServer returns a Server that stores the definitions in the EntityName
+// table of the scope and name prefix. Initialize must be called before the
+// other methods.
+func NewServer(connector dosa.Connector, scope, namePrefix string) *Server {
+	return &Server{
+		connector: connector,
+		ei: &dosa.EntityInfo{
+			Ref: &dosa.SchemaRef{Scope: scope, NamePrefix: namePrefix, EntityName: EntityName},
+			Def: Definition(),
+		},
+	}
+}
+
+// Initialize checks the schema of the EntityName table, like
+// dosa.Client.Initialize does for the entities of a client
+func (s *Server) Initialize(ctx context.Context) error {
+	version, err := s.connector.CheckSchema(ctx, s.ei.Ref.Scope, s.ei.Ref.NamePrefix, []*dosa.EntityDefinition{s.ei.Def})
+	if err != nil {
+		return errors.Wrap(err, "CheckSchema failed")
+	}
+	s.ei.Ref.Version = version
+	return nil
+}
+
+// RegisterEntity stores the definition, replacing the registered definition
+// of the same name if CheckCompatibility accepts the change; it fails with
+// ErrIncompatible otherwise. Two concurrent registrations of the same entity
+// are not checked against each other and the last one wins.
+func (s *Server) RegisterEntity(ctx context.Context, ed *dosa.EntityDefinition) error {
+	if err := ed.EnsureValid(); err != nil {
+		return err
+	}
+	old, err := s.LookupEntity(ctx, ed.Name)
+	switch {
+	case dosa.ErrorIsNotFound(err):
+	case err != nil:
+		return err
+	default:
+		result, err := s.CheckCompatibility(ctx, old, ed)
+		if err != nil {
+			return err
+		}
+		if !result.Compatible {
+			return &ErrIncompatible{Entity: ed.Name, Reason: result.Reason}
+		}
+	}
+
+	data, err := ed.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return s.connector.Upsert(ctx, s.ei, map[string]dosa.FieldValue{"name": ed.Name, "definition": data})
+}
+
+// LookupEntity returns the registered definition of the entity, or
+// dosa.ErrNotFound
+func (s *Server) LookupEntity(ctx context.Context, name string) (*dosa.EntityDefinition, error) {
+	values, err := s.connector.Read(ctx, s.ei, map[string]dosa.FieldValue{"name": name}, dosa.All())
+	if err != nil {
+		return nil, err
+	}
+	return decode(values)
+}
+
+// ListEntities calls fn with every registered definition, in the order the
+// connector scans them
+func (s *Server) ListEntities(ctx context.Context, fn func(*dosa.EntityDefinition) error) error {
+	token := ""
+	for {
+		rows, next, err := s.connector.Scan(ctx, s.ei, dosa.All(), token, listPageSize)
+		if dosa.ErrorIsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, values := range rows {
+			ed, err := decode(values)
+			if err != nil {
+				return err
+			}
+			if err := fn(ed); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
+
+// CheckCompatibility reports whether new can replace old, that is whether
+// it keeps the name, the primary key and the types of the columns of old, and
+// only removes deprecated columns. It doesn't look at the registered
+// definitions.
+func (s *Server) CheckCompatibility(_ context.Context, old, new *dosa.EntityDefinition) (*CompatibilityResult, error) {
+	if old == nil || new == nil {
+		return nil, errors.New("both entity definitions are required")
+	}
+	if err := new.CanBeUpsertedOn(old); err != nil {
+		return &CompatibilityResult{Reason: err.Error()}, nil
+	}
+	return &CompatibilityResult{Compatible: true}, nil
+}
+
+// decode returns the definition stored in a row of the EntityName table
+func decode(values map[string]dosa.FieldValue) (*dosa.EntityDefinition, error) {
+	data, _ := values["definition"].([]byte)
+	ed := &dosa.EntityDefinition{}
+	if err := ed.UnmarshalBinary(data); err != nil {
+		return nil, errors.Wrapf(err, "invalid definition stored for entity %v", values["name"])
+	}
+	return ed, nil
+}
@@ -22,9 +22,11 @@ package dosa
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"reflect"
+	"sort"
 
 	"time"
 
@@ -39,6 +41,163 @@ type Table struct {
 	ColToField map[string]string // map from column name -> field name
 	FieldToCol map[string]string // map from field name -> column name
 	TTL        time.Duration
+
+	// GoPackagePath is the import path of the package that declares the
+	// struct, for generated code that imports it. It is set from the
+	// type by TableFromInstance and TableFromType, and by FindEntities; see
+	// FindEntities for its accuracy.
+	GoPackagePath string
+
+	// SourcePosition is where FindEntities found the struct, as file:line:column,
+	// followed by the file's build constraint in parentheses if it has one
+	SourcePosition string
+
+	// DeprecatedColumns lists the columns tagged deprecated, in declaration order
+	DeprecatedColumns []string
+
+	reflectType reflect.Type // the struct type, only set by TableFromType
+}
+
+// ReflectType returns the Go struct type of the entity, for creating new
+// instances and setting fields dynamically. It is only known for tables
+// created by TableFromType, and is nil otherwise.
+func (t *Table) ReflectType() reflect.Type {
+	return t.reflectType
+}
+
+// FullyQualifiedName returns the physical name of the table of the entity in
+// the scope and the name prefix, built by DefaultNameFormatter. Use a
+// NameFormatter directly for a different format.
+func (t *Table) FullyQualifiedName(scope, prefix string) string {
+	return DefaultNameFormatter.Format(scope, prefix, t.Name)
+}
+
+// AcceptsType returns whether v has a Go type the column col can hold: the
+// Go type of its DOSA type, e.g. int64 for Int64 and UUID for TUUID, and for
+// nullable columns also a pointer to it or nil. It returns false for unknown
+// columns.
+func (t *Table) AcceptsType(col string, v FieldValue) bool {
+	cd := t.FindColumnDefinition(col)
+	if cd == nil {
+		return false
+	}
+	if v == nil {
+		return cd.IsPointer
+	}
+	typ, isPointer, err := typify(reflect.TypeOf(v))
+	if err != nil || typ != cd.Type {
+		return false
+	}
+	return cd.IsPointer || !isPointer
+}
+
+// AcceptsValues checks every value with AcceptsType, and returns an error
+// listing all of the columns that are unknown or have values of a wrong type
+func (t *Table) AcceptsValues(vals map[string]FieldValue) error {
+	cols := make([]string, 0, len(vals))
+	for col := range vals {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	var problems []string
+	for _, col := range cols {
+		cd := t.FindColumnDefinition(col)
+		switch {
+		case cd == nil:
+			problems = append(problems, fmt.Sprintf("unknown column %q", col))
+		case !t.AcceptsType(col, vals[col]):
+			problems = append(problems, fmt.Sprintf("column %q of type %s cannot hold a %T", col, cd.Type.GoType(), vals[col]))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("invalid values for %q: %s", t.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// FieldMeta is the metadata of one field of an entity struct: the definition
+// of its column, and the Go field name and kind
+type FieldMeta struct {
+	ColumnDefinition
+	// Field is the name of the Go struct field
+	Field string
+	// Kind is the kind of the Go type of the column, e.g. reflect.Int64 for
+	// Int64, reflect.String for TUUID and reflect.Struct for Timestamp. The
+	// fields of nullable columns (IsPointer) are pointers to that type.
+	Kind reflect.Kind
+}
+
+// goKinds maps each type to the kind of its Go type
+var goKinds = map[Type]reflect.Kind{
+	TUUID:     reflect.String,
+	String:    reflect.String,
+	Int32:     reflect.Int32,
+	Int64:     reflect.Int64,
+	Double:    reflect.Float64,
+	Blob:      reflect.Slice,
+	Timestamp: reflect.Struct,
+	Bool:      reflect.Bool,
+}
+
+// FieldMapping returns the metadata of every field of the entity, keyed by Go
+// field name, so callers don't have to look up FieldToCol and then search the
+// columns. Columns without a field in FieldToCol are left out.
+func (t *Table) FieldMapping() map[string]FieldMeta {
+	mapping := make(map[string]FieldMeta, len(t.FieldToCol))
+	for field, col := range t.FieldToCol {
+		cd := t.FindColumnDefinition(col)
+		if cd == nil {
+			continue
+		}
+		mapping[field] = FieldMeta{ColumnDefinition: *cd, Field: field, Kind: goKinds[cd.Type]}
+	}
+	return mapping
+}
+
+// NewTable builds a table from a logical name, its columns and its primary
+// key, for entities that are defined at runtime rather than by a Go struct.
+// Columns map to fields of the same name, and the table has no TTL.
+func NewTable(name string, columns []*ColumnDefinition, key *PrimaryKey) (*Table, error) {
+	normalized, err := NormalizeName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid table name")
+	}
+	return TableFromDefinition(&EntityDefinition{
+		Name:    normalized,
+		Key:     key,
+		Columns: columns,
+		Indexes: map[string]*IndexDefinition{},
+	})
+}
+
+// TableFromDefinition builds a table from a whole entity definition, such as
+// one read from a schema registry, the way NewTable does: columns map to
+// fields of the same name, and the table has no TTL. The table shares the
+// columns, key and indexes of the definition.
+func TableFromDefinition(ed *EntityDefinition) (*Table, error) {
+	if ed == nil {
+		return nil, errors.New("nil entity definition")
+	}
+	t := &Table{
+		EntityDefinition: *ed,
+		StructName:       ed.Name,
+		ColToField:       make(map[string]string, len(ed.Columns)),
+		FieldToCol:       make(map[string]string, len(ed.Columns)),
+		TTL:              NoTTL(),
+	}
+	for _, col := range ed.Columns {
+		if col == nil {
+			return nil, errors.Errorf("nil column definition in table %q", ed.Name)
+		}
+		t.ColToField[col.Name] = col.Name
+		t.FieldToCol[col.Name] = col.Name
+	}
+	t.DeprecatedColumns = deprecatedColumns(t)
+	if err := t.EnsureValid(); err != nil {
+		return nil, errors.Wrapf(err, "invalid table %q", ed.Name)
+	}
+	return t, nil
 }
 
 // ClusteringKey stores name and ordering of a clustering key
@@ -133,6 +292,8 @@ func formatPartitionKeys(keys []string) string {
 // String method produces the following output:
 // for multiple partition keys: ((partition-key, ...), clustering-key ASC/DESC, ...)
 // for one partition key: (partition-key, clustering-key ASC/DESC, ...)
+// This is the syntax of the primaryKey tag, which parses it back into an
+// equal PrimaryKey.
 func (pk PrimaryKey) String() string {
 	var b bytes.Buffer
 	b.WriteByte('(')
@@ -150,6 +311,28 @@ type ColumnDefinition struct {
 	Name      string // normalized column name
 	Type      Type
 	IsPointer bool // used by client only to indicate whether this field is pointer
+	Immutable bool // value cannot change once written, see connectors/immutable
+	MaxLength int  // maximum length in bytes of String and Blob values, 0 for no limit
+	// EstimatedBytes is the average size of the values, used by EstimatedRowSizeBytes
+	// instead of the default for the type when it is not 0
+	EstimatedBytes int
+	// SensitiveData marks columns holding personal or confidential data (set with the
+	// sensitive tag); their values are redacted from log output, see Redact
+	SensitiveData bool
+	// UniqueConstraint marks columns whose non-null values must differ between rows (set
+	// with the unique tag). The column needs an index with it as the only partition key,
+	// which connectors/unique reads to enforce it.
+	UniqueConstraint bool
+	// Deprecated marks columns that are kept only for existing data (set with the
+	// deprecated or deprecated_since=version tag); writes still succeed but
+	// connectors/validating logs a warning, and the column may later be removed
+	Deprecated bool
+	// DeprecatedSince is the version given by the deprecated_since tag, if any
+	DeprecatedSince string
+	// OrderedBy is the preferred on-disk order of the column (set with the ordered_asc
+	// or ordered_desc tag), nil for none. It is only allowed on the columns of an index,
+	// where connectors turn it into a storage hint, see schema/cql
+	OrderedBy *SortDirection
 	// TODO: change as need to support tags like pii, etc
 	// currently it's in the form of a map from tag name to (optional) tag value
 	Tags map[string]string
@@ -159,20 +342,40 @@ type ColumnDefinition struct {
 func (cd *ColumnDefinition) Clone() *ColumnDefinition {
 	// TODO: clone tag
 	return &ColumnDefinition{
-		Name: cd.Name,
-		Type: cd.Type,
+		Name:             cd.Name,
+		Type:             cd.Type,
+		Immutable:        cd.Immutable,
+		MaxLength:        cd.MaxLength,
+		EstimatedBytes:   cd.EstimatedBytes,
+		SensitiveData:    cd.SensitiveData,
+		UniqueConstraint: cd.UniqueConstraint,
+		Deprecated:       cd.Deprecated,
+		DeprecatedSince:  cd.DeprecatedSince,
+		OrderedBy:        cloneSortDirection(cd.OrderedBy),
 	}
 }
 
+func cloneSortDirection(d *SortDirection) *SortDirection {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	return &clone
+}
+
 // IndexDefinition stores information about a DOSA entity's index
 type IndexDefinition struct {
 	Key *PrimaryKey
+	// TTL is how long an index entry lives after its row is written; the
+	// row itself keeps the TTL of the entity. Zero means entries don't expire.
+	TTL time.Duration
 }
 
 // Clone returns a deep copy of IndexDefinition
 func (id *IndexDefinition) Clone() *IndexDefinition {
 	return &IndexDefinition{
 		Key: id.Key.Clone(),
+		TTL: id.TTL,
 	}
 }
 
@@ -189,9 +392,11 @@ type EntityDefinition struct {
 func (e *EntityDefinition) Clone() *EntityDefinition {
 	newEd := &EntityDefinition{
 		Name: e.Name,
-		Key:  e.Key.Clone(),
 		ETL:  e.ETL,
 	}
+	if e.Key != nil {
+		newEd.Key = e.Key.Clone()
+	}
 
 	if e.Columns != nil {
 		newEd.Columns = make([]*ColumnDefinition, len(e.Columns))
@@ -240,6 +445,12 @@ func (e *EntityDefinition) EnsureValid() error {
 		if c.Type == Invalid {
 			return errors.Errorf("invalid type for column: %q", c.Name)
 		}
+		if c.MaxLength < 0 {
+			return errors.Errorf("negative max length for column: %q", c.Name)
+		}
+		if c.MaxLength > 0 && c.Type != String && c.Type != Blob {
+			return errors.Errorf("max length can only be set on String and Blob columns: %q", c.Name)
+		}
 		columnNamesSeen[c.Name] = struct{}{}
 	}
 
@@ -253,6 +464,9 @@ func (e *EntityDefinition) EnsureValid() error {
 
 	keyNamesSeen := map[string]struct{}{}
 	for _, p := range e.Key.PartitionKeys {
+		if fields := strings.Fields(p); len(fields) == 2 && isSortDirection(fields[1]) {
+			return errors.Errorf("sort direction can only be specified for clustering keys: %q", p)
+		}
 		if _, ok := columnNamesSeen[p]; !ok {
 			return errors.Errorf("partition key does not refer to a column: %q", p)
 		}
@@ -281,6 +495,13 @@ func (e *EntityDefinition) EnsureValid() error {
 		return err
 	}
 
+	// key columns can never change, so marking them immutable is meaningless
+	for _, c := range e.Columns {
+		if _, ok := keyNamesSeen[c.Name]; ok && c.Immutable {
+			return errors.Errorf("key column cannot be immutable: %q", c.Name)
+		}
+	}
+
 	// validate index
 	for indexName, index := range e.Indexes {
 		if err := IsValidName(indexName); err != nil {
@@ -326,9 +547,57 @@ func (e *EntityDefinition) EnsureValid() error {
 		}
 	}
 
+	// unique columns are enforced by reading the index on them
+	for _, c := range e.Columns {
+		if c.UniqueConstraint && e.UniqueIndex(c.Name) == "" {
+			return errors.Errorf("unique column %q needs an index with it as the only partition key", c.Name)
+		}
+	}
+
+	// the on-disk order of a column only matters where it is part of an index
+	for _, c := range e.Columns {
+		if c.OrderedBy != nil && !e.isIndexColumn(c.Name) {
+			return errors.Errorf("ordered column %q is not part of an index", c.Name)
+		}
+	}
+
 	return nil
 }
 
+// isIndexColumn tells whether the column is part of the key of an index
+func (e *EntityDefinition) isIndexColumn(name string) bool {
+	for _, index := range e.Indexes {
+		if _, ok := index.Key.PrimaryKeySet()[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns the problems with the definition that EnsureValid allows
+// but that are likely to be mistakes. Currently these are String partition key
+// columns without a MaxLength, since unbounded partition keys can cause
+// hotspots in the backend, and indexes with a TTL whose key is the primary
+// key, as their entries expire while the rows they duplicate stay.
+func (e *EntityDefinition) Warnings() []string {
+	if e == nil || e.Key == nil {
+		return nil
+	}
+	columns := e.ColumnMap()
+	var warnings []string
+	for _, p := range e.Key.PartitionKeys {
+		if c, ok := columns[p]; ok && c.Type == String && c.MaxLength == 0 {
+			warnings = append(warnings, fmt.Sprintf("string partition key %q of %q has no max length", p, e.Name))
+		}
+	}
+	for _, name := range sortedIndexNames(e.Indexes) {
+		if index := e.Indexes[name]; index.TTL > 0 && index.Key.String() == e.Key.String() {
+			warnings = append(warnings, fmt.Sprintf("index %q of %q has a TTL but its key is the primary key", name, e.Name))
+		}
+	}
+	return warnings
+}
+
 func (e *EntityDefinition) ensureNonNullablePrimaryKeys() error {
 	columns := e.ColumnMap()
 
@@ -347,6 +616,53 @@ func (e *EntityDefinition) ensureNonNullablePrimaryKeys() error {
 	return nil
 }
 
+// ImmutableColumns returns the names of all columns marked as immutable.
+func (e *EntityDefinition) ImmutableColumns() []string {
+	var names []string
+	for _, c := range e.Columns {
+		if c.Immutable {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// UniqueColumns returns the names of all columns with a unique constraint.
+func (e *EntityDefinition) UniqueColumns() []string {
+	var names []string
+	for _, c := range e.Columns {
+		if c.UniqueConstraint {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// UniqueIndex returns the name of the first index, in name order, that has
+// column as its only partition key, or "" if there is none. A Range with an Eq
+// condition on the column reads that index, so it finds the rows holding a
+// value of a unique column.
+func (e *EntityDefinition) UniqueIndex(column string) string {
+	for _, name := range sortedIndexNames(e.Indexes) {
+		key := e.Indexes[name].Key
+		if key != nil && len(key.PartitionKeys) == 1 && key.PartitionKeys[0] == column {
+			return name
+		}
+	}
+	return ""
+}
+
+// SensitiveColumns returns the names of all columns marked as sensitive.
+func (e *EntityDefinition) SensitiveColumns() []string {
+	var names []string
+	for _, c := range e.Columns {
+		if c.SensitiveData {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
 // ColumnTypes returns a map of column name to column type for all columns.
 func (e *EntityDefinition) ColumnTypes() map[string]Type {
 	m := make(map[string]Type)
@@ -374,6 +690,17 @@ func (e *EntityDefinition) PartitionKeySet() map[string]struct{} {
 	return m
 }
 
+// RowIDColumn is the primary key of entities declared with primaryKey=(), which
+// have no key of their own. The client sets it to a new UUID on every Upsert,
+// so each upsert adds a row; these entities can only be read by scanning.
+const RowIDColumn = "dosa_row_id"
+
+// ScanOnly returns true for entities declared with primaryKey=(), whose only
+// key is the RowIDColumn generated by the client
+func (e *EntityDefinition) ScanOnly() bool {
+	return e.Key != nil && len(e.Key.PartitionKeys) == 1 && e.Key.PartitionKeys[0] == RowIDColumn && len(e.Key.ClusteringKeys) == 0
+}
+
 // KeySet returns a set of all keys, including partition keys and clustering keys.
 func (e *EntityDefinition) KeySet() map[string]struct{} {
 	m := e.Key.ClusteringKeySet()
@@ -410,13 +737,16 @@ func (e *EntityDefinition) CanBeUpsertedOn(older *EntityDefinition) error {
 			return errors.Errorf("clustering key mismatch: (%v vs %v)", cksNewer, cksOlder)
 		}
 	}
-	// only allow to add new columns
+	// only allow to add new columns, or to remove deprecated ones
 	colsMapNewer := newer.ColumnTypes()
 	colsMapOlder := older.ColumnTypes()
 
 	for name, colTypeOlder := range colsMapOlder {
 		colTypeNewer, ok := colsMapNewer[name]
 		if !ok {
+			if cd := older.FindColumnDefinition(name); cd != nil && cd.Deprecated {
+				continue
+			}
 			return errors.Errorf("the column %s in old entity %s but not in new entity", name, older.Name)
 		}
 		if colTypeNewer != colTypeOlder {
@@ -451,6 +781,163 @@ func (e *EntityDefinition) CanBeUpsertedOn(older *EntityDefinition) error {
 	return nil
 }
 
+// Differences lists what differs between the entity definition and another
+// definition of the same entity, such as the live schema returned by
+// Connector.DescribeTable: the primary key, missing or extra columns, column
+// types and indexes. It returns nil when they match.
+func (e *EntityDefinition) Differences(live *EntityDefinition) []string {
+	var diffs []string
+	if e.Name != live.Name {
+		diffs = append(diffs, fmt.Sprintf("name is %q, live name is %q", e.Name, live.Name))
+	}
+	if e.Key.String() != live.Key.String() {
+		diffs = append(diffs, fmt.Sprintf("primary key is %s, live primary key is %s", e.Key, live.Key))
+	}
+
+	liveTypes := live.ColumnTypes()
+	for _, cd := range e.Columns {
+		typ, ok := liveTypes[cd.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("column %q is missing from the live schema", cd.Name))
+			continue
+		}
+		if typ != cd.Type {
+			diffs = append(diffs, fmt.Sprintf("column %q is %v, live column is %v", cd.Name, cd.Type, typ))
+		}
+	}
+	types := e.ColumnTypes()
+	for _, cd := range live.Columns {
+		if _, ok := types[cd.Name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("live column %q is not defined", cd.Name))
+		}
+	}
+
+	for _, name := range sortedIndexNames(e.Indexes) {
+		liveIndex, ok := live.Indexes[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("index %q is missing from the live schema", name))
+			continue
+		}
+		if e.Indexes[name].Key.String() != liveIndex.Key.String() {
+			diffs = append(diffs, fmt.Sprintf("index %q key is %s, live key is %s", name, e.Indexes[name].Key, liveIndex.Key))
+		}
+	}
+	for _, name := range sortedIndexNames(live.Indexes) {
+		if _, ok := e.Indexes[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("live index %q is not defined", name))
+		}
+	}
+	return diffs
+}
+
+// SubsetOf returns true if every column of the entity definition exists in
+// other with the same type, e.g. when checking a projection against the
+// full entity. Keys and indexes are not compared.
+func (e *EntityDefinition) SubsetOf(other *EntityDefinition) bool {
+	types := other.ColumnTypes()
+	for _, cd := range e.Columns {
+		if typ, ok := types[cd.Name]; !ok || typ != cd.Type {
+			return false
+		}
+	}
+	return true
+}
+
+// Projection returns a new entity definition with only the given columns and
+// the primary key columns, which are always required. Columns keep the order
+// of the entity definition, and only the indexes whose keys are fully part of
+// the projection are kept.
+func (e *EntityDefinition) Projection(columns []string) (*EntityDefinition, error) {
+	keep := e.KeySet()
+	for _, name := range columns {
+		if e.FindColumnDefinition(name) == nil {
+			return nil, errors.Errorf("column %q not found in entity %q", name, e.Name)
+		}
+		keep[name] = struct{}{}
+	}
+
+	clone := e.Clone()
+	projected := make([]*ColumnDefinition, 0, len(keep))
+	for _, cd := range clone.Columns {
+		if _, ok := keep[cd.Name]; ok {
+			projected = append(projected, cd)
+		}
+	}
+	clone.Columns = projected
+	for name, index := range clone.Indexes {
+		for col := range index.Key.PrimaryKeySet() {
+			if _, ok := keep[col]; !ok {
+				delete(clone.Indexes, name)
+				break
+			}
+		}
+	}
+	return clone, nil
+}
+
+func sortedIndexNames(indexes map[string]*IndexDefinition) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Merge returns a new entity definition with the columns and indexes of both definitions,
+// for entities whose columns are owned by more than one schema. The names and primary keys
+// must be the same, and a column or index defined by both must have the same type or key.
+// The receiver's definition of a shared column is kept. ETL is on if it is on in either.
+func (e *EntityDefinition) Merge(other *EntityDefinition) (*EntityDefinition, error) {
+	if e.Name != other.Name {
+		return nil, errors.Errorf("entity name mismatch: (%s vs %s)", e.Name, other.Name)
+	}
+	if !reflect.DeepEqual(e.Key.PartitionKeys, other.Key.PartitionKeys) {
+		return nil, errors.Errorf("partition key mismatch: (%v vs %v)", e.Key.PartitionKeys, other.Key.PartitionKeys)
+	}
+	if len(e.Key.ClusteringKeys) != 0 || len(other.Key.ClusteringKeys) != 0 {
+		if !reflect.DeepEqual(e.Key.ClusteringKeys, other.Key.ClusteringKeys) {
+			return nil, errors.Errorf("clustering key mismatch: (%v vs %v)", e.Key.ClusteringKeys, other.Key.ClusteringKeys)
+		}
+	}
+
+	merged := e.Clone()
+	columns := merged.ColumnMap()
+	for _, cd := range other.Columns {
+		existing, ok := columns[cd.Name]
+		if !ok {
+			merged.Columns = append(merged.Columns, cd.Clone())
+			continue
+		}
+		if existing.Type != cd.Type {
+			return nil, errors.Errorf("the type for column %s mismatch: (%v vs %v)", cd.Name, existing.Type, cd.Type)
+		}
+	}
+
+	for name, index := range other.Indexes {
+		existing, ok := merged.Indexes[name]
+		if !ok {
+			if merged.Indexes == nil {
+				merged.Indexes = make(map[string]*IndexDefinition)
+			}
+			merged.Indexes[name] = index.Clone()
+			continue
+		}
+		if !reflect.DeepEqual(existing, index) {
+			return nil, errors.Errorf("index %q mismatch: (%v vs %v)", name, existing, index)
+		}
+	}
+
+	if other.ETL == EtlOn {
+		merged.ETL = EtlOn
+	}
+
+	if err := merged.EnsureValid(); err != nil {
+		return nil, errors.Wrap(err, "merged entity definition is invalid")
+	}
+	return merged, nil
+}
+
 // FindColumnDefinition finds the column definition by the column name
 func (e *EntityDefinition) FindColumnDefinition(name string) *ColumnDefinition {
 	for _, cd := range e.Columns {
@@ -461,6 +948,44 @@ func (e *EntityDefinition) FindColumnDefinition(name string) *ColumnDefinition {
 	return nil
 }
 
+const (
+	// DefaultEstimatedStringBytes is the average size of String values assumed by
+	// EstimatedRowSizeBytes
+	DefaultEstimatedStringBytes = 64
+	// DefaultEstimatedBlobBytes is the average size of Blob values assumed by
+	// EstimatedRowSizeBytes
+	DefaultEstimatedBlobBytes = 1024
+)
+
+// estimatedTypeBytes is the size of the values of each type, for EstimatedRowSizeBytes
+var estimatedTypeBytes = map[Type]int{
+	TUUID:     16,
+	String:    DefaultEstimatedStringBytes,
+	Int32:     4,
+	Int64:     8,
+	Double:    8,
+	Blob:      DefaultEstimatedBlobBytes,
+	Timestamp: 8,
+	Bool:      1,
+}
+
+// EstimatedRowSizeBytes returns a rough estimate of the size of a row of the
+// entity, for capacity planning: the sum of the sizes of its columns. The
+// size of a column is its EstimatedBytes if set, otherwise the size of its
+// type, with DefaultEstimatedStringBytes and DefaultEstimatedBlobBytes for
+// String and Blob columns. Storage overhead is not counted.
+func (e *EntityDefinition) EstimatedRowSizeBytes() int {
+	size := 0
+	for _, cd := range e.Columns {
+		if cd.EstimatedBytes != 0 {
+			size += cd.EstimatedBytes
+		} else {
+			size += estimatedTypeBytes[cd.Type]
+		}
+	}
+	return size
+}
+
 // UniqueKey adds any missing keys from the entity's primary key to the keys
 // specified in the index, to guarantee that the returned key is unique
 // This method is used to create materialized views
@@ -486,3 +1011,59 @@ func (e *EntityDefinition) UniqueKey(oldKey *PrimaryKey) *PrimaryKey {
 
 	return &result
 }
+
+// IndexFor returns the key that best serves a query on the given columns:
+// either an index, or the primary key, returned as an IndexDefinition whose
+// Key is e.Key. A key can serve the query if the columns include all of its
+// partition keys and are all part of it; of those, the best one has the
+// longest prefix of key columns, partition keys and then clustering keys, in
+// columns. Ties go to the primary key, and then to the index first in name
+// order. If no key can serve the query, false is returned, and the rows have
+// to be scanned.
+func (e *EntityDefinition) IndexFor(columns []string) (*IndexDefinition, bool) {
+	wanted := make(map[string]struct{}, len(columns))
+	for _, c := range columns {
+		wanted[c] = struct{}{}
+	}
+
+	candidates := []*IndexDefinition{{Key: e.Key}}
+	for _, name := range sortedIndexNames(e.Indexes) {
+		candidates = append(candidates, e.Indexes[name])
+	}
+
+	var best *IndexDefinition
+	bestPrefix := -1
+	for _, candidate := range candidates {
+		if prefix, ok := keyPrefix(candidate.Key, wanted); ok && prefix > bestPrefix {
+			best, bestPrefix = candidate, prefix
+		}
+	}
+	return best, best != nil
+}
+
+// keyPrefix returns the number of leading columns of the key that are in
+// columns, and whether the key can serve a query on columns
+func keyPrefix(key *PrimaryKey, columns map[string]struct{}) (int, bool) {
+	if key == nil {
+		return 0, false
+	}
+	for _, pk := range key.PartitionKeys {
+		if _, ok := columns[pk]; !ok {
+			return 0, false
+		}
+	}
+	keyColumns := key.PrimaryKeySet()
+	for c := range columns {
+		if _, ok := keyColumns[c]; !ok {
+			return 0, false
+		}
+	}
+	prefix := len(key.PartitionKeys)
+	for _, ck := range key.ClusteringKeys {
+		if _, ok := columns[ck.Name]; !ok {
+			break
+		}
+		prefix++
+	}
+	return prefix, true
+}
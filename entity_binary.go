@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+)
+
+// entityBinaryMagic starts every binary encoded EntityDefinition, followed by
+// entityBinaryVersion and the gob encoding of the definition. The version must
+// be bumped whenever a change to EntityDefinition would make old values
+// decode wrongly, so stale cached values are rejected instead.
+var entityBinaryMagic = []byte("DOSA")
+
+const entityBinaryVersion byte = 1
+
+// gobEntityDefinition has the fields of EntityDefinition but not its
+// methods, so gob encodes the fields rather than calling MarshalBinary
+type gobEntityDefinition EntityDefinition
+
+// MarshalBinary encodes the entity definition in a compact binary form,
+// suitable for caching it outside the process (in Redis, for example). It
+// implements encoding.BinaryMarshaler.
+func (e *EntityDefinition) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(entityBinaryMagic)
+	buf.WriteByte(entityBinaryVersion)
+	if err := gob.NewEncoder(&buf).Encode((*gobEntityDefinition)(e)); err != nil {
+		return nil, errors.Wrapf(err, "cannot encode entity definition %q", e.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes an entity definition encoded by MarshalBinary. It
+// fails on data that doesn't start with the expected magic number or that
+// was written by another version of the format. It implements
+// encoding.BinaryUnmarshaler.
+func (e *EntityDefinition) UnmarshalBinary(data []byte) error {
+	header := len(entityBinaryMagic) + 1
+	if len(data) < header || !bytes.Equal(data[:len(entityBinaryMagic)], entityBinaryMagic) {
+		return errors.New("not a binary encoded entity definition")
+	}
+	if version := data[header-1]; version != entityBinaryVersion {
+		return errors.Errorf("unsupported entity definition version %d, expected %d", version, entityBinaryVersion)
+	}
+	var decoded gobEntityDefinition
+	if err := gob.NewDecoder(bytes.NewReader(data[header:])).Decode(&decoded); err != nil {
+		return errors.Wrap(err, "cannot decode entity definition")
+	}
+	*e = EntityDefinition(decoded)
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+)
+
+func binaryTestEntity() *dosa.EntityDefinition {
+	ed := &dosa.EntityDefinition{
+		Name: "orders",
+		ETL:  dosa.EtlOn,
+		Key: &dosa.PrimaryKey{
+			PartitionKeys:  []string{"customer"},
+			ClusteringKeys: []*dosa.ClusteringKey{{Name: "placed", Descending: true}},
+		},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "customer", Type: dosa.String, MaxLength: 64},
+			{Name: "placed", Type: dosa.Timestamp, Immutable: true},
+		},
+		Indexes: map[string]*dosa.IndexDefinition{
+			"by_total": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"field0"}}},
+		},
+	}
+	for i := 0; len(ed.Columns) < 20; i++ {
+		ed.Columns = append(ed.Columns, &dosa.ColumnDefinition{
+			Name:      fmt.Sprintf("field%d", i),
+			Type:      dosa.Int64,
+			IsPointer: i%2 == 0,
+			Tags:      map[string]string{"owner": "billing"},
+		})
+	}
+	return ed
+}
+
+func TestEntityDefinition_MarshalBinary(t *testing.T) {
+	ed := binaryTestEntity()
+	data, err := ed.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("DOSA\x01"), data[:5])
+
+	decoded := &dosa.EntityDefinition{}
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, ed, decoded)
+
+	// smaller than the JSON encoding
+	jsonData, err := json.Marshal(ed)
+	assert.NoError(t, err)
+	assert.True(t, len(data) < len(jsonData))
+}
+
+func TestEntityDefinition_UnmarshalBinaryErrors(t *testing.T) {
+	data, err := binaryTestEntity().MarshalBinary()
+	assert.NoError(t, err)
+
+	ed := &dosa.EntityDefinition{}
+	assert.EqualError(t, ed.UnmarshalBinary(nil), "not a binary encoded entity definition")
+	assert.EqualError(t, ed.UnmarshalBinary([]byte(`{"Name":"orders"}`)), "not a binary encoded entity definition")
+
+	future := append([]byte("DOSA\x02"), data[5:]...)
+	assert.EqualError(t, ed.UnmarshalBinary(future), "unsupported entity definition version 2, expected 1")
+
+	err = ed.UnmarshalBinary(data[:len(data)-3])
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot decode entity definition")
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cassandraTypes maps DOSA types to Cassandra CQL types
+var cassandraTypes = map[Type]string{
+	TUUID:     "uuid",
+	String:    "text",
+	Int32:     "int",
+	Int64:     "bigint",
+	Double:    "double",
+	Blob:      "blob",
+	Timestamp: "timestamp",
+	Bool:      "boolean",
+}
+
+// ToCassandraCQL generates the Cassandra CREATE TABLE statement for the entity,
+// with a WITH CLUSTERING ORDER BY clause when the entity has clustering keys.
+// Indexes are not included.
+func (e *EntityDefinition) ToCassandraCQL() string {
+	return e.cassandraCQL(NoTTL())
+}
+
+// ToCassandraCQL generates the Cassandra CREATE TABLE statement for the table,
+// like EntityDefinition.ToCassandraCQL, and also sets default_time_to_live if
+// the table has a TTL of at least one second.
+func (t *Table) ToCassandraCQL() string {
+	return t.EntityDefinition.cassandraCQL(t.TTL)
+}
+
+func (e *EntityDefinition) cassandraCQL(ttl time.Duration) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE TABLE %s (\n", quoteCQL(e.Name))
+	for _, c := range e.Columns {
+		cqlType, ok := cassandraTypes[c.Type]
+		if !ok {
+			cqlType = "unknown"
+		}
+		fmt.Fprintf(&buf, "  %s %s,\n", quoteCQL(c.Name), cqlType)
+	}
+
+	keys := make([]string, 0, len(e.Key.PartitionKeys))
+	for _, k := range e.Key.PartitionKeys {
+		keys = append(keys, quoteCQL(k))
+	}
+	primaryKey := []string{"(" + strings.Join(keys, ", ") + ")"}
+	order := make([]string, 0, len(e.Key.ClusteringKeys))
+	for _, ck := range e.Key.ClusteringKeys {
+		primaryKey = append(primaryKey, quoteCQL(ck.Name))
+		direction := "ASC"
+		if ck.Descending {
+			direction = "DESC"
+		}
+		order = append(order, quoteCQL(ck.Name)+" "+direction)
+	}
+	fmt.Fprintf(&buf, "  PRIMARY KEY (%s)\n)", strings.Join(primaryKey, ", "))
+
+	var options []string
+	if len(order) > 0 {
+		options = append(options, "CLUSTERING ORDER BY ("+strings.Join(order, ", ")+")")
+	}
+	if ttl >= time.Second {
+		options = append(options, fmt.Sprintf("default_time_to_live = %d", int64(ttl/time.Second)))
+	}
+	if len(options) > 0 {
+		buf.WriteString(" WITH " + strings.Join(options, " AND "))
+	}
+	buf.WriteString(";")
+	return buf.String()
+}
+
+// quoteCQL quotes a CQL identifier, doubling any double quotes in it
+func quoteCQL(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
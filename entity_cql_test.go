@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+)
+
+func TestEntityDefinition_ToCassandraCQL(t *testing.T) {
+	ed := &dosa.EntityDefinition{
+		Name: "orders",
+		Key: &dosa.PrimaryKey{
+			PartitionKeys:  []string{"customer", "region"},
+			ClusteringKeys: []*dosa.ClusteringKey{{Name: "placed", Descending: true}, {Name: "id"}},
+		},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "customer", Type: dosa.String},
+			{Name: "region", Type: dosa.Int32},
+			{Name: "placed", Type: dosa.Timestamp},
+			{Name: "id", Type: dosa.TUUID},
+			{Name: "total", Type: dosa.Double},
+			{Name: "count", Type: dosa.Int64},
+			{Name: "paid", Type: dosa.Bool},
+			{Name: "receipt", Type: dosa.Blob},
+		},
+	}
+	assert.Equal(t, `CREATE TABLE "orders" (
+  "customer" text,
+  "region" int,
+  "placed" timestamp,
+  "id" uuid,
+  "total" double,
+  "count" bigint,
+  "paid" boolean,
+  "receipt" blob,
+  PRIMARY KEY (("customer", "region"), "placed", "id")
+) WITH CLUSTERING ORDER BY ("placed" DESC, "id" ASC);`, ed.ToCassandraCQL())
+
+	table := &dosa.Table{EntityDefinition: *ed, TTL: 90 * time.Minute}
+	assert.Equal(t, `CREATE TABLE "orders" (
+  "customer" text,
+  "region" int,
+  "placed" timestamp,
+  "id" uuid,
+  "total" double,
+  "count" bigint,
+  "paid" boolean,
+  "receipt" blob,
+  PRIMARY KEY (("customer", "region"), "placed", "id")
+) WITH CLUSTERING ORDER BY ("placed" DESC, "id" ASC) AND default_time_to_live = 5400;`, table.ToCassandraCQL())
+}
+
+func TestEntityDefinition_ToCassandraCQLNoClustering(t *testing.T) {
+	ed := &dosa.EntityDefinition{
+		Name:    "users",
+		Key:     &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosa.ColumnDefinition{{Name: "id", Type: dosa.TUUID}, {Name: "bad", Type: dosa.Invalid}},
+	}
+	expected := `CREATE TABLE "users" (
+  "id" uuid,
+  "bad" unknown,
+  PRIMARY KEY (("id"))
+);`
+	assert.Equal(t, expected, ed.ToCassandraCQL())
+
+	// no TTL, or one Cassandra can't express
+	for _, ttl := range []time.Duration{dosa.NoTTL(), 0, time.Millisecond} {
+		table := &dosa.Table{EntityDefinition: *ed, TTL: ttl}
+		assert.Equal(t, expected, table.ToCassandraCQL())
+	}
+	table := &dosa.Table{EntityDefinition: *ed, TTL: time.Second}
+	assert.Contains(t, table.ToCassandraCQL(), "\n) WITH default_time_to_live = 1;")
+}
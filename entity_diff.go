@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// ANSI escape codes for the colors of Diff
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBold   = "\x1b[1;31m"
+)
+
+// stdoutIsTerminal tells whether Diff should colorize its output
+var stdoutIsTerminal = func() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Diff returns a readable, line by line comparison of the entity definition
+// with other, in the spirit of diff -u: columns and indexes only in e are
+// prefixed with "-", those only in other with "+", and those that changed
+// with "~"; unchanged columns are listed for context. A change of primary
+// key, which is never compatible, is shown first with "!". The output is
+// colorized when stdout is a terminal. Diff returns "" when nothing differs.
+func (e *EntityDefinition) Diff(other *EntityDefinition) string {
+	return e.diff(other, stdoutIsTerminal())
+}
+
+func (e *EntityDefinition) diff(other *EntityDefinition, color bool) string {
+	if len(e.Differences(other)) == 0 {
+		return ""
+	}
+	var b bytes.Buffer
+	line := func(c, format string, args ...interface{}) {
+		if color && c != "" {
+			b.WriteString(c)
+		}
+		_, _ = fmt.Fprintf(&b, format, args...)
+		if color && c != "" {
+			b.WriteString(colorReset)
+		}
+		b.WriteByte('\n')
+	}
+
+	line("", "--- %s", e.Name)
+	line("", "+++ %s", other.Name)
+	if e.Key.String() != other.Key.String() {
+		line(colorBold, "! primary key changed, this is a breaking change")
+		line(colorBold, "!   - %s", e.Key)
+		line(colorBold, "!   + %s", other.Key)
+	}
+
+	otherTypes := other.ColumnTypes()
+	for _, cd := range e.Columns {
+		typ, ok := otherTypes[cd.Name]
+		switch {
+		case !ok:
+			line(colorRed, "- %s %v", cd.Name, cd.Type)
+		case typ != cd.Type:
+			line(colorYellow, "~ %s %v -> %v", cd.Name, cd.Type, typ)
+		default:
+			line("", "  %s %v", cd.Name, cd.Type)
+		}
+	}
+	types := e.ColumnTypes()
+	for _, cd := range other.Columns {
+		if _, ok := types[cd.Name]; !ok {
+			line(colorGreen, "+ %s %v", cd.Name, cd.Type)
+		}
+	}
+
+	for _, name := range sortedIndexNames(e.Indexes) {
+		otherIndex, ok := other.Indexes[name]
+		switch {
+		case !ok:
+			line(colorRed, "- index %s %s", name, e.Indexes[name].Key)
+		case otherIndex.Key.String() != e.Indexes[name].Key.String():
+			line(colorYellow, "~ index %s %s -> %s", name, e.Indexes[name].Key, otherIndex.Key)
+		}
+	}
+	for _, name := range sortedIndexNames(other.Indexes) {
+		if _, ok := e.Indexes[name]; !ok {
+			line(colorGreen, "+ index %s %s", name, other.Indexes[name].Key)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffTestEntity() *EntityDefinition {
+	return &EntityDefinition{
+		Name: "users",
+		Key:  &PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*ColumnDefinition{
+			{Name: "id", Type: TUUID},
+			{Name: "name", Type: String},
+			{Name: "age", Type: Int32},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"by_name": {Key: &PrimaryKey{PartitionKeys: []string{"name"}}},
+			"by_age":  {Key: &PrimaryKey{PartitionKeys: []string{"age"}}},
+		},
+	}
+}
+
+func TestEntityDefinitionDiff(t *testing.T) {
+	ed := diffTestEntity()
+	assert.Equal(t, "", ed.diff(diffTestEntity(), false))
+
+	other := diffTestEntity()
+	other.Columns = []*ColumnDefinition{
+		{Name: "id", Type: TUUID},
+		{Name: "age", Type: Int64},
+		{Name: "email", Type: String},
+	}
+	delete(other.Indexes, "by_name")
+	other.Indexes["by_age"] = &IndexDefinition{Key: &PrimaryKey{PartitionKeys: []string{"age"}, ClusteringKeys: []*ClusteringKey{{Name: "id"}}}}
+	other.Indexes["by_email"] = &IndexDefinition{Key: &PrimaryKey{PartitionKeys: []string{"email"}}}
+	assert.Equal(t, `--- users
++++ users
+  id TUUID
+- name String
+~ age Int32 -> Int64
++ email String
+~ index by_age (age) -> (age, id ASC)
+- index by_name (name)
++ index by_email (email)
+`, ed.diff(other, false))
+
+	other = diffTestEntity()
+	other.Key = &PrimaryKey{PartitionKeys: []string{"id"}, ClusteringKeys: []*ClusteringKey{{Name: "age", Descending: true}}}
+	assert.Equal(t, `--- users
++++ users
+! primary key changed, this is a breaking change
+!   - (id)
+!   + (id, age DESC)
+  id TUUID
+  name String
+  age Int32
+`, ed.diff(other, false))
+
+	// colors wrap the changed lines only
+	assert.Equal(t, "--- users\n+++ users\n"+
+		"\x1b[1;31m! primary key changed, this is a breaking change\x1b[0m\n"+
+		"\x1b[1;31m!   - (id)\x1b[0m\n"+
+		"\x1b[1;31m!   + (id, age DESC)\x1b[0m\n"+
+		"  id TUUID\n  name String\n  age Int32\n", ed.diff(other, true))
+
+	isTerminal := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = isTerminal }()
+	stdoutIsTerminal = func() bool { return false }
+	assert.Equal(t, ed.diff(other, false), ed.Diff(other))
+}
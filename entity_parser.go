@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -41,9 +42,15 @@ const (
 
 var (
 	primaryKeyPattern0 = regexp.MustCompile(`primaryKey\s*=\s*([^=]*)((\s+.*=)|$)`)
-	primaryKeyPattern1 = regexp.MustCompile(`\(\s*\((.*)\)(.*)\)`)
-	primaryKeyPattern2 = regexp.MustCompile(`\(\s*([^,\s]+),?(.*)\)`)
+	primaryKeyPattern1 = regexp.MustCompile(`\(\s*\(([^()]*)\)(.*)\)`)
+	primaryKeyPattern2 = regexp.MustCompile(`\(\s*([^,\s]+)(,?)(.*)\)`)
 	primaryKeyPattern3 = regexp.MustCompile(`^\s*([^(),\s]+)\s*$`)
+	// primaryKey=() declares an entity without a primary key
+	emptyPrimaryKeyPattern = regexp.MustCompile(`^\s*\(\s*\)\s*$`)
+
+	// compositeKeyPattern matches the + joining the columns of a composite
+	// partition key, as in primaryKey=(A+B, C)
+	compositeKeyPattern = regexp.MustCompile(`\s*\+\s*`)
 
 	indexKeyPattern0 = regexp.MustCompile(`key\s*=\s*([^=]*)((\s+.*=)|$)`)
 
@@ -53,15 +60,49 @@ var (
 
 	ttlPattern0 = regexp.MustCompile(`ttl\s*=\s*(\S*)`)
 
+	immutablePattern0 = regexp.MustCompile(`(^|[\s,])immutable\s*,?`)
+
+	sensitivePattern0 = regexp.MustCompile(`(^|[\s,])sensitive\s*,?`)
+
+	uniquePattern0 = regexp.MustCompile(`(^|[\s,])unique\s*,?`)
+
+	maxLengthPattern0 = regexp.MustCompile(`(^|[\s,])maxlen\s*=\s*([^\s,]*)\s*,?`)
+
+	deprecatedSincePattern0 = regexp.MustCompile(`(^|[\s,])deprecated_since\s*=\s*([^\s,]*)\s*,?`)
+
+	deprecatedPattern0 = regexp.MustCompile(`(^|[\s,])deprecated\s*,?`)
+
+	orderedPattern0 = regexp.MustCompile(`(^|[\s,])ordered_(asc|desc)\s*,?`)
+
 	indexType = reflect.TypeOf((*Index)(nil)).Elem()
+
+	domainObjectType = reflect.TypeOf((*DomainObject)(nil)).Elem()
 )
 
-// parseClusteringKeys func parses the clustering key of DOSA object
+// isSortDirection returns true if s is "asc" or "desc", in any case
+func isSortDirection(s string) bool {
+	s = strings.ToLower(s)
+	return s == asc || s == desc
+}
+
+// parseClusteringKeys func parses the clustering key of DOSA object. Each key
+// is "name", "name asc/desc" or, with the sort direction annotation in
+// parentheses, "(name asc/desc)".
 func parseClusteringKeys(ckStr string) ([]*ClusteringKey, error) {
 	ckStr = strings.TrimSpace(ckStr)
+	if strings.Contains(ckStr, "+") {
+		return nil, fmt.Errorf("only the partition key can be joined with +: %q", ckStr)
+	}
 	cks := strings.Split(ckStr, ",")
 	var clusteringKeys []*ClusteringKey
 	for _, ck := range cks {
+		ck = strings.TrimSpace(ck)
+		if strings.HasPrefix(ck, "(") || strings.HasSuffix(ck, ")") {
+			if !strings.HasPrefix(ck, "(") || !strings.HasSuffix(ck, ")") {
+				return nil, fmt.Errorf("Clustering key definition %q should look like \"(name asc/desc)\"", ck)
+			}
+			ck = ck[1 : len(ck)-1]
+		}
 		fields := strings.Fields(ck)
 		if len(fields) == 0 {
 			continue
@@ -88,18 +129,29 @@ func parseClusteringKeys(ckStr string) ([]*ClusteringKey, error) {
 	return clusteringKeys, nil
 }
 
-// parsePartitionKey func parses the partition key of DOSA object
-func parsePartitionKey(pkStr string) []string {
+// parsePartitionKey func parses the partition key of DOSA object. The columns
+// of a composite partition key are separated by commas, as in "(A, B)", or
+// joined with +, as in "A+B".
+func parsePartitionKey(pkStr string) ([]string, error) {
 	pkStr = strings.TrimSpace(pkStr)
 	var pks []string
 	partitionKeys := strings.Split(pkStr, ",")
 	for _, pk := range partitionKeys {
 		npk := strings.TrimSpace(pk)
-		if len(pk) > 0 {
-			pks = append(pks, npk)
+		if fields := strings.Fields(npk); len(fields) == 2 && isSortDirection(fields[1]) {
+			return nil, fmt.Errorf("sort direction can only be specified for clustering keys: %q", npk)
+		}
+		if len(pk) == 0 {
+			continue
+		}
+		for _, part := range strings.Split(npk, "+") {
+			if part == "" {
+				return nil, fmt.Errorf("empty column in composite partition key: %q", npk)
+			}
+			pks = append(pks, part)
 		}
 	}
-	return pks
+	return pks, nil
 }
 
 // parsePrimaryKey func parses the primary key of DOSA object
@@ -111,6 +163,8 @@ func parsePrimaryKey(tableName, pkStr string) (*PrimaryKey, error) {
 	// filter out "trailing comma and space"
 	pkStr = strings.TrimRight(pkStr, ", ")
 	pkStr = strings.TrimSpace(pkStr)
+	// (A + B, C) is (A+B, C), so that the patterns below see A+B as one key
+	pkStr = compositeKeyPattern.ReplaceAllString(pkStr, "+")
 
 	var partitionKeyStr string
 	var clusteringKeyStr string
@@ -126,10 +180,14 @@ func parsePrimaryKey(tableName, pkStr string) (*PrimaryKey, error) {
 	// case 2: primaryKey=(PK1,PK2)
 	if !matched {
 		matchs = primaryKeyPattern2.FindStringSubmatch(pkStr)
-		if len(matchs) == 3 {
+		if len(matchs) == 4 {
+			// (PK1 DESC, PK2) puts a sort direction on the partition key
+			if fields := strings.Fields(matchs[3]); matchs[2] == "" && len(fields) > 0 && isSortDirection(strings.TrimRight(fields[0], ",")) {
+				return nil, fmt.Errorf("sort direction can only be specified for clustering keys: %q", pkStr)
+			}
 			matched = true
 			partitionKeyStr = matchs[1]
-			clusteringKeyStr = matchs[2]
+			clusteringKeyStr = matchs[3]
 		}
 	}
 
@@ -146,7 +204,10 @@ func parsePrimaryKey(tableName, pkStr string) (*PrimaryKey, error) {
 	if !matched {
 		return nil, fmt.Errorf("invalid primary key: %s", pkStr)
 	}
-	partitionKeys := parsePartitionKey(partitionKeyStr)
+	partitionKeys, err := parsePartitionKey(partitionKeyStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid primary key: %s", pkStr)
+	}
 	clusteringKeys, err := parseClusteringKeys(clusteringKeyStr)
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid primary key: %s", pkStr)
@@ -177,12 +238,41 @@ func parsePrimaryKey(tableName, pkStr string) (*PrimaryKey, error) {
 // Note: this method is not cheap as it does a lot of reflection to build the
 // Table instances. It is recommended to only be called once and cache results.
 func TableFromInstance(object DomainObject) (*Table, error) {
-	elem := reflect.TypeOf(object).Elem()
+	return tableFromStruct(reflect.TypeOf(object).Elem())
+}
+
+// TableFromType creates a dosa.Table from the type of an entity struct, or a
+// pointer to one, and records the type so that ReflectType returns it. The
+// struct must embed dosa.Entity and a pointer to it must be a DomainObject.
+// Like TableFromInstance, it is not cheap and its results should be cached.
+func TableFromType(typ reflect.Type) (*Table, error) {
+	if typ == nil {
+		return nil, errors.New("cannot create a table from a nil type")
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.Errorf("type %s is not a struct", typ)
+	}
+	if !reflect.PtrTo(typ).Implements(domainObjectType) {
+		return nil, errors.Errorf("type %s is not a dosa.DomainObject, it must embed dosa.Entity", typ)
+	}
 
+	t, err := tableFromStruct(typ)
+	if err != nil {
+		return nil, err
+	}
+	t.reflectType = typ
+	return t, nil
+}
+
+func tableFromStruct(elem reflect.Type) (*Table, error) {
 	t := &Table{
-		StructName: elem.Name(),
-		ColToField: map[string]string{},
-		FieldToCol: map[string]string{},
+		StructName:    elem.Name(),
+		ColToField:    map[string]string{},
+		FieldToCol:    map[string]string{},
+		GoPackagePath: elem.PkgPath(),
 		EntityDefinition: EntityDefinition{
 			Columns: []*ColumnDefinition{},
 			Indexes: map[string]*IndexDefinition{},
@@ -206,14 +296,14 @@ func TableFromInstance(object DomainObject) (*Table, error) {
 		} else {
 			// parse index fields
 			if structField.Type == indexType {
-				indexName, indexKey, err := parseIndexTag(structField.Name, tag)
+				indexName, index, err := parseIndexTag(structField.Name, tag)
 				if err != nil {
 					return nil, err
 				}
 				if _, exist := t.Indexes[indexName]; exist {
 					return nil, errors.Errorf("index name is duplicated: %s", indexName)
 				}
-				t.Indexes[indexName] = &IndexDefinition{Key: indexKey}
+				t.Indexes[indexName] = index
 			} else {
 				cd, err := parseFieldTag(structField, tag)
 				if err != nil {
@@ -230,7 +320,11 @@ func TableFromInstance(object DomainObject) (*Table, error) {
 		return nil, errors.Errorf("cannot find dosa.Entity in object %s", t.StructName)
 	}
 
+	if err := addRowIDColumn(t); err != nil {
+		return nil, err
+	}
 	translateKeyName(t)
+	t.DeprecatedColumns = deprecatedColumns(t)
 
 	if err := t.EnsureValid(); err != nil {
 		return nil, errors.Wrap(err, "failed to parse dosa object")
@@ -239,6 +333,19 @@ func TableFromInstance(object DomainObject) (*Table, error) {
 	return t, nil
 }
 
+// addRowIDColumn adds the RowIDColumn that is the key of entities declared
+// with primaryKey=()
+func addRowIDColumn(t *Table) error {
+	if !t.ScanOnly() {
+		return nil
+	}
+	if t.FindColumnDefinition(RowIDColumn) != nil {
+		return errors.Errorf("column name %q is reserved for the row ID of entities without a primary key", RowIDColumn)
+	}
+	t.Columns = append(t.Columns, &ColumnDefinition{Name: RowIDColumn, Type: TUUID})
+	return nil
+}
+
 // translateKeyName translate the primary keys to the internal column name based on the mapping
 // between fields and columns.
 func translateKeyName(t *Table) {
@@ -276,7 +383,7 @@ func translateKeyName(t *Table) {
 }
 
 // parseIndexTag functions parses DOSA index tag
-func parseIndexTag(indexName, dosaAnnotation string) (string, *PrimaryKey, error) {
+func parseIndexTag(indexName, dosaAnnotation string) (string, *IndexDefinition, error) {
 	// index name struct must be exported in the entity,
 	// otherwise it will be ignored when upserting the schema.
 	if len(indexName) != 0 && unicode.IsLower([]rune(indexName)[0]) {
@@ -307,12 +414,23 @@ func parseIndexTag(indexName, dosaAnnotation string) (string, *PrimaryKey, error
 	}
 
 	tag = strings.Replace(tag, fullNameTag, "", 1)
+
+	// find the ttl flag; without one the entries don't expire
+	fullTTLTag, ttl, err := parseTTLTag(tag)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "invalid ttl tag: %s", tag)
+	}
+	if ttl == NoTTL() {
+		ttl = 0
+	}
+	tag = strings.Replace(tag, fullTTLTag, "", 1)
+
 	tag = strings.TrimSpace(tag)
 	if tag != "" {
 		return "", nil, fmt.Errorf("index field %s with an invalid dosa index tag: %s", indexName, tag)
 	}
 
-	return name, key, nil
+	return name, &IndexDefinition{Key: key, TTL: ttl}, nil
 }
 
 // parseNameTag functions parses DOSA "name" tag
@@ -386,6 +504,10 @@ func parseTTLTag(tag string) (string, time.Duration, error) {
 	// filter out "trailing comma"
 	ttlTag = strings.TrimRight(ttlTag, " ,")
 	ttl, err := time.ParseDuration(ttlTag)
+	if seconds, serr := strconv.ParseInt(ttlTag, 10, 64); serr == nil {
+		// a plain number is a number of seconds
+		ttl, err = time.Duration(seconds)*time.Second, nil
+	}
 	if err != nil {
 		return "", NoTTL(), err
 	}
@@ -408,7 +530,13 @@ func parseEntityTag(structName, dosaAnnotation string) (string, time.Duration, E
 	}
 	pkString := matchs[1]
 
-	key, err := parsePrimaryKey(structName, pkString)
+	var key *PrimaryKey
+	var err error
+	if emptyPrimaryKeyPattern.MatchString(pkString) {
+		key = &PrimaryKey{PartitionKeys: []string{RowIDColumn}}
+	} else {
+		key, err = parsePrimaryKey(structName, pkString)
+	}
 	if err != nil {
 		return "", NoTTL(), EtlOff, nil, errors.Wrapf(err, "struct %s has an invalid primary key %q", structName, pkString)
 	}
@@ -462,11 +590,147 @@ func parseField(typ Type, isPointer bool, name string, tag string) (*ColumnDefin
 	}
 
 	tag = strings.Replace(tag, fullNameTag, "", 1)
+
+	// parse immutable tag
+	fullImmutableTag, immutable := parseImmutableTag(tag)
+	tag = strings.Replace(tag, fullImmutableTag, "", 1)
+
+	// parse sensitive tag
+	fullSensitiveTag, sensitive := parseSensitiveTag(tag)
+	tag = strings.Replace(tag, fullSensitiveTag, "", 1)
+
+	// parse unique tag
+	fullUniqueTag, unique := parseUniqueTag(tag)
+	tag = strings.Replace(tag, fullUniqueTag, "", 1)
+
+	// parse maxlen tag
+	fullMaxLengthTag, maxLength, err := parseMaxLengthTag(tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "field %s with an invalid dosa field tag", name)
+	}
+	if maxLength > 0 && typ != String && typ != Blob {
+		return nil, fmt.Errorf("field %s: maxlen can only be used on string and []byte fields", name)
+	}
+	tag = strings.Replace(tag, fullMaxLengthTag, "", 1)
+
+	// parse deprecated_since before deprecated, which would otherwise match its prefix
+	fullDeprecatedSinceTag, deprecatedSince, err := parseDeprecatedSinceTag(tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "field %s with an invalid dosa field tag", name)
+	}
+	tag = strings.Replace(tag, fullDeprecatedSinceTag, "", 1)
+
+	fullDeprecatedTag, deprecated := parseDeprecatedTag(tag)
+	tag = strings.Replace(tag, fullDeprecatedTag, "", 1)
+
+	fullOrderedTag, orderedBy := parseOrderedTag(tag)
+	tag = strings.Replace(tag, fullOrderedTag, "", 1)
+	if fullOrderedTag, _ := parseOrderedTag(tag); fullOrderedTag != "" {
+		return nil, fmt.Errorf("field %s can only have one of ordered_asc and ordered_desc", name)
+	}
+
 	if strings.TrimSpace(tag) != "" {
 		return nil, fmt.Errorf("field %s with an invalid dosa field tag: %s", name, tag)
 	}
 
-	return &ColumnDefinition{Name: name, IsPointer: isPointer, Type: typ}, nil
+	return &ColumnDefinition{
+		Name:             name,
+		IsPointer:        isPointer,
+		Type:             typ,
+		Immutable:        immutable,
+		MaxLength:        maxLength,
+		SensitiveData:    sensitive,
+		UniqueConstraint: unique,
+		Deprecated:       deprecated || deprecatedSince != "",
+		DeprecatedSince:  deprecatedSince,
+		OrderedBy:        orderedBy,
+	}, nil
+}
+
+// parseDeprecatedSinceTag functions parses DOSA "deprecated_since" tag
+func parseDeprecatedSinceTag(tag string) (string, string, error) {
+	matches := deprecatedSincePattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", "", nil
+	}
+	if matches[2] == "" {
+		return "", "", errors.New("deprecated_since must name a version")
+	}
+	return matches[0], matches[2], nil
+}
+
+// parseDeprecatedTag functions parses DOSA "deprecated" tag
+func parseDeprecatedTag(tag string) (string, bool) {
+	matches := deprecatedPattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// parseOrderedTag functions parses DOSA "ordered_asc" and "ordered_desc" tags
+func parseOrderedTag(tag string) (string, *SortDirection) {
+	matches := orderedPattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", nil
+	}
+	direction := Ascending
+	if matches[2] == "desc" {
+		direction = Descending
+	}
+	return matches[0], &direction
+}
+
+// deprecatedColumns returns the names of the table's deprecated columns in
+// declaration order, or nil if there are none
+func deprecatedColumns(t *Table) []string {
+	var names []string
+	for _, cd := range t.Columns {
+		if cd.Deprecated {
+			names = append(names, cd.Name)
+		}
+	}
+	return names
+}
+
+// parseMaxLengthTag functions parses DOSA "maxlen" tag
+func parseMaxLengthTag(tag string) (string, int, error) {
+	matches := maxLengthPattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", 0, nil
+	}
+	maxLength, err := strconv.Atoi(matches[2])
+	if err != nil || maxLength <= 0 {
+		return "", 0, fmt.Errorf("maxlen must be a positive integer: %q", matches[2])
+	}
+	return matches[0], maxLength, nil
+}
+
+// parseImmutableTag functions parses DOSA "immutable" tag
+func parseImmutableTag(tag string) (string, bool) {
+	matches := immutablePattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// parseSensitiveTag functions parses DOSA "sensitive" tag
+func parseSensitiveTag(tag string) (string, bool) {
+	matches := sensitivePattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// parseUniqueTag functions parses DOSA "unique" tag
+func parseUniqueTag(tag string) (string, bool) {
+	matches := uniquePattern0.FindStringSubmatch(tag)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[0], true
 }
 
 func parensBalanced(s string) bool {
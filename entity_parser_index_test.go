@@ -120,3 +120,37 @@ func TestComplexIndexes(t *testing.T) {
 		},
 	}, dosaTable.Indexes)
 }
+
+type IndexesWithTTL struct {
+	Entity       `dosa:"primaryKey=PrimaryKey"`
+	SearchByData Index `dosa:"key=Data, ttl=3600"`
+	SearchByDate Index `dosa:"key=(Date, PrimaryKey), ttl=24h, name=recent"`
+	PrimaryKey   int64
+	Data         string
+	Date         time.Time
+}
+
+func TestIndexesWithTTL(t *testing.T) {
+	dosaTable, err := TableFromInstance(&IndexesWithTTL{})
+	assert.Nil(t, err)
+	assert.Equal(t, NoTTL(), dosaTable.TTL)
+	assert.Equal(t, map[string]*IndexDefinition{
+		"searchbydata": {
+			Key: &PrimaryKey{PartitionKeys: []string{"data"}},
+			TTL: time.Hour,
+		},
+		"recent": {
+			Key: &PrimaryKey{
+				PartitionKeys:  []string{"date"},
+				ClusteringKeys: []*ClusteringKey{{Name: "primarykey"}},
+			},
+			TTL: 24 * time.Hour,
+		},
+	}, dosaTable.Indexes)
+	assert.Empty(t, dosaTable.Warnings())
+
+	_, _, err = parseIndexTag("SearchByData", "key=Data, ttl=1ms")
+	assert.Contains(t, err.Error(), "invalid ttl tag")
+	_, _, err = parseIndexTag("SearchByData", "key=Data, ttl=soon")
+	assert.Contains(t, err.Error(), "invalid ttl tag")
+}
@@ -249,6 +249,60 @@ func TestPrimaryKey(t *testing.T) {
 				},
 			},
 		},
+		{
+			PrimaryKey: "(pk1, (pk2 DESC), (pk3 asc), pk4)",
+			Error:      nil,
+			Result: &PrimaryKey{
+				PartitionKeys: []string{"pk1"},
+				ClusteringKeys: []*ClusteringKey{
+					{
+						Name:       "pk2",
+						Descending: true,
+					},
+					{
+						Name:       "pk3",
+						Descending: false,
+					},
+					{
+						Name:       "pk4",
+						Descending: false,
+					},
+				},
+			},
+		},
+		{
+			PrimaryKey: "((pk1, pk2), (pk3 DESC))",
+			Error:      nil,
+			Result: &PrimaryKey{
+				PartitionKeys: []string{"pk1", "pk2"},
+				ClusteringKeys: []*ClusteringKey{
+					{
+						Name:       "pk3",
+						Descending: true,
+					},
+				},
+			},
+		},
+		{
+			PrimaryKey: "(pk1, (pk2, pk3 DESC))",
+			Error:      errors.New("should look like \"(name asc/desc)\""),
+			Result:     nil,
+		},
+		{
+			PrimaryKey: "(pk1, (pk2 sideways))",
+			Error:      errors.New("invalid clustering key order \"sideways\""),
+			Result:     nil,
+		},
+		{
+			PrimaryKey: "(pk1 DESC, pk2)",
+			Error:      errors.New("sort direction can only be specified for clustering keys"),
+			Result:     nil,
+		},
+		{
+			PrimaryKey: "((pk1 DESC, pk2), pk3)",
+			Error:      errors.New("sort direction can only be specified for clustering keys: \"pk1 DESC\""),
+			Result:     nil,
+		},
 	}
 
 	for _, d := range data {
@@ -928,13 +982,50 @@ func TestIndexParse(t *testing.T) {
 	}
 
 	for _, d := range data {
-		name, primaryKey, err := parseIndexTag(d.InputIndexName, d.Tag)
+		name, index, err := parseIndexTag(d.InputIndexName, d.Tag)
 		if d.Error != nil {
 			assert.Contains(t, err.Error(), d.Error.Error())
 		} else {
 			assert.Nil(t, err)
 			assert.Equal(t, name, d.ExpectedIndexName)
-			assert.Equal(t, primaryKey, d.PrimaryKey)
+			assert.Equal(t, index.Key, d.PrimaryKey)
+			assert.Equal(t, time.Duration(0), index.TTL)
 		}
 	}
 }
+
+func TestPrimaryKeyStringRoundTrip(t *testing.T) {
+	data := []struct {
+		Key    *PrimaryKey
+		String string
+	}{
+		{
+			Key:    &PrimaryKey{PartitionKeys: []string{"parta"}},
+			String: "(parta)",
+		},
+		{
+			Key: &PrimaryKey{
+				PartitionKeys:  []string{"parta"},
+				ClusteringKeys: []*ClusteringKey{{Name: "clustera"}},
+			},
+			String: "(parta, clustera ASC)",
+		},
+		{
+			Key: &PrimaryKey{
+				PartitionKeys: []string{"parta", "partb"},
+				ClusteringKeys: []*ClusteringKey{
+					{Name: "clustera"},
+					{Name: "clusterb", Descending: true},
+				},
+			},
+			String: "((parta, partb), clustera ASC, clusterb DESC)",
+		},
+	}
+
+	for _, d := range data {
+		assert.Equal(t, d.String, d.Key.String())
+		_, _, _, key, err := parseEntityTag("Test", "primaryKey="+d.Key.String())
+		assert.NoError(t, err, d.String)
+		assert.Equal(t, d.Key, key, d.String)
+	}
+}
@@ -27,6 +27,8 @@ import (
 
 	"io"
 
+	"reflect"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,6 +46,38 @@ func TestSinglePrimaryKeyNoParen(t *testing.T) {
 	assert.Equal(t, 0, len(dosaTable.Key.ClusteringKeys))
 }
 
+func TestTableFromType(t *testing.T) {
+	fromInstance, err := TableFromInstance(&SinglePrimaryKey{})
+	assert.NoError(t, err)
+	assert.Nil(t, fromInstance.ReflectType())
+
+	typ := reflect.TypeOf(SinglePrimaryKey{})
+	for _, tt := range []reflect.Type{typ, reflect.PtrTo(typ)} {
+		dosaTable, err := TableFromType(tt)
+		assert.NoError(t, err)
+		assert.Equal(t, typ, dosaTable.ReflectType())
+		assert.Equal(t, "github.com/uber-go/dosa", dosaTable.GoPackagePath)
+		assert.Equal(t, fromInstance.EntityDefinition, dosaTable.EntityDefinition)
+		assert.Equal(t, fromInstance.FieldToCol, dosaTable.FieldToCol)
+	}
+
+	// the type can be used to make new entities
+	dosaTable, _ := TableFromType(typ)
+	obj := reflect.New(dosaTable.ReflectType())
+	obj.Elem().FieldByName(dosaTable.ColToField["primarykey"]).SetInt(7)
+	assert.Equal(t, int64(7), obj.Interface().(*SinglePrimaryKey).PrimaryKey)
+
+	_, err = TableFromType(nil)
+	assert.Error(t, err)
+	_, err = TableFromType(reflect.TypeOf(1))
+	assert.EqualError(t, err, "type int is not a struct")
+	_, err = TableFromType(reflect.TypeOf(struct{ ID int64 }{}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must embed dosa.Entity")
+	_, err = TableFromType(reflect.TypeOf(NoPrimaryKey{}))
+	assert.Error(t, err)
+}
+
 func TestNilPointer(t *testing.T) {
 	dosaTable, err := TableFromInstance((*SinglePrimaryKeyNoParen)(nil))
 	assert.Nil(t, err)
@@ -108,8 +142,19 @@ type EmptyPrimaryKey struct {
 // unhappy path: If there is no field marked with a primary nor partition key, throw an error
 func TestEmptyPrimaryKey(t *testing.T) {
 	dosaTable, err := TableFromInstance(&EmptyPrimaryKey{})
-	assert.Nil(t, dosaTable)
-	assert.NotNil(t, err)
+	assert.NoError(t, err)
+	assert.True(t, dosaTable.ScanOnly())
+	assert.Equal(t, &PrimaryKey{PartitionKeys: []string{RowIDColumn}}, dosaTable.Key)
+	assert.Equal(t, &ColumnDefinition{Name: RowIDColumn, Type: TUUID}, dosaTable.FindColumnDefinition(RowIDColumn))
+	_, ok := dosaTable.ColToField[RowIDColumn]
+	assert.False(t, ok)
+
+	_, _, _, key, err := parseEntityTag("EmptyPrimaryKey", "primaryKey=( )")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{RowIDColumn}, key.PartitionKeys)
+
+	// the row ID can't clash with an existing column
+	assert.EqualError(t, addRowIDColumn(dosaTable), `column name "dosa_row_id" is reserved for the row ID of entities without a primary key`)
 }
 
 type PrimaryKeyWithSecondaryRange struct {
@@ -154,6 +199,38 @@ func TestMultiComponentPrimaryKey(t *testing.T) {
 	assert.Nil(t, dosaTable.Key.ClusteringKeys)
 }
 
+type PlusPartitionKey struct {
+	Entity         `dosa:"primaryKey=(PartKey + AnotherPartKey, ClusterKey DESC)"`
+	PartKey        int64
+	AnotherPartKey string
+	ClusterKey     int64
+}
+
+func TestPlusPartitionKey(t *testing.T) {
+	dosaTable, err := TableFromInstance(&PlusPartitionKey{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"partkey", "anotherpartkey"}, dosaTable.Key.PartitionKeys)
+	assert.Equal(t, []*ClusteringKey{{"clusterkey", true}}, dosaTable.Key.ClusteringKeys)
+
+	for tag, expected := range map[string]*PrimaryKey{
+		"A+B":         {PartitionKeys: []string{"A", "B"}},
+		"(A+B+C)":     {PartitionKeys: []string{"A", "B", "C"}},
+		"(A+B, C, D)": {PartitionKeys: []string{"A", "B"}, ClusteringKeys: []*ClusteringKey{{"C", false}, {"D", false}}},
+		"((A+B), C)":  {PartitionKeys: []string{"A", "B"}, ClusteringKeys: []*ClusteringKey{{"C", false}}},
+	} {
+		pk, err := parsePrimaryKey("t", tag)
+		assert.NoError(t, err, tag)
+		assert.Equal(t, expected, pk, tag)
+	}
+
+	_, err = parsePrimaryKey("t", "(A, B+C)")
+	assert.Contains(t, err.Error(), "only the partition key can be joined with +")
+	_, err = parsePrimaryKey("t", "(A+, B)")
+	assert.Contains(t, err.Error(), "empty column in composite partition key")
+	_, err = parsePrimaryKey("t", "(A+A, B)")
+	assert.Contains(t, err.Error(), "duplicate field")
+}
+
 type NameInPrimaryKey struct {
 	Entity     `dosa:"name=nameinprimarykey,primaryKey=(PrimaryKey, Name)"`
 	PrimaryKey int64
@@ -531,6 +608,195 @@ func TestIgnoreTag(t *testing.T) {
 	assert.Len(t, table.Columns, 1)
 }
 
+type ImmutableTagType struct {
+	Entity    `dosa:"primaryKey=ID"`
+	ID        int64
+	CreatedAt time.Time `dosa:"immutable"`
+	CreatedBy string    `dosa:"name=creator, immutable"`
+	UpdatedAt time.Time
+}
+
+func TestImmutableTag(t *testing.T) {
+	table, err := TableFromInstance(&ImmutableTagType{})
+	assert.NoError(t, err)
+	cols := table.ColumnMap()
+	assert.True(t, cols["createdat"].Immutable)
+	assert.True(t, cols["creator"].Immutable)
+	assert.False(t, cols["updatedat"].Immutable)
+	assert.False(t, cols["id"].Immutable)
+	assert.Equal(t, []string{"createdat", "creator"}, table.ImmutableColumns())
+
+	type ImmutableKey struct {
+		Entity `dosa:"primaryKey=ID"`
+		ID     int64 `dosa:"immutable"`
+	}
+	_, err = TableFromInstance(&ImmutableKey{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key column cannot be immutable")
+
+	type BadImmutableTag struct {
+		Entity    `dosa:"primaryKey=ID"`
+		ID        int64
+		CreatedAt time.Time `dosa:"immutablex"`
+	}
+	_, err = TableFromInstance(&BadImmutableTag{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid dosa field tag")
+}
+
+func TestMaxLengthTag(t *testing.T) {
+	for _, tc := range []struct {
+		typ       Type
+		tag       string
+		maxLength int
+		err       string
+	}{
+		{String, "", 0, ""},
+		{String, "maxlen=64", 64, ""},
+		{String, "name=email, maxlen = 254, immutable", 254, ""},
+		{Blob, "maxlen=16,name=avatar", 16, ""},
+		{String, "maxlen=0", 0, "maxlen must be a positive integer"},
+		{String, "maxlen=-1", 0, "maxlen must be a positive integer"},
+		{String, "maxlen=lots", 0, "maxlen must be a positive integer"},
+		{Int64, "maxlen=8", 0, "maxlen can only be used on string and []byte fields"},
+		{String, "mymaxlen=8", 0, "invalid dosa field tag"},
+	} {
+		cd, err := parseField(tc.typ, false, "Field", tc.tag)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.tag) {
+				assert.Contains(t, err.Error(), tc.err, tc.tag)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.tag) {
+			assert.Equal(t, tc.maxLength, cd.MaxLength, tc.tag)
+		}
+	}
+}
+
+func TestSensitiveTag(t *testing.T) {
+	for _, tc := range []struct {
+		tag       string
+		sensitive bool
+		err       string
+	}{
+		{"", false, ""},
+		{"sensitive", true, ""},
+		{"name=email, sensitive, immutable", true, ""},
+		{"sensitive,maxlen=254", true, ""},
+		{"insensitive", false, "invalid dosa field tag"},
+	} {
+		cd, err := parseField(String, false, "Field", tc.tag)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.tag) {
+				assert.Contains(t, err.Error(), tc.err, tc.tag)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.tag) {
+			assert.Equal(t, tc.sensitive, cd.SensitiveData, tc.tag)
+			assert.Equal(t, tc.sensitive, cd.Clone().SensitiveData, tc.tag)
+		}
+	}
+}
+
+func TestUniqueTag(t *testing.T) {
+	for _, tc := range []struct {
+		tag    string
+		unique bool
+		err    string
+	}{
+		{"", false, ""},
+		{"unique", true, ""},
+		{"name=email, unique, sensitive", true, ""},
+		{"unique,maxlen=254", true, ""},
+		{"nonunique", false, "invalid dosa field tag"},
+	} {
+		cd, err := parseField(String, false, "Field", tc.tag)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.tag) {
+				assert.Contains(t, err.Error(), tc.err, tc.tag)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.tag) {
+			assert.Equal(t, tc.unique, cd.UniqueConstraint, tc.tag)
+			assert.Equal(t, tc.unique, cd.Clone().UniqueConstraint, tc.tag)
+		}
+	}
+}
+
+func TestOrderedTag(t *testing.T) {
+	asc, desc := Ascending, Descending
+	for _, tc := range []struct {
+		tag       string
+		orderedBy *SortDirection
+		err       string
+	}{
+		{"", nil, ""},
+		{"ordered_asc", &asc, ""},
+		{"name=at, ordered_desc, sensitive", &desc, ""},
+		{"ordered_asc, ordered_desc", nil, "one of ordered_asc and ordered_desc"},
+		{"ordered", nil, "invalid dosa field tag"},
+	} {
+		cd, err := parseField(String, false, "Field", tc.tag)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.tag) {
+				assert.Contains(t, err.Error(), tc.err, tc.tag)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.tag) {
+			assert.Equal(t, tc.orderedBy, cd.OrderedBy, tc.tag)
+			assert.Equal(t, tc.orderedBy, cd.Clone().OrderedBy, tc.tag)
+		}
+	}
+}
+
+func TestDeprecatedTag(t *testing.T) {
+	for _, tc := range []struct {
+		tag        string
+		deprecated bool
+		since      string
+		err        string
+	}{
+		{"", false, "", ""},
+		{"deprecated", true, "", ""},
+		{"deprecated_since=v1.2.0", true, "v1.2.0", ""},
+		{"name=old_email, deprecated, sensitive", true, "", ""},
+		{"deprecated_since = 2020-01, immutable", true, "2020-01", ""},
+		{"deprecated_since=", false, "", "deprecated_since must name a version"},
+		{"undeprecated", false, "", "invalid dosa field tag"},
+	} {
+		cd, err := parseField(String, false, "Field", tc.tag)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.tag) {
+				assert.Contains(t, err.Error(), tc.err, tc.tag)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.tag) {
+			assert.Equal(t, tc.deprecated, cd.Deprecated, tc.tag)
+			assert.Equal(t, tc.since, cd.DeprecatedSince, tc.tag)
+			assert.Equal(t, tc.since, cd.Clone().DeprecatedSince, tc.tag)
+		}
+	}
+}
+
+type DeprecatedTagType struct {
+	Entity   `dosa:"primaryKey=ID"`
+	ID       string
+	Email    string `dosa:"deprecated_since=v2"`
+	Name     string
+	Nickname string `dosa:"deprecated"`
+}
+
+func TestDeprecatedColumns(t *testing.T) {
+	table, err := TableFromInstance(&DeprecatedTagType{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email", "nickname"}, table.DeprecatedColumns)
+}
+
 func TestExtraStuffInClusteringKeyDecl(t *testing.T) {
 	type BadClusteringKeyDefinition struct {
 		Entity     `dosa:"primaryKey=(BoolType,StringType asc asc)"`
@@ -0,0 +1,256 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa/dosapb"
+)
+
+// E_PrimaryKey is the field option set on the fields of the primary key
+// columns in the descriptors made by Table.ToProtobufDescriptor
+var E_PrimaryKey = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         51234,
+	Name:          "dosa.primary_key",
+	Tag:           "varint,51234,opt,name=primary_key",
+}
+
+func init() {
+	proto.RegisterExtension(E_PrimaryKey)
+}
+
+// protobufTypes maps DOSA types to protobuf field types
+var protobufTypes = map[Type]descriptor.FieldDescriptorProto_Type{
+	TUUID:     descriptor.FieldDescriptorProto_TYPE_STRING,
+	String:    descriptor.FieldDescriptorProto_TYPE_STRING,
+	Int32:     descriptor.FieldDescriptorProto_TYPE_INT32,
+	Int64:     descriptor.FieldDescriptorProto_TYPE_INT64,
+	Double:    descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+	Blob:      descriptor.FieldDescriptorProto_TYPE_BYTES,
+	Timestamp: descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+	Bool:      descriptor.FieldDescriptorProto_TYPE_BOOL,
+}
+
+// ToProtobufDescriptor describes the table as a protobuf message, for schema
+// registries that take protobuf descriptors. Fields are numbered from 1 in
+// alphabetical order of the column names, so the numbers only change when
+// columns are added or removed. Timestamps are google.protobuf.Timestamp
+// messages, UUIDs are strings, and the fields of the primary key columns have
+// the E_PrimaryKey option set.
+func (t *Table) ToProtobufDescriptor() *descriptor.DescriptorProto {
+	columns := make([]*ColumnDefinition, len(t.Columns))
+	copy(columns, t.Columns)
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Name < columns[j].Name })
+
+	keys := t.KeySet()
+	fields := make([]*descriptor.FieldDescriptorProto, 0, len(columns))
+	for i, c := range columns {
+		field := &descriptor.FieldDescriptorProto{
+			Name:   proto.String(c.Name),
+			Number: proto.Int32(int32(i + 1)),
+			Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   protobufTypes[c.Type].Enum(),
+		}
+		if c.Type == Timestamp {
+			field.TypeName = proto.String(".google.protobuf.Timestamp")
+		}
+		if _, ok := keys[c.Name]; ok {
+			field.Options = &descriptor.FieldOptions{}
+			// only fails for a mismatched extension type, which the tests catch
+			if err := proto.SetExtension(field.Options, E_PrimaryKey, proto.Bool(true)); err != nil {
+				panic(err)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return &descriptor.DescriptorProto{
+		Name:  proto.String(t.Name),
+		Field: fields,
+	}
+}
+
+// ToProto converts the entity definition to its message in the
+// SchemaRegistry service of dosapb/registry.proto
+func (e *EntityDefinition) ToProto() *dosapb.EntityDefinitionProto {
+	pb := &dosapb.EntityDefinitionProto{
+		Name: e.Name,
+		Key:  primaryKeyToProto(e.Key),
+		Etl:  string(e.ETL),
+	}
+	for _, cd := range e.Columns {
+		var tags map[string]string
+		if len(cd.Tags) > 0 {
+			tags = make(map[string]string, len(cd.Tags))
+			for k, v := range cd.Tags {
+				tags[k] = v
+			}
+		}
+		pb.Columns = append(pb.Columns, &dosapb.ColumnDefinitionProto{
+			Name:             cd.Name,
+			Type:             dosapb.Type(cd.Type),
+			IsPointer:        cd.IsPointer,
+			Immutable:        cd.Immutable,
+			MaxLength:        int32(cd.MaxLength),
+			EstimatedBytes:   int32(cd.EstimatedBytes),
+			SensitiveData:    cd.SensitiveData,
+			UniqueConstraint: cd.UniqueConstraint,
+			Deprecated:       cd.Deprecated,
+			DeprecatedSince:  cd.DeprecatedSince,
+			Tags:             tags,
+			OrderedBy:        orderedByString(cd.OrderedBy),
+		})
+	}
+	if len(e.Indexes) > 0 {
+		pb.Indexes = make(map[string]*dosapb.IndexDefinitionProto, len(e.Indexes))
+		for name, index := range e.Indexes {
+			pb.Indexes[name] = &dosapb.IndexDefinitionProto{
+				Key:        primaryKeyToProto(index.Key),
+				TtlSeconds: int64(index.TTL / time.Second),
+			}
+		}
+	}
+	return pb
+}
+
+func primaryKeyToProto(pk *PrimaryKey) *dosapb.PrimaryKeyProto {
+	if pk == nil {
+		return nil
+	}
+	pb := &dosapb.PrimaryKeyProto{PartitionKeys: append([]string(nil), pk.PartitionKeys...)}
+	for _, ck := range pk.ClusteringKeys {
+		pb.ClusteringKeys = append(pb.ClusteringKeys, &dosapb.ClusteringKeyProto{Name: ck.Name, Descending: ck.Descending})
+	}
+	return pb
+}
+
+// TableFromProto builds a table from the message made by
+// EntityDefinition.ToProto. Since there is no Go struct, the struct and field
+// names are the entity and column names in PascalCase, for example
+// "order_item" becomes "OrderItem", and the table has no TTL. An empty ETL
+// state is off. The table is checked with EnsureValid.
+func TableFromProto(pb *dosapb.EntityDefinitionProto) (*Table, error) {
+	if pb == nil {
+		return nil, errors.New("nil entity definition proto")
+	}
+	t := &Table{
+		EntityDefinition: EntityDefinition{
+			Name:    pb.Name,
+			Key:     primaryKeyFromProto(pb.Key),
+			Indexes: make(map[string]*IndexDefinition, len(pb.Indexes)),
+			ETL:     EtlOff,
+		},
+		StructName: pascalCase(pb.Name),
+		ColToField: make(map[string]string, len(pb.Columns)),
+		FieldToCol: make(map[string]string, len(pb.Columns)),
+		TTL:        NoTTL(),
+	}
+	if pb.Etl != "" {
+		etl, err := ToETLState(pb.Etl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid table %q", pb.Name)
+		}
+		t.ETL = etl
+	}
+	for _, c := range pb.Columns {
+		if c == nil {
+			return nil, errors.Errorf("nil column definition in table %q", pb.Name)
+		}
+		if _, ok := dosapb.Type_name[int32(c.Type)]; !ok {
+			return nil, errors.Errorf("invalid type %d for column %q of table %q", c.Type, c.Name, pb.Name)
+		}
+		var tags map[string]string
+		if len(c.Tags) > 0 {
+			tags = make(map[string]string, len(c.Tags))
+			for k, v := range c.Tags {
+				tags[k] = v
+			}
+		}
+		orderedBy, err := orderedByFromString(c.OrderedBy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q of table %q", c.Name, pb.Name)
+		}
+		t.Columns = append(t.Columns, &ColumnDefinition{
+			Name:             c.Name,
+			Type:             Type(c.Type),
+			IsPointer:        c.IsPointer,
+			Immutable:        c.Immutable,
+			MaxLength:        int(c.MaxLength),
+			EstimatedBytes:   int(c.EstimatedBytes),
+			SensitiveData:    c.SensitiveData,
+			UniqueConstraint: c.UniqueConstraint,
+			Deprecated:       c.Deprecated,
+			DeprecatedSince:  c.DeprecatedSince,
+			OrderedBy:        orderedBy,
+			Tags:             tags,
+		})
+		field := pascalCase(c.Name)
+		t.ColToField[c.Name] = field
+		t.FieldToCol[field] = c.Name
+		if c.Deprecated {
+			t.DeprecatedColumns = append(t.DeprecatedColumns, c.Name)
+		}
+	}
+	for name, index := range pb.Indexes {
+		if index == nil {
+			return nil, errors.Errorf("nil index %q in table %q", name, pb.Name)
+		}
+		t.Indexes[name] = &IndexDefinition{
+			Key: primaryKeyFromProto(index.Key),
+			TTL: time.Duration(index.TtlSeconds) * time.Second,
+		}
+	}
+	if err := t.EnsureValid(); err != nil {
+		return nil, errors.Wrapf(err, "invalid table %q", pb.Name)
+	}
+	return t, nil
+}
+
+func primaryKeyFromProto(pb *dosapb.PrimaryKeyProto) *PrimaryKey {
+	if pb == nil {
+		return nil
+	}
+	pk := &PrimaryKey{PartitionKeys: append([]string(nil), pb.PartitionKeys...)}
+	for _, ck := range pb.ClusteringKeys {
+		if ck != nil {
+			pk.ClusteringKeys = append(pk.ClusteringKeys, &ClusteringKey{Name: ck.Name, Descending: ck.Descending})
+		}
+	}
+	return pk
+}
+
+// pascalCase turns a normalized name such as "order_item" into "OrderItem"
+func pascalCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/dosapb"
+)
+
+func TestTable_ToProtobufDescriptor(t *testing.T) {
+	table, err := dosa.NewTable("orders", []*dosa.ColumnDefinition{
+		{Name: "customer", Type: dosa.String},
+		{Name: "placed", Type: dosa.Timestamp},
+		{Name: "id", Type: dosa.TUUID},
+		{Name: "total", Type: dosa.Double, IsPointer: true},
+		{Name: "count", Type: dosa.Int64},
+		{Name: "region", Type: dosa.Int32},
+		{Name: "paid", Type: dosa.Bool},
+		{Name: "receipt", Type: dosa.Blob},
+	}, &dosa.PrimaryKey{
+		PartitionKeys:  []string{"customer"},
+		ClusteringKeys: []*dosa.ClusteringKey{{Name: "placed"}},
+	})
+	assert.NoError(t, err)
+
+	// the descriptor survives encoding, extensions included
+	data, err := proto.Marshal(table.ToProtobufDescriptor())
+	assert.NoError(t, err)
+	desc := &descriptor.DescriptorProto{}
+	assert.NoError(t, proto.Unmarshal(data, desc))
+
+	assert.Equal(t, "orders", desc.GetName())
+	assert.Len(t, desc.GetField(), 8)
+	expected := []struct {
+		name string
+		typ  descriptor.FieldDescriptorProto_Type
+		key  bool
+	}{
+		{"count", descriptor.FieldDescriptorProto_TYPE_INT64, false},
+		{"customer", descriptor.FieldDescriptorProto_TYPE_STRING, true},
+		{"id", descriptor.FieldDescriptorProto_TYPE_STRING, false},
+		{"paid", descriptor.FieldDescriptorProto_TYPE_BOOL, false},
+		{"placed", descriptor.FieldDescriptorProto_TYPE_MESSAGE, true},
+		{"receipt", descriptor.FieldDescriptorProto_TYPE_BYTES, false},
+		{"region", descriptor.FieldDescriptorProto_TYPE_INT32, false},
+		{"total", descriptor.FieldDescriptorProto_TYPE_DOUBLE, false},
+	}
+	for i, field := range desc.GetField() {
+		assert.Equal(t, expected[i].name, field.GetName())
+		assert.Equal(t, int32(i+1), field.GetNumber())
+		assert.Equal(t, expected[i].typ, field.GetType(), field.GetName())
+		isKey := field.GetOptions() != nil && proto.HasExtension(field.GetOptions(), dosa.E_PrimaryKey)
+		assert.Equal(t, expected[i].key, isKey, field.GetName())
+	}
+	assert.Equal(t, ".google.protobuf.Timestamp", desc.GetField()[4].GetTypeName())
+
+	pk, err := proto.GetExtension(desc.GetField()[1].GetOptions(), dosa.E_PrimaryKey)
+	assert.NoError(t, err)
+	assert.Equal(t, true, *pk.(*bool))
+}
+
+func TestTableFromProto_RoundTrip(t *testing.T) {
+	id := &dosa.ColumnDefinition{Name: "id", Type: dosa.TUUID}
+	key := &dosa.PrimaryKey{PartitionKeys: []string{"id"}}
+	descending := dosa.Descending
+	definitions := []*dosa.EntityDefinition{
+		{Name: "simple", Key: key, Columns: []*dosa.ColumnDefinition{id}},
+		{Name: "all_types", Key: key, Columns: []*dosa.ColumnDefinition{
+			id,
+			{Name: "s", Type: dosa.String},
+			{Name: "i32", Type: dosa.Int32},
+			{Name: "i64", Type: dosa.Int64},
+			{Name: "d", Type: dosa.Double},
+			{Name: "b", Type: dosa.Blob},
+			{Name: "ts", Type: dosa.Timestamp},
+			{Name: "ok", Type: dosa.Bool},
+		}},
+		{Name: "pointers", Key: key, Columns: []*dosa.ColumnDefinition{
+			id, {Name: "name", Type: dosa.String, IsPointer: true}, {Name: "age", Type: dosa.Int32, IsPointer: true},
+		}},
+		{Name: "clustered", Key: &dosa.PrimaryKey{
+			PartitionKeys:  []string{"id"},
+			ClusteringKeys: []*dosa.ClusteringKey{{Name: "at", Descending: true}, {Name: "seq"}},
+		}, Columns: []*dosa.ColumnDefinition{id, {Name: "at", Type: dosa.Timestamp}, {Name: "seq", Type: dosa.Int64}}},
+		{Name: "composite_partition", Key: &dosa.PrimaryKey{PartitionKeys: []string{"city", "id"}}, Columns: []*dosa.ColumnDefinition{
+			id, {Name: "city", Type: dosa.String},
+		}},
+		{Name: "indexed", Key: key, Columns: []*dosa.ColumnDefinition{id, {Name: "email", Type: dosa.String}}, Indexes: map[string]*dosa.IndexDefinition{
+			"by_email": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"email"}, ClusteringKeys: []*dosa.ClusteringKey{{Name: "id"}}}},
+			"recent":   {Key: &dosa.PrimaryKey{PartitionKeys: []string{"email"}}, TTL: time.Hour},
+		}},
+		{Name: "with_etl", Key: key, Columns: []*dosa.ColumnDefinition{id}, ETL: dosa.EtlOn},
+		{Name: "limits", Key: key, Columns: []*dosa.ColumnDefinition{
+			id, {Name: "bio", Type: dosa.String, MaxLength: 256, EstimatedBytes: 100},
+		}},
+		{Name: "flags", Key: key, Columns: []*dosa.ColumnDefinition{
+			id,
+			{Name: "ssn", Type: dosa.String, SensitiveData: true, Immutable: true},
+			{Name: "handle", Type: dosa.String, UniqueConstraint: true},
+			{Name: "old", Type: dosa.String, Deprecated: true, DeprecatedSince: "v2"},
+		}, Indexes: map[string]*dosa.IndexDefinition{
+			"by_handle": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"handle"}}},
+		}},
+		{Name: "tagged", Key: key, Columns: []*dosa.ColumnDefinition{
+			id, {Name: "note", Type: dosa.String, Tags: map[string]string{"pii": "", "owner": "team"}},
+		}},
+		{Name: "ordered", Key: key, Columns: []*dosa.ColumnDefinition{
+			id, {Name: "score", Type: dosa.Int64, OrderedBy: &descending},
+		}, Indexes: map[string]*dosa.IndexDefinition{
+			"by_score": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}, ClusteringKeys: []*dosa.ClusteringKey{{Name: "score"}}}},
+		}},
+	}
+
+	for _, ed := range definitions {
+		if ed.Indexes == nil {
+			ed.Indexes = map[string]*dosa.IndexDefinition{}
+		}
+		if ed.ETL == "" {
+			ed.ETL = dosa.EtlOff
+		}
+
+		// through the wire encoding too
+		data, err := proto.Marshal(ed.ToProto())
+		assert.NoError(t, err, ed.Name)
+		pb := &dosapb.EntityDefinitionProto{}
+		assert.NoError(t, proto.Unmarshal(data, pb), ed.Name)
+
+		table, err := dosa.TableFromProto(pb)
+		if !assert.NoError(t, err, ed.Name) {
+			continue
+		}
+		assert.Equal(t, ed, &table.EntityDefinition, ed.Name)
+		for _, cd := range ed.Columns {
+			assert.Equal(t, cd.Name, table.FieldToCol[table.ColToField[cd.Name]], ed.Name)
+		}
+	}
+
+	table, err := dosa.TableFromProto(definitions[1].ToProto())
+	assert.NoError(t, err)
+	assert.Equal(t, "AllTypes", table.StructName)
+	assert.Equal(t, "I32", table.ColToField["i32"])
+	assert.Equal(t, dosa.NoTTL(), table.TTL)
+	table, err = dosa.TableFromProto(definitions[8].ToProto())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old"}, table.DeprecatedColumns)
+}
+
+func TestTableFromProto_Invalid(t *testing.T) {
+	_, err := dosa.TableFromProto(nil)
+	assert.Error(t, err)
+
+	pb := &dosapb.EntityDefinitionProto{
+		Name:    "bad",
+		Key:     &dosapb.PrimaryKeyProto{PartitionKeys: []string{"id"}},
+		Columns: []*dosapb.ColumnDefinitionProto{{Name: "id", Type: dosapb.Type(42)}},
+	}
+	_, err = dosa.TableFromProto(pb)
+	assert.Contains(t, err.Error(), "invalid type 42")
+
+	pb.Columns[0].Type = dosapb.Type_STRING
+	pb.Etl = "maybe"
+	_, err = dosa.TableFromProto(pb)
+	assert.Contains(t, err.Error(), "unrecognized ETL state")
+
+	pb.Etl = ""
+	pb.Columns[0].OrderedBy = "sideways"
+	_, err = dosa.TableFromProto(pb)
+	assert.Contains(t, err.Error(), `invalid sort direction "sideways"`)
+	pb.Columns[0].OrderedBy = ""
+
+	// EnsureValid catches keys on missing columns
+	pb.Key.PartitionKeys = []string{"missing"}
+	_, err = dosa.TableFromProto(pb)
+	assert.Error(t, err)
+}
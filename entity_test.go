@@ -21,10 +21,13 @@
 package dosa_test
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/testentity"
 )
 
 func TestEntityDefinitionEnsureValid(t *testing.T) {
@@ -58,6 +61,18 @@ func TestEntityDefinitionEnsureValid(t *testing.T) {
 	invalidPartitionKeyName := getValidEntityDefinition()
 	invalidPartitionKeyName.Key.PartitionKeys[0] = "fox"
 
+	negativeMaxLength := getValidEntityDefinition()
+	negativeMaxLength.Columns[2].MaxLength = -1
+
+	maxLengthOnInt := getValidEntityDefinition()
+	maxLengthOnInt.Columns[1].MaxLength = 10
+
+	maxLengthOnBlob := getValidEntityDefinition()
+	maxLengthOnBlob.Columns[2].MaxLength = 10
+
+	sortedPartitionKey := getValidEntityDefinition()
+	sortedPartitionKey.Key.PartitionKeys[0] = "foo DESC"
+
 	dupParitionKeyNames := getValidEntityDefinition()
 	dupParitionKeyNames.Key.PartitionKeys = append(dupParitionKeyNames.Key.PartitionKeys, "foo")
 
@@ -73,6 +88,25 @@ func TestEntityDefinitionEnsureValid(t *testing.T) {
 	noClusteringKey := getValidEntityDefinition()
 	noClusteringKey.Key.ClusteringKeys = []*dosa.ClusteringKey{}
 
+	immutablePartitionKey := getValidEntityDefinition()
+	immutablePartitionKey.Columns[0].Immutable = true
+
+	immutableClusteringKey := getValidEntityDefinition()
+	immutableClusteringKey.Columns[1].Immutable = true
+
+	immutableColumn := getValidEntityDefinition()
+	immutableColumn.Columns[2].Immutable = true
+
+	compositePartitionKey := getValidEntityDefinition()
+	compositePartitionKey.Key.PartitionKeys = []string{"foo", "qux"}
+
+	compositePartitionKeyMissing := getValidEntityDefinition()
+	compositePartitionKeyMissing.Key.PartitionKeys = []string{"foo", "fox"}
+
+	compositePartitionKeyNullable := getValidEntityDefinition()
+	compositePartitionKeyNullable.Key.PartitionKeys = []string{"foo", "qux"}
+	compositePartitionKeyNullable.Columns[2].IsPointer = true
+
 	data := []testData{
 		{
 			e:     nil,
@@ -154,6 +188,26 @@ func TestEntityDefinitionEnsureValid(t *testing.T) {
 			valid: false,
 			msg:   "a column cannot be used twice in key",
 		},
+		{
+			e:     negativeMaxLength,
+			valid: false,
+			msg:   "negative max length for column: \"qux\"",
+		},
+		{
+			e:     maxLengthOnInt,
+			valid: false,
+			msg:   "max length can only be set on String and Blob columns: \"bar\"",
+		},
+		{
+			e:     maxLengthOnBlob,
+			valid: true,
+			msg:   "blob columns can have a max length",
+		},
+		{
+			e:     sortedPartitionKey,
+			valid: false,
+			msg:   "sort direction can only be specified for clustering keys: \"foo DESC\"",
+		},
 		{
 			e:     getValidEntityDefinition(),
 			valid: true,
@@ -169,6 +223,36 @@ func TestEntityDefinitionEnsureValid(t *testing.T) {
 			valid: false,
 			msg:   "nil clustering key",
 		},
+		{
+			e:     immutablePartitionKey,
+			valid: false,
+			msg:   "key column cannot be immutable: \"foo\"",
+		},
+		{
+			e:     immutableClusteringKey,
+			valid: false,
+			msg:   "key column cannot be immutable: \"bar\"",
+		},
+		{
+			e:     immutableColumn,
+			valid: true,
+			msg:   "non-key columns can be immutable",
+		},
+		{
+			e:     compositePartitionKey,
+			valid: true,
+			msg:   "multi-column partition key",
+		},
+		{
+			e:     compositePartitionKeyMissing,
+			valid: false,
+			msg:   "partition key does not refer to a column: \"fox\"",
+		},
+		{
+			e:     compositePartitionKeyNullable,
+			valid: false,
+			msg:   "primary key is of nullable type: \"qux\"",
+		},
 	}
 
 	for _, entry := range data {
@@ -219,6 +303,19 @@ func TestEntityDefinitionEnsureValidForIndex(t *testing.T) {
 	noClusteringKey := getValidEntityDefinition()
 	noClusteringKey.Indexes["index1"].Key.ClusteringKeys = []*dosa.ClusteringKey{}
 
+	uniqueIndexed := getValidEntityDefinition()
+	uniqueIndexed.Columns[2].UniqueConstraint = true
+
+	uniqueNotIndexed := getValidEntityDefinition()
+	uniqueNotIndexed.Columns[0].UniqueConstraint = true
+
+	descending := dosa.Descending
+	orderedIndexed := getValidEntityDefinition()
+	orderedIndexed.Columns[2].OrderedBy = &descending
+
+	orderedNotIndexed := getValidEntityDefinition()
+	orderedNotIndexed.Columns[0].OrderedBy = &descending
+
 	data := []testData{
 		{
 			e:     invalidName,
@@ -285,6 +382,26 @@ func TestEntityDefinitionEnsureValidForIndex(t *testing.T) {
 			valid: false,
 			msg:   "nil clustering key",
 		},
+		{
+			e:     uniqueIndexed,
+			valid: true,
+			msg:   "unique column with an index is ok",
+		},
+		{
+			e:     uniqueNotIndexed,
+			valid: false,
+			msg:   `unique column "foo" needs an index with it as the only partition key`,
+		},
+		{
+			e:     orderedIndexed,
+			valid: true,
+			msg:   "ordered column in an index is ok",
+		},
+		{
+			e:     orderedNotIndexed,
+			valid: false,
+			msg:   `ordered column "foo" is not part of an index`,
+		},
 	}
 
 	for _, entry := range data {
@@ -298,6 +415,33 @@ func TestEntityDefinitionEnsureValidForIndex(t *testing.T) {
 	}
 }
 
+func TestEntityDefinitionUniqueColumns(t *testing.T) {
+	ed := getValidEntityDefinition()
+	assert.Empty(t, ed.UniqueColumns())
+	ed.Columns[2].UniqueConstraint = true
+	assert.Equal(t, []string{"qux"}, ed.UniqueColumns())
+	assert.Equal(t, "index1", ed.UniqueIndex("qux"))
+	assert.Equal(t, "index2", ed.UniqueIndex("bar"))
+	assert.Equal(t, "", ed.UniqueIndex("foo"))
+}
+
+func TestEntityDefinitionEstimatedRowSizeBytes(t *testing.T) {
+	ed := getValidEntityDefinition()
+	// foo is a UUID, bar an int64 and qux a blob
+	assert.Equal(t, 16+8+dosa.DefaultEstimatedBlobBytes, ed.EstimatedRowSizeBytes())
+
+	ed.Columns[2].EstimatedBytes = 100
+	ed.Columns = append(ed.Columns,
+		&dosa.ColumnDefinition{Name: "name", Type: dosa.String},
+		&dosa.ColumnDefinition{Name: "at", Type: dosa.Timestamp},
+		&dosa.ColumnDefinition{Name: "ok", Type: dosa.Bool},
+		&dosa.ColumnDefinition{Name: "n", Type: dosa.Int32},
+		&dosa.ColumnDefinition{Name: "x", Type: dosa.Double},
+	)
+	assert.Equal(t, 16+8+100+dosa.DefaultEstimatedStringBytes+8+1+4+8, ed.EstimatedRowSizeBytes())
+	assert.Equal(t, 0, (&dosa.EntityDefinition{}).EstimatedRowSizeBytes())
+}
+
 func TestEntityDefinitionHelpers(t *testing.T) {
 	ed := getValidEntityDefinition()
 
@@ -319,6 +463,27 @@ func TestEntityDefinitionHelpers(t *testing.T) {
 	assert.Equal(t, expectedKeySet, ed.KeySet())
 }
 
+func TestEntityDefinitionWarnings(t *testing.T) {
+	ed := getValidEntityDefinition()
+	assert.Empty(t, ed.Warnings())
+
+	ed.Columns[0].Type = dosa.String
+	assert.Equal(t, []string{`string partition key "foo" of "testentity" has no max length`}, ed.Warnings())
+	assert.NoError(t, ed.EnsureValid())
+
+	ed.Columns[0].MaxLength = 64
+	assert.Empty(t, ed.Warnings())
+
+	// a TTL is fine on an index, but not on a copy of the primary key
+	ed.Indexes["index1"].TTL = time.Hour
+	assert.Empty(t, ed.Warnings())
+	ed.Indexes["index3"] = &dosa.IndexDefinition{Key: ed.Key.Clone(), TTL: time.Hour}
+	assert.Equal(t, []string{`index "index3" of "testentity" has a TTL but its key is the primary key`}, ed.Warnings())
+	assert.NoError(t, ed.EnsureValid())
+
+	assert.Empty(t, (*dosa.EntityDefinition)(nil).Warnings())
+}
+
 func getValidEntityDefinition() *dosa.EntityDefinition {
 	return &dosa.EntityDefinition{
 		Name: "testentity",
@@ -490,6 +655,11 @@ func TestEntityDefinitionCanBeUpsertedOn(t *testing.T) {
 	err = validEd.CanBeUpsertedOn(aEd)
 	assert.Error(t, err)
 
+	// remove a deprecated column
+	aEd.Columns[len(aEd.Columns)-1].Deprecated = true
+	err = validEd.CanBeUpsertedOn(aEd)
+	assert.NoError(t, err)
+
 	// add new index
 	aEd = getValidEntityDefinition()
 	aEd.Indexes["newindex"] = &dosa.IndexDefinition{
@@ -520,6 +690,77 @@ func TestEntityDefinitionCanBeUpsertedOn(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEntityDefinitionMerge(t *testing.T) {
+	ed := getValidEntityDefinition()
+	ed.ETL = dosa.EtlOff
+	other := getValidEntityDefinition()
+	other.Columns = append(other.Columns, &dosa.ColumnDefinition{Name: "extra", Type: dosa.String})
+	other.Indexes = map[string]*dosa.IndexDefinition{
+		"index1": other.Indexes["index1"],
+		"index3": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"extra"}}},
+	}
+
+	merged, err := ed.Merge(other)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "qux", "extra"}, columnNames(merged))
+	assert.Len(t, merged.Indexes, 3)
+	assert.Equal(t, dosa.EtlOn, merged.ETL)
+	assert.NoError(t, merged.EnsureValid())
+	// neither input is modified
+	assert.Len(t, ed.Columns, 3)
+	assert.Len(t, ed.Indexes, 2)
+
+	// merging with itself is a no-op
+	merged, err = ed.Merge(ed)
+	assert.NoError(t, err)
+	assert.Equal(t, ed, merged)
+
+	errEd := getValidEntityDefinition()
+	errEd.Columns[2].Type = dosa.String
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the type for column qux mismatch")
+
+	errEd = getValidEntityDefinition()
+	errEd.Name = "other"
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "entity name")
+
+	errEd = getValidEntityDefinition()
+	errEd.Key.PartitionKeys = []string{"qux"}
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "partition key mismatch")
+
+	errEd = getValidEntityDefinition()
+	errEd.Key.ClusteringKeys[0].Descending = false
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "clustering key mismatch")
+
+	errEd = getValidEntityDefinition()
+	errEd.Indexes["index2"] = &dosa.IndexDefinition{Key: &dosa.PrimaryKey{PartitionKeys: []string{"qux"}}}
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `index "index2" mismatch`)
+
+	// the result must be valid
+	errEd = getValidEntityDefinition()
+	errEd.Columns = append(errEd.Columns, &dosa.ColumnDefinition{Name: "Bad Name", Type: dosa.String})
+	_, err = ed.Merge(errEd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "merged entity definition is invalid")
+}
+
+func columnNames(ed *dosa.EntityDefinition) []string {
+	names := make([]string, len(ed.Columns))
+	for i, c := range ed.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
 func TestEntityDefinition_FindColumnDefinition(t *testing.T) {
 	ed := getValidEntityDefinition()
 
@@ -531,8 +772,230 @@ func TestEntityDefinition_FindColumnDefinition(t *testing.T) {
 	assert.Nil(t, ed.FindColumnDefinition("notacolumn"))
 }
 
+func TestEntityDefinition_IndexFor(t *testing.T) {
+	byCity := &dosa.IndexDefinition{Key: &dosa.PrimaryKey{
+		PartitionKeys:  []string{"city"},
+		ClusteringKeys: []*dosa.ClusteringKey{{Name: "zip"}},
+	}}
+	byCityAndName := &dosa.IndexDefinition{Key: &dosa.PrimaryKey{
+		PartitionKeys:  []string{"city"},
+		ClusteringKeys: []*dosa.ClusteringKey{{Name: "name"}, {Name: "zip"}},
+	}}
+	ed := &dosa.EntityDefinition{
+		Name: "t",
+		Key: &dosa.PrimaryKey{
+			PartitionKeys:  []string{"id"},
+			ClusteringKeys: []*dosa.ClusteringKey{{Name: "ts"}},
+		},
+		Indexes: map[string]*dosa.IndexDefinition{"by_city": byCity, "by_city_name": byCityAndName},
+	}
+
+	idx, ok := ed.IndexFor([]string{"id", "ts"})
+	assert.True(t, ok)
+	assert.True(t, idx.Key == ed.Key, "the primary key is returned as is")
+
+	// both indexes have the columns, by_city has the longer prefix
+	idx, ok = ed.IndexFor([]string{"city", "zip"})
+	assert.True(t, ok)
+	assert.Equal(t, byCity, idx)
+
+	// only by_city_name has name
+	idx, ok = ed.IndexFor([]string{"city", "name"})
+	assert.True(t, ok)
+	assert.Equal(t, byCityAndName, idx)
+
+	// on a tie the first index by name wins
+	idx, ok = ed.IndexFor([]string{"city"})
+	assert.True(t, ok)
+	assert.Equal(t, byCity, idx)
+
+	// no key has all of the columns, or all of the partition keys
+	_, ok = ed.IndexFor([]string{"id", "city"})
+	assert.False(t, ok)
+	_, ok = ed.IndexFor([]string{"zip"})
+	assert.False(t, ok)
+	_, ok = ed.IndexFor(nil)
+	assert.False(t, ok)
+}
+
+func TestTable_AcceptsType(t *testing.T) {
+	table, err := dosa.NewTable("t", []*dosa.ColumnDefinition{
+		{Name: "id", Type: dosa.TUUID},
+		{Name: "count", Type: dosa.Int64},
+		{Name: "name", Type: dosa.String, IsPointer: true},
+		{Name: "ts", Type: dosa.Timestamp, IsPointer: true},
+	}, &dosa.PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.NoError(t, err)
+	name := "name"
+
+	assert.True(t, table.AcceptsType("id", dosa.UUID("3e4befa0-69d2-11e7-a06a-b22b68e1b9f4")))
+	assert.False(t, table.AcceptsType("id", "3e4befa0-69d2-11e7-a06a-b22b68e1b9f4"))
+	assert.True(t, table.AcceptsType("count", int64(1)))
+	assert.False(t, table.AcceptsType("count", int32(1)))
+	assert.False(t, table.AcceptsType("count", 1))
+	assert.False(t, table.AcceptsType("count", nil), "not nullable")
+	assert.True(t, table.AcceptsType("name", &name))
+	assert.True(t, table.AcceptsType("name", name))
+	assert.True(t, table.AcceptsType("name", nil))
+	assert.True(t, table.AcceptsType("ts", dosa.NullTimestamp))
+	assert.True(t, table.AcceptsType("ts", time.Now()))
+	assert.False(t, table.AcceptsType("ts", &name))
+	assert.False(t, table.AcceptsType("missing", int64(1)))
+
+	assert.NoError(t, table.AcceptsValues(map[string]dosa.FieldValue{"count": int64(1), "name": &name}))
+	err = table.AcceptsValues(map[string]dosa.FieldValue{"count": int32(1), "id": "x", "other": 1, "name": name})
+	assert.EqualError(t, err, `invalid values for "t": column "count" of type int64 cannot hold a int32; `+
+		`column "id" of type UUID cannot hold a string; unknown column "other"`)
+}
+
+func TestTable_FieldMapping(t *testing.T) {
+	table, err := dosa.TableFromInstance(&testentity.TestEntity{})
+	assert.NoError(t, err)
+	mapping := table.FieldMapping()
+	assert.Len(t, mapping, len(table.Columns))
+
+	meta := mapping["UUIDKey"]
+	assert.Equal(t, "UUIDKey", meta.Field)
+	assert.Equal(t, "an_uuid_key", meta.Name)
+	assert.Equal(t, dosa.TUUID, meta.Type)
+	assert.False(t, meta.IsPointer)
+	assert.True(t, mapping["StrVP"].IsPointer)
+
+	// the kind is that of the field, or of what it points to
+	typ := reflect.TypeOf(testentity.TestEntity{})
+	for field, meta := range mapping {
+		sf, ok := typ.FieldByName(field)
+		if assert.True(t, ok, field) {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			assert.Equal(t, ft.Kind(), meta.Kind, field)
+		}
+	}
+}
+
 func TestClone(t *testing.T) {
 	ed := getValidEntityDefinition()
 	ed1 := ed.Clone()
 	assert.Equal(t, ed, ed1)
 }
+
+func TestNewTable(t *testing.T) {
+	columns := []*dosa.ColumnDefinition{
+		{Name: "id", Type: dosa.TUUID},
+		{Name: "name", Type: dosa.String},
+	}
+	table, err := dosa.NewTable(" MyTable ", columns, &dosa.PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "mytable", table.Name)
+	assert.Equal(t, "mytable", table.StructName)
+	assert.Equal(t, columns, table.Columns)
+	assert.Equal(t, map[string]string{"id": "id", "name": "name"}, table.ColToField)
+	assert.Equal(t, map[string]string{"id": "id", "name": "name"}, table.FieldToCol)
+	assert.Equal(t, dosa.NoTTL(), table.TTL)
+	assert.Empty(t, table.Indexes)
+
+	_, err = dosa.NewTable("1bad", columns, &dosa.PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.Contains(t, err.Error(), "invalid table name")
+
+	_, err = dosa.NewTable("t", columns, &dosa.PrimaryKey{PartitionKeys: []string{"missing"}})
+	assert.Contains(t, err.Error(), "invalid table \"t\"")
+
+	_, err = dosa.NewTable("t", columns, nil)
+	assert.Error(t, err)
+
+	_, err = dosa.NewTable("t", []*dosa.ColumnDefinition{nil}, &dosa.PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.Contains(t, err.Error(), "nil column definition")
+}
+
+func TestTableFromDefinition(t *testing.T) {
+	descending := dosa.Descending
+	ed := &dosa.EntityDefinition{
+		Name: "users",
+		Key:  &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+		Columns: []*dosa.ColumnDefinition{
+			{Name: "id", Type: dosa.TUUID},
+			{Name: "email", Type: dosa.String, OrderedBy: &descending},
+			{Name: "nick", Type: dosa.String, IsPointer: true, Deprecated: true, DeprecatedSince: "v2"},
+		},
+		Indexes: map[string]*dosa.IndexDefinition{
+			"by_email": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"email"}}, TTL: time.Hour},
+		},
+		ETL: dosa.EtlOn,
+	}
+	table, err := dosa.TableFromDefinition(ed)
+	assert.NoError(t, err)
+	assert.Equal(t, *ed, table.EntityDefinition)
+	assert.Equal(t, "users", table.StructName)
+	assert.Equal(t, map[string]string{"id": "id", "email": "email", "nick": "nick"}, table.ColToField)
+	assert.Equal(t, map[string]string{"id": "id", "email": "email", "nick": "nick"}, table.FieldToCol)
+	assert.Equal(t, []string{"nick"}, table.DeprecatedColumns)
+	assert.Equal(t, dosa.NoTTL(), table.TTL)
+
+	_, err = dosa.TableFromDefinition(nil)
+	assert.Error(t, err)
+	_, err = dosa.TableFromDefinition(&dosa.EntityDefinition{Name: "t", Columns: []*dosa.ColumnDefinition{nil}})
+	assert.Contains(t, err.Error(), "nil column definition")
+	ed.Key.PartitionKeys = []string{"missing"}
+	_, err = dosa.TableFromDefinition(ed)
+	assert.Contains(t, err.Error(), `invalid table "users"`)
+}
+
+func TestEntityDefinitionDifferences(t *testing.T) {
+	ed := getValidEntityDefinition()
+	assert.Nil(t, ed.Differences(ed.Clone()))
+
+	live := ed.Clone()
+	live.Name = "other"
+	live.Key = &dosa.PrimaryKey{PartitionKeys: []string{"foo"}}
+	live.Columns = append(live.Columns[1:], &dosa.ColumnDefinition{Name: "extra", Type: dosa.Bool})
+	live.Columns[0].Type = dosa.Blob
+	live.Indexes = map[string]*dosa.IndexDefinition{
+		"index1": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"foo"}}},
+		"index3": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"bar"}}},
+	}
+	diffs := ed.Differences(live)
+	assert.Len(t, diffs, 8)
+	assert.Contains(t, diffs, `name is "testentity", live name is "other"`)
+	assert.Contains(t, diffs, `column "foo" is missing from the live schema`)
+	assert.Contains(t, diffs, `live column "extra" is not defined`)
+	assert.Contains(t, diffs, `index "index2" is missing from the live schema`)
+	assert.Contains(t, diffs, `live index "index3" is not defined`)
+}
+
+func TestEntityDefinitionSubsetOf(t *testing.T) {
+	ed := getValidEntityDefinition()
+	assert.True(t, ed.SubsetOf(ed))
+
+	sub := ed.Clone()
+	sub.Columns = sub.Columns[1:]
+	assert.True(t, sub.SubsetOf(ed))
+	assert.False(t, ed.SubsetOf(sub))
+
+	sub.Columns[0].Type = dosa.String
+	assert.False(t, sub.SubsetOf(ed))
+}
+
+func TestEntityDefinitionProjection(t *testing.T) {
+	ed := getValidEntityDefinition()
+
+	// the key columns foo and bar are always there
+	p, err := ed.Projection(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, columnNames(p))
+	assert.True(t, p.SubsetOf(ed))
+	assert.Equal(t, ed.Key, p.Key)
+	// index1 needs qux, index2 only bar
+	assert.Len(t, p.Indexes, 1)
+	assert.NotNil(t, p.Indexes["index2"])
+
+	p, err = ed.Projection([]string{"qux", "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "qux"}, columnNames(p))
+	assert.Len(t, p.Indexes, 2)
+	assert.Len(t, ed.Columns, 3)
+
+	_, err = ed.Projection([]string{"nope"})
+	assert.EqualError(t, err, `column "nope" not found in entity "testentity"`)
+}
@@ -24,3 +24,13 @@ import "github.com/pkg/errors"
 
 // ErrNullValue is returned if a caller tries to call Get() on a nullable primitive value.
 var ErrNullValue = errors.New("Value is null")
+
+// ErrStop is returned by the callback of an iteration API, such as WalkRange or
+// ScanWithCallback, to stop iterating early. Every DOSA API that accepts a callback treats
+// ErrStop (or an error caused by it) as a clean termination and returns nil to its caller.
+var ErrStop = errors.New("scan stopped by caller")
+
+// ErrorIsStop checks if the error is caused by ErrStop
+func ErrorIsStop(err error) bool {
+	return errors.Cause(err) == ErrStop
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// The constructors below build a FieldValue holding the Go type that the
+// connectors expect for each Type. They are thin wrappers, but a call such as
+// Int64Value(n) can't accidentally store an int or an int32.
+
+// UUIDValue returns a FieldValue for a TUUID column
+func UUIDValue(u UUID) FieldValue { return u }
+
+// StringValue returns a FieldValue for a String column
+func StringValue(s string) FieldValue { return s }
+
+// Int32Value returns a FieldValue for an Int32 column
+func Int32Value(v int32) FieldValue { return v }
+
+// Int64Value returns a FieldValue for an Int64 column
+func Int64Value(v int64) FieldValue { return v }
+
+// DoubleValue returns a FieldValue for a Double column
+func DoubleValue(v float64) FieldValue { return v }
+
+// BlobValue returns a FieldValue for a Blob column
+func BlobValue(b []byte) FieldValue { return b }
+
+// TimestampValue returns a FieldValue for a Timestamp column
+func TimestampValue(t time.Time) FieldValue { return t }
+
+// BoolValue returns a FieldValue for a Bool column
+func BoolValue(b bool) FieldValue { return b }
+
+// NullTimestamp is the FieldValue that writes null to a Timestamp column. It's
+// a nil *time.Time, the same value the client writes for a nil nullable field.
+// Unlike a column left out of the values, which Upsert leaves unchanged, it
+// clears the column; and unlike the zero time.Time, which is a timestamp like
+// any other, reading the column back returns null. See IsNull.
+var NullTimestamp FieldValue = (*time.Time)(nil)
+
+// IsNull returns true if v is null: nil, or a nil pointer such as NullTimestamp
+func IsNull(v FieldValue) bool {
+	return DerefFieldValue(v) == nil
+}
+
+// The extractors below return the value held by a FieldValue, and ok=false
+// instead of panicking when it holds another type. Values of nullable columns
+// are pointers; they are dereferenced, and a nil pointer is not ok.
+
+// AsUUID returns the UUID held by v
+func AsUUID(v FieldValue) (UUID, bool) {
+	u, ok := DerefFieldValue(v).(UUID)
+	return u, ok
+}
+
+// AsString returns the string held by v
+func AsString(v FieldValue) (string, bool) {
+	s, ok := DerefFieldValue(v).(string)
+	return s, ok
+}
+
+// AsInt32 returns the int32 held by v
+func AsInt32(v FieldValue) (int32, bool) {
+	i, ok := DerefFieldValue(v).(int32)
+	return i, ok
+}
+
+// AsInt64 returns the int64 held by v
+func AsInt64(v FieldValue) (int64, bool) {
+	i, ok := DerefFieldValue(v).(int64)
+	return i, ok
+}
+
+// AsDouble returns the float64 held by v
+func AsDouble(v FieldValue) (float64, bool) {
+	d, ok := DerefFieldValue(v).(float64)
+	return d, ok
+}
+
+// AsBlob returns the byte slice held by v
+func AsBlob(v FieldValue) ([]byte, bool) {
+	b, ok := v.([]byte)
+	return b, ok
+}
+
+// AsTimestamp returns the time.Time held by v
+func AsTimestamp(v FieldValue) (time.Time, bool) {
+	t, ok := DerefFieldValue(v).(time.Time)
+	return t, ok
+}
+
+// AsBool returns the bool held by v
+func AsBool(v FieldValue) (bool, bool) {
+	b, ok := DerefFieldValue(v).(bool)
+	return b, ok
+}
+
+// FieldValueLess returns true if a sorts before b. The values must have the
+// same type: UUIDs are ordered by their 16 bytes, big-endian; timestamps by
+// time; numbers by value, with NaN before every other Double; false before
+// true; and strings and blobs byte by byte. Values of nullable columns are
+// dereferenced, and null sorts before every other value. FieldValueLess
+// panics on values of different or unknown types.
+func FieldValueLess(a, b FieldValue) bool {
+	return orderFieldValues(a, b) < 0
+}
+
+// FieldValueEqual returns true if a and b are the same value, with the same
+// rules and panics as FieldValueLess. Timestamps are equal when they are the
+// same instant, even in different time zones.
+func FieldValueEqual(a, b FieldValue) bool {
+	return orderFieldValues(a, b) == 0
+}
+
+// SameFieldValue returns true if a and b are FieldValueEqual, but returns false
+// instead of panicking when they have different or unknown types. It compares
+// values given by callers, which may not have the type of their column.
+func SameFieldValue(a, b FieldValue) bool {
+	a, b = DerefFieldValue(a), DerefFieldValue(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	switch a.(type) {
+	case UUID, string, []byte, int32, int64, float64, time.Time, bool:
+		return orderFieldValues(a, b) == 0
+	}
+	return false
+}
+
+// orderFieldValues returns -1, 0 or 1 when a sorts before, with or after b
+func orderFieldValues(a, b FieldValue) int {
+	a, b = DerefFieldValue(a), DerefFieldValue(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		panic(fmt.Sprintf("cannot compare values of types %T and %T", a, b))
+	}
+	switch a := a.(type) {
+	case UUID:
+		return orderUUIDs(a, b.(UUID))
+	case string:
+		return strings.Compare(a, b.(string))
+	case []byte:
+		return bytes.Compare(a, b.([]byte))
+	case int32:
+		return orderInts(int64(a), int64(b.(int32)))
+	case int64:
+		return orderInts(a, b.(int64))
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		case math.IsNaN(a) && !math.IsNaN(b):
+			return -1
+		case !math.IsNaN(a) && math.IsNaN(b):
+			return 1
+		}
+		return 0
+	case time.Time:
+		switch {
+		case a.Before(b.(time.Time)):
+			return -1
+		case a.After(b.(time.Time)):
+			return 1
+		}
+		return 0
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	}
+	panic(fmt.Sprintf("cannot compare values of type %T", a))
+}
+
+// orderUUIDs compares the 16 bytes of the UUIDs, big-endian, also for time
+// UUIDs, whose first bytes are the low bits of their timestamp
+func orderUUIDs(a, b UUID) int {
+	ua, aerr := uuid.FromString(string(a))
+	ub, berr := uuid.FromString(string(b))
+	if aerr != nil || berr != nil {
+		// not a valid UUID; the canonical form orders like the bytes
+		return strings.Compare(strings.ToLower(string(a)), strings.ToLower(string(b)))
+	}
+	return bytes.Compare(ua.Bytes(), ub.Bytes())
+}
+
+// orderInts compares without subtracting, which could overflow
+func orderInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// DerefFieldValue returns the value a non-nil pointer points to, such as the
+// value of a nullable column, nil for a nil pointer, and any other value
+// unchanged
+func DerefFieldValue(v FieldValue) FieldValue {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldValueConstructors(t *testing.T) {
+	now := time.Now()
+	id := NewUUID()
+	assert.Equal(t, FieldValue(id), UUIDValue(id))
+	assert.Equal(t, FieldValue("foo"), StringValue("foo"))
+	assert.Equal(t, FieldValue(int32(1)), Int32Value(1))
+	assert.Equal(t, FieldValue(int64(1)), Int64Value(1))
+	assert.Equal(t, FieldValue(float64(1.5)), DoubleValue(1.5))
+	assert.Equal(t, FieldValue([]byte("foo")), BlobValue([]byte("foo")))
+	assert.Equal(t, FieldValue(now), TimestampValue(now))
+	assert.Equal(t, FieldValue(true), BoolValue(true))
+}
+
+func TestFieldValueExtractors(t *testing.T) {
+	now := time.Now()
+	id := NewUUID()
+
+	u, ok := AsUUID(UUIDValue(id))
+	assert.True(t, ok)
+	assert.Equal(t, id, u)
+	s, ok := AsString(StringValue("foo"))
+	assert.True(t, ok)
+	assert.Equal(t, "foo", s)
+	i32, ok := AsInt32(Int32Value(1))
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), i32)
+	i64, ok := AsInt64(Int64Value(2))
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), i64)
+	d, ok := AsDouble(DoubleValue(1.5))
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, d)
+	b, ok := AsBlob(BlobValue([]byte("foo")))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("foo"), b)
+	ts, ok := AsTimestamp(TimestampValue(now))
+	assert.True(t, ok)
+	assert.Equal(t, now, ts)
+	bo, ok := AsBool(BoolValue(true))
+	assert.True(t, ok)
+	assert.True(t, bo)
+
+	// null is not a timestamp, but the zero time is
+	_, ok = AsTimestamp(NullTimestamp)
+	assert.False(t, ok)
+	assert.True(t, IsNull(NullTimestamp))
+	assert.True(t, IsNull(nil))
+	assert.False(t, IsNull(TimestampValue(time.Time{})))
+	assert.False(t, IsNull(&now))
+
+	// type mismatches are not ok
+	_, ok = AsString(Int64Value(1))
+	assert.False(t, ok)
+	_, ok = AsInt64(Int32Value(1))
+	assert.False(t, ok)
+	_, ok = AsInt64(1)
+	assert.False(t, ok)
+	_, ok = AsUUID(StringValue(string(id)))
+	assert.False(t, ok)
+	_, ok = AsBool(nil)
+	assert.False(t, ok)
+
+	// nullable values are dereferenced
+	str := "bar"
+	s, ok = AsString(&str)
+	assert.True(t, ok)
+	assert.Equal(t, "bar", s)
+	_, ok = AsString((*string)(nil))
+	assert.False(t, ok)
+}
+
+func TestFieldValueLess(t *testing.T) {
+	cases := []struct {
+		a, b     FieldValue
+		expected int
+	}{
+		{UUID("267275CD-D312-4EFB-A304-020A43971D68"), UUID("4268FCA1-7CE3-4624-AC2B-204F138A81E8"), -1},
+		{UUID("4268FCA1-7CE3-4624-AC2B-204F138A81E8"), UUID("267275CD-D312-4EFB-A304-020A43971D68"), 1},
+		{UUID("267275CD-D312-4EFB-A304-020A43971D68"), UUID("267275CD-D312-4EFB-A304-020A43971D68"), 0},
+		// by bytes, not by case
+		{UUID("a67275cd-d312-4efb-a304-020a43971d68"), UUID("B67275CD-D312-4EFB-A304-020A43971D68"), -1},
+		{UUID("a67275cd-d312-4efb-a304-020a43971d68"), UUID("A67275CD-D312-4EFB-A304-020A43971D68"), 0},
+		// time UUIDs by their bytes too, although the first is the earlier one
+		{UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459"), UUID("0c2c17e4-a7d3-11e8-98d0-529269fb1459"), 1},
+		{UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459"), UUID("0d4b8a84-a7d2-11e8-98d0-529269fb145a"), -1},
+		// and not by version
+		{UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459"), UUID("067275cd-d312-4efb-a304-020a43971d68"), 1},
+		{Int64Value(0), Int64Value(1), -1},
+		{Int64Value(1), Int64Value(0), 1},
+		{Int64Value(1), Int64Value(1), 0},
+		{Int64Value(math.MinInt64), Int64Value(math.MaxInt64), -1},
+		{Int64Value(math.MaxInt64), Int64Value(-1), 1},
+		{Int32Value(0), Int32Value(1), -1},
+		{Int32Value(1), Int32Value(0), 1},
+		{Int32Value(1), Int32Value(1), 0},
+		{Int32Value(math.MinInt32), Int32Value(math.MaxInt32), -1},
+		{StringValue("abc"), StringValue("defg"), -1},
+		{StringValue("defg"), StringValue("abc"), 1},
+		{StringValue("abc"), StringValue("abc"), 0},
+		{BlobValue([]byte{1, 2, 3}), BlobValue([]byte{4, 5}), -1},
+		{BlobValue([]byte{4, 5}), BlobValue([]byte{1, 2, 3}), 1},
+		{BlobValue([]byte{1, 2, 3}), BlobValue([]byte{1, 2, 3}), 0},
+		{BoolValue(false), BoolValue(true), -1},
+		{BoolValue(true), BoolValue(false), 1},
+		{BoolValue(true), BoolValue(true), 0},
+		{DoubleValue(0.0), DoubleValue(1.0), -1},
+		{DoubleValue(1.0), DoubleValue(0.0), 1},
+		{DoubleValue(1.0), DoubleValue(1.0), 0},
+		{DoubleValue(math.NaN()), DoubleValue(math.Inf(-1)), -1},
+		{DoubleValue(math.NaN()), DoubleValue(math.NaN()), 0},
+		{TimestampValue(time.Unix(5, 0)), TimestampValue(time.Unix(6, 0)), -1},
+		{TimestampValue(time.Unix(6, 0)), TimestampValue(time.Unix(5, 0)), 1},
+		{TimestampValue(time.Unix(5, 0)), TimestampValue(time.Unix(5, 0).UTC()), 0},
+		// nullable values are dereferenced, and null sorts first
+		{&[]string{"abc"}[0], StringValue("abc"), 0},
+		{NullTimestamp, TimestampValue(time.Unix(5, 0)), -1},
+		{NullTimestamp, nil, 0},
+	}
+
+	for _, c := range cases {
+		msg := fmt.Sprint(c.a, " vs ", c.b)
+		assert.Equal(t, c.expected < 0, FieldValueLess(c.a, c.b), msg)
+		assert.Equal(t, c.expected > 0, FieldValueLess(c.b, c.a), msg)
+		assert.Equal(t, c.expected == 0, FieldValueEqual(c.a, c.b), msg)
+		assert.Equal(t, c.expected, CompareFieldValues(c.a, c.b), msg)
+		assert.Equal(t, c.expected == 0, SameFieldValue(c.a, c.b), msg)
+	}
+	assert.Panics(t, func() { FieldValueLess(0, 0) })
+	assert.Panics(t, func() { FieldValueEqual(Int32Value(1), Int64Value(1)) })
+}
+
+func TestSameFieldValue(t *testing.T) {
+	// values of different or unknown types are never the same, and don't panic
+	assert.False(t, SameFieldValue(Int32Value(1), Int64Value(1)))
+	assert.False(t, SameFieldValue(1, Int64Value(1)))
+	assert.False(t, SameFieldValue(StringValue("a"), UUID("a")))
+	assert.False(t, SameFieldValue(0, 0))
+	assert.False(t, SameFieldValue(nil, Int64Value(0)))
+	assert.True(t, SameFieldValue(nil, NullTimestamp))
+	assert.True(t, SameFieldValue(&[]int64{1}[0], Int64Value(1)))
+}
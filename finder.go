@@ -23,11 +23,14 @@ package dosa
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -37,15 +40,22 @@ import (
 
 // findEntities finds all entities in the given file paths. An error is
 // returned if there are naming collisions, otherwise, return a slice of
-// warnings (or nil).
-func findEntities(paths, excludes []string) ([]*Table, []error, error) {
+// warnings (or nil). Files are skipped unless their build constraints are
+// satisfied by the current GOOS and GOARCH and the build tags.
+func findEntities(paths, excludes []string, buildTags ...string) ([]*Table, []error, error) {
+	buildContext := build.Default
+	buildContext.GOOS = runtime.GOOS
+	buildContext.GOARCH = runtime.GOARCH
+	buildContext.BuildTags = buildTags
+
 	var entities []*Table
 	var warnings []error
 	for _, path := range paths {
 		fileSet := token.NewFileSet()
 		packages, err := parser.ParseDir(fileSet, path, func(fileInfo os.FileInfo) bool {
-			if len(excludes) == 0 {
-				return true
+			// files that can't be matched are left for the parser to report
+			if match, err := buildContext.MatchFile(path, fileInfo.Name()); err == nil && !match {
+				return false
 			}
 			for _, exclude := range excludes {
 				if matched, _ := filepath.Match(exclude, fileInfo.Name()); matched {
@@ -57,19 +67,24 @@ func findEntities(paths, excludes []string) ([]*Table, []error, error) {
 		if err != nil {
 			return nil, nil, err
 		}
-		erv := new(entityRecordingVisitor)
+		erv := &entityRecordingVisitor{typeAliasMap: findTypeAliases(packages), fileSet: fileSet}
 		for _, pkg := range packages { // go through all the packages
-			for _, file := range pkg.Files { // go through all the files
+			for filename, file := range pkg.Files { // go through all the files
 				packagePrefix, hasDosa := findDosaPackage(file)
 				//if erv.PackageName != "" { // skip packages that don't import 'dosa'
 				if hasDosa {
 					erv.packagePrefix = packagePrefix
+					erv.visitFile(filename)
 					for _, decl := range file.Decls { // go through all the declarations
 						ast.Walk(erv, decl)
 					}
 				}
 			}
 		}
+		pkgPath := goPackagePath(path)
+		for _, entity := range erv.entities {
+			entity.GoPackagePath = pkgPath
+		}
 		entities = append(entities, erv.entities...)
 		warnings = append(warnings, erv.warnings...)
 	}
@@ -77,6 +92,199 @@ func findEntities(paths, excludes []string) ([]*Table, []error, error) {
 	return entities, warnings, nil
 }
 
+// goPackagePath returns the import path of the package in dir: the module
+// path of the nearest go.mod above it joined with the directory relative to
+// the module root, or else the directory relative to a GOPATH src directory.
+// The empty string is returned if neither applies.
+func goPackagePath(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		if data, err := ioutil.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			module := modulePath(data)
+			if module == "" {
+				return ""
+			}
+			if rel, _ := filepath.Rel(root, abs); rel != "." {
+				return module + "/" + filepath.ToSlash(rel)
+			}
+			return module
+		}
+		if filepath.Dir(root) == root {
+			break
+		}
+	}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		rel, err := filepath.Rel(filepath.Join(gopath, "src"), abs)
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return ""
+}
+
+// buildConstraint returns the build constraint of a Go source file as
+// written, e.g. "//go:build linux", or "" if it has none. A //go:build line
+// is preferred to the // +build lines, which are joined with "; ".
+func buildConstraint(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	var plusBuild []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if strings.HasPrefix(line, "//go:build ") {
+			return line
+		}
+		if strings.HasPrefix(line, "// +build ") {
+			plusBuild = append(plusBuild, line)
+		}
+	}
+	return strings.Join(plusBuild, "; ")
+}
+
+// modulePath returns the path in the module directive of a go.mod file
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
+}
+
+// findTypeAliases returns the type aliases (type MyID = dosa.UUID) declared in the
+// packages whose target is a DOSA type, so that fields declared with the alias can
+// be parsed. The targets are normalized so that the dosa package prefix of the
+// declaring file doesn't matter, e.g. MyID maps to "UUID". Defined types
+// (type MyID dosa.UUID) are not included, they are distinct types at runtime.
+func findTypeAliases(packages map[string]*ast.Package) map[string]string {
+	declared := map[string]string{}
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			packagePrefix, _ := findDosaPackage(file)
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok || !spec.Assign.IsValid() {
+					return true
+				}
+				kind, err := parseASTType(spec.Type)
+				if err != nil || kind == "" {
+					return true
+				}
+				if packagePrefix != "" {
+					kind = strings.Replace(kind, packagePrefix+".UUID", "UUID", 1)
+				}
+				declared[spec.Name.Name] = kind
+				return true
+			})
+		}
+	}
+
+	// resolve aliases of aliases, and drop the ones that don't end up at a DOSA type
+	aliases := map[string]string{}
+	for name := range declared {
+		kind := resolveTypeAlias(name, declared)
+		if typ, _ := stringToDosaType(kind, ""); typ != Invalid {
+			aliases[name] = kind
+		}
+	}
+	return aliases
+}
+
+// resolveTypeAlias follows kind through the alias map, at most len(aliases) times
+// so that cycles terminate. A pointer to an alias is resolved to a pointer to its
+// target, unless the target is a pointer already.
+func resolveTypeAlias(kind string, aliases map[string]string) string {
+	for i := 0; i <= len(aliases); i++ {
+		base := strings.TrimPrefix(kind, "*")
+		target, ok := aliases[base]
+		if !ok {
+			return kind
+		}
+		if base == kind {
+			kind = target
+		} else if strings.HasPrefix(target, "*") {
+			return ""
+		} else {
+			kind = "*" + target
+		}
+	}
+	return ""
+}
+
+// FindOption configures how FindEntities finds entities
+type FindOption func(*findOptions)
+
+type findOptions struct {
+	buildTags []string
+}
+
+// WithBuildTags sets the build tags that FindEntities satisfies, like the
+// -tags flag of go build. Files whose build constraints aren't satisfied are
+// skipped, so that entities declared once per platform aren't duplicates.
+func WithBuildTags(tags []string) FindOption {
+	return func(o *findOptions) {
+		o.buildTags = tags
+	}
+}
+
+// FindEntities finds all entities in the given directories. Files whose names
+// match any of the excludes, which are filepath.Match patterns such as
+// "*_test.go" or "mock_*.go", are skipped. So are the files whose build
+// constraints aren't satisfied by the GOOS and GOARCH of the current machine
+// and the build tags set with WithBuildTags; the SourcePosition of an entity
+// declared in a constrained file notes the constraint. An error is returned for a
+// malformed pattern or if there are naming collisions; the second return
+// value holds warnings about structs that could not be parsed.
+//
+// The GoPackagePath of each table is computed from its directory, using the
+// nearest go.mod or else the GOPATH. It may be inaccurate for files outside
+// the module root, where the GOPATH layout is assumed, or reached through a
+// symlink, and is empty when the directory is in neither.
+func FindEntities(paths, excludes []string, opts ...FindOption) ([]*Table, []error, error) {
+	for _, exclude := range excludes {
+		if _, err := filepath.Match(exclude, ""); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid exclude pattern %q", exclude)
+		}
+	}
+	var options findOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return findEntities(paths, excludes, options.buildTags...)
+}
+
+// FindEntitiesSimple finds all entities in a single directory, skipping the
+// files whose names match a single exclude pattern. An empty pattern
+// excludes nothing.
+func FindEntitiesSimple(path, singleExclude string) ([]*Table, []error, error) {
+	var excludes []string
+	if singleExclude != "" {
+		excludes = []string{singleExclude}
+	}
+	return FindEntities([]string{path}, excludes)
+}
+
+// FindEntitiesInPackage finds all entities in the package with the given
+// import path. The import path is resolved to a directory the same way the go
+// tool would, so callers don't need to know the GOPATH layout. Test files are
+// not considered part of the package.
+func FindEntitiesInPackage(importPath string) ([]*Table, []error, error) {
+	pkg, err := build.Default.Import(importPath, ".", build.FindOnly)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot find package %q", importPath)
+	}
+	return findEntities([]string{pkg.Dir}, []string{"*_test.go"})
+}
+
 // FindEntityByName returns the entity with given name in the path.
 func FindEntityByName(path string, structName string) (*Table, error) {
 	// find all entites in the given path
@@ -132,9 +340,20 @@ func findDosaPackage(file *ast.File) (string, bool) {
 // It also keeps track of all failed entities that pass the basic "looks like a DOSA object" test
 // (see isDosaEntity to understand that test)
 type entityRecordingVisitor struct {
-	entities      []*Table
-	warnings      []error
-	packagePrefix string
+	entities        []*Table
+	warnings        []error
+	packagePrefix   string
+	typeAliasMap    map[string]string
+	fileSet         *token.FileSet
+	filename        string   // of the file being visited
+	buildConstraint string   // of the file being visited
+	entityFiles     []string // the file each of the entities was found in
+}
+
+// visitFile sets the file whose declarations are visited next
+func (f *entityRecordingVisitor) visitFile(filename string) {
+	f.filename = filename
+	f.buildConstraint = buildConstraint(filename)
 }
 
 // Visit records all the entities seen into the entityRecordingVisitor structure
@@ -146,9 +365,14 @@ func (f *entityRecordingVisitor) Visit(n ast.Node) ast.Visitor {
 		if structType, ok := n.Type.(*ast.StructType); ok {
 			// look for a Entity with a dosa annotation
 			if isDosaEntity(structType) {
-				table, err := tableFromStructType(n.Name.Name, structType, f.packagePrefix)
+				table, err := tableFromStructType(n.Name.Name, structType, f.packagePrefix, f.typeAliasMap)
 				if err == nil {
+					table.SourcePosition = f.fileSet.Position(n.Pos()).String()
+					if f.buildConstraint != "" {
+						table.SourcePosition += " (" + f.buildConstraint + ")"
+					}
 					f.entities = append(f.entities, table)
+					f.entityFiles = append(f.entityFiles, f.filename)
 				} else {
 					f.warnings = append(f.warnings, err)
 				}
@@ -198,9 +422,9 @@ func parseASTType(expr ast.Expr) (string, error) {
 		kind = typeName.Name
 		// not an Entity type, perhaps another primitive type
 	case *ast.ArrayType:
-		// only dosa allowed array type is []byte
+		// only dosa allowed array type is []byte, which can be spelled []uint8
 		if typeName, ok := typeName.Elt.(*ast.Ident); ok {
-			if typeName.Name == "byte" {
+			if typeName.Name == "byte" || typeName.Name == "uint8" {
 				kind = "[]byte"
 			}
 		}
@@ -221,8 +445,10 @@ func parseASTType(expr ast.Expr) (string, error) {
 	return kind, err
 }
 
-// tableFromStructType takes an ast StructType and converts it into a Table object
-func tableFromStructType(structName string, structType *ast.StructType, packagePrefix string) (*Table, error) {
+// tableFromStructType takes an ast StructType and converts it into a Table object.
+// typeAliasMap maps the names of type aliases to the DOSA types they stand for,
+// see findTypeAliases.
+func tableFromStructType(structName string, structType *ast.StructType, packagePrefix string, typeAliasMap map[string]string) (*Table, error) {
 	normalizedName, err := NormalizeName(structName)
 	if err != nil {
 		// TODO: This isn't correct, someone could override the name later
@@ -263,14 +489,14 @@ func tableFromStructType(structName string, structType *ast.StructType, packageP
 			for _, fieldName := range field.Names {
 				name := fieldName.Name
 				if kind == packagePrefix+"."+indexName || (packagePrefix == "" && kind == indexName) {
-					indexName, indexKey, err := parseIndexTag(name, dosaTag)
+					indexName, index, err := parseIndexTag(name, dosaTag)
 					if err != nil {
 						return nil, err
 					}
 					if _, exist := t.Indexes[indexName]; exist {
 						return nil, errors.Errorf("index name is duplicated: %s", indexName)
 					}
-					t.Indexes[indexName] = &IndexDefinition{Key: indexKey}
+					t.Indexes[indexName] = index
 				} else {
 					firstRune, _ := utf8.DecodeRuneInString(name)
 					if unicode.IsLower(firstRune) {
@@ -278,6 +504,11 @@ func tableFromStructType(structName string, structType *ast.StructType, packageP
 						continue
 					}
 					typ, isPointer := stringToDosaType(kind, packagePrefix)
+					if typ == Invalid {
+						if resolved := resolveTypeAlias(kind, typeAliasMap); resolved != kind {
+							typ, isPointer = stringToDosaType(resolved, "")
+						}
+					}
 					if typ == Invalid {
 						return nil, fmt.Errorf("Column %q has invalid type %q", name, kind)
 					}
@@ -293,14 +524,14 @@ func tableFromStructType(structName string, structType *ast.StructType, packageP
 
 			if len(field.Names) == 0 {
 				if kind == packagePrefix+"."+indexName || (packagePrefix == "" && kind == indexName) {
-					indexName, indexKey, err := parseIndexTag("", dosaTag)
+					indexName, index, err := parseIndexTag("", dosaTag)
 					if err != nil {
 						return nil, err
 					}
 					if _, exist := t.Indexes[indexName]; exist {
 						return nil, errors.Errorf("index name is duplicated: %s", indexName)
 					}
-					t.Indexes[indexName] = &IndexDefinition{Key: indexKey}
+					t.Indexes[indexName] = index
 				}
 			}
 		}
@@ -310,7 +541,11 @@ func tableFromStructType(structName string, structType *ast.StructType, packageP
 		return nil, errors.Errorf("cannot find dosa.Entity in object %s", t.StructName)
 	}
 
+	if err := addRowIDColumn(t); err != nil {
+		return nil, err
+	}
 	translateKeyName(t)
+	t.DeprecatedColumns = deprecatedColumns(t)
 	if err := t.EnsureValid(); err != nil {
 		return nil, errors.Wrap(err, "failed to parse dosa object")
 	}
@@ -327,7 +562,7 @@ func stringToDosaType(inType, pkg string) (Type, bool) {
 	switch inType {
 	case "string":
 		return String, false
-	case "[]byte":
+	case "[]byte", "[]uint8":
 		return Blob, false
 	case "bool":
 		return Bool, false
@@ -359,3 +594,13 @@ func stringToDosaType(inType, pkg string) (Type, bool) {
 		return Invalid, false
 	}
 }
+
+// StringToDosaType returns the DOSA type of fields of the Go type named typeName,
+// as written in code that imports dosa: e.g. "int64", "[]byte", "time.Time",
+// "UUID" or "dosa.UUID". A pointer type such as "*string" has the type it points
+// to, since pointers only make a column nullable. Invalid is returned for the
+// empty string and for any type that can't be a field.
+func StringToDosaType(typeName string) Type {
+	typ, _ := stringToDosaType(typeName, "dosa")
+	return typ
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jsonEntity is the JSON form of an entity read by FindEntitiesFromJSON, and
+// the YAML form read by LoadEntitiesFromYAML and written by
+// Registry.MarshalYAML. It mirrors EntityDefinition, except that the primary
+// key is given by the "key" of each column, or else by PrimaryKey in the
+// syntax of the primaryKey struct tag, and index keys use the same syntax as
+// the index struct tag.
+type jsonEntity struct {
+	Name       string               `json:"name" yaml:"name"`
+	PrimaryKey string               `json:"primaryKey" yaml:"primaryKey,omitempty"`
+	Columns    []*jsonColumn        `json:"columns" yaml:"columns"`
+	Indexes    map[string]jsonIndex `json:"indexes" yaml:"indexes,omitempty"`
+	ETL        string               `json:"etl" yaml:"etl,omitempty"`
+	TTL        string               `json:"ttl" yaml:"ttl,omitempty"`
+}
+
+// jsonColumn is a column of a jsonEntity. Key is "partition" or "clustering"
+// for primary key columns, and Order is "asc" (the default) or "desc" for
+// clustering key columns. OrderedBy is the preferred on-disk order of an index
+// column, "asc" or "desc", like the ordered_asc and ordered_desc tags.
+type jsonColumn struct {
+	Name            string            `json:"name" yaml:"name"`
+	Type            string            `json:"type" yaml:"type"`
+	Key             string            `json:"key" yaml:"key,omitempty"`
+	Order           string            `json:"order" yaml:"order,omitempty"`
+	Nullable        bool              `json:"nullable" yaml:"nullable,omitempty"`
+	Immutable       bool              `json:"immutable" yaml:"immutable,omitempty"`
+	Unique          bool              `json:"unique" yaml:"unique,omitempty"`
+	Sensitive       bool              `json:"sensitive" yaml:"sensitive,omitempty"`
+	MaxLength       int               `json:"maxLength" yaml:"maxLength,omitempty"`
+	EstimatedBytes  int               `json:"estimatedBytes" yaml:"estimatedBytes,omitempty"`
+	Deprecated      bool              `json:"deprecated" yaml:"deprecated,omitempty"`
+	DeprecatedSince string            `json:"deprecatedSince" yaml:"deprecatedSince,omitempty"`
+	OrderedBy       string            `json:"orderedBy" yaml:"orderedBy,omitempty"`
+	Tags            map[string]string `json:"tags" yaml:"tags,omitempty"`
+}
+
+// jsonIndex is an index of a jsonEntity, e.g. {"key": "(email, createdat DESC)"},
+// with an optional TTL for its entries like the TTL of the entity
+type jsonIndex struct {
+	Key string `json:"key" yaml:"key"`
+	TTL string `json:"ttl" yaml:"ttl,omitempty"`
+}
+
+const (
+	jsonPartitionKey  = "partition"
+	jsonClusteringKey = "clustering"
+)
+
+// FindEntitiesFromJSON reads entity definitions from JSON, for services that
+// define their schema without Go structs. The JSON is either a single entity or
+// an array of them, where an entity looks like:
+//
+//	{
+//	  "name": "users",
+//	  "columns": [
+//	    {"name": "id", "type": "UUID", "key": "partition"},
+//	    {"name": "createdat", "type": "Timestamp", "key": "clustering", "order": "desc"},
+//	    {"name": "email", "type": "String", "nullable": true, "immutable": true, "maxLength": 254}
+//	  ],
+//	  "indexes": {"byemail": {"key": "(email, createdat DESC)"}},
+//	  "etl": "on",
+//	  "ttl": "24h"
+//	}
+//
+// Instead of the "key" of its columns, an entity may give its primary key as
+// "primaryKey", in the syntax of the primaryKey struct tag, e.g.
+// "(id, createdat DESC)". An index may also have a "ttl" for its entries, e.g.
+// {"key": "(email)", "ttl": "168h"}.
+//
+// Types are the names returned by Type.String or Type.GoType. Names are
+// normalized like those derived from Go structs, and every entity is checked
+// with EnsureValid. The "fields" of the returned tables are the column names as
+// they are written in the JSON.
+func FindEntitiesFromJSON(r io.Reader) ([]*Table, error) {
+	br := bufio.NewReader(r)
+	var entities []*jsonEntity
+	if isJSONArray(br) {
+		if err := json.NewDecoder(br).Decode(&entities); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON entity definitions")
+		}
+	} else {
+		var entity jsonEntity
+		if err := json.NewDecoder(br).Decode(&entity); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON entity definition")
+		}
+		entities = append(entities, &entity)
+	}
+
+	tables := make([]*Table, 0, len(entities))
+	for i, entity := range entities {
+		if entity == nil {
+			return nil, errors.Errorf("JSON entity %d is null", i)
+		}
+		table, err := entity.table()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid JSON entity %q", entity.Name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// isJSONArray returns true if the next non-whitespace character is the start of an array
+func isJSONArray(br *bufio.Reader) bool {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return false
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+		default:
+			return b[0] == '['
+		}
+	}
+}
+
+func (e *jsonEntity) table() (*Table, error) {
+	name, err := NormalizeName(e.Name)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{
+		EntityDefinition: EntityDefinition{
+			Name: name,
+			Key:  &PrimaryKey{},
+			ETL:  EtlOff,
+		},
+		StructName: e.Name,
+		ColToField: map[string]string{},
+		FieldToCol: map[string]string{},
+		TTL:        NoTTL(),
+	}
+
+	if e.ETL != "" {
+		if t.ETL, err = ToETLState(strings.ToLower(e.ETL)); err != nil {
+			return nil, err
+		}
+	}
+	if e.TTL != "" {
+		if t.TTL, err = time.ParseDuration(e.TTL); err != nil {
+			return nil, errors.Wrapf(err, "invalid ttl %q", e.TTL)
+		}
+		if err := ValidateTTL(t.TTL); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range e.Columns {
+		if c == nil {
+			return nil, errors.New("null column")
+		}
+		cd, err := c.columnDefinition()
+		if err != nil {
+			return nil, err
+		}
+		t.Columns = append(t.Columns, cd)
+		t.ColToField[cd.Name] = c.Name
+		t.FieldToCol[c.Name] = cd.Name
+
+		switch strings.ToLower(c.Key) {
+		case "":
+		case jsonPartitionKey:
+			t.Key.PartitionKeys = append(t.Key.PartitionKeys, c.Name)
+		case jsonClusteringKey:
+			ck := &ClusteringKey{Name: c.Name}
+			switch strings.ToLower(c.Order) {
+			case "", asc:
+			case desc:
+				ck.Descending = true
+			default:
+				return nil, errors.Errorf("invalid clustering key order %q for column %q", c.Order, c.Name)
+			}
+			t.Key.ClusteringKeys = append(t.Key.ClusteringKeys, ck)
+		default:
+			return nil, errors.Errorf("invalid key %q for column %q, should be %q or %q", c.Key, c.Name, jsonPartitionKey, jsonClusteringKey)
+		}
+		if c.Order != "" && !strings.EqualFold(c.Key, jsonClusteringKey) {
+			return nil, errors.Errorf("sort direction can only be specified for clustering keys: %q", c.Name)
+		}
+	}
+
+	if e.PrimaryKey != "" {
+		if len(t.Key.PartitionKeys) > 0 || len(t.Key.ClusteringKeys) > 0 {
+			return nil, errors.New("the primary key is given both by primaryKey and by the columns")
+		}
+		if t.Key, err = parsePrimaryKey(e.Name, e.PrimaryKey); err != nil {
+			return nil, errors.Wrapf(err, "invalid primary key %q", e.PrimaryKey)
+		}
+	}
+
+	for indexName, index := range e.Indexes {
+		normalized, err := NormalizeName(indexName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid index name %q", indexName)
+		}
+		key, err := parsePrimaryKey(indexName, index.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "index %q has an invalid key %q", indexName, index.Key)
+		}
+		var ttl time.Duration
+		if index.TTL != "" {
+			if ttl, err = time.ParseDuration(index.TTL); err != nil {
+				return nil, errors.Wrapf(err, "index %q has an invalid ttl %q", indexName, index.TTL)
+			}
+		}
+		if t.Indexes == nil {
+			t.Indexes = map[string]*IndexDefinition{}
+		}
+		t.Indexes[normalized] = &IndexDefinition{Key: key, TTL: ttl}
+	}
+
+	// the keys refer to the columns by the names in the JSON, like struct
+	// tags refer to fields
+	translateKeyName(t)
+	t.DeprecatedColumns = deprecatedColumns(t)
+	if err := t.EnsureValid(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (c *jsonColumn) columnDefinition() (*ColumnDefinition, error) {
+	name, err := NormalizeName(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := TypeFromString(c.Type)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid type for column %q", c.Name)
+	}
+	orderedBy, err := orderedByFromString(c.OrderedBy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid orderedBy for column %q", c.Name)
+	}
+	return &ColumnDefinition{
+		Name:             name,
+		Type:             typ,
+		IsPointer:        c.Nullable,
+		Immutable:        c.Immutable,
+		UniqueConstraint: c.Unique,
+		SensitiveData:    c.Sensitive,
+		MaxLength:        c.MaxLength,
+		EstimatedBytes:   c.EstimatedBytes,
+		Deprecated:       c.Deprecated || c.DeprecatedSince != "",
+		DeprecatedSince:  c.DeprecatedSince,
+		OrderedBy:        orderedBy,
+		Tags:             c.Tags,
+	}, nil
+}
+
+// newJSONEntity returns the jsonEntity form of a table, which table turns back
+// into an equal entity definition. The primary key is written as PrimaryKey,
+// because the keys of the columns cannot express a key order that differs
+// from the column order.
+func newJSONEntity(t *Table) *jsonEntity {
+	e := &jsonEntity{
+		Name:       t.Name,
+		PrimaryKey: t.Key.String(),
+	}
+	if t.ETL == EtlOn {
+		e.ETL = string(t.ETL)
+	}
+	if t.TTL != NoTTL() {
+		e.TTL = t.TTL.String()
+	}
+	for _, cd := range t.Columns {
+		e.Columns = append(e.Columns, &jsonColumn{
+			Name:            cd.Name,
+			Type:            cd.Type.String(),
+			Nullable:        cd.IsPointer,
+			Immutable:       cd.Immutable,
+			Unique:          cd.UniqueConstraint,
+			Sensitive:       cd.SensitiveData,
+			MaxLength:       cd.MaxLength,
+			EstimatedBytes:  cd.EstimatedBytes,
+			Deprecated:      cd.Deprecated,
+			DeprecatedSince: cd.DeprecatedSince,
+			OrderedBy:       strings.ToLower(orderedByString(cd.OrderedBy)),
+			Tags:            cd.Tags,
+		})
+	}
+	for name, index := range t.Indexes {
+		if e.Indexes == nil {
+			e.Indexes = map[string]jsonIndex{}
+		}
+		ji := jsonIndex{Key: index.Key.String()}
+		if index.TTL != 0 {
+			ji.TTL = index.TTL.String()
+		}
+		e.Indexes[name] = ji
+	}
+	return e
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindEntitiesFromJSON(t *testing.T) {
+	descending := Descending
+	tables, err := FindEntitiesFromJSON(strings.NewReader(`
+	[{
+		"name": "Users",
+		"columns": [
+			{"name": "ID", "type": "UUID", "key": "partition"},
+			{"name": "CreatedAt", "type": "time.Time", "key": "clustering", "order": "DESC"},
+			{"name": "Email", "type": "String", "nullable": true, "immutable": true, "orderedBy": "DESC"},
+			{"name": "Age", "type": "int32", "estimatedBytes": 2, "tags": {"pii": ""}},
+			{"name": "Nickname", "type": "String", "nullable": true, "deprecatedSince": "v2"}
+		],
+		"indexes": {"ByEmail": {"key": "(Email, (CreatedAt DESC))", "ttl": "168h"}},
+		"etl": "on",
+		"ttl": "24h"
+	}, {
+		"name": "events",
+		"columns": [
+			{"name": "a", "type": "Int64", "key": "partition"},
+			{"name": "b", "type": "Int64", "key": "partition"}
+		]
+	}]`))
+	assert.NoError(t, err)
+	assert.Len(t, tables, 2)
+
+	assert.Equal(t, &Table{
+		EntityDefinition: EntityDefinition{
+			Name: "users",
+			Key: &PrimaryKey{
+				PartitionKeys:  []string{"id"},
+				ClusteringKeys: []*ClusteringKey{{Name: "createdat", Descending: true}},
+			},
+			Columns: []*ColumnDefinition{
+				{Name: "id", Type: TUUID},
+				{Name: "createdat", Type: Timestamp},
+				{Name: "email", Type: String, IsPointer: true, Immutable: true, OrderedBy: &descending},
+				{Name: "age", Type: Int32, EstimatedBytes: 2, Tags: map[string]string{"pii": ""}},
+				{Name: "nickname", Type: String, IsPointer: true, Deprecated: true, DeprecatedSince: "v2"},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"byemail": {Key: &PrimaryKey{
+					PartitionKeys:  []string{"email"},
+					ClusteringKeys: []*ClusteringKey{{Name: "createdat", Descending: true}},
+				}, TTL: 168 * time.Hour},
+			},
+			ETL: EtlOn,
+		},
+		StructName:        "Users",
+		ColToField:        map[string]string{"id": "ID", "createdat": "CreatedAt", "email": "Email", "age": "Age", "nickname": "Nickname"},
+		FieldToCol:        map[string]string{"ID": "id", "CreatedAt": "createdat", "Email": "email", "Age": "age", "Nickname": "nickname"},
+		TTL:               24 * time.Hour,
+		DeprecatedColumns: []string{"nickname"},
+	}, tables[0])
+
+	assert.Equal(t, []string{"a", "b"}, tables[1].Key.PartitionKeys)
+	assert.Equal(t, EtlOff, tables[1].ETL)
+	assert.Equal(t, NoTTL(), tables[1].TTL)
+}
+
+func TestFindEntitiesFromJSONSingleEntity(t *testing.T) {
+	tables, err := FindEntitiesFromJSON(strings.NewReader(`{"name": "t", "columns": [{"name": "id", "type": "int64", "key": "partition"}]}`))
+	assert.NoError(t, err)
+	assert.Len(t, tables, 1)
+	assert.Equal(t, "t", tables[0].Name)
+}
+
+func TestFindEntitiesFromJSONErrors(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		msg  string
+	}{
+		{`{"name": `, "invalid JSON entity definition"},
+		{`[{"name": 1}]`, "invalid JSON entity definitions"},
+		{`[null]`, "JSON entity 0 is null"},
+		{`{"name": "t", "columns": [null]}`, "null column"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "uuid", "key": "partition"}]}`, `unknown type "uuid"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "primary"}]}`, `invalid key "primary"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition", "order": "desc"}]}`, "sort direction can only be specified for clustering keys"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}, {"name": "c", "type": "UUID", "key": "clustering", "order": "up"}]}`, `invalid clustering key order "up"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID"}]}`, "does not have partition key"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition", "nullable": true}]}`, "primary key"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "indexes": {"i": {"key": "(nope)"}}}`, "nope"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "indexes": {"i": {"key": "((id"}}}`, "invalid key"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "indexes": {"i": {"key": "(id)", "ttl": "soon"}}}`, `invalid ttl "soon"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition", "orderedBy": "up"}]}`, `invalid sort direction "up"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "etl": "maybe"}`, "unrecognized ETL state"},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "ttl": "forever"}`, `invalid ttl "forever"`},
+		{`{"name": "t", "columns": [{"name": "id", "type": "UUID", "key": "partition"}], "ttl": "1ms"}`, "less than 1 second"},
+		{`{"name": "", "columns": [{"name": "id", "type": "UUID", "key": "partition"}]}`, "invalid JSON entity"},
+	} {
+		_, err := FindEntitiesFromJSON(strings.NewReader(tc.json))
+		if assert.Error(t, err, tc.json) {
+			assert.Contains(t, err.Error(), tc.msg, tc.json)
+		}
+	}
+}
@@ -22,6 +22,9 @@ package dosa
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -44,6 +47,97 @@ func TestUnparseableGoCode(t *testing.T) {
 	assert.Contains(t, err.Error(), "expected '('")
 }
 
+func TestTypeAliases(t *testing.T) {
+	const tmpdir = ".testaliases"
+	defer os.RemoveAll(tmpdir)
+	if err := os.Mkdir(tmpdir, 0770); err != nil {
+		t.Fatalf("can't create %s: %s", tmpdir, err)
+	}
+	aliases := `package aliases
+
+import dosav3 "github.com/uber-go/dosa"
+
+type UserID = dosav3.UUID
+type Name = string
+type DisplayName = Name
+type Loop1 = Loop2
+type Loop2 = Loop1
+type Email string
+`
+	entity := `package aliases
+
+import "github.com/uber-go/dosa"
+
+type User struct {
+	dosa.Entity ` + "`dosa:\"primaryKey=(ID)\"`" + `
+	ID       UserID
+	Nick     Name
+	Display  *DisplayName
+}
+
+type BadUser struct {
+	dosa.Entity ` + "`dosa:\"primaryKey=(ID)\"`" + `
+	ID    UserID
+	Email Email
+}
+`
+	if err := ioutil.WriteFile(tmpdir+"/aliases.go", []byte(aliases), 0644); err != nil {
+		t.Fatalf("can't create %s/aliases.go: %s", tmpdir, err)
+	}
+	if err := ioutil.WriteFile(tmpdir+"/entity.go", []byte(entity), 0644); err != nil {
+		t.Fatalf("can't create %s/entity.go: %s", tmpdir, err)
+	}
+
+	entities, errs, err := findEntities([]string{tmpdir}, []string{})
+	assert.NoError(t, err)
+	assert.Len(t, entities, 1)
+	user := entities[0]
+	assert.Equal(t, "User", user.StructName)
+	assert.Equal(t, TUUID, user.FindColumnDefinition("id").Type)
+	assert.Equal(t, String, user.FindColumnDefinition("nick").Type)
+	assert.Equal(t, String, user.FindColumnDefinition("display").Type)
+	assert.True(t, user.FindColumnDefinition("display").IsPointer)
+
+	// defined types are not aliases
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), `"Email" has invalid type "Email"`)
+}
+
+func TestUint8Blobs(t *testing.T) {
+	const tmpdir = ".testuint8"
+	defer os.RemoveAll(tmpdir)
+	if err := os.Mkdir(tmpdir, 0770); err != nil {
+		t.Fatalf("can't create %s: %s", tmpdir, err)
+	}
+	entity := `package blobs
+
+import "github.com/uber-go/dosa"
+
+type Bytes struct {
+	dosa.Entity ` + "`dosa:\"name=blobs,primaryKey=(K)\"`" + `
+	K []byte
+	V []byte
+}
+
+type Uint8s struct {
+	dosa.Entity ` + "`dosa:\"name=blobs,primaryKey=(K)\"`" + `
+	K []uint8
+	V []uint8
+}
+`
+	if err := ioutil.WriteFile(tmpdir+"/entity.go", []byte(entity), 0644); err != nil {
+		t.Fatalf("can't create %s/entity.go: %s", tmpdir, err)
+	}
+
+	entities, errs, err := findEntities([]string{tmpdir}, []string{})
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Len(t, entities, 2)
+	assert.Equal(t, Blob, entities[0].FindColumnDefinition("k").Type)
+	assert.Equal(t, entities[0].EntityDefinition, entities[1].EntityDefinition)
+	assert.Equal(t, entities[0].ColToField, entities[1].ColToField)
+}
+
 func TestNonExistentDirectory(t *testing.T) {
 	const nonExistentDirectory = "ThisDirectoryBetterNotExist"
 	entities, errs, err := findEntities([]string{nonExistentDirectory}, []string{})
@@ -59,9 +153,11 @@ func TestParser(t *testing.T) {
 		"singleprimarykeynoparen":       &SinglePrimaryKeyNoParen{},
 		"singleprimarykey":              &SinglePrimaryKey{},
 		"singlepartitionkey":            &SinglePartitionKey{},
+		"emptyprimarykey":               &EmptyPrimaryKey{},
 		"primarykeywithsecondaryrange":  &PrimaryKeyWithSecondaryRange{},
 		"primarykeywithdescendingrange": &PrimaryKeyWithDescendingRange{},
 		"multicomponentprimarykey":      &MultiComponentPrimaryKey{},
+		"pluspartitionkey":              &PlusPartitionKey{},
 		"nameinprimarykey":              &NameInPrimaryKey{},
 		"noetltag":                      &NoETLTag{},
 		"etltagoff":                     &ETLTagOff{},
@@ -71,10 +167,13 @@ func TestParser(t *testing.T) {
 		"alltypes":                      &AllTypes{},
 		"unexportedfieldtype":           &UnexportedFieldType{},
 		"ignoretagtype":                 &IgnoreTagType{},
+		"immutabletagtype":              &ImmutableTagType{},
+		"deprecatedtagtype":             &DeprecatedTagType{},
 		"badcolnamebutrenamed":          &BadColNameButRenamed{},
 		"singleindexnoparen":            &SingleIndexNoParen{},
 		"multipleindexes":               &MultipleIndexes{},
 		"complexindexes":                &ComplexIndexes{},
+		"indexeswithttl":                &IndexesWithTTL{},
 		"scopemetadata":                 &ScopeMetadata{},
 	}
 	entitiesExcludedForTest := map[string]interface{}{
@@ -83,11 +182,12 @@ func TestParser(t *testing.T) {
 		"registrytestvalid":      struct{}{}, // skip, same as above
 		"allfieldtypes":          struct{}{},
 		"alltypesscantestentity": struct{}{},
+		"clienttestscanonly":     struct{}{},
 	}
 
 	assert.Equal(t, len(expectedEntities)+len(entitiesExcludedForTest), len(entities), fmt.Sprintf("%s", entities))
 	// TODO(jzhan): remove the hard-coded number of errors.
-	assert.Equal(t, 24, len(errs), fmt.Sprintf("%v", errs))
+	assert.Equal(t, 25, len(errs), fmt.Sprintf("%v", errs))
 
 	for _, entity := range entities {
 		if _, ok := entitiesExcludedForTest[entity.Name]; ok {
@@ -98,6 +198,9 @@ func TestParser(t *testing.T) {
 		assert.True(t, ok)
 		e, err := TableFromInstance(inst)
 		assert.NoError(t, err)
+		// only the finder knows where the struct is declared
+		assert.Contains(t, entity.SourcePosition, ".go:")
+		e.SourcePosition = entity.SourcePosition
 		assert.Equal(t, e, entity)
 	}
 }
@@ -118,6 +221,181 @@ func TestFindEntitiesInOtherPkg(t *testing.T) {
 	assert.Empty(t, warnings)
 }
 
+func TestFindEntitiesExcludes(t *testing.T) {
+	// a file is skipped if it matches any of the patterns
+	entities, warnings, err := FindEntities([]string{"testentity"}, []string{"keyvalue.go", "named_*.go"})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, 1, len(entities))
+	assert.Equal(t, "TestEntity", entities[0].StructName)
+
+	entities, _, err = FindEntitiesSimple("testentity", "keyvalue.go")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entities))
+	entities, _, err = FindEntitiesSimple("testentity", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(entities))
+
+	_, _, err = FindEntities([]string{"testentity"}, []string{"*_test.go", "["})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid exclude pattern "["`)
+}
+
+func TestFindEntitiesBuildTags(t *testing.T) {
+	const tmpdir = ".testbuildtags"
+	defer os.RemoveAll(tmpdir)
+	if err := os.Mkdir(tmpdir, 0770); err != nil {
+		t.Fatalf("can't create %s: %s", tmpdir, err)
+	}
+	entity := func(constraint, name string) string {
+		return constraint + `
+
+package tagged
+
+import "github.com/uber-go/dosa"
+
+type ` + name + ` struct {
+	dosa.Entity ` + "`dosa:\"primaryKey=(ID)\"`" + `
+	ID int64
+}
+`
+	}
+	files := map[string]string{
+		"plain.go": entity("", "Plain"),
+		"extra.go": entity("//go:build dosa_extra\n// +build dosa_extra", "Extra"),
+		// the same entity for another build, which would be a duplicate
+		"other.go": entity("//go:build !dosa_extra\n// +build !dosa_extra", "Extra"),
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(tmpdir+"/"+name, []byte(contents), 0644); err != nil {
+			t.Fatalf("can't create %s/%s: %s", tmpdir, name, err)
+		}
+	}
+
+	entities, warnings, err := FindEntities([]string{tmpdir}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Len(t, entities, 2)
+	for _, e := range entities {
+		if e.StructName == "Extra" {
+			assert.Contains(t, e.SourcePosition, "other.go:")
+			assert.Contains(t, e.SourcePosition, "(//go:build !dosa_extra)")
+		} else {
+			assert.Contains(t, e.SourcePosition, "plain.go:")
+			assert.NotContains(t, e.SourcePosition, "(")
+		}
+	}
+
+	entities, _, err = FindEntities([]string{tmpdir}, nil, WithBuildTags([]string{"dosa_extra"}))
+	assert.NoError(t, err)
+	assert.Len(t, entities, 2)
+	for _, e := range entities {
+		if e.StructName == "Extra" {
+			assert.Contains(t, e.SourcePosition, "extra.go:")
+			assert.Contains(t, e.SourcePosition, "(//go:build dosa_extra)")
+		}
+	}
+
+	assert.Equal(t, "// +build linux; // +build amd64",
+		buildConstraintFromTestFile(t, tmpdir+"/old.go", "// +build linux\n// +build amd64\n\npackage tagged\n"))
+}
+
+func TestEntityRecordingVisitorFiles(t *testing.T) {
+	const tmpdir = ".testvisitfile"
+	defer os.RemoveAll(tmpdir)
+	if err := os.Mkdir(tmpdir, 0770); err != nil {
+		t.Fatalf("can't create %s: %s", tmpdir, err)
+	}
+	files := map[string]string{
+		"a.go": "package files\nimport \"github.com/uber-go/dosa\"\n" +
+			"type A1 struct {\n\tdosa.Entity `dosa:\"primaryKey=(ID)\"`\n\tID int64\n}\n" +
+			"type A2 struct {\n\tdosa.Entity `dosa:\"primaryKey=(ID)\"`\n\tID int64\n}\n",
+		"b.go": "package files\nimport \"github.com/uber-go/dosa\"\n" +
+			"type B struct {\n\tdosa.Entity `dosa:\"primaryKey=(ID)\"`\n\tID int64\n}\n",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(tmpdir+"/"+name, []byte(contents), 0644); err != nil {
+			t.Fatalf("can't create %s/%s: %s", tmpdir, name, err)
+		}
+	}
+
+	fileSet := token.NewFileSet()
+	packages, err := parser.ParseDir(fileSet, tmpdir, nil, 0)
+	assert.NoError(t, err)
+	erv := &entityRecordingVisitor{fileSet: fileSet}
+	for filename, file := range packages["files"].Files {
+		erv.packagePrefix, _ = findDosaPackage(file)
+		erv.visitFile(filename)
+		for _, decl := range file.Decls {
+			ast.Walk(erv, decl)
+		}
+	}
+
+	assert.Len(t, erv.entityFiles, len(erv.entities))
+	found := map[string]string{}
+	for i, table := range erv.entities {
+		found[table.StructName] = erv.entityFiles[i]
+	}
+	assert.Equal(t, map[string]string{
+		"A1": tmpdir + "/a.go",
+		"A2": tmpdir + "/a.go",
+		"B":  tmpdir + "/b.go",
+	}, found)
+}
+
+// buildConstraintFromTestFile writes the contents to a file and returns its build constraint
+func buildConstraintFromTestFile(t *testing.T, filename, contents string) string {
+	if err := ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatalf("can't create %s: %s", filename, err)
+	}
+	return buildConstraint(filename)
+}
+
+func TestFindEntitiesInPackage(t *testing.T) {
+	entities, warnings, err := FindEntitiesInPackage("github.com/uber-go/dosa/testentity")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(entities))
+	assert.Empty(t, warnings)
+
+	entities, warnings, err = FindEntitiesInPackage("github.com/uber-go/dosa/ThisPackageBetterNotExist")
+	assert.Nil(t, entities)
+	assert.Nil(t, warnings)
+	assert.Contains(t, err.Error(), "cannot find package")
+}
+
+func TestGoPackagePath(t *testing.T) {
+	const tmpdir = ".testmodule"
+	defer os.RemoveAll(tmpdir)
+	if err := os.MkdirAll(tmpdir+"/sub", 0770); err != nil {
+		t.Fatalf("can't create %s/sub: %s", tmpdir, err)
+	}
+	if err := ioutil.WriteFile(tmpdir+"/go.mod", []byte("// a test module\nmodule \"example.com/mod\"\n\ngo 1.12\n"), 0644); err != nil {
+		t.Fatalf("can't create %s/go.mod: %s", tmpdir, err)
+	}
+	entity := `package sub
+
+import "github.com/uber-go/dosa"
+
+type User struct {
+	dosa.Entity ` + "`dosa:\"primaryKey=(ID)\"`" + `
+	ID int64
+}
+`
+	if err := ioutil.WriteFile(tmpdir+"/sub/user.go", []byte(entity), 0644); err != nil {
+		t.Fatalf("can't create %s/sub/user.go: %s", tmpdir, err)
+	}
+
+	assert.Equal(t, "example.com/mod", goPackagePath(tmpdir))
+	assert.Equal(t, "example.com/mod/sub", goPackagePath(tmpdir+"/sub"))
+
+	entities, _, err := FindEntities([]string{tmpdir + "/sub"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 1)
+	assert.Equal(t, "example.com/mod/sub", entities[0].GoPackagePath)
+
+	assert.Equal(t, "", modulePath([]byte("go 1.12\n")))
+}
+
 func BenchmarkFinder(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		findEntities([]string{"."}, []string{})
@@ -183,3 +461,40 @@ func TestStringToDosaType(t *testing.T) {
 			fmt.Sprintf("stringToDosaType(%q, %q) != %d -- actual: %d", tc.inType, tc.pkg, tc.expected, actual))
 	}
 }
+
+func TestStringToDosaType_Exported(t *testing.T) {
+	data := map[string]Type{
+		"string":     String,
+		"[]byte":     Blob,
+		"[]uint8":    Blob,
+		"bool":       Bool,
+		"int32":      Int32,
+		"int64":      Int64,
+		"float64":    Double,
+		"time.Time":  Timestamp,
+		"UUID":       TUUID,
+		"dosa.UUID":  TUUID,
+		"*string":    String,
+		"*bool":      Bool,
+		"*int32":     Int32,
+		"*int64":     Int64,
+		"*float64":   Double,
+		"*time.Time": Timestamp,
+		"*UUID":      TUUID,
+		"*dosa.UUID": TUUID,
+
+		"":            Invalid,
+		"*":           Invalid,
+		"**string":    Invalid,
+		"*[]byte":     Invalid,
+		"int":         Invalid,
+		"uint8":       Invalid,
+		"[]string":    Invalid,
+		"dosav2.UUID": Invalid,
+		"String":      Invalid,
+		" string":     Invalid,
+	}
+	for name, expected := range data {
+		assert.Equal(t, expected, StringToDosaType(name), name)
+	}
+}
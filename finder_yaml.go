@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadEntitiesFromYAML reads entity definitions from YAML, for services
+// written in languages other than Go. The document is a list of entities, or
+// a single one, in the same form FindEntitiesFromJSON reads. This document
+// holds a single entity:
+//
+//	name: users
+//	columns:
+//	- {name: id, type: UUID, key: partition}
+//	- {name: createdat, type: Timestamp, key: clustering, order: desc}
+//	- {name: email, type: String, nullable: true, immutable: true, maxLength: 254}
+//	indexes:
+//	  byemail: {key: "(email, createdat DESC)"}
+//	etl: "on"
+//	ttl: 24h
+//
+// Unknown fields are an error. Every entity is checked with EnsureValid.
+func LoadEntitiesFromYAML(r io.Reader) ([]*Table, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read YAML entity definitions")
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "invalid YAML entity definitions")
+	}
+	var entities []*jsonEntity
+	if _, isList := doc.([]interface{}); isList {
+		if err := yaml.UnmarshalStrict(data, &entities); err != nil {
+			return nil, errors.Wrap(err, "invalid YAML entity definitions")
+		}
+	} else {
+		var entity jsonEntity
+		if err := yaml.UnmarshalStrict(data, &entity); err != nil {
+			return nil, errors.Wrap(err, "invalid YAML entity definition")
+		}
+		entities = append(entities, &entity)
+	}
+
+	tables := make([]*Table, 0, len(entities))
+	for i, entity := range entities {
+		if entity == nil {
+			return nil, errors.Errorf("YAML entity %d is null", i)
+		}
+		table, err := entity.table()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid YAML entity %q", entity.Name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// loadEntitiesFromYAMLFile reads the entities of a YAML file with LoadEntitiesFromYAML
+func loadEntitiesFromYAMLFile(path string) ([]*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open schema file")
+	}
+	defer func() { _ = f.Close() }()
+	tables, err := LoadEntitiesFromYAML(f)
+	return tables, errors.Wrapf(err, "schema file %q", path)
+}
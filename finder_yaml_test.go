@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEntitiesFromYAML(t *testing.T) {
+	tables, err := LoadEntitiesFromYAML(strings.NewReader(`
+- name: Users
+  columns:
+  - {name: ID, type: UUID, key: partition}
+  - {name: CreatedAt, type: time.Time, key: clustering, order: DESC}
+  - {name: Email, type: String, nullable: true, immutable: true, maxLength: 254}
+  - name: Age
+    type: int32
+    tags: {pii: ""}
+  indexes:
+    ByEmail: {key: "(Email, (CreatedAt DESC))"}
+  etl: "on"
+  ttl: 24h
+- name: events
+  columns:
+  - {name: a, type: Int64, key: partition}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, tables, 2)
+
+	assert.Equal(t, &Table{
+		EntityDefinition: EntityDefinition{
+			Name: "users",
+			Key: &PrimaryKey{
+				PartitionKeys:  []string{"id"},
+				ClusteringKeys: []*ClusteringKey{{Name: "createdat", Descending: true}},
+			},
+			Columns: []*ColumnDefinition{
+				{Name: "id", Type: TUUID},
+				{Name: "createdat", Type: Timestamp},
+				{Name: "email", Type: String, IsPointer: true, Immutable: true, MaxLength: 254},
+				{Name: "age", Type: Int32, Tags: map[string]string{"pii": ""}},
+			},
+			Indexes: map[string]*IndexDefinition{
+				"byemail": {Key: &PrimaryKey{
+					PartitionKeys:  []string{"email"},
+					ClusteringKeys: []*ClusteringKey{{Name: "createdat", Descending: true}},
+				}},
+			},
+			ETL: EtlOn,
+		},
+		StructName: "Users",
+		ColToField: map[string]string{"id": "ID", "createdat": "CreatedAt", "email": "Email", "age": "Age"},
+		FieldToCol: map[string]string{"ID": "id", "CreatedAt": "createdat", "Email": "email", "Age": "age"},
+		TTL:        24 * time.Hour,
+	}, tables[0])
+	assert.Equal(t, "events", tables[1].Name)
+
+	// a single entity
+	tables, err = LoadEntitiesFromYAML(strings.NewReader("name: t\ncolumns:\n- {name: id, type: int64, key: partition}\n"))
+	assert.NoError(t, err)
+	assert.Len(t, tables, 1)
+	assert.Equal(t, "t", tables[0].Name)
+}
+
+func TestLoadEntitiesFromYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		yaml string
+		msg  string
+	}{
+		{"name: [\n", "invalid YAML entity definitions"},
+		{"- name: [t]\n", "invalid YAML entity definitions"},
+		{"name: t\ncolour: blue\n", "invalid YAML entity definition"},
+		{"- null\n", "YAML entity 0 is null"},
+		{"name: t\ncolumns:\n- {name: id, type: uuid, key: partition}\n", `unknown type "uuid"`},
+		{"name: t\ncolumns:\n- {name: id, type: UUID}\n", "does not have partition key"},
+		{"name: t\ncolumns:\n- {name: id, type: UUID, key: partition, nullable: true}\n", "primary key"},
+	} {
+		_, err := LoadEntitiesFromYAML(strings.NewReader(tc.yaml))
+		if assert.Error(t, err, tc.yaml) {
+			assert.Contains(t, err.Error(), tc.msg, tc.yaml)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"sync"
+)
+
+// HookedClient is a Client that calls hooks before and after Upsert and
+// Read, for cross-cutting concerns like audit logging, input sanitization or
+// setting an UpdatedAt field, without wrapping the connector. Hooks are
+// called in the order they were added. A before hook that returns an error
+// aborts the operation with that error, and the later hooks aren't called.
+// After hooks are called whether or not the operation succeeded, with its
+// error.
+//
+// The hooks are run by a built-in interceptor of the InterceptedClient
+// HookedClient extends; interceptors added with Use run inside it.
+type HookedClient struct {
+	*InterceptedClient
+
+	lock         sync.RWMutex
+	beforeUpsert []func(entity DomainObject) error
+	afterUpsert  []func(entity DomainObject, err error)
+	beforeRead   []func(entity DomainObject) error
+	afterRead    []func(entity DomainObject, err error)
+}
+
+// NewHookedClient returns a HookedClient around c without any hooks
+func NewHookedClient(c Client) *HookedClient {
+	h := &HookedClient{}
+	h.InterceptedClient = NewInterceptedClient(c, h.runHooks)
+	return h
+}
+
+// OnBeforeUpsert adds a hook called with the entity before it's upserted
+func (h *HookedClient) OnBeforeUpsert(hook func(entity DomainObject) error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.beforeUpsert = append(h.beforeUpsert, hook)
+}
+
+// OnAfterUpsert adds a hook called with the entity and the error of Upsert,
+// which is nil if the upsert succeeded
+func (h *HookedClient) OnAfterUpsert(hook func(entity DomainObject, err error)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.afterUpsert = append(h.afterUpsert, hook)
+}
+
+// OnBeforeRead adds a hook called with the entity, which has its key fields
+// set, before it's read
+func (h *HookedClient) OnBeforeRead(hook func(entity DomainObject) error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.beforeRead = append(h.beforeRead, hook)
+}
+
+// OnAfterRead adds a hook called with the entity and the error of Read; the
+// entity has the values that were read only if the error is nil
+func (h *HookedClient) OnAfterRead(hook func(entity DomainObject, err error)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.afterRead = append(h.afterRead, hook)
+}
+
+// runHooks is the interceptor that calls the hooks of Upsert and Read around
+// those methods
+func (h *HookedClient) runHooks(ctx context.Context, method string, entity DomainObject, next func() error) error {
+	h.lock.RLock()
+	var before []func(entity DomainObject) error
+	var after []func(entity DomainObject, err error)
+	switch method {
+	case "Upsert":
+		before, after = h.beforeUpsert, h.afterUpsert
+	case "Read":
+		before, after = h.beforeRead, h.afterRead
+	}
+	h.lock.RUnlock()
+
+	if err := runBeforeHooks(before, entity); err != nil {
+		return err
+	}
+	err := next()
+	for _, hook := range after {
+		hook(entity, err)
+	}
+	return err
+}
+
+// runBeforeHooks calls the hooks in order until one returns an error
+func runBeforeHooks(hooks []func(entity DomainObject) error, entity DomainObject) error {
+	for _, hook := range hooks {
+		if err := hook(entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
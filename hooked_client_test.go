@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	dosaRenamed "github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+func TestHookedClient_Upsert(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewHookedClient(dosaRenamed.NewClient(reg, memory.NewConnector()))
+
+	var calls []string
+	c.OnBeforeUpsert(func(entity dosaRenamed.DomainObject) error {
+		calls = append(calls, "before 1")
+		e := entity.(*ClientTestEntity1)
+		e.Email = strings.ToLower(e.Email)
+		return nil
+	})
+	c.OnBeforeUpsert(func(entity dosaRenamed.DomainObject) error {
+		calls = append(calls, "before 2")
+		if entity.(*ClientTestEntity1).Name == "" {
+			return errors.New("name is required")
+		}
+		return nil
+	})
+	var upsertErrs []error
+	c.OnAfterUpsert(func(entity dosaRenamed.DomainObject, err error) {
+		calls = append(calls, "after")
+		upsertErrs = append(upsertErrs, err)
+	})
+
+	// the after hooks see the error of a failed upsert
+	err := c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo"})
+	assert.True(t, dosaRenamed.ErrorIsNotInitialized(err))
+	assert.Equal(t, []error{err}, upsertErrs)
+
+	assert.NoError(t, c.Initialize(ctx))
+	calls, upsertErrs = nil, nil
+	assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo", Email: "FOO@Email.com"}))
+	assert.Equal(t, []string{"before 1", "before 2", "after"}, calls)
+	assert.Equal(t, []error{nil}, upsertErrs)
+
+	e := &ClientTestEntity1{ID: 1}
+	assert.NoError(t, c.Read(ctx, dosaRenamed.All(), e))
+	assert.Equal(t, "foo@email.com", e.Email)
+
+	// a failing before hook aborts the upsert
+	calls = nil
+	err = c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 2})
+	assert.EqualError(t, err, "name is required")
+	assert.Equal(t, []string{"before 1", "before 2"}, calls)
+	err = c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 2})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+}
+
+func TestHookedClient_Read(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewHookedClient(dosaRenamed.NewClient(reg, memory.NewConnector()))
+	assert.NoError(t, c.Initialize(ctx))
+	assert.NoError(t, c.Upsert(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 1, Name: "foo", Email: "foo@email.com"}))
+
+	c.OnBeforeRead(func(entity dosaRenamed.DomainObject) error {
+		if entity.(*ClientTestEntity1).ID < 0 {
+			return errors.New("invalid id")
+		}
+		return nil
+	})
+	var readErrs []error
+	c.OnAfterRead(func(entity dosaRenamed.DomainObject, err error) {
+		readErrs = append(readErrs, err)
+		if err == nil {
+			entity.(*ClientTestEntity1).Email = "redacted"
+		}
+	})
+
+	e := &ClientTestEntity1{ID: 1}
+	assert.NoError(t, c.Read(ctx, dosaRenamed.All(), e))
+	assert.Equal(t, "foo", e.Name)
+	assert.Equal(t, "redacted", e.Email)
+
+	err := c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: 2})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+	assert.Equal(t, []error{nil, err}, readErrs)
+
+	err = c.Read(ctx, dosaRenamed.All(), &ClientTestEntity1{ID: -1})
+	assert.EqualError(t, err, "invalid id")
+	assert.Len(t, readErrs, 2)
+}
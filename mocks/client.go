@@ -55,6 +55,44 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Aggregate mocks base method
+func (m *MockClient) Aggregate(arg0 context.Context, arg1 dosa.AggFunc, arg2 string, arg3 *dosa.RangeOp) (dosa.FieldValue, error) {
+	ret := m.ctrl.Call(m, "Aggregate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(dosa.FieldValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Aggregate indicates an expected call of Aggregate
+func (mr *MockClientMockRecorder) Aggregate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aggregate", reflect.TypeOf((*MockClient)(nil).Aggregate), arg0, arg1, arg2, arg3)
+}
+
+// BatchRemove mocks base method
+func (m *MockClient) BatchRemove(arg0 context.Context, arg1 []dosa.DomainObject) []error {
+	ret := m.ctrl.Call(m, "BatchRemove", arg0, arg1)
+	ret0, _ := ret[0].([]error)
+	return ret0
+}
+
+// BatchRemove indicates an expected call of BatchRemove
+func (mr *MockClientMockRecorder) BatchRemove(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemove", reflect.TypeOf((*MockClient)(nil).BatchRemove), arg0, arg1)
+}
+
+// CountRange mocks base method
+func (m *MockClient) CountRange(arg0 context.Context, arg1 *dosa.RangeOp) (int64, error) {
+	ret := m.ctrl.Call(m, "CountRange", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRange indicates an expected call of CountRange
+func (mr *MockClientMockRecorder) CountRange(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRange", reflect.TypeOf((*MockClient)(nil).CountRange), arg0, arg1)
+}
+
 // CreateIfNotExists mocks base method
 func (m *MockClient) CreateIfNotExists(arg0 context.Context, arg1 dosa.DomainObject) error {
 	ret := m.ctrl.Call(m, "CreateIfNotExists", arg0, arg1)
@@ -67,6 +105,85 @@ func (mr *MockClientMockRecorder) CreateIfNotExists(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIfNotExists", reflect.TypeOf((*MockClient)(nil).CreateIfNotExists), arg0, arg1)
 }
 
+// CrossPartitionScan mocks base method
+func (m *MockClient) CrossPartitionScan(arg0 context.Context, arg1 dosa.DomainObject, arg2 []map[string]dosa.FieldValue, arg3 int) ([]dosa.DomainObject, error) {
+	ret := m.ctrl.Call(m, "CrossPartitionScan", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]dosa.DomainObject)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CrossPartitionScan indicates an expected call of CrossPartitionScan
+func (mr *MockClientMockRecorder) CrossPartitionScan(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CrossPartitionScan", reflect.TypeOf((*MockClient)(nil).CrossPartitionScan), arg0, arg1, arg2, arg3)
+}
+
+// ExplainQuery mocks base method
+func (m *MockClient) ExplainQuery(arg0 context.Context, arg1 *dosa.RangeOp) (*dosa.QueryPlan, error) {
+	ret := m.ctrl.Call(m, "ExplainQuery", arg0, arg1)
+	ret0, _ := ret[0].(*dosa.QueryPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExplainQuery indicates an expected call of ExplainQuery
+func (mr *MockClientMockRecorder) ExplainQuery(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainQuery", reflect.TypeOf((*MockClient)(nil).ExplainQuery), arg0, arg1)
+}
+
+// GetFirst mocks base method
+func (m *MockClient) GetFirst(arg0 context.Context, arg1 dosa.DomainObject) (dosa.DomainObject, error) {
+	ret := m.ctrl.Call(m, "GetFirst", arg0, arg1)
+	ret0, _ := ret[0].(dosa.DomainObject)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirst indicates an expected call of GetFirst
+func (mr *MockClientMockRecorder) GetFirst(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirst", reflect.TypeOf((*MockClient)(nil).GetFirst), arg0, arg1)
+}
+
+// GetLast mocks base method
+func (m *MockClient) GetLast(arg0 context.Context, arg1 dosa.DomainObject) (dosa.DomainObject, error) {
+	ret := m.ctrl.Call(m, "GetLast", arg0, arg1)
+	ret0, _ := ret[0].(dosa.DomainObject)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLast indicates an expected call of GetLast
+func (mr *MockClientMockRecorder) GetLast(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLast", reflect.TypeOf((*MockClient)(nil).GetLast), arg0, arg1)
+}
+
+// GetMany mocks base method
+func (m *MockClient) GetMany(arg0 context.Context, arg1 []dosa.DomainObject) ([]dosa.DomainObject, []error, error) {
+	ret := m.ctrl.Call(m, "GetMany", arg0, arg1)
+	ret0, _ := ret[0].([]dosa.DomainObject)
+	ret1, _ := ret[1].([]error)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMany indicates an expected call of GetMany
+func (mr *MockClientMockRecorder) GetMany(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockClient)(nil).GetMany), arg0, arg1)
+}
+
+// GetOrSet mocks base method
+func (m *MockClient) GetOrSet(arg0 context.Context, arg1 dosa.DomainObject, arg2 func(dosa.DomainObject) error) (bool, error) {
+	ret := m.ctrl.Call(m, "GetOrSet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrSet indicates an expected call of GetOrSet
+func (mr *MockClientMockRecorder) GetOrSet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrSet", reflect.TypeOf((*MockClient)(nil).GetOrSet), arg0, arg1, arg2)
+}
+
 // GetRegistrar mocks base method
 func (m *MockClient) GetRegistrar() dosa.Registrar {
 	ret := m.ctrl.Call(m, "GetRegistrar")
@@ -79,6 +196,31 @@ func (mr *MockClientMockRecorder) GetRegistrar() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrar", reflect.TypeOf((*MockClient)(nil).GetRegistrar))
 }
 
+// GetWithFieldMask mocks base method
+func (m *MockClient) GetWithFieldMask(arg0 context.Context, arg1 dosa.DomainObject, arg2 []string) error {
+	ret := m.ctrl.Call(m, "GetWithFieldMask", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWithFieldMask indicates an expected call of GetWithFieldMask
+func (mr *MockClientMockRecorder) GetWithFieldMask(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithFieldMask", reflect.TypeOf((*MockClient)(nil).GetWithFieldMask), arg0, arg1, arg2)
+}
+
+// IncrementCounter mocks base method
+func (m *MockClient) IncrementCounter(arg0 context.Context, arg1 dosa.DomainObject, arg2 string, arg3 int64) (int64, error) {
+	ret := m.ctrl.Call(m, "IncrementCounter", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementCounter indicates an expected call of IncrementCounter
+func (mr *MockClientMockRecorder) IncrementCounter(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementCounter", reflect.TypeOf((*MockClient)(nil).IncrementCounter), arg0, arg1, arg2, arg3)
+}
+
 // Initialize mocks base method
 func (m *MockClient) Initialize(arg0 context.Context) error {
 	ret := m.ctrl.Call(m, "Initialize", arg0)
@@ -109,6 +251,18 @@ func (mr *MockClientMockRecorder) MultiRead(arg0, arg1 interface{}, arg2 ...inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MultiRead", reflect.TypeOf((*MockClient)(nil).MultiRead), varargs...)
 }
 
+// MultiUpsert mocks base method
+func (m *MockClient) MultiUpsert(arg0 context.Context, arg1 []dosa.DomainObject) []error {
+	ret := m.ctrl.Call(m, "MultiUpsert", arg0, arg1)
+	ret0, _ := ret[0].([]error)
+	return ret0
+}
+
+// MultiUpsert indicates an expected call of MultiUpsert
+func (mr *MockClientMockRecorder) MultiUpsert(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MultiUpsert", reflect.TypeOf((*MockClient)(nil).MultiUpsert), arg0, arg1)
+}
+
 // Range mocks base method
 func (m *MockClient) Range(arg0 context.Context, arg1 *dosa.RangeOp) ([]dosa.DomainObject, string, error) {
 	ret := m.ctrl.Call(m, "Range", arg0, arg1)
@@ -135,6 +289,19 @@ func (mr *MockClientMockRecorder) Read(arg0, arg1, arg2 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockClient)(nil).Read), arg0, arg1, arg2)
 }
 
+// ReadOrCreate mocks base method
+func (m *MockClient) ReadOrCreate(arg0 context.Context, arg1 dosa.DomainObject) (bool, error) {
+	ret := m.ctrl.Call(m, "ReadOrCreate", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadOrCreate indicates an expected call of ReadOrCreate
+func (mr *MockClientMockRecorder) ReadOrCreate(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOrCreate", reflect.TypeOf((*MockClient)(nil).ReadOrCreate), arg0, arg1)
+}
+
 // Remove mocks base method
 func (m *MockClient) Remove(arg0 context.Context, arg1 dosa.DomainObject) error {
 	ret := m.ctrl.Call(m, "Remove", arg0, arg1)
@@ -147,6 +314,18 @@ func (mr *MockClientMockRecorder) Remove(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockClient)(nil).Remove), arg0, arg1)
 }
 
+// RemoveAll mocks base method
+func (m *MockClient) RemoveAll(arg0 context.Context, arg1 dosa.DomainObject) error {
+	ret := m.ctrl.Call(m, "RemoveAll", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAll indicates an expected call of RemoveAll
+func (mr *MockClientMockRecorder) RemoveAll(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAll", reflect.TypeOf((*MockClient)(nil).RemoveAll), arg0, arg1)
+}
+
 // RemoveRange mocks base method
 func (m *MockClient) RemoveRange(arg0 context.Context, arg1 *dosa.RemoveRangeOp) error {
 	ret := m.ctrl.Call(m, "RemoveRange", arg0, arg1)
@@ -159,6 +338,32 @@ func (mr *MockClientMockRecorder) RemoveRange(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRange", reflect.TypeOf((*MockClient)(nil).RemoveRange), arg0, arg1)
 }
 
+// Replace mocks base method
+func (m *MockClient) Replace(arg0 context.Context, arg1 dosa.DomainObject) error {
+	ret := m.ctrl.Call(m, "Replace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace
+func (mr *MockClientMockRecorder) Replace(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockClient)(nil).Replace), arg0, arg1)
+}
+
+// ScanBySecondaryIndex mocks base method
+func (m *MockClient) ScanBySecondaryIndex(arg0 context.Context, arg1 dosa.DomainObject, arg2 string, arg3 []*dosa.ColumnCondition, arg4 int, arg5 string) ([]dosa.DomainObject, string, error) {
+	ret := m.ctrl.Call(m, "ScanBySecondaryIndex", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]dosa.DomainObject)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ScanBySecondaryIndex indicates an expected call of ScanBySecondaryIndex
+func (mr *MockClientMockRecorder) ScanBySecondaryIndex(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanBySecondaryIndex", reflect.TypeOf((*MockClient)(nil).ScanBySecondaryIndex), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
 // ScanEverything mocks base method
 func (m *MockClient) ScanEverything(arg0 context.Context, arg1 *dosa.ScanOp) ([]dosa.DomainObject, string, error) {
 	ret := m.ctrl.Call(m, "ScanEverything", arg0, arg1)
@@ -173,6 +378,18 @@ func (mr *MockClientMockRecorder) ScanEverything(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanEverything", reflect.TypeOf((*MockClient)(nil).ScanEverything), arg0, arg1)
 }
 
+// ScanWithCallback mocks base method
+func (m *MockClient) ScanWithCallback(arg0 context.Context, arg1 dosa.DomainObject, arg2 int, arg3 func(dosa.DomainObject) error) error {
+	ret := m.ctrl.Call(m, "ScanWithCallback", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScanWithCallback indicates an expected call of ScanWithCallback
+func (mr *MockClientMockRecorder) ScanWithCallback(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanWithCallback", reflect.TypeOf((*MockClient)(nil).ScanWithCallback), arg0, arg1, arg2, arg3)
+}
+
 // Shutdown mocks base method
 func (m *MockClient) Shutdown() error {
 	ret := m.ctrl.Call(m, "Shutdown")
@@ -197,6 +414,18 @@ func (mr *MockClientMockRecorder) Upsert(arg0, arg1, arg2 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockClient)(nil).Upsert), arg0, arg1, arg2)
 }
 
+// UpsertWithConditions mocks base method
+func (m *MockClient) UpsertWithConditions(arg0 context.Context, arg1 dosa.DomainObject, arg2 []*dosa.ColumnCondition) error {
+	ret := m.ctrl.Call(m, "UpsertWithConditions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertWithConditions indicates an expected call of UpsertWithConditions
+func (mr *MockClientMockRecorder) UpsertWithConditions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertWithConditions", reflect.TypeOf((*MockClient)(nil).UpsertWithConditions), arg0, arg1, arg2)
+}
+
 // WalkRange mocks base method
 func (m *MockClient) WalkRange(arg0 context.Context, arg1 *dosa.RangeOp, arg2 func(dosa.DomainObject) error) error {
 	ret := m.ctrl.Call(m, "WalkRange", arg0, arg1, arg2)
@@ -209,6 +438,30 @@ func (mr *MockClientMockRecorder) WalkRange(arg0, arg1, arg2 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkRange", reflect.TypeOf((*MockClient)(nil).WalkRange), arg0, arg1, arg2)
 }
 
+// WarmConnections mocks base method
+func (m *MockClient) WarmConnections(arg0 context.Context, arg1 int) error {
+	ret := m.ctrl.Call(m, "WarmConnections", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WarmConnections indicates an expected call of WarmConnections
+func (mr *MockClientMockRecorder) WarmConnections(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmConnections", reflect.TypeOf((*MockClient)(nil).WarmConnections), arg0, arg1)
+}
+
+// WarmUp mocks base method
+func (m *MockClient) WarmUp(arg0 context.Context) error {
+	ret := m.ctrl.Call(m, "WarmUp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WarmUp indicates an expected call of WarmUp
+func (mr *MockClientMockRecorder) WarmUp(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmUp", reflect.TypeOf((*MockClient)(nil).WarmUp), arg0)
+}
+
 // MockAdminClient is a mock of AdminClient interface
 type MockAdminClient struct {
 	ctrl     *gomock.Controller
@@ -294,6 +547,30 @@ func (mr *MockAdminClientMockRecorder) DropScope(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropScope", reflect.TypeOf((*MockAdminClient)(nil).DropScope), arg0, arg1)
 }
 
+// DropTables mocks base method
+func (m *MockAdminClient) DropTables(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "DropTables", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropTables indicates an expected call of DropTables
+func (mr *MockAdminClientMockRecorder) DropTables(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropTables", reflect.TypeOf((*MockAdminClient)(nil).DropTables), arg0, arg1)
+}
+
+// Entity mocks base method
+func (m *MockAdminClient) Entity(arg0 string) dosa.AdminClient {
+	ret := m.ctrl.Call(m, "Entity", arg0)
+	ret0, _ := ret[0].(dosa.AdminClient)
+	return ret0
+}
+
+// Entity indicates an expected call of Entity
+func (mr *MockAdminClientMockRecorder) Entity(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Entity", reflect.TypeOf((*MockAdminClient)(nil).Entity), arg0)
+}
+
 // Excludes mocks base method
 func (m *MockAdminClient) Excludes(arg0 []string) dosa.AdminClient {
 	ret := m.ctrl.Call(m, "Excludes", arg0)
@@ -319,6 +596,30 @@ func (mr *MockAdminClientMockRecorder) GetSchema() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchema", reflect.TypeOf((*MockAdminClient)(nil).GetSchema))
 }
 
+// Definitions mocks base method
+func (m *MockAdminClient) Definitions(arg0 []*dosa.EntityDefinition) dosa.AdminClient {
+	ret := m.ctrl.Call(m, "Definitions", arg0)
+	ret0, _ := ret[0].(dosa.AdminClient)
+	return ret0
+}
+
+// Definitions indicates an expected call of Definitions
+func (mr *MockAdminClientMockRecorder) Definitions(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Definitions", reflect.TypeOf((*MockAdminClient)(nil).Definitions), arg0)
+}
+
+// SchemaFiles mocks base method
+func (m *MockAdminClient) SchemaFiles(arg0 []string) dosa.AdminClient {
+	ret := m.ctrl.Call(m, "SchemaFiles", arg0)
+	ret0, _ := ret[0].(dosa.AdminClient)
+	return ret0
+}
+
+// SchemaFiles indicates an expected call of SchemaFiles
+func (mr *MockAdminClientMockRecorder) SchemaFiles(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaFiles", reflect.TypeOf((*MockAdminClient)(nil).SchemaFiles), arg0)
+}
+
 // Scope mocks base method
 func (m *MockAdminClient) Scope(arg0 string) dosa.AdminClient {
 	ret := m.ctrl.Call(m, "Scope", arg0)
@@ -55,6 +55,32 @@ func (m *MockConnector) EXPECT() *MockConnectorMockRecorder {
 	return m.recorder
 }
 
+// Aggregate mocks base method
+func (m *MockConnector) Aggregate(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 dosa.AggFunc, arg3 string, arg4 map[string][]*dosa.Condition) (dosa.FieldValue, error) {
+	ret := m.ctrl.Call(m, "Aggregate", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(dosa.FieldValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Aggregate indicates an expected call of Aggregate
+func (mr *MockConnectorMockRecorder) Aggregate(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aggregate", reflect.TypeOf((*MockConnector)(nil).Aggregate), arg0, arg1, arg2, arg3, arg4)
+}
+
+// AtomicAdd mocks base method
+func (m *MockConnector) AtomicAdd(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue, arg3 string, arg4 int64) (int64, error) {
+	ret := m.ctrl.Call(m, "AtomicAdd", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AtomicAdd indicates an expected call of AtomicAdd
+func (mr *MockConnectorMockRecorder) AtomicAdd(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AtomicAdd", reflect.TypeOf((*MockConnector)(nil).AtomicAdd), arg0, arg1, arg2, arg3, arg4)
+}
+
 // CanUpsertSchema mocks base method
 func (m *MockConnector) CanUpsertSchema(arg0 context.Context, arg1, arg2 string, arg3 []*dosa.EntityDefinition) (int32, error) {
 	ret := m.ctrl.Call(m, "CanUpsertSchema", arg0, arg1, arg2, arg3)
@@ -94,6 +120,43 @@ func (mr *MockConnectorMockRecorder) CheckSchemaStatus(arg0, arg1, arg2, arg3 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSchemaStatus", reflect.TypeOf((*MockConnector)(nil).CheckSchemaStatus), arg0, arg1, arg2, arg3)
 }
 
+// CompareAndSwap mocks base method
+func (m *MockConnector) CompareAndSwap(arg0 context.Context, arg1 *dosa.EntityInfo, arg2, arg3 map[string]dosa.FieldValue) error {
+	ret := m.ctrl.Call(m, "CompareAndSwap", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompareAndSwap indicates an expected call of CompareAndSwap
+func (mr *MockConnectorMockRecorder) CompareAndSwap(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSwap", reflect.TypeOf((*MockConnector)(nil).CompareAndSwap), arg0, arg1, arg2, arg3)
+}
+
+// CopyTable mocks base method
+func (m *MockConnector) CopyTable(arg0 context.Context, arg1, arg2 *dosa.EntityInfo) error {
+	ret := m.ctrl.Call(m, "CopyTable", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyTable indicates an expected call of CopyTable
+func (mr *MockConnectorMockRecorder) CopyTable(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyTable", reflect.TypeOf((*MockConnector)(nil).CopyTable), arg0, arg1, arg2)
+}
+
+// CountRange mocks base method
+func (m *MockConnector) CountRange(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string][]*dosa.Condition) (int64, error) {
+	ret := m.ctrl.Call(m, "CountRange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRange indicates an expected call of CountRange
+func (mr *MockConnectorMockRecorder) CountRange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRange", reflect.TypeOf((*MockConnector)(nil).CountRange), arg0, arg1, arg2)
+}
+
 // CreateIfNotExists mocks base method
 func (m *MockConnector) CreateIfNotExists(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue) error {
 	ret := m.ctrl.Call(m, "CreateIfNotExists", arg0, arg1, arg2)
@@ -118,6 +181,31 @@ func (mr *MockConnectorMockRecorder) CreateScope(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScope", reflect.TypeOf((*MockConnector)(nil).CreateScope), arg0, arg1)
 }
 
+// DeletePartition mocks base method
+func (m *MockConnector) DeletePartition(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue) error {
+	ret := m.ctrl.Call(m, "DeletePartition", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePartition indicates an expected call of DeletePartition
+func (mr *MockConnectorMockRecorder) DeletePartition(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePartition", reflect.TypeOf((*MockConnector)(nil).DeletePartition), arg0, arg1, arg2)
+}
+
+// DescribeTable mocks base method
+func (m *MockConnector) DescribeTable(arg0 context.Context, arg1 *dosa.EntityInfo) (*dosa.EntityDefinition, error) {
+	ret := m.ctrl.Call(m, "DescribeTable", arg0, arg1)
+	ret0, _ := ret[0].(*dosa.EntityDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeTable indicates an expected call of DescribeTable
+func (mr *MockConnectorMockRecorder) DescribeTable(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTable", reflect.TypeOf((*MockConnector)(nil).DescribeTable), arg0, arg1)
+}
+
 // DropScope mocks base method
 func (m *MockConnector) DropScope(arg0 context.Context, arg1 string) error {
 	ret := m.ctrl.Call(m, "DropScope", arg0, arg1)
@@ -130,6 +218,31 @@ func (mr *MockConnectorMockRecorder) DropScope(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropScope", reflect.TypeOf((*MockConnector)(nil).DropScope), arg0, arg1)
 }
 
+// DropTable mocks base method
+func (m *MockConnector) DropTable(arg0 context.Context, arg1 *dosa.EntityInfo) error {
+	ret := m.ctrl.Call(m, "DropTable", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropTable indicates an expected call of DropTable
+func (mr *MockConnectorMockRecorder) DropTable(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropTable", reflect.TypeOf((*MockConnector)(nil).DropTable), arg0, arg1)
+}
+
+// ExplainQuery mocks base method
+func (m *MockConnector) ExplainQuery(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string][]*dosa.Condition) (*dosa.QueryPlan, error) {
+	ret := m.ctrl.Call(m, "ExplainQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dosa.QueryPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExplainQuery indicates an expected call of ExplainQuery
+func (mr *MockConnectorMockRecorder) ExplainQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainQuery", reflect.TypeOf((*MockConnector)(nil).ExplainQuery), arg0, arg1, arg2)
+}
+
 // GetEntitySchema mocks base method
 func (m *MockConnector) GetEntitySchema(arg0 context.Context, arg1, arg2, arg3 string, arg4 int32) (*dosa.EntityDefinition, error) {
 	ret := m.ctrl.Call(m, "GetEntitySchema", arg0, arg1, arg2, arg3, arg4)
@@ -233,6 +346,18 @@ func (mr *MockConnectorMockRecorder) RemoveRange(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRange", reflect.TypeOf((*MockConnector)(nil).RemoveRange), arg0, arg1, arg2)
 }
 
+// Replace mocks base method
+func (m *MockConnector) Replace(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue) error {
+	ret := m.ctrl.Call(m, "Replace", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace
+func (mr *MockConnectorMockRecorder) Replace(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockConnector)(nil).Replace), arg0, arg1, arg2)
+}
+
 // Scan mocks base method
 func (m *MockConnector) Scan(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 []string, arg3 string, arg4 int) ([]map[string]dosa.FieldValue, string, error) {
 	ret := m.ctrl.Call(m, "Scan", arg0, arg1, arg2, arg3, arg4)
@@ -296,6 +421,19 @@ func (mr *MockConnectorMockRecorder) Upsert(arg0, arg1, arg2 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockConnector)(nil).Upsert), arg0, arg1, arg2)
 }
 
+// UpsertAndRead mocks base method
+func (m *MockConnector) UpsertAndRead(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue) (map[string]dosa.FieldValue, error) {
+	ret := m.ctrl.Call(m, "UpsertAndRead", arg0, arg1, arg2)
+	ret0, _ := ret[0].(map[string]dosa.FieldValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertAndRead indicates an expected call of UpsertAndRead
+func (mr *MockConnectorMockRecorder) UpsertAndRead(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertAndRead", reflect.TypeOf((*MockConnector)(nil).UpsertAndRead), arg0, arg1, arg2)
+}
+
 // UpsertSchema mocks base method
 func (m *MockConnector) UpsertSchema(arg0 context.Context, arg1, arg2 string, arg3 []*dosa.EntityDefinition) (*dosa.SchemaStatus, error) {
 	ret := m.ctrl.Call(m, "UpsertSchema", arg0, arg1, arg2, arg3)
@@ -308,3 +446,15 @@ func (m *MockConnector) UpsertSchema(arg0 context.Context, arg1, arg2 string, ar
 func (mr *MockConnectorMockRecorder) UpsertSchema(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertSchema", reflect.TypeOf((*MockConnector)(nil).UpsertSchema), arg0, arg1, arg2, arg3)
 }
+
+// UpsertWithConditions mocks base method
+func (m *MockConnector) UpsertWithConditions(arg0 context.Context, arg1 *dosa.EntityInfo, arg2 map[string]dosa.FieldValue, arg3 map[string][]*dosa.Condition) error {
+	ret := m.ctrl.Call(m, "UpsertWithConditions", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertWithConditions indicates an expected call of UpsertWithConditions
+func (mr *MockConnectorMockRecorder) UpsertWithConditions(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertWithConditions", reflect.TypeOf((*MockConnector)(nil).UpsertWithConditions), arg0, arg1, arg2, arg3)
+}
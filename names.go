@@ -90,3 +90,32 @@ func NormalizeName(name string) (string, error) {
 	}
 	return lowercaseName, nil
 }
+
+// NameFormatter builds the physical name of an entity's table from the scope,
+// the name prefix and the entity name, for backends that keep the tables of
+// every scope and prefix together
+type NameFormatter interface {
+	Format(scope, prefix, entity string) string
+}
+
+// SeparatorNameFormatter is a NameFormatter that joins the scope, the prefix
+// and the entity name with the separator it holds, skipping empty parts, e.g.
+// SeparatorNameFormatter("_") formats "prod", "team", "users" as
+// "prod_team_users"
+type SeparatorNameFormatter string
+
+// Format satisfies NameFormatter
+func (s SeparatorNameFormatter) Format(scope, prefix, entity string) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{scope, prefix, entity} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, string(s))
+}
+
+// DefaultNameFormatter is the NameFormatter used by Table.FullyQualifiedName.
+// It joins the parts with dots; programs that name tables differently can
+// replace it at startup, before any names are built.
+var DefaultNameFormatter NameFormatter = SeparatorNameFormatter(".")
@@ -148,3 +148,25 @@ func TestIsValidNamePrefix(t *testing.T) {
 	err = IsValidNamePrefix("this.prefix.has.more.than.thrity.two.characters.in.it")
 	assert.Error(t, err)
 }
+
+type scopeOnlyFormatter struct{}
+
+func (scopeOnlyFormatter) Format(scope, prefix, entity string) string {
+	return fmt.Sprintf("%s/%s", scope, entity)
+}
+
+func TestFullyQualifiedName(t *testing.T) {
+	table, err := NewTable("users", []*ColumnDefinition{{Name: "id", Type: Int64}}, &PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "prod.team.service.users", table.FullyQualifiedName("prod", "team.service"))
+	assert.Equal(t, "prod.users", table.FullyQualifiedName("prod", ""))
+	assert.Equal(t, "users", table.FullyQualifiedName("", ""))
+
+	assert.Equal(t, "prod_team_users", SeparatorNameFormatter("_").Format("prod", "team", "users"))
+	assert.Equal(t, "produsers", SeparatorNameFormatter("").Format("prod", "", "users"))
+
+	defer func(f NameFormatter) { DefaultNameFormatter = f }(DefaultNameFormatter)
+	DefaultNameFormatter = scopeOnlyFormatter{}
+	assert.Equal(t, "prod/users", table.FullyQualifiedName("prod", "team"))
+}
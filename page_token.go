@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import "context"
+
+// pageTokenKey is the context key of the page token
+type pageTokenKey struct{}
+
+// WithPageToken returns a copy of the context that carries a page token, such
+// as the one an HTTP handler received from its client. Range and
+// ScanEverything use it when the operation has no Offset, so the token
+// doesn't have to be passed through every function in between.
+func WithPageToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, pageTokenKey{}, token)
+}
+
+// PageTokenFromContext returns the page token set with WithPageToken, and
+// whether there was one
+func PageTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(pageTokenKey{}).(string)
+	return token, ok
+}
+
+// pageToken returns the explicit token, or else the one in the context
+func pageToken(ctx context.Context, token string) string {
+	if token != "" {
+		return token
+	}
+	token, _ = PageTokenFromContext(ctx)
+	return token
+}
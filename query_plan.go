@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+// UnknownRows is the EstimatedRows of a QueryPlan when the backend can't
+// estimate the number of rows
+const UnknownRows = -1
+
+// QueryPlan describes how a connector would serve a Range with the given
+// conditions. It's meant for diagnostics, such as debug endpoints, so it can
+// be marshaled to JSON.
+type QueryPlan struct {
+	// Index is the name of the index the rows are read from, or empty when
+	// they're read from the entity's own table
+	Index string `json:"index,omitempty"`
+	// EstimatedRows is the number of rows the backend expects to read, or
+	// UnknownRows if it can't tell
+	EstimatedRows int64 `json:"estimatedRows"`
+	// FullScan is true when no key matches the conditions, so every row of
+	// the table has to be read
+	FullScan bool `json:"fullScan"`
+	// ServerSideFiltering is true when the backend reads rows that don't match
+	// the conditions and leaves them out of the results
+	ServerSideFiltering bool `json:"serverSideFiltering"`
+	// Details is the backend's own description of the plan, if it has one
+	Details string `json:"details,omitempty"`
+}
+
+// PlanFromConditions returns the plan that follows from the schema alone:
+// the conditions are served from the entity's table or the first index they
+// match (see EntityInfo.IndexFromConditions), or else by scanning the table
+// and filtering its rows. The number of rows is unknown.
+func PlanFromConditions(ei *EntityInfo, columnConditions map[string][]*Condition) *QueryPlan {
+	plan := &QueryPlan{EstimatedRows: UnknownRows}
+	name, _, err := ei.IndexFromConditions(columnConditions, true)
+	if err != nil {
+		plan.FullScan = true
+		plan.ServerSideFiltering = len(columnConditions) > 0
+		return plan
+	}
+	if name != ei.Def.Name {
+		plan.Index = name
+	}
+	return plan
+}
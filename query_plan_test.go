@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanFromConditions(t *testing.T) {
+	ei := &EntityInfo{Def: &EntityDefinition{
+		Name: "users",
+		Columns: []*ColumnDefinition{
+			{Name: "id", Type: Int64},
+			{Name: "email", Type: String},
+			{Name: "city", Type: String},
+		},
+		Key:     &PrimaryKey{PartitionKeys: []string{"id"}},
+		Indexes: map[string]*IndexDefinition{"by_email": {Key: &PrimaryKey{PartitionKeys: []string{"email"}}}},
+	}}
+
+	assert.Equal(t, &QueryPlan{EstimatedRows: UnknownRows}, PlanFromConditions(ei, map[string][]*Condition{
+		"id": {{Op: Eq, Value: int64(1)}},
+	}))
+	assert.Equal(t, &QueryPlan{Index: "by_email", EstimatedRows: UnknownRows}, PlanFromConditions(ei, map[string][]*Condition{
+		"email": {{Op: Eq, Value: "a@example.com"}},
+	}))
+	assert.Equal(t, &QueryPlan{EstimatedRows: UnknownRows, FullScan: true, ServerSideFiltering: true}, PlanFromConditions(ei, map[string][]*Condition{
+		"city": {{Op: Eq, Value: "Paris"}},
+	}))
+	assert.Equal(t, &QueryPlan{EstimatedRows: UnknownRows, FullScan: true}, PlanFromConditions(ei, nil))
+}
+
+func TestQueryPlanJSON(t *testing.T) {
+	data, err := json.Marshal(&QueryPlan{Index: "by_email", EstimatedRows: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"index":"by_email","estimatedRows":3,"fullScan":false,"serverSideFiltering":false}`, string(data))
+}
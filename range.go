@@ -22,6 +22,7 @@ package dosa
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 
@@ -40,6 +41,7 @@ const (
 type RangeOp struct {
 	pager
 	conditioner
+	sortColumns []ColumnOrder
 }
 
 // NewRangeOp returns a new RangeOp instance
@@ -72,6 +74,15 @@ func (r *RangeOp) Fields(fields []string) *RangeOp {
 	return r
 }
 
+// WithSortColumns sets the order of the results by field name. The fields
+// must be a prefix of the clustering keys, all in the direction of the schema
+// or all reversed, so that the results can be read in reverse order without
+// scanning the partition; the connector rejects other orders.
+func (r *RangeOp) WithSortColumns(cols []ColumnOrder) *RangeOp {
+	r.sortColumns = cols
+	return r
+}
+
 // String satisfies the Stringer interface
 func (r *RangeOp) String() string {
 	result := &bytes.Buffer{}
@@ -169,3 +180,36 @@ func (ei *EntityInfo) IndexFromConditions(conditions map[string][]*Condition, se
 	// none of the indexes work, so fail
 	return "", nil, errors.Wrapf(baseTableError, "No index matches specified conditions")
 }
+
+// NamedIndex returns the key info of the named index, after checking that
+// the conditions are valid range conditions for it. Connectors use it
+// instead of IndexFromConditions when the context names the index to read
+// (see WithIndexName).
+func (ei *EntityInfo) NamedIndex(name string, conditions map[string][]*Condition) (*PrimaryKey, error) {
+	indexDef, ok := ei.Def.Indexes[name]
+	if !ok {
+		return nil, errors.Errorf("%q is not an index of %q", name, ei.Def.Name)
+	}
+	identityFunc := func(s string) string { return s }
+	if err := EnsureValidRangeConditions(ei.Def, indexDef.Key, conditions, identityFunc); err != nil {
+		return nil, errors.Wrapf(err, "index %q", name)
+	}
+	return ei.Def.UniqueKey(indexDef.Key), nil
+}
+
+// indexNameKey is the context key of the index a Range reads
+type indexNameKey struct{}
+
+// WithIndexName returns a copy of the context that tells the connector to
+// serve a Range from the named index, rather than the table or index picked
+// from the conditions. Client.ScanBySecondaryIndex sets it.
+func WithIndexName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, indexNameKey{}, name)
+}
+
+// IndexNameFromContext returns the index set on the context with
+// WithIndexName, and whether there was one
+func IndexNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(indexNameKey{}).(string)
+	return name, ok && name != ""
+}
@@ -21,9 +21,7 @@
 package dosa
 
 import (
-	"bytes"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -218,12 +216,12 @@ func ensureValidConditions(t Type, conditions []*Condition) error {
 	switch {
 	//  v1 < fv < v0, v1 <= fv < v0, v1 < fv <= v0    ===> v0 > v1
 	case op0 == Lt && op1 == Gt, op0 == Lt && op1 == GtOrEq, op0 == LtOrEq && op1 == Gt:
-		if compare(t, v0, v1) <= 0 {
+		if !FieldValueLess(v1, v0) {
 			return errors.Errorf("invalid range: %v", conditions)
 		}
 		// v1 <= fv <= v0   ===> v0 >= v1
 	case op0 == LtOrEq && op1 == GtOrEq:
-		if compare(t, v0, v1) < 0 {
+		if FieldValueLess(v0, v1) {
 			return errors.Errorf("invalid range: %v", conditions)
 		}
 	default: // invalid combination of operators
@@ -234,55 +232,6 @@ func ensureValidConditions(t Type, conditions []*Condition) error {
 	return nil
 }
 
-// compare compares two values; return 0 if equal, -1 if <, 1 if >.
-// Assumes args are valid.
-func compare(t Type, a, b interface{}) int {
-	switch t {
-	case TUUID:
-		// TODO: make sure if comparison for UUID like below makes sense.
-		return strings.Compare(string(a.(UUID)), string(b.(UUID)))
-	case Int64:
-		return int(a.(int64) - b.(int64))
-	case Int32:
-		return int(a.(int32) - b.(int32))
-	case String:
-		return strings.Compare(a.(string), b.(string))
-	case Blob:
-		return bytes.Compare(a.([]byte), b.([]byte))
-	case Bool:
-		// TODO: we don't need to order bools for range query and should report error if people do dumb things
-		var ia, ib int
-		if a.(bool) {
-			ia = 1
-		}
-		if b.(bool) {
-			ib = 1
-		}
-		return ia - ib
-	case Double:
-		fa := a.(float64)
-		fb := b.(float64)
-		if fa < fb {
-			return -1
-		}
-		if fa > fb {
-			return 1
-		}
-		return 0
-	case Timestamp:
-		ta := a.(time.Time)
-		tb := b.(time.Time)
-		if ta.Before(tb) {
-			return -1
-		}
-		if ta.After(tb) {
-			return 1
-		}
-		return 0
-	}
-	panic("invalid type") // shouldn't reach here
-}
-
 func ensureTypeMatch(t Type, v FieldValue) error {
 	switch t {
 	case TUUID:
@@ -23,7 +23,6 @@ package dosa
 import (
 	"testing"
 
-	"fmt"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -69,48 +68,6 @@ func TestEnsureTypeMatch(t *testing.T) {
 	})
 }
 
-func TestCompare(t *testing.T) {
-	type testCase struct {
-		tp       Type
-		a, b     interface{}
-		expected int
-	}
-
-	cases := []testCase{
-		{TUUID, UUID("267275CD-D312-4EFB-A304-020A43971D68"), UUID("4268FCA1-7CE3-4624-AC2B-204F138A81E8"), -1},
-		{TUUID, UUID("4268FCA1-7CE3-4624-AC2B-204F138A81E8"), UUID("267275CD-D312-4EFB-A304-020A43971D68"), 1},
-		{TUUID, UUID("267275CD-D312-4EFB-A304-020A43971D68"), UUID("267275CD-D312-4EFB-A304-020A43971D68"), 0},
-		{Int64, int64(0), int64(1), -1},
-		{Int64, int64(1), int64(0), 1},
-		{Int64, int64(1), int64(1), 0},
-		{Int32, int32(0), int32(1), -1},
-		{Int32, int32(1), int32(0), 1},
-		{Int32, int32(1), int32(1), 0},
-		{String, "abc", "defg", -1},
-		{String, "defg", "abc", 1},
-		{String, "abc", "abc", 0},
-		{Blob, []byte{1, 2, 3}, []byte{4, 5}, -1},
-		{Blob, []byte{4, 5}, []byte{1, 2, 3}, 1},
-		{Blob, []byte{1, 2, 3}, []byte{1, 2, 3}, 0},
-		{Bool, false, true, -1},
-		{Bool, true, false, 1},
-		{Bool, true, true, 0},
-		{Double, float64(0.0), float64(1.0), -1},
-		{Double, float64(1.0), float64(0.0), 1},
-		{Double, float64(1.0), float64(1.0), 0},
-		{Timestamp, time.Unix(5, 0), time.Unix(6, 0), -1},
-		{Timestamp, time.Unix(6, 0), time.Unix(5, 0), 1},
-		{Timestamp, time.Unix(5, 0), time.Unix(5, 0), 0},
-	}
-
-	for _, c := range cases {
-		assert.Equal(t, c.expected, compare(c.tp, c.a, c.b), fmt.Sprintf("%v", c))
-	}
-	assert.Panics(t, func() {
-		compare(Invalid, 0, 0)
-	})
-}
-
 func TestEnsureValidConditions(t *testing.T) {
 	type testCase struct {
 		tp         Type
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// RangeQuery builds the conditions, limit and page token of a
+// Connector.Range call by column name:
+//
+//	conditions, limit, token, err := dosa.NewRangeQuery(table).
+//		Where("id").Eq(id).
+//		Where("ts").GtOrEq(since).
+//		Limit(50).
+//		Build()
+//
+// When the query has a table, Build checks the columns and the types of the
+// values against it, and SortAsc and SortDesc order the results by the
+// first clustering key of the table or index the conditions select.
+type RangeQuery struct {
+	table      *Table
+	conditions map[string][]*Condition
+	limit      int
+	token      string
+	sort       *SortDirection
+}
+
+// NewRangeQuery returns a new, empty RangeQuery. The table may be nil, in
+// which case the columns are not checked and the results cannot be sorted.
+func NewRangeQuery(table *Table) *RangeQuery {
+	return &RangeQuery{
+		table:      table,
+		conditions: map[string][]*Condition{},
+	}
+}
+
+// RangeColumn is a column of a RangeQuery waiting for a condition
+type RangeColumn struct {
+	query  *RangeQuery
+	column string
+}
+
+// Where starts a condition on the named column
+func (q *RangeQuery) Where(column string) *RangeColumn {
+	return &RangeColumn{query: q, column: column}
+}
+
+func (c *RangeColumn) appendOp(op Operator, value FieldValue) *RangeQuery {
+	c.query.conditions[c.column] = append(c.query.conditions[c.column], &Condition{Op: op, Value: value})
+	return c.query
+}
+
+// Eq requires the column to be equal to the value
+func (c *RangeColumn) Eq(value FieldValue) *RangeQuery {
+	return c.appendOp(Eq, value)
+}
+
+// Gt requires the column to be greater than the value
+func (c *RangeColumn) Gt(value FieldValue) *RangeQuery {
+	return c.appendOp(Gt, value)
+}
+
+// GtOrEq requires the column to be greater than or equal to the value
+func (c *RangeColumn) GtOrEq(value FieldValue) *RangeQuery {
+	return c.appendOp(GtOrEq, value)
+}
+
+// Lt requires the column to be less than the value
+func (c *RangeColumn) Lt(value FieldValue) *RangeQuery {
+	return c.appendOp(Lt, value)
+}
+
+// LtOrEq requires the column to be less than or equal to the value
+func (c *RangeColumn) LtOrEq(value FieldValue) *RangeQuery {
+	return c.appendOp(LtOrEq, value)
+}
+
+// Limit sets the number of rows returned per call
+func (q *RangeQuery) Limit(n int) *RangeQuery {
+	q.limit = n
+	return q
+}
+
+// PageToken sets the token of the page to read, as returned by the previous
+// call. If not set, the first page is read.
+func (q *RangeQuery) PageToken(token string) *RangeQuery {
+	q.token = token
+	return q
+}
+
+// SortAsc returns the results in ascending order of the first clustering key
+func (q *RangeQuery) SortAsc() *RangeQuery {
+	dir := Ascending
+	q.sort = &dir
+	return q
+}
+
+// SortDesc returns the results in descending order of the first clustering key
+func (q *RangeQuery) SortDesc() *RangeQuery {
+	dir := Descending
+	q.sort = &dir
+	return q
+}
+
+// Build returns the conditions, limit and page token to pass to
+// Connector.Range, or an error if the query does not match its table.
+func (q *RangeQuery) Build() (map[string][]*Condition, int, string, error) {
+	if q.table != nil {
+		for column, conds := range q.conditions {
+			cd := q.table.FindColumnDefinition(column)
+			if cd == nil {
+				return nil, 0, "", errors.Errorf("cannot find column %q in table %q", column, q.table.Name)
+			}
+			for _, cond := range conds {
+				if err := ensureTypeMatch(cd.Type, cond.Value); err != nil {
+					return nil, 0, "", errors.Wrapf(err, "column %s", column)
+				}
+			}
+		}
+	}
+	if _, err := q.sortColumns(); err != nil {
+		return nil, 0, "", err
+	}
+	return q.conditions, q.limit, q.token, nil
+}
+
+// Context returns a copy of ctx that carries the sort order of the query to
+// the connector. It returns ctx itself if the query is not sorted.
+func (q *RangeQuery) Context(ctx context.Context) context.Context {
+	cols, err := q.sortColumns()
+	if err != nil || len(cols) == 0 {
+		return ctx
+	}
+	return WithSortColumns(ctx, cols)
+}
+
+func (q *RangeQuery) sortColumns() ([]ColumnOrder, error) {
+	if q.sort == nil {
+		return nil, nil
+	}
+	if q.table == nil {
+		return nil, errors.New("cannot sort a range query without its table")
+	}
+	ei := &EntityInfo{Def: &q.table.EntityDefinition}
+	_, key, err := ei.IndexFromConditions(q.conditions, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sort range query")
+	}
+	if len(key.ClusteringKeys) == 0 {
+		return nil, errors.Errorf("cannot sort range query on %q, it has no clustering keys", q.table.Name)
+	}
+	return []ColumnOrder{{Column: key.ClusteringKeys[0].Name, Direction: *q.sort}}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rangeQueryTable = &Table{
+	EntityDefinition: EntityDefinition{
+		Name: "events",
+		Key: &PrimaryKey{
+			PartitionKeys:  []string{"id"},
+			ClusteringKeys: []*ClusteringKey{{Name: "ts", Descending: true}},
+		},
+		Columns: []*ColumnDefinition{
+			{Name: "id", Type: String},
+			{Name: "ts", Type: Int64},
+			{Name: "kind", Type: String},
+		},
+		Indexes: map[string]*IndexDefinition{
+			"by_kind": {Key: &PrimaryKey{PartitionKeys: []string{"kind"}}},
+		},
+	},
+}
+
+func TestRangeQuery_Build(t *testing.T) {
+	conditions, limit, token, err := NewRangeQuery(rangeQueryTable).
+		Where("id").Eq("a").
+		Where("ts").GtOrEq(int64(1)).
+		Where("ts").Lt(int64(10)).
+		Limit(50).
+		PageToken("next").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]*Condition{
+		"id": {{Op: Eq, Value: "a"}},
+		"ts": {{Op: GtOrEq, Value: int64(1)}, {Op: Lt, Value: int64(10)}},
+	}, conditions)
+	assert.Equal(t, 50, limit)
+	assert.Equal(t, "next", token)
+
+	// without a table anything goes
+	conditions, limit, token, err = NewRangeQuery(nil).Where("x").Gt(1).Where("y").LtOrEq("z").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]*Condition{
+		"x": {{Op: Gt, Value: 1}},
+		"y": {{Op: LtOrEq, Value: "z"}},
+	}, conditions)
+	assert.Equal(t, 0, limit)
+	assert.Equal(t, "", token)
+}
+
+func TestRangeQuery_BuildErrors(t *testing.T) {
+	_, _, _, err := NewRangeQuery(rangeQueryTable).Where("nope").Eq("a").Build()
+	assert.EqualError(t, err, `cannot find column "nope" in table "events"`)
+
+	_, _, _, err = NewRangeQuery(rangeQueryTable).Where("ts").Gt(1).Build()
+	assert.EqualError(t, err, "column ts: invalid value for int64 type: 1")
+
+	_, _, _, err = NewRangeQuery(nil).Where("id").Eq("a").SortAsc().Build()
+	assert.EqualError(t, err, "cannot sort a range query without its table")
+
+	_, _, _, err = NewRangeQuery(rangeQueryTable).Where("ts").Gt(int64(1)).SortAsc().Build()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot sort range query")
+}
+
+func TestRangeQuery_Sort(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewRangeQuery(rangeQueryTable).Where("id").Eq("a")
+	assert.Equal(t, ctx, q.Context(ctx))
+
+	cols, ok := SortColumnsFromContext(q.SortAsc().Context(ctx))
+	assert.True(t, ok)
+	assert.Equal(t, []ColumnOrder{{Column: "ts", Direction: Ascending}}, cols)
+
+	cols, _ = SortColumnsFromContext(q.SortDesc().Context(ctx))
+	assert.Equal(t, []ColumnOrder{{Column: "ts", Direction: Descending}}, cols)
+
+	// an index query sorts on the clustering keys of the index
+	q = NewRangeQuery(rangeQueryTable).Where("kind").Eq("click").SortDesc()
+	_, _, _, err := q.Build()
+	assert.NoError(t, err)
+	cols, _ = SortColumnsFromContext(q.Context(ctx))
+	assert.Equal(t, []ColumnOrder{{Column: "id", Direction: Descending}}, cols)
+}
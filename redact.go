@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+// RedactedValue replaces the values of sensitive columns in log output
+const RedactedValue = "[REDACTED]"
+
+// Redact returns a copy of values where the values of the given columns, if
+// present, are replaced with RedactedValue. It's meant for log output; pass
+// EntityDefinition.SensitiveColumns to redact the columns tagged sensitive.
+func Redact(values map[string]FieldValue, columns []string) map[string]FieldValue {
+	if len(columns) == 0 {
+		return values
+	}
+	redacted := make(map[string]FieldValue, len(values))
+	for name, value := range values {
+		redacted[name] = value
+	}
+	for _, name := range columns {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = RedactedValue
+		}
+	}
+	return redacted
+}
@@ -80,10 +80,17 @@ func (e *RegisteredEntity) EntityDefinition() *EntityDefinition {
 }
 
 // KeyFieldValues is a helper for generating a map of field values to be used in a query.
+// Entities without a primary key get a new RowIDColumn value instead.
 func (e *RegisteredEntity) KeyFieldValues(entity DomainObject) map[string]FieldValue {
 	v := reflect.ValueOf(entity).Elem()
 	fieldValues := make(map[string]FieldValue)
 
+	// entities without a primary key get a new row ID every time
+	if e.table.ScanOnly() {
+		fieldValues[RowIDColumn] = NewUUID()
+		return fieldValues
+	}
+
 	// populate partition key values
 	for _, pk := range e.table.Key.PartitionKeys {
 		fieldName := e.table.ColToField[pk]
@@ -39,7 +39,7 @@ type RegistryTestValid struct {
 }
 
 type RegistryTestInvalid struct {
-	dosa.Entity `dosa:"primaryKey=()"`
+	dosa.Entity `dosa:"primaryKey=(Missing)"`
 	PrimaryKey  int64
 	data        string
 }
@@ -74,6 +74,47 @@ func TestNewRegisteredEntity(t *testing.T) {
 	assert.Equal(t, table.EntityDefinition, *def)
 }
 
+func TestNewEntityInfo(t *testing.T) {
+	table, _ := dosa.TableFromInstance(&RegistryTestValid{})
+
+	ei, err := dosa.NewEntityInfo("test", "team.service", table)
+	assert.NoError(t, err)
+	assert.Equal(t, dosa.NewRegisteredEntity("test", "team.service", table).EntityInfo(), ei)
+	assert.Equal(t, "registrytestvalid", ei.Ref.EntityName)
+
+	_, err = dosa.NewEntityInfo("test", "team.service", nil)
+	assert.Error(t, err)
+	_, err = dosa.NewEntityInfo("", "team.service", table)
+	assert.Contains(t, err.Error(), "without a scope")
+	_, err = dosa.NewEntityInfo("test", "bad-prefix", table)
+	assert.Contains(t, err.Error(), "Name Prefix bad-prefix is invalid")
+
+	invalid, _ := dosa.TableFromInstance(&RegistryTestValid{})
+	invalid.Key = nil
+	_, err = dosa.NewEntityInfo("test", "team.service", invalid)
+	assert.Error(t, err)
+}
+
+func TestEntityInfo_WithFields(t *testing.T) {
+	table, _ := dosa.TableFromInstance(&RegistryTestValid{})
+	ei, _ := dosa.NewEntityInfo("test", "team.service", table)
+
+	projected, err := ei.WithFields([]string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, ei.Ref, projected.Ref)
+	assert.Equal(t, ei.TTL, projected.TTL)
+	var columns []string
+	for _, cd := range projected.Def.Columns {
+		columns = append(columns, cd.Name)
+	}
+	assert.Equal(t, []string{"id", "name"}, columns)
+	// the original is unchanged
+	assert.Len(t, ei.Def.Columns, 3)
+
+	_, err = ei.WithFields([]string{"borkborkbork"})
+	assert.Error(t, err)
+}
+
 func TestRegisteredEntity_KeyFieldValues(t *testing.T) {
 	entity := &RegistryTestValid{
 		ID:    int64(1),
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Registry is a set of tables keyed by entity name. It is safe for
+// concurrent use, and the zero value is an empty registry.
+type Registry struct {
+	lock   sync.RWMutex
+	tables map[string]*Table
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{tables: make(map[string]*Table)}
+}
+
+// Register adds a table to the registry. It returns an error if a table with
+// the same entity name is already registered.
+func (r *Registry) Register(t *Table) error {
+	if t == nil {
+		return errors.New("cannot register a nil table")
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.tables[t.Name]; ok {
+		return errors.Errorf("entity %q is already registered", t.Name)
+	}
+	if r.tables == nil {
+		r.tables = make(map[string]*Table)
+	}
+	r.tables[t.Name] = t
+	return nil
+}
+
+// Lookup returns the table registered for the entity name
+func (r *Registry) Lookup(name string) (*Table, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	t, ok := r.tables[name]
+	return t, ok
+}
+
+// Unregister removes the table registered for the entity name, if any. This
+// is mostly for tests, which register their own tables and must clean up.
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.tables, name)
+}
+
+// All returns the registered tables, sorted by entity name
+func (r *Registry) All() []*Table {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	tables := make([]*Table, 0, len(r.tables))
+	for _, t := range r.tables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// MarshalYAML satisfies the yaml.Marshaler interface. The registry is
+// written as the list of its entity definitions, sorted by name, in the form
+// read by LoadEntitiesFromYAML, so that snapshots of the registered schemas
+// diff cleanly.
+func (r *Registry) MarshalYAML() (interface{}, error) {
+	tables := r.All()
+	entities := make([]*jsonEntity, len(tables))
+	for i, t := range tables {
+		entities[i] = newJSONEntity(t)
+	}
+	return entities, nil
+}
+
+// UnmarshalYAML satisfies the yaml.Unmarshaler interface. It registers the
+// entities of a document written by MarshalYAML, or of any list of entities
+// LoadEntitiesFromYAML reads.
+func (r *Registry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var entities []*jsonEntity
+	if err := unmarshal(&entities); err != nil {
+		return errors.Wrap(err, "invalid YAML registry")
+	}
+	for i, entity := range entities {
+		if entity == nil {
+			return errors.Errorf("YAML entity %d is null", i)
+		}
+		table, err := entity.table()
+		if err != nil {
+			return errors.Wrapf(err, "invalid YAML entity %q", entity.Name)
+		}
+		if err := r.Register(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
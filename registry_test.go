@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	dosayaml "github.com/uber-go/dosa/schema/yaml"
+	"github.com/uber-go/dosa/testentity"
+	"gopkg.in/yaml.v2"
+)
+
+func registryTestTable(name string) *dosa.Table {
+	return &dosa.Table{
+		EntityDefinition: dosa.EntityDefinition{
+			Name:    name,
+			Key:     &dosa.PrimaryKey{PartitionKeys: []string{"id"}},
+			Columns: []*dosa.ColumnDefinition{{Name: "id", Type: dosa.Int64}},
+			ETL:     dosa.EtlOff,
+		},
+		TTL: dosa.NoTTL(),
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := dosa.NewRegistry()
+	b, a := registryTestTable("b"), registryTestTable("a")
+	assert.NoError(t, r.Register(b))
+	assert.NoError(t, r.Register(a))
+	assert.Error(t, r.Register(registryTestTable("a")))
+	assert.Error(t, r.Register(nil))
+
+	found, ok := r.Lookup("a")
+	assert.True(t, ok)
+	assert.Equal(t, a, found)
+	assert.Equal(t, []*dosa.Table{a, b}, r.All())
+
+	r.Unregister("a")
+	r.Unregister("missing")
+	_, ok = r.Lookup("a")
+	assert.False(t, ok)
+	assert.Equal(t, []*dosa.Table{b}, r.All())
+	assert.NoError(t, r.Register(registryTestTable("a")))
+
+	// the zero value works too
+	var zero dosa.Registry
+	assert.Empty(t, zero.All())
+	assert.NoError(t, zero.Register(a))
+	assert.Len(t, zero.All(), 1)
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := dosa.NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprint("table", i)
+			assert.NoError(t, r.Register(registryTestTable(name)))
+			_, ok := r.Lookup(name)
+			assert.True(t, ok)
+			_ = r.All()
+			if i%2 == 0 {
+				r.Unregister(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, r.All(), 10)
+}
+
+func TestRegistry_MarshalYAML(t *testing.T) {
+	r := dosa.NewRegistry()
+	table, err := dosa.TableFromInstance(&testentity.TestEntity{})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Register(table))
+	for _, name := range []string{"c", "a", "b"} {
+		assert.NoError(t, r.Register(registryTestTable(name)))
+	}
+	descending := dosa.Descending
+	// the key is not in column order, and the table has every attribute the YAML keeps
+	assert.NoError(t, r.Register(&dosa.Table{
+		EntityDefinition: dosa.EntityDefinition{
+			Name: "events",
+			Key: &dosa.PrimaryKey{
+				PartitionKeys:  []string{"id"},
+				ClusteringKeys: []*dosa.ClusteringKey{{Name: "ts", Descending: true}, {Name: "kind"}},
+			},
+			Columns: []*dosa.ColumnDefinition{
+				{Name: "kind", Type: dosa.String},
+				{Name: "ts", Type: dosa.Timestamp},
+				{Name: "id", Type: dosa.TUUID},
+				{Name: "email", Type: dosa.String, IsPointer: true, SensitiveData: true, UniqueConstraint: true, MaxLength: 254, EstimatedBytes: 32, OrderedBy: &descending},
+				{Name: "source", Type: dosa.String, IsPointer: true, Deprecated: true, DeprecatedSince: "v2"},
+			},
+			Indexes: map[string]*dosa.IndexDefinition{
+				"byemail": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"email"}}, TTL: time.Hour},
+			},
+			ETL: dosa.EtlOn,
+		},
+		TTL:               24 * time.Hour,
+		DeprecatedColumns: []string{"source"},
+	}))
+
+	data, err := yaml.Marshal(r)
+	assert.NoError(t, err)
+	again, err := yaml.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	loaded := dosa.NewRegistry()
+	assert.NoError(t, yaml.Unmarshal(data, loaded))
+	tables, loadedTables := r.All(), loaded.All()
+	assert.Len(t, loadedTables, 5)
+	for i, table := range tables {
+		want, err := dosayaml.Fingerprint(&table.EntityDefinition)
+		assert.NoError(t, err)
+		got, err := dosayaml.Fingerprint(&loadedTables[i].EntityDefinition)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, table.Name)
+		assert.Equal(t, table.TTL, loadedTables[i].TTL, table.Name)
+		assert.Equal(t, table.DeprecatedColumns, loadedTables[i].DeprecatedColumns, table.Name)
+	}
+	roundTrip, err := yaml.Marshal(loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(roundTrip))
+
+	// the document is readable by LoadEntitiesFromYAML too
+	fromFile, err := dosa.LoadEntitiesFromYAML(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Len(t, fromFile, 5)
+
+	assert.Error(t, yaml.Unmarshal([]byte("- name: a\n  columns: []\n"), dosa.NewRegistry()))
+	assert.Error(t, yaml.Unmarshal(data, loaded), "entities are already registered")
+}
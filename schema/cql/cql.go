@@ -22,6 +22,7 @@ package cql
 
 import (
 	"bytes"
+	"strings"
 	"text/template"
 
 	"github.com/uber-go/dosa"
@@ -31,6 +32,31 @@ func uniqueKey(e dosa.EntityDefinition, k *dosa.PrimaryKey) *dosa.PrimaryKey {
 	return e.UniqueKey(k)
 }
 
+// clusteringOrder returns the clustering order clause of the materialized
+// view of an index, used in the template. It is only set when a clustering
+// column of the view has an OrderedBy, which then overrides the direction of
+// the index key.
+func clusteringOrder(e dosa.EntityDefinition, k *dosa.PrimaryKey) string {
+	key := e.UniqueKey(k)
+	order := make([]string, 0, len(key.ClusteringKeys))
+	ordered := false
+	for _, ck := range key.ClusteringKeys {
+		direction := dosa.Ascending
+		if ck.Descending {
+			direction = dosa.Descending
+		}
+		if cd := e.FindColumnDefinition(ck.Name); cd != nil && cd.OrderedBy != nil {
+			direction = *cd.OrderedBy
+			ordered = true
+		}
+		order = append(order, ck.Name+" "+direction.String())
+	}
+	if !ordered {
+		return ""
+	}
+	return "\n  with clustering order by (" + strings.Join(order, ", ") + ")"
+}
+
 // typeMap returns the CQL type associated with the given dosa.Type,
 // used in the template
 func typeMap(t dosa.Type) string {
@@ -60,12 +86,13 @@ var cqlCreateTableTemplate = template.Must(template.
 	New("cqlCreateTable").
 	Funcs(map[string]interface{}{"typeMap": typeMap}).
 	Funcs(map[string]interface{}{"uniqueKey": uniqueKey}).
+	Funcs(map[string]interface{}{"clusteringOrder": clusteringOrder}).
 	Parse(`create table "{{.Name}}" ({{range .Columns}}"{{- .Name -}}" {{ typeMap .Type -}}, {{end}}primary key {{ .Key }});
 {{- range $name, $indexdef := .Indexes }}
 create materialized view "{{- $name -}}" as
   select * from "{{- $.Name -}}"
   where{{range $keynum, $key := $indexdef.Key.PartitionKeys }}{{if $keynum}} AND {{end}} "{{ $key }}" is not null {{- end}}
-  primary key {{ uniqueKey $ $indexdef.Key }};
+  primary key {{ uniqueKey $ $indexdef.Key }}{{ clusteringOrder $ $indexdef.Key }};
 {{- end -}}`))
 
 // ToCQL generates CQL from an EntityDefinition
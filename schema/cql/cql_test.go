@@ -50,6 +50,14 @@ type SinglePrimaryKey struct {
 	Data        string
 }
 
+type OrderedIndex struct {
+	dosa.Entity `dosa:"primaryKey=(ID)"`
+	ByCity      dosa.Index `dosa:"key=(City, Score)"`
+	ID          int64
+	City        string
+	Score       int64 `dosa:"ordered_desc"`
+}
+
 func TestCQL(t *testing.T) {
 	data := []struct {
 		Instance  dosa.DomainObject
@@ -70,6 +78,15 @@ create materialized view "i2" as
   select * from "alltypes"
   where "int64type" is not null
   primary key (int64type, booltype ASC);`,
+		},
+		{
+			Instance: &OrderedIndex{},
+			Statement: `create table "orderedindex" ("id" bigint, "city" text, "score" bigint, primary key (id));
+create materialized view "bycity" as
+  select * from "orderedindex"
+  where "city" is not null
+  primary key (city, score ASC, id ASC)
+  with clustering order by (score DESC, id ASC);`,
 		},
 		// TODO: Add more test cases
 	}
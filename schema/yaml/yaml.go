@@ -0,0 +1,310 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package yaml translates entity definitions to and from YAML documents, so
+// that schema snapshots can be kept and reviewed outside of Go source.
+package yaml
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/dosa"
+	goyaml "gopkg.in/yaml.v2"
+)
+
+// Version is the version of the YAML layout written by ToYAML. Version 2
+// writes indexes as objects with a key and a TTL; version 1 wrote the key
+// alone, which FromYAML still reads.
+const Version = 2
+
+const (
+	versionPrefix     = "# DOSA schema version "
+	fingerprintPrefix = "# fingerprint: "
+)
+
+type entity struct {
+	Name    string           `yaml:"name"`
+	ETL     string           `yaml:"etl,omitempty"`
+	Key     key              `yaml:"key"`
+	Columns []column         `yaml:"columns"`
+	Indexes map[string]index `yaml:"indexes,omitempty"`
+}
+
+type index struct {
+	Key key    `yaml:"key"`
+	TTL string `yaml:"ttl,omitempty"`
+}
+
+type key struct {
+	Partition  []string        `yaml:"partition"`
+	Clustering []clusteringKey `yaml:"clustering,omitempty"`
+}
+
+type clusteringKey struct {
+	Name       string `yaml:"name"`
+	Descending bool   `yaml:"descending,omitempty"`
+}
+
+type column struct {
+	Name            string            `yaml:"name"`
+	Type            string            `yaml:"type"`
+	Nullable        bool              `yaml:"nullable,omitempty"`
+	Immutable       bool              `yaml:"immutable,omitempty"`
+	MaxLength       int               `yaml:"maxLength,omitempty"`
+	EstimatedBytes  int               `yaml:"estimatedBytes,omitempty"`
+	Sensitive       bool              `yaml:"sensitive,omitempty"`
+	Unique          bool              `yaml:"unique,omitempty"`
+	Deprecated      bool              `yaml:"deprecated,omitempty"`
+	DeprecatedSince string            `yaml:"deprecatedSince,omitempty"`
+	OrderedBy       string            `yaml:"orderedBy,omitempty"`
+	Tags            map[string]string `yaml:"tags,omitempty"`
+}
+
+// ToYAML translates an entity definition to a YAML document. The document
+// starts with comments holding the layout version and the fingerprint of the
+// definition.
+func ToYAML(e *dosa.EntityDefinition) ([]byte, error) {
+	body, err := marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%d\n", versionPrefix, Version)
+	fmt.Fprintf(&buf, "%s%s\n", fingerprintPrefix, fingerprint(body))
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// Fingerprint returns a hash of the entity definition that changes whenever
+// the YAML document for it does
+func Fingerprint(e *dosa.EntityDefinition) (string, error) {
+	body, err := marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint(body), nil
+}
+
+// ReadFingerprint returns the fingerprint recorded in a document written by
+// ToYAML, or an empty string if there is none
+func ReadFingerprint(data []byte) string {
+	fp, _ := headerValue(data, fingerprintPrefix)
+	return fp
+}
+
+// FromYAML translates a YAML document written by ToYAML, possibly edited by
+// hand, back to an entity definition
+func FromYAML(data []byte) (*dosa.EntityDefinition, error) {
+	if err := checkVersion(data); err != nil {
+		return nil, err
+	}
+	var y entity
+	if err := goyaml.Unmarshal(data, &y); err != nil {
+		return nil, errors.Wrap(err, "failed to parse YAML")
+	}
+
+	e := &dosa.EntityDefinition{
+		Name: y.Name,
+		Key:  y.Key.toPrimaryKey(),
+	}
+	if y.ETL != "" {
+		etl, err := dosa.ToETLState(y.ETL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "entity %q", y.Name)
+		}
+		e.ETL = etl
+	}
+	for _, c := range y.Columns {
+		t, err := dosa.TypeFromString(c.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q of entity %q", c.Name, y.Name)
+		}
+		var orderedBy *dosa.SortDirection
+		if c.OrderedBy != "" {
+			d, err := dosa.ToSortDirection(c.OrderedBy)
+			if err != nil {
+				return nil, errors.Wrapf(err, "column %q of entity %q", c.Name, y.Name)
+			}
+			orderedBy = &d
+		}
+		e.Columns = append(e.Columns, &dosa.ColumnDefinition{
+			Name:             c.Name,
+			Type:             t,
+			IsPointer:        c.Nullable,
+			Immutable:        c.Immutable,
+			MaxLength:        c.MaxLength,
+			EstimatedBytes:   c.EstimatedBytes,
+			SensitiveData:    c.Sensitive,
+			UniqueConstraint: c.Unique,
+			Deprecated:       c.Deprecated || c.DeprecatedSince != "",
+			DeprecatedSince:  c.DeprecatedSince,
+			OrderedBy:        orderedBy,
+			Tags:             c.Tags,
+		})
+	}
+	if len(y.Indexes) > 0 {
+		e.Indexes = make(map[string]*dosa.IndexDefinition, len(y.Indexes))
+		for name, i := range y.Indexes {
+			index := &dosa.IndexDefinition{Key: i.Key.toPrimaryKey()}
+			if i.TTL != "" {
+				ttl, err := time.ParseDuration(i.TTL)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid ttl %q of index %q of entity %q", i.TTL, name, y.Name)
+				}
+				index.TTL = ttl
+			}
+			e.Indexes[name] = index
+		}
+	}
+
+	if err := e.EnsureValid(); err != nil {
+		return nil, errors.Wrap(err, "EntityDefinition is invalid")
+	}
+	return e, nil
+}
+
+func marshal(e *dosa.EntityDefinition) ([]byte, error) {
+	if err := e.EnsureValid(); err != nil {
+		return nil, errors.Wrap(err, "EntityDefinition is invalid")
+	}
+
+	y := entity{
+		Name: e.Name,
+		ETL:  string(e.ETL),
+		Key:  fromPrimaryKey(e.Key),
+	}
+	for _, c := range e.Columns {
+		var orderedBy string
+		if c.OrderedBy != nil {
+			orderedBy = strings.ToLower(c.OrderedBy.String())
+		}
+		y.Columns = append(y.Columns, column{
+			Name:            c.Name,
+			Type:            c.Type.String(),
+			Nullable:        c.IsPointer,
+			Immutable:       c.Immutable,
+			MaxLength:       c.MaxLength,
+			EstimatedBytes:  c.EstimatedBytes,
+			Sensitive:       c.SensitiveData,
+			Unique:          c.UniqueConstraint,
+			Deprecated:      c.Deprecated,
+			DeprecatedSince: c.DeprecatedSince,
+			OrderedBy:       orderedBy,
+			Tags:            c.Tags,
+		})
+	}
+	if len(e.Indexes) > 0 {
+		y.Indexes = make(map[string]index, len(e.Indexes))
+		for name, i := range e.Indexes {
+			y.Indexes[name] = fromIndexDefinition(i)
+		}
+	}
+
+	body, err := goyaml.Marshal(y)
+	if err != nil {
+		// shouldn't happen unless we have a bug in our code
+		return nil, errors.Wrap(err, "failed to marshal YAML; this is most likely a DOSA bug")
+	}
+	return body, nil
+}
+
+func fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// checkVersion returns an error if the document has a version comment with a
+// version this package can't read. Documents without one are read as the
+// current version.
+func checkVersion(data []byte) error {
+	s, ok := headerValue(data, versionPrefix)
+	if !ok {
+		return nil
+	}
+	var v int
+	if _, err := fmt.Sscanf(s, "%d", &v); err != nil || v < 1 || v > Version {
+		return errors.Errorf("unsupported schema version %q", s)
+	}
+	return nil
+}
+
+// headerValue finds the comment with the prefix among the comments at the top
+// of the document and returns the rest of it
+func headerValue(data []byte, prefix string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+		}
+	}
+	return "", false
+}
+
+func fromPrimaryKey(pk *dosa.PrimaryKey) key {
+	k := key{Partition: pk.PartitionKeys}
+	for _, ck := range pk.ClusteringKeys {
+		k.Clustering = append(k.Clustering, clusteringKey{Name: ck.Name, Descending: ck.Descending})
+	}
+	return k
+}
+
+func (k key) toPrimaryKey() *dosa.PrimaryKey {
+	pk := &dosa.PrimaryKey{PartitionKeys: k.Partition}
+	for _, ck := range k.Clustering {
+		pk.ClusteringKeys = append(pk.ClusteringKeys, &dosa.ClusteringKey{Name: ck.Name, Descending: ck.Descending})
+	}
+	return pk
+}
+
+func fromIndexDefinition(id *dosa.IndexDefinition) index {
+	i := index{Key: fromPrimaryKey(id.Key)}
+	if id.TTL != 0 {
+		i.TTL = id.TTL.String()
+	}
+	return i
+}
+
+// UnmarshalYAML satisfies the yaml.Unmarshaler interface. It also reads the
+// indexes of version 1 documents, which are only a key.
+func (i *index) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain index
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	if len(p.Key.Partition) == 0 {
+		if err := unmarshal(&p.Key); err != nil {
+			return err
+		}
+	}
+	*i = index(p)
+	return nil
+}
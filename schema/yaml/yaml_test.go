@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yaml_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/schema/yaml"
+)
+
+var descending = dosa.Descending
+
+var testEntity = &dosa.EntityDefinition{
+	Name: "orders",
+	ETL:  dosa.EtlOn,
+	Key: &dosa.PrimaryKey{
+		PartitionKeys:  []string{"customer"},
+		ClusteringKeys: []*dosa.ClusteringKey{{Name: "placed", Descending: true}, {Name: "id"}},
+	},
+	Columns: []*dosa.ColumnDefinition{
+		{Name: "customer", Type: dosa.String, MaxLength: 64, EstimatedBytes: 16},
+		{Name: "placed", Type: dosa.Timestamp},
+		{Name: "id", Type: dosa.TUUID, UniqueConstraint: true},
+		{Name: "total", Type: dosa.Double, IsPointer: true, SensitiveData: true},
+		{Name: "note", Type: dosa.String, Immutable: true, OrderedBy: &descending, Tags: map[string]string{"owner": "billing"}},
+		{Name: "coupon", Type: dosa.String, IsPointer: true, Deprecated: true, DeprecatedSince: "v2"},
+		{Name: "discount", Type: dosa.Double, IsPointer: true, Deprecated: true},
+	},
+	Indexes: map[string]*dosa.IndexDefinition{
+		"by_id":   {Key: &dosa.PrimaryKey{PartitionKeys: []string{"id"}}},
+		"by_note": {Key: &dosa.PrimaryKey{PartitionKeys: []string{"note"}}, TTL: 36 * time.Hour},
+	},
+}
+
+func TestToYAML(t *testing.T) {
+	data, err := yaml.ToYAML(testEntity)
+	assert.NoError(t, err)
+	fp, err := yaml.Fingerprint(testEntity)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(fp, "sha256:"))
+	assert.Equal(t, `# DOSA schema version 2
+# fingerprint: `+fp+`
+name: orders
+etl: "on"
+key:
+  partition:
+  - customer
+  clustering:
+  - name: placed
+    descending: true
+  - name: id
+columns:
+- name: customer
+  type: String
+  maxLength: 64
+  estimatedBytes: 16
+- name: placed
+  type: Timestamp
+- name: id
+  type: TUUID
+  unique: true
+- name: total
+  type: Double
+  nullable: true
+  sensitive: true
+- name: note
+  type: String
+  immutable: true
+  orderedBy: desc
+  tags:
+    owner: billing
+- name: coupon
+  type: String
+  nullable: true
+  deprecated: true
+  deprecatedSince: v2
+- name: discount
+  type: Double
+  nullable: true
+  deprecated: true
+indexes:
+  by_id:
+    key:
+      partition:
+      - id
+  by_note:
+    key:
+      partition:
+      - note
+    ttl: 36h0m0s
+`, string(data))
+	assert.Equal(t, fp, yaml.ReadFingerprint(data))
+
+	_, err = yaml.ToYAML(&dosa.EntityDefinition{})
+	assert.Error(t, err)
+}
+
+func TestFromYAML(t *testing.T) {
+	data, err := yaml.ToYAML(testEntity)
+	assert.NoError(t, err)
+	e, err := yaml.FromYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, testEntity, e)
+
+	// the fingerprint follows changes
+	changed := testEntity.Clone()
+	changed.Columns[3].IsPointer = false
+	fp, err := yaml.Fingerprint(changed)
+	assert.NoError(t, err)
+	assert.NotEqual(t, yaml.ReadFingerprint(data), fp)
+	changed = testEntity.Clone()
+	changed.Indexes["by_note"].TTL = time.Hour
+	fp, err = yaml.Fingerprint(changed)
+	assert.NoError(t, err)
+	assert.NotEqual(t, yaml.ReadFingerprint(data), fp)
+
+	// a version implies the column is deprecated
+	e, err = yaml.FromYAML([]byte("name: t\nkey:\n  partition: [c]\ncolumns:\n- name: c\n  type: String\n- name: d\n  type: String\n  nullable: true\n  deprecatedSince: v3\n"))
+	assert.NoError(t, err)
+	assert.True(t, e.Columns[1].Deprecated)
+	assert.Equal(t, "v3", e.Columns[1].DeprecatedSince)
+
+	// version 1 documents have the key of an index alone
+	e, err = yaml.FromYAML([]byte("# DOSA schema version 1\nname: t\nkey:\n  partition: [c]\ncolumns:\n- name: c\n  type: String\n- name: d\n  type: String\nindexes:\n  by_d:\n    partition: [d]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &dosa.IndexDefinition{Key: &dosa.PrimaryKey{PartitionKeys: []string{"d"}}}, e.Indexes["by_d"])
+
+	_, err = yaml.FromYAML([]byte("# DOSA schema version 3\nname: orders\n"))
+	assert.EqualError(t, err, `unsupported schema version "3"`)
+	_, err = yaml.FromYAML([]byte("name: t\nkey:\n  partition: [c]\ncolumns:\n- name: c\n  type: String\nindexes:\n  i:\n    key:\n      partition: [c]\n    ttl: soon\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid ttl "soon"`)
+	_, err = yaml.FromYAML([]byte("name: t\nkey:\n  partition: [c]\ncolumns:\n- name: c\n  type: String\n  orderedBy: up\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid sort direction "up"`)
+	_, err = yaml.FromYAML([]byte("name: t\nkey:\n  partition: [c]\ncolumns:\n- name: c\n  type: nope\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown type "nope"`)
+	_, err = yaml.FromYAML([]byte("name: t\nkey:\n  partition: [d]\ncolumns:\n- name: c\n  type: String\n"))
+	assert.Error(t, err)
+	_, err = yaml.FromYAML([]byte("name: [\n"))
+	assert.Error(t, err)
+	assert.Equal(t, "", yaml.ReadFingerprint([]byte("name: t\n")))
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// EnsureSchemaChangesApplied brings the backend's schema in line with the
+// tables, as on startup of a deployment, by upserting the schema of the
+// tables that need safe changes only: tables that DescribeTable reports as
+// not found, and tables that only gain nullable columns or indexes. Tables
+// with other changes, such as a new non-nullable column, a removed column or
+// a changed key, are skipped with a warning on the logger, which may be nil.
+// Tables that already match the backend are left alone, so running it again
+// after it succeeded doesn't call UpsertSchema at all.
+func EnsureSchemaChangesApplied(ctx context.Context, connector Connector, scope, namePrefix string, entities []*Table, logger Logger) error {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	var changed []*EntityDefinition
+	for _, table := range entities {
+		ei := &EntityInfo{
+			Ref: &SchemaRef{Scope: scope, NamePrefix: namePrefix, EntityName: table.Name},
+			Def: &table.EntityDefinition,
+		}
+		live, err := connector.DescribeTable(ctx, ei)
+		if ErrorIsNotFound(err) {
+			logger.Infof("creating table %q", table.Name)
+			changed = append(changed, ei.Def)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not describe table %q", table.Name)
+		}
+		if len(ei.Def.Differences(live)) == 0 {
+			continue
+		}
+		if err := safeSchemaChange(ei.Def, live); err != nil {
+			logger.Warnf("skipping schema change of table %q: %v", table.Name, err)
+			continue
+		}
+		logger.Infof("adding columns or indexes to table %q", table.Name)
+		changed = append(changed, ei.Def)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+	if _, err := connector.UpsertSchema(ctx, scope, namePrefix, changed); err != nil {
+		return errors.Wrap(err, "could not apply the schema changes")
+	}
+	return nil
+}
+
+// safeSchemaChange returns an error unless the only changes from live to ed
+// are new nullable columns and new indexes
+func safeSchemaChange(ed, live *EntityDefinition) error {
+	if err := ed.CanBeUpsertedOn(live); err != nil {
+		return err
+	}
+	liveColumns := live.ColumnMap()
+	for _, cd := range ed.Columns {
+		if _, ok := liveColumns[cd.Name]; !ok && !cd.IsPointer {
+			return errors.Errorf("new column %q is not nullable", cd.Name)
+		}
+	}
+	columns := ed.ColumnMap()
+	for _, cd := range live.Columns {
+		if _, ok := columns[cd.Name]; !ok {
+			return errors.Errorf("column %q would be removed", cd.Name)
+		}
+	}
+	return nil
+}
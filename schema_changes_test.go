@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	dosaRenamed "github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/mocks"
+)
+
+type schemaWarnLogger struct {
+	warnings []string
+}
+
+func (l *schemaWarnLogger) Debugf(string, ...interface{}) {}
+func (l *schemaWarnLogger) Infof(string, ...interface{})  {}
+func (l *schemaWarnLogger) Errorf(string, ...interface{}) {}
+
+func (l *schemaWarnLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func schemaTable(t *testing.T, name string, extra ...*dosaRenamed.ColumnDefinition) *dosaRenamed.Table {
+	columns := append([]*dosaRenamed.ColumnDefinition{{Name: "id", Type: dosaRenamed.Int64}}, extra...)
+	table, err := dosaRenamed.NewTable(name, columns, &dosaRenamed.PrimaryKey{PartitionKeys: []string{"id"}})
+	assert.NoError(t, err)
+	return table
+}
+
+func TestEnsureSchemaChangesApplied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	conn := mocks.NewMockConnector(ctrl)
+	logger := &schemaWarnLogger{}
+
+	unchanged := schemaTable(t, "unchanged")
+	created := schemaTable(t, "created")
+	nullable := schemaTable(t, "nullable", &dosaRenamed.ColumnDefinition{Name: "note", Type: dosaRenamed.String, IsPointer: true})
+	notNullable := schemaTable(t, "notnullable", &dosaRenamed.ColumnDefinition{Name: "note", Type: dosaRenamed.String})
+	retyped := schemaTable(t, "retyped", &dosaRenamed.ColumnDefinition{Name: "note", Type: dosaRenamed.Int64})
+	tables := []*dosaRenamed.Table{unchanged, created, nullable, notNullable, retyped}
+
+	// the backend has an older version of every table but "created"
+	nullableLive := schemaTable(t, "nullable")
+	gomock.InOrder(
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Do(func(_ interface{}, ei *dosaRenamed.EntityInfo) {
+			assert.Equal(t, &dosaRenamed.SchemaRef{Scope: scope, NamePrefix: namePrefix, EntityName: "unchanged"}, ei.Ref)
+		}).Return(unchanged.EntityDefinition.Clone(), nil),
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{}),
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(nullableLive.EntityDefinition.Clone(), nil),
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(schemaTable(t, "notnullable").EntityDefinition.Clone(), nil),
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(schemaTable(t, "retyped", &dosaRenamed.ColumnDefinition{Name: "note", Type: dosaRenamed.String}).EntityDefinition.Clone(), nil),
+		conn.EXPECT().UpsertSchema(ctx, scope, namePrefix, []*dosaRenamed.EntityDefinition{
+			&created.EntityDefinition, &nullable.EntityDefinition,
+		}).Return(&dosaRenamed.SchemaStatus{Version: 2}, nil),
+	)
+	assert.NoError(t, dosaRenamed.EnsureSchemaChangesApplied(ctx, conn, scope, namePrefix, tables, logger))
+	assert.Equal(t, []string{
+		`skipping schema change of table "notnullable": new column "note" is not nullable`,
+		`skipping schema change of table "retyped": the type for column note mismatch: (Int64 vs String)`,
+	}, logger.warnings)
+
+	// once the safe changes are live, running again doesn't upsert anything
+	for _, table := range tables {
+		live := table.EntityDefinition.Clone()
+		if table == notNullable || table == retyped {
+			live.Columns = live.Columns[:1]
+		}
+		conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(live, nil)
+	}
+	assert.NoError(t, dosaRenamed.EnsureSchemaChangesApplied(ctx, conn, scope, namePrefix, tables, nil))
+}
+
+func TestEnsureSchemaChangesApplied_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	conn := mocks.NewMockConnector(ctrl)
+	table := schemaTable(t, "t")
+
+	conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(nil, errors.New("unavailable"))
+	err := dosaRenamed.EnsureSchemaChangesApplied(ctx, conn, scope, namePrefix, []*dosaRenamed.Table{table}, nil)
+	assert.EqualError(t, err, `could not describe table "t": unavailable`)
+
+	conn.EXPECT().DescribeTable(ctx, gomock.Any()).Return(nil, &dosaRenamed.ErrNotFound{})
+	conn.EXPECT().UpsertSchema(ctx, scope, namePrefix, gomock.Any()).Return(nil, errors.New("rejected"))
+	err = dosaRenamed.EnsureSchemaChangesApplied(ctx, conn, scope, namePrefix, []*dosaRenamed.Table{table}, nil)
+	assert.EqualError(t, err, "could not apply the schema changes: rejected")
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa
+
+import (
+	"sort"
+)
+
+// SortKey holds the clustering key values of a row, in clustering key order,
+// and orders rows within a partition the way backends like Cassandra do.
+type SortKey struct {
+	values     []FieldValue
+	descending []bool
+}
+
+// NewSortKey extracts the sort key of a row from its clustering key values
+func NewSortKey(pk *PrimaryKey, row map[string]FieldValue) SortKey {
+	sk := SortKey{
+		values:     make([]FieldValue, len(pk.ClusteringKeys)),
+		descending: make([]bool, len(pk.ClusteringKeys)),
+	}
+	for i, ck := range pk.ClusteringKeys {
+		sk.values[i] = row[ck.Name]
+		sk.descending[i] = ck.Descending
+	}
+	return sk
+}
+
+// Compare returns -1, 0 or 1 when the row of k sorts before, with or after
+// the row of other. Both keys must come from the same primary key.
+func (k SortKey) Compare(other SortKey) int {
+	for i, v := range k.values {
+		cmp := CompareFieldValues(v, other.values[i])
+		if k.descending[i] {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// SortRows sorts rows of the same partition by their clustering keys, keeping
+// the order of rows with equal keys
+func SortRows(pk *PrimaryKey, rows []map[string]FieldValue) {
+	keys := make([]SortKey, len(rows))
+	for i, row := range rows {
+		keys[i] = NewSortKey(pk, row)
+	}
+	sort.Stable(sortRows{rows: rows, keys: keys})
+}
+
+type sortRows struct {
+	rows []map[string]FieldValue
+	keys []SortKey
+}
+
+func (s sortRows) Len() int           { return len(s.rows) }
+func (s sortRows) Less(i, j int) bool { return s.keys[i].Compare(s.keys[j]) < 0 }
+func (s sortRows) Swap(i, j int) {
+	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// CompareFieldValues returns -1, 0 or 1 when v1 is less than, equal to or
+// greater than v2, in the order of FieldValueLess. The values must have the
+// same type, or CompareFieldValues panics.
+func CompareFieldValues(v1, v2 FieldValue) int {
+	return orderFieldValues(v1, v2)
+}
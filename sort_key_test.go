@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dosa_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/dosa"
+)
+
+func TestCompareFieldValues(t *testing.T) {
+	now := time.Now()
+	v1a := dosa.UUID("0d4b8a84-a7d2-11e8-98d0-529269fb1459") // time UUID, earlier
+	v1b := dosa.UUID("0c2c17e4-a7d3-11e8-98d0-529269fb1459") // time UUID, later
+	v4 := dosa.UUID("04f7a85a-4bb0-4a3e-9f3c-7a6c4b3ea5d0")
+	tests := []struct {
+		v1, v2 dosa.FieldValue
+		want   int
+	}{
+		{int64(1), int64(2), -1},
+		{int64(2), int64(2), 0},
+		{int32(3), int32(2), 1},
+		{1.5, 0.5, 1},
+		{"a", "b", -1},
+		{"b", "b", 0},
+		{[]byte{1}, []byte{2}, -1},
+		{false, true, -1},
+		{true, true, 0},
+		{now, now.Add(time.Second), -1},
+		{now.Add(time.Second), now, 1},
+		{v1a, v1b, 1}, // bytes, not timestamps
+		{v1b, v1a, -1},
+		{v1a, v4, 1}, // nor versions
+		{v4, v4, 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, dosa.CompareFieldValues(test.v1, test.v2), fmt.Sprint(test.v1, " vs ", test.v2))
+	}
+	assert.Panics(t, func() { dosa.CompareFieldValues(t, t) })
+}
+
+func TestSortRows(t *testing.T) {
+	now := time.Now()
+	pk := &dosa.PrimaryKey{
+		PartitionKeys: []string{"p"},
+		ClusteringKeys: []*dosa.ClusteringKey{
+			{Name: "s"},
+			{Name: "ts", Descending: true},
+		},
+	}
+	rows := []map[string]dosa.FieldValue{
+		{"s": "b", "ts": now, "n": 1},
+		{"s": "a", "ts": now, "n": 2},
+		{"s": "a", "ts": now.Add(time.Second), "n": 3},
+		{"s": "b", "ts": now, "n": 4},
+	}
+	dosa.SortRows(pk, rows)
+	var order []int
+	for _, row := range rows {
+		order = append(order, row["n"].(int))
+	}
+	assert.Equal(t, []int{3, 2, 1, 4}, order)
+
+	assert.Equal(t, 0, dosa.NewSortKey(pk, rows[2]).Compare(dosa.NewSortKey(pk, rows[3])))
+	assert.Equal(t, -1, dosa.NewSortKey(pk, rows[0]).Compare(dosa.NewSortKey(pk, rows[1])))
+	assert.Equal(t, 1, dosa.NewSortKey(pk, rows[1]).Compare(dosa.NewSortKey(pk, rows[0])))
+}
@@ -112,6 +112,53 @@ func FromString(s string) Type {
 	}
 }
 
+// goTypeNames maps the types to the name of the Go type of their fields
+var goTypeNames = map[Type]string{
+	TUUID:     "UUID",
+	String:    "string",
+	Int32:     "int32",
+	Int64:     "int64",
+	Double:    "float64",
+	Blob:      "[]byte",
+	Timestamp: "time.Time",
+	Bool:      "bool",
+}
+
+// GoType returns the name of the Go type used for fields of this type, e.g. "[]byte" for Blob.
+// String() returns the name of the constant instead, which is what schemas are serialized with.
+func (t Type) GoType() string {
+	if name, ok := goTypeNames[t]; ok {
+		return name
+	}
+	return t.String()
+}
+
+// DosaTypeToString returns the name of the Go type of fields of type t, as
+// written in code that imports dosa, e.g. "[]byte" for Blob and "dosa.UUID" for
+// TUUID. It is the reverse of StringToDosaType, and returns "" for Invalid and
+// unknown types.
+func DosaTypeToString(t Type) string {
+	if t == TUUID {
+		return "dosa.UUID"
+	}
+	return goTypeNames[t]
+}
+
+// TypeFromString converts either the name of a type (as returned by String) or the
+// name of its Go type (as returned by GoType) to the type. Unlike FromString, it
+// returns an error for unrecognized names.
+func TypeFromString(s string) (Type, error) {
+	if t := FromString(s); t != Invalid {
+		return t, nil
+	}
+	for t, name := range goTypeNames {
+		if name == s {
+			return t, nil
+		}
+	}
+	return Invalid, errors.Errorf("unknown type %q", s)
+}
+
 func isInvalidPrimaryKeyType(c *ColumnDefinition) bool {
 	if c.IsPointer {
 		return true
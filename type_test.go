@@ -103,3 +103,43 @@ func TestFromString(t *testing.T) {
 		assert.Equal(t, FromString(tc.input), tc.expected)
 	}
 }
+
+func TestTypeFromString(t *testing.T) {
+	for _, typ := range []Type{TUUID, String, Int32, Int64, Double, Blob, Timestamp, Bool} {
+		actual, err := TypeFromString(typ.String())
+		assert.NoError(t, err)
+		assert.Equal(t, typ, actual)
+
+		actual, err = TypeFromString(typ.GoType())
+		assert.NoError(t, err)
+		assert.Equal(t, typ, actual)
+	}
+
+	actual, err := TypeFromString("float32")
+	assert.Equal(t, Invalid, actual)
+	assert.EqualError(t, err, `unknown type "float32"`)
+
+	_, err = TypeFromString(Invalid.String())
+	assert.Error(t, err)
+}
+
+func TestDosaTypeToString(t *testing.T) {
+	assert.Equal(t, "dosa.UUID", DosaTypeToString(TUUID))
+	assert.Equal(t, "[]byte", DosaTypeToString(Blob))
+	assert.Equal(t, "time.Time", DosaTypeToString(Timestamp))
+	assert.Equal(t, "", DosaTypeToString(Invalid))
+	assert.Equal(t, "", DosaTypeToString(Type(-1)))
+	assert.Equal(t, "", DosaTypeToString(Bool+1))
+
+	// every type makes the round trip
+	for typ := TUUID; typ <= Bool; typ++ {
+		assert.Equal(t, typ, StringToDosaType(DosaTypeToString(typ)), typ.String())
+	}
+}
+
+func TestGoType(t *testing.T) {
+	assert.Equal(t, "[]byte", Blob.GoType())
+	assert.Equal(t, "time.Time", Timestamp.GoType())
+	assert.Equal(t, "UUID", TUUID.GoType())
+	assert.Equal(t, "Invalid", Invalid.GoType())
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package dosa
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// EntityPointer is satisfied by *T when T is an entity struct, i.e. a struct
+// that embeds dosa.Entity
+type EntityPointer[T any] interface {
+	*T
+	DomainObject
+}
+
+// TypedClient wraps a Client with a type-safe API for a single entity type:
+// entities go in and come out as *T rather than as DomainObject, so callers
+// never need a type assertion. T is the entity struct, e.g.
+//
+//	users := dosa.NewTypedClient[User](client)
+//	u, err := users.Read(ctx, User{ID: id})
+//
+// It is only available when building with Go 1.18 or later.
+type TypedClient[T any, PT EntityPointer[T]] struct {
+	client Client
+}
+
+// NewTypedClient returns a TypedClient for entities of type T; the entity
+// must be registered with the client
+func NewTypedClient[T any, PT EntityPointer[T]](client Client) *TypedClient[T, PT] {
+	return &TypedClient[T, PT]{client: client}
+}
+
+// Read reads the entity with the primary key of pk and returns it; pk itself
+// is not modified
+func (c *TypedClient[T, PT]) Read(ctx context.Context, pk T) (*T, error) {
+	e := pk
+	if err := c.client.Read(ctx, All(), PT(&e)); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Upsert writes all the fields of e
+func (c *TypedClient[T, PT]) Upsert(ctx context.Context, e *T) error {
+	return c.client.Upsert(ctx, All(), PT(e))
+}
+
+// Remove removes the entity with the primary key of pk
+func (c *TypedClient[T, PT]) Remove(ctx context.Context, pk T) error {
+	return c.client.Remove(ctx, PT(&pk))
+}
+
+// Scan reads a page of at most pageSize entities, starting at token (empty for
+// the first page), and returns the token of the next page, which is empty
+// after the last one
+func (c *TypedClient[T, PT]) Scan(ctx context.Context, token string, pageSize int) ([]*T, string, error) {
+	objs, next, err := c.client.ScanEverything(ctx, NewScanOp(PT(new(T))).Limit(pageSize).Offset(token))
+	if err != nil {
+		return nil, "", err
+	}
+	entities := make([]*T, len(objs))
+	for i, obj := range objs {
+		e, ok := obj.(PT)
+		if !ok {
+			return nil, "", errors.Errorf("scan returned %T, expected %T", obj, PT(nil))
+		}
+		entities[i] = (*T)(e)
+	}
+	return entities, next, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package dosa_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	dosaRenamed "github.com/uber-go/dosa"
+	"github.com/uber-go/dosa/connectors/memory"
+)
+
+// the typed API is checked by the compiler: these don't build unless the
+// methods take and return *ClientTestEntity1 rather than a DomainObject
+var (
+	_ func(context.Context, ClientTestEntity1) (*ClientTestEntity1, error)     = (*dosaRenamed.TypedClient[ClientTestEntity1, *ClientTestEntity1])(nil).Read
+	_ func(context.Context, *ClientTestEntity1) error                          = (*dosaRenamed.TypedClient[ClientTestEntity1, *ClientTestEntity1])(nil).Upsert
+	_ func(context.Context, ClientTestEntity1) error                           = (*dosaRenamed.TypedClient[ClientTestEntity1, *ClientTestEntity1])(nil).Remove
+	_ func(context.Context, string, int) ([]*ClientTestEntity1, string, error) = (*dosaRenamed.TypedClient[ClientTestEntity1, *ClientTestEntity1])(nil).Scan
+)
+
+func TestTypedClient(t *testing.T) {
+	reg, _ := dosaRenamed.NewRegistrar(scope, namePrefix, cte1)
+	c := dosaRenamed.NewClient(reg, memory.NewConnector())
+	assert.NoError(t, c.Initialize(ctx))
+	users := dosaRenamed.NewTypedClient[ClientTestEntity1](c)
+
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, users.Upsert(ctx, &ClientTestEntity1{ID: i, Name: "name", Email: "email"}))
+	}
+
+	pk := ClientTestEntity1{ID: 2}
+	e, err := users.Read(ctx, pk)
+	assert.NoError(t, err)
+	assert.Equal(t, &ClientTestEntity1{ID: 2, Name: "name", Email: "email"}, e)
+	assert.Empty(t, pk.Name)
+
+	_, err = users.Read(ctx, ClientTestEntity1{ID: 4})
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	page, token, err := users.Scan(ctx, "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, token)
+	page, token, err = users.Scan(ctx, token, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Empty(t, token)
+
+	assert.NoError(t, users.Remove(ctx, ClientTestEntity1{ID: 2}))
+	_, err = users.Read(ctx, pk)
+	assert.True(t, dosaRenamed.ErrorIsNotFound(err))
+
+	// unregistered entities fail like they do with the untyped client
+	_, err = dosaRenamed.NewTypedClient[ClientTestEntity2](c).Read(ctx, ClientTestEntity2{})
+	assert.Error(t, err)
+}
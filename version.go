@@ -22,3 +22,13 @@ package dosa
 
 // VERSION indicates the dosa client version
 const VERSION = "3.3.0"
+
+// Version is the library version reported by tools such as the CLI. It
+// defaults to VERSION and can be set at build time with
+// -ldflags "-X github.com/uber-go/dosa.Version=$(git describe --tags)".
+var Version = VERSION
+
+// SchemaFormatVersion is the version of the dosa struct tag syntax. It is
+// incremented on breaking changes to the tags, so that migration tooling can
+// tell whether it understands the schemas it reads.
+const SchemaFormatVersion = 1